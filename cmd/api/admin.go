@@ -0,0 +1,229 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/96malhar/realworld-backend/internal/data"
+	"github.com/96malhar/realworld-backend/internal/validator"
+	"github.com/go-chi/chi/v5"
+)
+
+// listAuditLogHandler returns a page of the audit log of privileged actions, most recent first.
+func (app *application) listAuditLogHandler(w http.ResponseWriter, r *http.Request) {
+	pagination := app.readPagination(r, 20, 100)
+	entries, totalCount, err := app.modelStore.Audit.GetAll(pagination.Limit, pagination.Offset)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"entries": entries, "entriesCount": totalCount}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// listUsersHandler returns a page of user accounts, newest first, optionally filtered
+// by a search term matching the username or email address, by role and by account status.
+func (app *application) listUsersHandler(w http.ResponseWriter, r *http.Request) {
+	pagination := app.readPagination(r, 20, 100)
+	qs := r.URL.Query()
+
+	filters := data.UserFilters{
+		Search: qs.Get("search"),
+		Role:   qs.Get("role"),
+		Status: qs.Get("status"),
+		Limit:  pagination.Limit,
+		Offset: pagination.Offset,
+	}
+
+	v := validator.New()
+	filters.Validate(v)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	accounts, totalCount, err := app.modelStore.Users.List(filters)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"users": accounts, "usersCount": totalCount}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// getUserAccountHandler returns the account of the user in the URL.
+func (app *application) getUserAccountHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := app.readUserParam(w, r)
+	if !ok {
+		return
+	}
+
+	app.writeUserAccount(w, r, user)
+}
+
+// updateUserRoleHandler changes the role of the user in the URL. Admins cannot change their own role,
+// so that there is always an admin left to undo a mistake.
+func (app *application) updateUserRoleHandler(w http.ResponseWriter, r *http.Request) {
+	actor := app.contextGetUser(r)
+
+	var input struct {
+		Role string `json:"role"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	user, ok := app.readUserParam(w, r)
+	if !ok {
+		return
+	}
+
+	v := validator.New()
+	data.ValidateRole(v, input.Role)
+	v.Check(user.ID != actor.ID, "you cannot change your own role")
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = app.modelStore.Users.SetRole(user, input.Role, actor)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"user": envelope{"username": user.Username, "role": user.Role}}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// suspendUserHandler suspends the account of the user in the URL. Their requests are rejected
+// from then on, including those made with tokens issued before the suspension.
+func (app *application) suspendUserHandler(w http.ResponseWriter, r *http.Request) {
+	actor := app.contextGetUser(r)
+
+	var input struct {
+		Reason string `json:"reason"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	user, ok := app.readUserParam(w, r)
+	if !ok {
+		return
+	}
+
+	v := validator.New()
+	v.Check(validator.NotEmptyOrWhitespace(input.Reason), "reason must be provided")
+	v.Check(len(input.Reason) <= 500, "reason must not be more than 500 bytes long")
+	v.Check(user.ID != actor.ID, "you cannot suspend your own account")
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = app.modelStore.Users.Suspend(user, input.Reason, actor)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	app.writeUserAccount(w, r, user)
+}
+
+// unsuspendUserHandler lifts the suspension of the account of the user in the URL.
+func (app *application) unsuspendUserHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := app.readUserParam(w, r)
+	if !ok {
+		return
+	}
+
+	err := app.modelStore.Users.Unsuspend(user, app.contextGetUser(r))
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	app.writeUserAccount(w, r, user)
+}
+
+// forcePasswordResetHandler makes the user in the URL change their password before they can do anything else.
+func (app *application) forcePasswordResetHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := app.readUserParam(w, r)
+	if !ok {
+		return
+	}
+
+	err := app.modelStore.Users.RequirePasswordReset(user, app.contextGetUser(r))
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	app.writeUserAccount(w, r, user)
+}
+
+// deleteUserAccountHandler deletes the account of the user in the URL along with their content.
+func (app *application) deleteUserAccountHandler(w http.ResponseWriter, r *http.Request) {
+	actor := app.contextGetUser(r)
+
+	user, ok := app.readUserParam(w, r)
+	if !ok {
+		return
+	}
+
+	if user.ID == actor.ID {
+		app.failedValidationResponse(w, r, []string{"you cannot delete your own account here"})
+		return
+	}
+
+	err := app.modelStore.Users.Delete(user, actor)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// readUserParam looks up the user named by the {username} URL parameter. If the user doesn't exist
+// or the lookup fails, it sends the error response and returns false.
+func (app *application) readUserParam(w http.ResponseWriter, r *http.Request) (*data.User, bool) {
+	user, err := app.modelStore.Users.GetByUsername(chi.URLParam(r, "username"))
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			app.notFoundResponse(w, r)
+			return nil, false
+		}
+		app.serverErrorResponse(w, r, err)
+		return nil, false
+	}
+
+	return user, true
+}
+
+func (app *application) writeUserAccount(w http.ResponseWriter, r *http.Request, user *data.User) {
+	err := app.writeJSON(w, http.StatusOK, envelope{"user": user.Account()}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
@@ -0,0 +1,605 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/96malhar/realworld-backend/internal/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type auditEntry struct {
+	ID         int64           `json:"id"`
+	Actor      string          `json:"actor"`
+	Action     string          `json:"action"`
+	TargetType string          `json:"targetType"`
+	TargetID   int64           `json:"targetId"`
+	Details    json.RawMessage `json:"details"`
+	CreatedAt  time.Time       `json:"createdAt"`
+}
+
+type auditLogResponse struct {
+	Entries      []auditEntry `json:"entries"`
+	EntriesCount int          `json:"entriesCount"`
+}
+
+type roleResponse struct {
+	User struct {
+		Username string `json:"username"`
+		Role     string `json:"role"`
+	} `json:"user"`
+}
+
+// setRole gives a user a role directly through the store, the way the first admin is set up.
+func setRole(t *testing.T, ts *testServer, username, role string) {
+	t.Helper()
+
+	user, err := ts.app.modelStore.Users.GetByUsername(username)
+	require.NoError(t, err)
+	require.NoError(t, ts.app.modelStore.Users.SetRole(user, role, nil))
+}
+
+func getAuditLog(t *testing.T, ts *testServer, token string) auditLogResponse {
+	t.Helper()
+
+	res, err := ts.executeRequest(http.MethodGet, "/admin/audit-log", "", map[string]string{"Authorization": "Token " + token})
+	require.NoError(t, err)
+	defer res.Body.Close() //nolint: errcheck
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	var resp auditLogResponse
+	readJsonResponse(t, res.Body, &resp)
+	return resp
+}
+
+func TestModeratorPermissions(t *testing.T) {
+	t.Parallel()
+
+	ts := newTestServer(t)
+
+	registerUser(t, ts, "alice", "alice@example.com", "password123")
+	registerUser(t, ts, "bob", "bob@example.com", "password123")
+	registerUser(t, ts, "mod", "mod@example.com", "password123")
+	registerUser(t, ts, "admin", "admin@example.com", "password123")
+	setRole(t, ts, "mod", data.RoleModerator)
+	setRole(t, ts, "admin", data.RoleAdmin)
+	aliceToken := loginUser(t, ts, "alice@example.com", "password123")
+	bobToken := loginUser(t, ts, "bob@example.com", "password123")
+	modToken := loginUser(t, ts, "mod@example.com", "password123")
+	adminToken := loginUser(t, ts, "admin@example.com", "password123")
+
+	bobHeader := map[string]string{"Authorization": "Token " + bobToken}
+	modHeader := map[string]string{"Authorization": "Token " + modToken}
+
+	articleLocation := createArticle(t, ts, aliceToken, "Alice Article", "Test description", "Test body", []string{"test"})
+	commentID := postComment(t, ts, bobToken, articleLocation, "Spam", 0)
+	commentLocation := articleLocation + "/comments/" + strconv.FormatInt(commentID, 10)
+
+	testHandler(t, ts,
+		handlerTestcase{
+			name:                   "Regular users cannot edit other users' articles",
+			requestMethodType:      http.MethodPut,
+			requestUrlPath:         articleLocation,
+			requestBody:            `{"article": {"body": "Edited by bob"}}`,
+			requestHeader:          bobHeader,
+			wantResponseStatusCode: http.StatusForbidden,
+		},
+		handlerTestcase{
+			name:                   "Moderators can edit other users' articles",
+			requestMethodType:      http.MethodPut,
+			requestUrlPath:         articleLocation,
+			requestBody:            `{"article": {"body": "Edited by a moderator"}}`,
+			requestHeader:          modHeader,
+			wantResponseStatusCode: http.StatusOK,
+			additionalChecks: func(t *testing.T, res *http.Response) {
+				var resp getArticleResponse
+				readJsonResponse(t, res.Body, &resp)
+				assert.Equal(t, "Edited by a moderator", resp.Article.Body)
+				assert.Equal(t, "alice", resp.Article.Author.Username, "the author is unchanged")
+			},
+		},
+		handlerTestcase{
+			name:                   "Regular users cannot delete other users' comments",
+			requestMethodType:      http.MethodDelete,
+			requestUrlPath:         commentLocation,
+			requestHeader:          map[string]string{"Authorization": "Token " + aliceToken},
+			wantResponseStatusCode: http.StatusNotFound,
+		},
+		handlerTestcase{
+			name:                   "Moderators cannot read the audit log",
+			requestMethodType:      http.MethodGet,
+			requestUrlPath:         "/admin/audit-log",
+			requestHeader:          modHeader,
+			wantResponseStatusCode: http.StatusForbidden,
+		},
+	)
+
+	res, err := ts.executeRequest(http.MethodDelete, commentLocation, "", modHeader)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNoContent, res.StatusCode, "moderators can delete other users' comments")
+
+	res, err = ts.executeRequest(http.MethodDelete, articleLocation, "", bobHeader)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNotFound, res.StatusCode, "regular users cannot delete other users' articles")
+
+	res, err = ts.executeRequest(http.MethodDelete, articleLocation, "", modHeader)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNoContent, res.StatusCode, "moderators can delete other users' articles")
+
+	// Moderators acting on their own content is not audited
+	ownLocation := createArticle(t, ts, modToken, "Mod Article", "Test description", "Test body", []string{"test"})
+	res, err = ts.executeRequest(http.MethodDelete, ownLocation, "", modHeader)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNoContent, res.StatusCode)
+
+	log := getAuditLog(t, ts, adminToken)
+	require.Equal(t, 5, log.EntriesCount)
+	require.Len(t, log.Entries, 5)
+
+	actions := make([]string, len(log.Entries))
+	for i, e := range log.Entries {
+		actions[i] = e.Action
+	}
+	assert.Equal(t, []string{
+		data.AuditArticleDeleted,
+		data.AuditCommentDeleted,
+		data.AuditArticleUpdated,
+		data.AuditUserRoleChanged,
+		data.AuditUserRoleChanged,
+	}, actions)
+
+	assert.Equal(t, "mod", log.Entries[0].Actor)
+	assert.Equal(t, data.AuditTargetArticle, log.Entries[0].TargetType)
+	assert.JSONEq(t, `{"slug": "alice-article", "title": "Alice Article", "status": "published"}`, string(log.Entries[0].Details))
+	assert.Equal(t, data.AuditTargetComment, log.Entries[1].TargetType)
+	assert.Equal(t, commentID, log.Entries[1].TargetID)
+	assert.Empty(t, log.Entries[3].Actor, "roles set up by the system have no actor")
+}
+
+func TestUpdateUserRoleHandler(t *testing.T) {
+	t.Parallel()
+
+	ts := newTestServer(t)
+
+	registerUser(t, ts, "alice", "alice@example.com", "password123")
+	registerUser(t, ts, "bob", "bob@example.com", "password123")
+	registerUser(t, ts, "admin", "admin@example.com", "password123")
+	setRole(t, ts, "admin", data.RoleAdmin)
+	aliceToken := loginUser(t, ts, "alice@example.com", "password123")
+	bobToken := loginUser(t, ts, "bob@example.com", "password123")
+	adminToken := loginUser(t, ts, "admin@example.com", "password123")
+
+	adminHeader := map[string]string{"Authorization": "Token " + adminToken}
+	articleLocation := createArticle(t, ts, aliceToken, "Alice Article", "Test description", "Test body", []string{"test"})
+
+	testHandler(t, ts,
+		handlerTestcase{
+			name:                   "Requires authentication",
+			requestMethodType:      http.MethodPut,
+			requestUrlPath:         "/admin/users/bob/role",
+			requestBody:            `{"role": "moderator"}`,
+			wantResponseStatusCode: http.StatusUnauthorized,
+		},
+		handlerTestcase{
+			name:                   "Regular users cannot change roles",
+			requestMethodType:      http.MethodPut,
+			requestUrlPath:         "/admin/users/bob/role",
+			requestBody:            `{"role": "moderator"}`,
+			requestHeader:          map[string]string{"Authorization": "Token " + bobToken},
+			wantResponseStatusCode: http.StatusForbidden,
+		},
+		handlerTestcase{
+			name:                   "Unknown role",
+			requestMethodType:      http.MethodPut,
+			requestUrlPath:         "/admin/users/bob/role",
+			requestBody:            `{"role": "superuser"}`,
+			requestHeader:          adminHeader,
+			wantResponseStatusCode: http.StatusUnprocessableEntity,
+			wantResponse: errorResponse{
+				Errors: []string{"role must be one of user, moderator or admin"},
+			},
+		},
+		handlerTestcase{
+			name:                   "Admins cannot change their own role",
+			requestMethodType:      http.MethodPut,
+			requestUrlPath:         "/admin/users/admin/role",
+			requestBody:            `{"role": "user"}`,
+			requestHeader:          adminHeader,
+			wantResponseStatusCode: http.StatusUnprocessableEntity,
+			wantResponse: errorResponse{
+				Errors: []string{"you cannot change your own role"},
+			},
+		},
+		handlerTestcase{
+			name:                   "Unknown user",
+			requestMethodType:      http.MethodPut,
+			requestUrlPath:         "/admin/users/nobody/role",
+			requestBody:            `{"role": "moderator"}`,
+			requestHeader:          adminHeader,
+			wantResponseStatusCode: http.StatusNotFound,
+		},
+		handlerTestcase{
+			name:                   "Promote a user to moderator",
+			requestMethodType:      http.MethodPut,
+			requestUrlPath:         "/admin/users/bob/role",
+			requestBody:            `{"role": "moderator"}`,
+			requestHeader:          adminHeader,
+			wantResponseStatusCode: http.StatusOK,
+			wantResponse: roleResponse{User: struct {
+				Username string `json:"username"`
+				Role     string `json:"role"`
+			}{Username: "bob", Role: data.RoleModerator}},
+		},
+	)
+
+	// The new role applies to the user's existing token right away
+	res, err := ts.executeRequest(http.MethodPut, articleLocation, `{"article": {"body": "Edited by bob"}}`,
+		map[string]string{"Authorization": "Token " + bobToken})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	log := getAuditLog(t, ts, adminToken)
+	require.GreaterOrEqual(t, len(log.Entries), 2)
+	assert.Equal(t, data.AuditArticleUpdated, log.Entries[0].Action)
+	assert.Equal(t, data.AuditUserRoleChanged, log.Entries[1].Action)
+	assert.Equal(t, "admin", log.Entries[1].Actor)
+	assert.JSONEq(t, `{"username": "bob", "from": "user", "to": "moderator"}`, string(log.Entries[1].Details))
+}
+
+type userAccount struct {
+	ID                    int64      `json:"id"`
+	Username              string     `json:"username"`
+	Email                 string     `json:"email"`
+	Bio                   string     `json:"bio"`
+	Image                 string     `json:"image"`
+	Role                  string     `json:"role"`
+	CreatedAt             time.Time  `json:"createdAt"`
+	SuspendedAt           *time.Time `json:"suspendedAt"`
+	SuspensionReason      string     `json:"suspensionReason,omitempty"`
+	PasswordResetRequired bool       `json:"passwordResetRequired"`
+}
+
+type userAccountResponse struct {
+	User userAccount `json:"user"`
+}
+
+type userAccountsResponse struct {
+	Users      []userAccount `json:"users"`
+	UsersCount int           `json:"usersCount"`
+}
+
+func TestAdminUserHandlers(t *testing.T) {
+	t.Parallel()
+
+	ts := newTestServer(t)
+
+	registerUser(t, ts, "alice", "alice@example.com", "password123")
+	registerUser(t, ts, "bob", "bob@example.com", "password123")
+	registerUser(t, ts, "carol", "carol@example.org", "password123")
+	registerUser(t, ts, "admin", "admin@example.com", "password123")
+	setRole(t, ts, "admin", data.RoleAdmin)
+	setRole(t, ts, "bob", data.RoleModerator)
+	adminToken := loginUser(t, ts, "admin@example.com", "password123")
+	aliceToken := loginUser(t, ts, "alice@example.com", "password123")
+	bobToken := loginUser(t, ts, "bob@example.com", "password123")
+	carolToken := loginUser(t, ts, "carol@example.org", "password123")
+
+	adminHeader := map[string]string{"Authorization": "Token " + adminToken}
+	aliceHeader := map[string]string{"Authorization": "Token " + aliceToken}
+	bobHeader := map[string]string{"Authorization": "Token " + bobToken}
+
+	usernames := func(t *testing.T, res *http.Response) []string {
+		var resp userAccountsResponse
+		readJsonResponse(t, res.Body, &resp)
+		names := make([]string, len(resp.Users))
+		for i, u := range resp.Users {
+			names[i] = u.Username
+		}
+		assert.Equal(t, len(names), resp.UsersCount)
+		return names
+	}
+
+	t.Run("List and view users", func(t *testing.T) {
+		testHandler(t, ts,
+			handlerTestcase{
+				name:                   "Moderators cannot manage users",
+				requestMethodType:      http.MethodGet,
+				requestUrlPath:         "/admin/users",
+				requestHeader:          bobHeader,
+				wantResponseStatusCode: http.StatusForbidden,
+			},
+			handlerTestcase{
+				name:                   "Newest users first",
+				requestMethodType:      http.MethodGet,
+				requestUrlPath:         "/admin/users",
+				requestHeader:          adminHeader,
+				wantResponseStatusCode: http.StatusOK,
+				additionalChecks: func(t *testing.T, res *http.Response) {
+					assert.Equal(t, []string{"admin", "carol", "bob", "alice"}, usernames(t, res))
+				},
+			},
+			handlerTestcase{
+				name:                   "Search by email address",
+				requestMethodType:      http.MethodGet,
+				requestUrlPath:         "/admin/users?search=EXAMPLE.ORG",
+				requestHeader:          adminHeader,
+				wantResponseStatusCode: http.StatusOK,
+				additionalChecks: func(t *testing.T, res *http.Response) {
+					assert.Equal(t, []string{"carol"}, usernames(t, res))
+				},
+			},
+			handlerTestcase{
+				name:                   "Wildcards in the search term are matched literally",
+				requestMethodType:      http.MethodGet,
+				requestUrlPath:         "/admin/users?search=%25",
+				requestHeader:          adminHeader,
+				wantResponseStatusCode: http.StatusOK,
+				additionalChecks: func(t *testing.T, res *http.Response) {
+					assert.Empty(t, usernames(t, res))
+				},
+			},
+			handlerTestcase{
+				name:                   "Filter by role",
+				requestMethodType:      http.MethodGet,
+				requestUrlPath:         "/admin/users?role=moderator",
+				requestHeader:          adminHeader,
+				wantResponseStatusCode: http.StatusOK,
+				additionalChecks: func(t *testing.T, res *http.Response) {
+					assert.Equal(t, []string{"bob"}, usernames(t, res))
+				},
+			},
+			handlerTestcase{
+				name:                   "Paginate",
+				requestMethodType:      http.MethodGet,
+				requestUrlPath:         "/admin/users?limit=2&offset=1",
+				requestHeader:          adminHeader,
+				wantResponseStatusCode: http.StatusOK,
+				additionalChecks: func(t *testing.T, res *http.Response) {
+					var resp userAccountsResponse
+					readJsonResponse(t, res.Body, &resp)
+					require.Len(t, resp.Users, 2)
+					assert.Equal(t, "carol", resp.Users[0].Username)
+					assert.Equal(t, 4, resp.UsersCount)
+				},
+			},
+			handlerTestcase{
+				name:                   "Invalid filters",
+				requestMethodType:      http.MethodGet,
+				requestUrlPath:         "/admin/users?role=owner&status=banned",
+				requestHeader:          adminHeader,
+				wantResponseStatusCode: http.StatusUnprocessableEntity,
+				wantResponse: errorResponse{Errors: []string{
+					"role must be one of user, moderator or admin",
+					"status must be one of active or suspended",
+				}},
+			},
+			handlerTestcase{
+				name:                   "View a user",
+				requestMethodType:      http.MethodGet,
+				requestUrlPath:         "/admin/users/bob",
+				requestHeader:          adminHeader,
+				wantResponseStatusCode: http.StatusOK,
+				additionalChecks: func(t *testing.T, res *http.Response) {
+					var resp userAccountResponse
+					readJsonResponse(t, res.Body, &resp)
+					assert.Equal(t, "bob", resp.User.Username)
+					assert.Equal(t, "bob@example.com", resp.User.Email)
+					assert.Equal(t, data.RoleModerator, resp.User.Role)
+					assert.Nil(t, resp.User.SuspendedAt)
+					assert.WithinDuration(t, time.Now(), resp.User.CreatedAt, time.Minute)
+				},
+			},
+			handlerTestcase{
+				name:                   "View an unknown user",
+				requestMethodType:      http.MethodGet,
+				requestUrlPath:         "/admin/users/nobody",
+				requestHeader:          adminHeader,
+				wantResponseStatusCode: http.StatusNotFound,
+			},
+		)
+	})
+
+	t.Run("Suspend and unsuspend", func(t *testing.T) {
+		testHandler(t, ts,
+			handlerTestcase{
+				name:                   "A reason is required",
+				requestMethodType:      http.MethodPost,
+				requestUrlPath:         "/admin/users/alice/suspend",
+				requestBody:            `{"reason": " "}`,
+				requestHeader:          adminHeader,
+				wantResponseStatusCode: http.StatusUnprocessableEntity,
+				wantResponse:           errorResponse{Errors: []string{"reason must be provided"}},
+			},
+			handlerTestcase{
+				name:                   "Admins cannot suspend themselves",
+				requestMethodType:      http.MethodPost,
+				requestUrlPath:         "/admin/users/admin/suspend",
+				requestBody:            `{"reason": "Testing"}`,
+				requestHeader:          adminHeader,
+				wantResponseStatusCode: http.StatusUnprocessableEntity,
+				wantResponse:           errorResponse{Errors: []string{"you cannot suspend your own account"}},
+			},
+			handlerTestcase{
+				name:                   "Suspend a user",
+				requestMethodType:      http.MethodPost,
+				requestUrlPath:         "/admin/users/alice/suspend",
+				requestBody:            `{"reason": "Spamming"}`,
+				requestHeader:          adminHeader,
+				wantResponseStatusCode: http.StatusOK,
+				additionalChecks: func(t *testing.T, res *http.Response) {
+					var resp userAccountResponse
+					readJsonResponse(t, res.Body, &resp)
+					assert.NotNil(t, resp.User.SuspendedAt)
+					assert.Equal(t, "Spamming", resp.User.SuspensionReason)
+				},
+			},
+			handlerTestcase{
+				name:                   "Existing tokens of suspended users are rejected",
+				requestMethodType:      http.MethodGet,
+				requestUrlPath:         "/user",
+				requestHeader:          aliceHeader,
+				wantResponseStatusCode: http.StatusForbidden,
+				wantResponse:           errorResponse{Errors: []string{"your user account has been suspended"}},
+			},
+			handlerTestcase{
+				name:                   "Suspended users cannot log in",
+				requestMethodType:      http.MethodPost,
+				requestUrlPath:         "/users/login",
+				requestBody:            `{"user":{"email":"alice@example.com","password":"password123"}}`,
+				wantResponseStatusCode: http.StatusForbidden,
+				wantResponse:           errorResponse{Errors: []string{"your user account has been suspended"}},
+			},
+			handlerTestcase{
+				name:                   "Filter by status",
+				requestMethodType:      http.MethodGet,
+				requestUrlPath:         "/admin/users?status=suspended",
+				requestHeader:          adminHeader,
+				wantResponseStatusCode: http.StatusOK,
+				additionalChecks: func(t *testing.T, res *http.Response) {
+					assert.Equal(t, []string{"alice"}, usernames(t, res))
+				},
+			},
+			handlerTestcase{
+				name:                   "Unsuspend a user",
+				requestMethodType:      http.MethodDelete,
+				requestUrlPath:         "/admin/users/alice/suspend",
+				requestHeader:          adminHeader,
+				wantResponseStatusCode: http.StatusOK,
+				additionalChecks: func(t *testing.T, res *http.Response) {
+					var resp userAccountResponse
+					readJsonResponse(t, res.Body, &resp)
+					assert.Nil(t, resp.User.SuspendedAt)
+					assert.Empty(t, resp.User.SuspensionReason)
+				},
+			},
+			handlerTestcase{
+				name:                   "Unsuspended users can use their tokens again",
+				requestMethodType:      http.MethodGet,
+				requestUrlPath:         "/user",
+				requestHeader:          aliceHeader,
+				wantResponseStatusCode: http.StatusOK,
+			},
+		)
+	})
+
+	t.Run("Force a password reset", func(t *testing.T) {
+		// An update of bob loaded before the reset is forced must not undo it
+		loaded, err := ts.app.modelStore.Users.GetByEmail("bob@example.com")
+		require.NoError(t, err)
+		stale := *loaded
+
+		res, err := ts.executeRequest(http.MethodPost, "/admin/users/bob/password-reset", "", adminHeader)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		var resp userAccountResponse
+		readJsonResponse(t, res.Body, &resp)
+		assert.True(t, resp.User.PasswordResetRequired)
+
+		stale.Bio = "Updated concurrently"
+		require.NoError(t, ts.app.modelStore.Users.Update(&stale))
+		assert.True(t, stale.PasswordResetRequired)
+
+		testHandler(t, ts,
+			handlerTestcase{
+				name:                   "Other requests are rejected until the password is changed",
+				requestMethodType:      http.MethodGet,
+				requestUrlPath:         "/articles/feed",
+				requestHeader:          bobHeader,
+				wantResponseStatusCode: http.StatusForbidden,
+				wantResponse:           errorResponse{Errors: []string{"you must change your password before continuing"}},
+			},
+			handlerTestcase{
+				name:                   "The current user can still be viewed",
+				requestMethodType:      http.MethodGet,
+				requestUrlPath:         "/user",
+				requestHeader:          bobHeader,
+				wantResponseStatusCode: http.StatusOK,
+			},
+			handlerTestcase{
+				name:                   "Changing the password requires the current one",
+				requestMethodType:      http.MethodPut,
+				requestUrlPath:         "/user",
+				requestBody:            `{"user": {"password": "newpassword123"}}`,
+				requestHeader:          bobHeader,
+				wantResponseStatusCode: http.StatusUnprocessableEntity,
+				wantResponse:           errorResponse{Errors: []string{"currentPassword must be provided to reset your password"}},
+			},
+			handlerTestcase{
+				name:                   "Changing the password with a wrong current one is rejected",
+				requestMethodType:      http.MethodPut,
+				requestUrlPath:         "/user",
+				requestBody:            `{"user": {"password": "newpassword123", "currentPassword": "wrongpassword"}}`,
+				requestHeader:          bobHeader,
+				wantResponseStatusCode: http.StatusUnauthorized,
+			},
+			handlerTestcase{
+				name:                   "Change the password",
+				requestMethodType:      http.MethodPut,
+				requestUrlPath:         "/user",
+				requestBody:            `{"user": {"password": "newpassword123", "currentPassword": "password123"}}`,
+				requestHeader:          bobHeader,
+				wantResponseStatusCode: http.StatusOK,
+			},
+			handlerTestcase{
+				name:                   "Requests are accepted after the password was changed",
+				requestMethodType:      http.MethodGet,
+				requestUrlPath:         "/articles/feed",
+				requestHeader:          bobHeader,
+				wantResponseStatusCode: http.StatusOK,
+			},
+		)
+	})
+
+	t.Run("Delete a user", func(t *testing.T) {
+		createArticle(t, ts, carolToken, "Carol Article", "Test description", "Test body", []string{"test"})
+
+		res, err := ts.executeRequest(http.MethodDelete, "/admin/users/admin", "", adminHeader)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+
+		res, err = ts.executeRequest(http.MethodDelete, "/admin/users/carol", "", adminHeader)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusNoContent, res.StatusCode)
+
+		testHandler(t, ts,
+			handlerTestcase{
+				name:                   "The deleted user's token is rejected",
+				requestMethodType:      http.MethodGet,
+				requestUrlPath:         "/user",
+				requestHeader:          map[string]string{"Authorization": "Token " + carolToken},
+				wantResponseStatusCode: http.StatusUnauthorized,
+			},
+			handlerTestcase{
+				name:                   "The deleted user's articles are gone",
+				requestMethodType:      http.MethodGet,
+				requestUrlPath:         "/articles/carol-article",
+				wantResponseStatusCode: http.StatusNotFound,
+			},
+			handlerTestcase{
+				name:                   "The deleted user no longer exists",
+				requestMethodType:      http.MethodDelete,
+				requestUrlPath:         "/admin/users/carol",
+				requestHeader:          adminHeader,
+				wantResponseStatusCode: http.StatusNotFound,
+			},
+		)
+	})
+
+	log := getAuditLog(t, ts, adminToken)
+	actions := make([]string, 0, len(log.Entries))
+	for _, e := range log.Entries {
+		if e.Actor == "admin" {
+			actions = append(actions, e.Action)
+		}
+	}
+	assert.Equal(t, []string{
+		data.AuditUserDeleted,
+		data.AuditUserPasswordResetForced,
+		data.AuditUserUnsuspended,
+		data.AuditUserSuspended,
+	}, actions)
+}
@@ -3,20 +3,37 @@ package main
 import (
 	"context"
 	"log/slog"
+	"net/http"
 	"os"
+	"slices"
 	"sync"
 	"time"
 
 	"github.com/96malhar/realworld-backend/internal/auth"
+	"github.com/96malhar/realworld-backend/internal/contentfilter"
 	"github.com/96malhar/realworld-backend/internal/data"
+	"github.com/96malhar/realworld-backend/internal/events"
+	"github.com/96malhar/realworld-backend/internal/markdown"
+	"github.com/96malhar/realworld-backend/internal/oidc"
+	"github.com/96malhar/realworld-backend/internal/outbox"
+	"github.com/96malhar/realworld-backend/internal/passwords"
+	"github.com/96malhar/realworld-backend/internal/webhooks"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type appConfig struct {
-	port     int
-	env      string
-	db       dbConfig
-	jwtMaker jwtMakerConfig
+	port      int
+	env       string
+	db        dbConfig
+	jwtMaker  jwtMakerConfig
+	articles  articlesConfig
+	events    eventsConfig
+	webhooks  webhooksConfig
+	outbox    outboxConfig
+	filter    contentFilterConfig
+	users     usersConfig
+	passwords passwordsConfig
+	oidc      oidcConfig
 }
 
 type dbConfig struct {
@@ -26,6 +43,62 @@ type dbConfig struct {
 	timeout      time.Duration
 }
 
+type articlesConfig struct {
+	publishInterval       time.Duration
+	keepSlugOnTitleChange bool
+	trashRetention        time.Duration
+}
+
+type eventsConfig struct {
+	heartbeatInterval time.Duration
+	historySize       int
+}
+
+type webhooksConfig struct {
+	pollInterval time.Duration
+	timeout      time.Duration
+	maxAttempts  int
+	retryBackoff time.Duration
+	// allowPrivateNetworks lets deliveries reach loopback, private and link-local addresses.
+	allowPrivateNetworks bool
+}
+
+type outboxConfig struct {
+	pollInterval time.Duration
+	retryBackoff time.Duration
+	retention    time.Duration
+}
+
+type contentFilterConfig struct {
+	wordlist        string
+	wordlistAction  string
+	spamHoldScore   int
+	spamRejectScore int
+	newAccountAge   time.Duration
+}
+
+type usersConfig struct {
+	// deletionPolicy decides what happens to the content of users who delete their account; one of data.DeletionPolicies.
+	deletionPolicy string
+	exportTTL      time.Duration
+}
+
+type passwordsConfig struct {
+	argon2Memory      uint // in KiB
+	argon2Iterations  uint
+	argon2Parallelism uint
+	// minScore is the lowest acceptable strength score of a new password, from 0 to 4.
+	minScore         int
+	rejectUserInputs bool
+	// breachedList is the path of a file of SHA-1 hashes of breached passwords; empty disables the check.
+	breachedList string
+}
+
+type oidcConfig struct {
+	// providers is the path of a JSON file with a list of oidc.Config; empty disables OpenID Connect login.
+	providers string
+}
+
 type jwtMakerConfig struct {
 	secretKey      string
 	issuer         string
@@ -41,6 +114,41 @@ func (c appConfig) LogValue() slog.Value {
 		slog.Duration("db-max-idle-time", c.db.maxIdleTime),
 		slog.Duration("db-timeout", c.db.timeout),
 
+		slog.Duration("articles-publish-interval", c.articles.publishInterval),
+		slog.Bool("articles-keep-slug-on-title-change", c.articles.keepSlugOnTitleChange),
+		slog.Duration("articles-trash-retention", c.articles.trashRetention),
+
+		slog.Duration("events-heartbeat-interval", c.events.heartbeatInterval),
+		slog.Int("events-history-size", c.events.historySize),
+
+		slog.Duration("webhooks-poll-interval", c.webhooks.pollInterval),
+		slog.Duration("webhooks-timeout", c.webhooks.timeout),
+		slog.Int("webhooks-max-attempts", c.webhooks.maxAttempts),
+		slog.Duration("webhooks-retry-backoff", c.webhooks.retryBackoff),
+		slog.Bool("webhooks-allow-private-networks", c.webhooks.allowPrivateNetworks),
+
+		slog.Duration("outbox-poll-interval", c.outbox.pollInterval),
+		slog.Duration("outbox-retry-backoff", c.outbox.retryBackoff),
+		slog.Duration("outbox-retention", c.outbox.retention),
+
+		slog.String("filter-wordlist", c.filter.wordlist),
+		slog.String("filter-wordlist-action", c.filter.wordlistAction),
+		slog.Int("filter-spam-hold-score", c.filter.spamHoldScore),
+		slog.Int("filter-spam-reject-score", c.filter.spamRejectScore),
+		slog.Duration("filter-new-account-age", c.filter.newAccountAge),
+
+		slog.String("users-deletion-policy", c.users.deletionPolicy),
+		slog.Duration("users-export-ttl", c.users.exportTTL),
+
+		slog.Uint64("password-argon2-memory", uint64(c.passwords.argon2Memory)),
+		slog.Uint64("password-argon2-iterations", uint64(c.passwords.argon2Iterations)),
+		slog.Uint64("password-argon2-parallelism", uint64(c.passwords.argon2Parallelism)),
+		slog.Int("password-min-score", c.passwords.minScore),
+		slog.Bool("password-reject-user-inputs", c.passwords.rejectUserInputs),
+		slog.String("password-breached-list", c.passwords.breachedList),
+
+		slog.String("oidc-providers", c.oidc.providers),
+
 		slog.String("version", version),
 	)
 }
@@ -52,11 +160,32 @@ type application struct {
 	jwtMaker   jwtMaker
 	wg         sync.WaitGroup
 	userCache  *data.UserCache
+	markdown   *markdown.Renderer
+	events     *events.Broker
+	// webhookClient sends webhook deliveries; its timeout bounds each attempt.
+	webhookClient *http.Client
+	// outbox publishes domain events written by the data layer; in-process handlers subscribe through subscribers.
+	outbox      *outbox.Dispatcher
+	subscribers *outbox.Subscribers
+	// contentFilter screens articles and comments before they are saved.
+	contentFilter contentfilter.Filter
+	// passwords hashes new passwords and verifies passwords against stored hashes.
+	passwords passwords.Hasher
+	// passwordPolicy decides whether new passwords are strong enough.
+	passwordPolicy passwords.Policy
+	// oidcProviders are the OpenID Connect providers users can log in with, by name.
+	oidcProviders map[string]*oidc.Provider
+	// now returns the current time; tests replace it to control the codes of two-factor authentication.
+	now func() time.Time
+	// shutdown is closed when the server begins shutting down, signalling background workers to stop.
+	shutdown chan struct{}
 }
 
 type jwtMaker interface {
 	CreateToken(userID int64, duration time.Duration) (string, error)
 	VerifyToken(tokenString string) (*auth.Claims, error)
+	CreateMFAToken(userID int64, duration time.Duration) (string, error)
+	VerifyMFAToken(tokenString string) (*auth.Claims, error)
 }
 
 func newApplication(config appConfig, logger *slog.Logger) *application {
@@ -66,16 +195,68 @@ func newApplication(config appConfig, logger *slog.Logger) *application {
 		os.Exit(1)
 	}
 
+	contentFilter, err := newContentFilter(config.filter)
+	if err != nil {
+		slog.Error("failed to create content filter", "error", err)
+		os.Exit(1)
+	}
+
+	passwordHasher, err := newPasswordHasher(config.passwords)
+	if err != nil {
+		slog.Error("failed to create password hasher", "error", err)
+		os.Exit(1)
+	}
+
+	passwordPolicy, err := newPasswordPolicy(config.passwords)
+	if err != nil {
+		slog.Error("failed to create password policy", "error", err)
+		os.Exit(1)
+	}
+
+	oidcProviders, err := newOIDCProviders(config.oidc)
+	if err != nil {
+		slog.Error("failed to set up OpenID Connect providers", "error", err)
+		os.Exit(1)
+	}
+
+	if !slices.Contains(data.DeletionPolicies, config.users.deletionPolicy) {
+		slog.Error("unknown account deletion policy", "policy", config.users.deletionPolicy)
+		os.Exit(1)
+	}
+
 	// Cache users for 15 minutes, cleanup expired items every 10 minutes
 	userCache := data.NewUserCache(15*time.Minute, 10*time.Minute)
 
-	return &application{
-		config:     config,
-		logger:     logger,
-		modelStore: newModelStore(config, userCache),
-		jwtMaker:   jwtMaker,
-		userCache:  userCache,
+	modelStore := newModelStore(config, userCache)
+	subscribers := outbox.NewSubscribers()
+	dispatcher := outbox.NewDispatcher(modelStore.Outbox, logger, config.outbox.retryBackoff,
+		outbox.LogSink{Logger: logger},
+		outbox.WebhookSink{Webhooks: modelStore.Webhooks},
+		subscribers,
+	)
+
+	app := &application{
+		config:         config,
+		logger:         logger,
+		modelStore:     modelStore,
+		jwtMaker:       jwtMaker,
+		userCache:      userCache,
+		markdown:       markdown.NewRenderer(),
+		events:         events.NewBroker(config.events.historySize),
+		webhookClient:  webhooks.NewClient(config.webhooks.timeout, config.webhooks.allowPrivateNetworks),
+		outbox:         dispatcher,
+		subscribers:    subscribers,
+		contentFilter:  contentFilter,
+		passwords:      passwordHasher,
+		passwordPolicy: passwordPolicy,
+		oidcProviders:  oidcProviders,
+		now:            time.Now,
+		shutdown:       make(chan struct{}),
 	}
+	subscribers.Subscribe(app.handleArticlePublished, data.EventArticlePublished)
+	subscribers.Subscribe(app.handleCommentApproved, data.EventCommentCreated)
+
+	return app
 }
 
 func newModelStore(config appConfig, userCache *data.UserCache) data.ModelStore {
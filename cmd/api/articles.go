@@ -3,6 +3,7 @@ package main
 import (
 	"errors"
 	"net/http"
+	"time"
 
 	"github.com/96malhar/realworld-backend/internal/data"
 	"github.com/96malhar/realworld-backend/internal/validator"
@@ -22,6 +23,7 @@ func (app *application) listArticlesHandler(w http.ResponseWriter, r *http.Reque
 		Tag:       qs.Get("tag"),
 		Author:    qs.Get("author"),
 		Favorited: qs.Get("favorited"),
+		Status:    qs.Get("status"),
 		Limit:     pagination.Limit,
 		Offset:    pagination.Offset,
 	}
@@ -88,13 +90,42 @@ func (app *application) feedArticlesHandler(w http.ResponseWriter, r *http.Reque
 	}
 }
 
+// listBookmarksHandler returns the current user's private reading list, most recently bookmarked first.
+func (app *application) listBookmarksHandler(w http.ResponseWriter, r *http.Request) {
+	pagination := app.readPagination(r, 20, 100)
+	currentUser := app.contextGetUser(r)
+
+	filters := data.ArticleFilters{
+		Bookmarked: true,
+		Limit:      pagination.Limit,
+		Offset:     pagination.Offset,
+	}
+
+	articles, totalCount, err := app.modelStore.Articles.List(filters, currentUser)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{
+		"articles":      articles,
+		"articlesCount": totalCount,
+	}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+}
+
 func (app *application) createArticleHandler(w http.ResponseWriter, r *http.Request) {
 	var input struct {
 		Article struct {
-			Title       string   `json:"title"`
-			Description string   `json:"description"`
-			Body        string   `json:"body"`
-			TagList     []string `json:"tagList"`
+			Title       string     `json:"title"`
+			Description string     `json:"description"`
+			Body        string     `json:"body"`
+			TagList     []string   `json:"tagList"`
+			Status      *string    `json:"status"`
+			PublishAt   *time.Time `json:"publishAt"`
 		} `json:"article"`
 	}
 
@@ -110,15 +141,33 @@ func (app *application) createArticleHandler(w http.ResponseWriter, r *http.Requ
 		Body:        input.Article.Body,
 		TagList:     input.Article.TagList,
 		AuthorID:    app.contextGetUser(r).ID,
+		Status:      data.ArticleStatusPublished,
+		PublishAt:   input.Article.PublishAt,
 	}
 
-	v := validator.New()
+	if input.Article.Status != nil {
+		article.Status = *input.Article.Status
+	}
+
+	// A publish time is only meaningful for scheduled articles
+	if article.Status != data.ArticleStatusScheduled {
+		article.PublishAt = nil
+	}
 
-	if data.ValidateArticle(v, article); !v.Valid() {
+	v := validator.New()
+	data.ValidateArticle(v, article)
+	data.ValidatePublishAt(v, article, app.now())
+	if !v.Valid() {
 		app.failedValidationResponse(w, r, v.Errors)
 		return
 	}
 
+	var ok bool
+	article.HoldReasons, ok = app.screenContent(w, r, articleText(article)...)
+	if !ok {
+		return
+	}
+
 	// Insert article and get complete article with author in a single query
 	// Tags are inserted synchronously as part of the article insertion
 	createdArticle, err := app.modelStore.Articles.InsertAndReturn(article, app.contextGetUser(r))
@@ -127,6 +176,14 @@ func (app *application) createArticleHandler(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
+	app.publishArticleCreated(*createdArticle)
+
+	err = app.renderArticleHTML(r, createdArticle)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
 	// Return response with created article
 	headers := make(http.Header)
 	headers.Set("Location", "/articles/"+createdArticle.Slug)
@@ -150,6 +207,12 @@ func (app *application) getArticleHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	err = app.renderArticleHTML(r, article)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
 	err = app.writeJSON(w, http.StatusOK, envelope{"article": article}, nil)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
@@ -162,6 +225,34 @@ func (app *application) favoriteArticleHandler(w http.ResponseWriter, r *http.Re
 	user := app.contextGetUser(r)
 
 	article, err := app.modelStore.Articles.FavoriteBySlug(slug, user.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		case errors.Is(err, data.ErrBlocked):
+			app.blockedResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	app.notify(func() error {
+		return app.modelStore.Notifications.NotifyArticleAuthor(data.NotificationFavorite, user.ID, article.ID)
+	})
+	app.publishFavoritesChanged(article)
+
+	if err := app.writeJSON(w, http.StatusOK, envelope{"article": article}, nil); err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+}
+
+func (app *application) unfavoriteArticleHandler(w http.ResponseWriter, r *http.Request) {
+	slug := chi.URLParam(r, "slug")
+	user := app.contextGetUser(r)
+
+	article, err := app.modelStore.Articles.UnfavoriteBySlug(slug, user.ID)
 	if err != nil {
 		if errors.Is(err, data.ErrRecordNotFound) {
 			app.notFoundResponse(w, r)
@@ -171,17 +262,50 @@ func (app *application) favoriteArticleHandler(w http.ResponseWriter, r *http.Re
 		return
 	}
 
+	app.publishFavoritesChanged(article)
+
 	if err := app.writeJSON(w, http.StatusOK, envelope{"article": article}, nil); err != nil {
 		app.serverErrorResponse(w, r, err)
 		return
 	}
 }
 
-func (app *application) unfavoriteArticleHandler(w http.ResponseWriter, r *http.Request) {
+// bookmarkArticleHandler adds a published article to the current user's reading list.
+// Unlike favorites, bookmarks are private and do not affect favoritesCount.
+func (app *application) bookmarkArticleHandler(w http.ResponseWriter, r *http.Request) {
+	app.setBookmark(w, r, true)
+}
+
+// unbookmarkArticleHandler removes an article from the current user's reading list.
+func (app *application) unbookmarkArticleHandler(w http.ResponseWriter, r *http.Request) {
+	app.setBookmark(w, r, false)
+}
+
+func (app *application) setBookmark(w http.ResponseWriter, r *http.Request, bookmarked bool) {
 	slug := chi.URLParam(r, "slug")
 	user := app.contextGetUser(r)
 
-	article, err := app.modelStore.Articles.UnfavoriteBySlug(slug, user.ID)
+	articleID, err := app.modelStore.Articles.GetIDBySlug(slug, user)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			app.notFoundResponse(w, r)
+			return
+		}
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	if bookmarked {
+		err = app.modelStore.Articles.Bookmark(articleID, user.ID)
+	} else {
+		err = app.modelStore.Articles.Unbookmark(articleID, user.ID)
+	}
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	article, err := app.modelStore.Articles.GetBySlug(slug, user)
 	if err != nil {
 		if errors.Is(err, data.ErrRecordNotFound) {
 			app.notFoundResponse(w, r)
@@ -201,7 +325,7 @@ func (app *application) deleteArticleHandler(w http.ResponseWriter, r *http.Requ
 	slug := chi.URLParam(r, "slug")
 	user := app.contextGetUser(r)
 
-	err := app.modelStore.Articles.DeleteBySlug(slug, user.ID)
+	err := app.modelStore.Articles.DeleteBySlug(slug, user)
 	if err != nil {
 		switch {
 		case errors.Is(err, data.ErrRecordNotFound):
@@ -229,16 +353,18 @@ func (app *application) updateArticleHandler(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	if article.Author.Username != user.Username {
+	if article.Author.Username != user.Username && !user.HasPermission(data.PermissionArticlesUpdateAny) {
 		app.notPermittedResponse(w, r)
 		return
 	}
 
 	var input struct {
 		Article struct {
-			Title       *string `json:"title"`
-			Description *string `json:"description"`
-			Body        *string `json:"body"`
+			Title       *string    `json:"title"`
+			Description *string    `json:"description"`
+			Body        *string    `json:"body"`
+			Status      *string    `json:"status"`
+			PublishAt   *time.Time `json:"publishAt"`
 		} `json:"article"`
 	}
 
@@ -249,8 +375,7 @@ func (app *application) updateArticleHandler(w http.ResponseWriter, r *http.Requ
 	}
 
 	if input.Article.Title != nil {
-		article.Title = *input.Article.Title
-		article.GenerateSlug()
+		app.setArticleTitle(article, *input.Article.Title)
 	}
 
 	if input.Article.Description != nil {
@@ -261,23 +386,60 @@ func (app *application) updateArticleHandler(w http.ResponseWriter, r *http.Requ
 		article.Body = *input.Article.Body
 	}
 
+	previousStatus, previousPublishAt := article.Status, article.PublishAt
+
+	if input.Article.Status != nil {
+		article.Status = *input.Article.Status
+	}
+
+	if input.Article.PublishAt != nil {
+		article.PublishAt = input.Article.PublishAt
+	}
+
+	// A publish time is only meaningful for scheduled articles
+	if article.Status != data.ArticleStatusScheduled {
+		article.PublishAt = nil
+	}
+
 	v := validator.New()
-	if data.ValidateArticle(v, article); !v.Valid() {
+	data.ValidateArticle(v, article)
+	// Only a change to the schedule has to be in the future, so that an article that has fallen due can still be edited
+	if article.Status != previousStatus || !equalTimes(article.PublishAt, previousPublishAt) {
+		data.ValidatePublishAt(v, article, app.now())
+	}
+	if !v.Valid() {
 		app.failedValidationResponse(w, r, v.Errors)
 		return
 	}
 
-	err = app.modelStore.Articles.Update(article)
+	// Only edits of the text are screened, so that publishing a draft doesn't hold it again
+	if input.Article.Title != nil || input.Article.Description != nil || input.Article.Body != nil {
+		var ok bool
+		article.HoldReasons, ok = app.screenContent(w, r, articleText(article)...)
+		if !ok {
+			return
+		}
+	}
+
+	err = app.modelStore.Articles.Update(article, user)
 	if err != nil {
 		switch {
 		case errors.Is(err, data.ErrRecordNotFound):
 			app.notFoundResponse(w, r)
+		case errors.Is(err, data.ErrEditConflict):
+			app.editConflictResponse(w, r)
 		default:
 			app.serverErrorResponse(w, r, err)
 		}
 		return
 	}
 
+	err = app.renderArticleHTML(r, article)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
 	// set location header to point to the new article
 	headers := make(http.Header)
 	headers.Set("Location", "/articles/"+article.Slug)
@@ -286,3 +448,29 @@ func (app *application) updateArticleHandler(w http.ResponseWriter, r *http.Requ
 		app.serverErrorResponse(w, r, err)
 	}
 }
+
+// articleText returns the fields of an article screened by the content filter.
+func articleText(article *data.Article) []string {
+	return append([]string{article.Title, article.Description, article.Body}, article.TagList...)
+}
+
+// equalTimes reports whether two optional times are both unset or the same instant.
+func equalTimes(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+// setArticleTitle changes the title of an existing article. A new slug is generated when the
+// title actually changes, unless the application is configured to keep slugs stable.
+func (app *application) setArticleTitle(article *data.Article, title string) {
+	if title == article.Title {
+		return
+	}
+
+	article.Title = title
+	if !app.config.articles.keepSlugOnTitleChange {
+		article.GenerateSlug()
+	}
+}
@@ -1421,7 +1421,7 @@ func TestArticleStore_GetIDBySlug(t *testing.T) {
 	slug := articleLocation[10:] // Remove "/articles/" prefix
 
 	// Test: Get article ID by slug
-	articleID, err := ts.app.modelStore.Articles.GetIDBySlug(slug)
+	articleID, err := ts.app.modelStore.Articles.GetIDBySlug(slug, nil)
 	require.NoError(t, err)
 	require.NotZero(t, articleID, "Article ID should not be zero")
 
@@ -1431,7 +1431,7 @@ func TestArticleStore_GetIDBySlug(t *testing.T) {
 	require.Equal(t, fullArticle.ID, articleID, "IDs should match")
 
 	// Test: Non-existent slug
-	nonExistentID, err := ts.app.modelStore.Articles.GetIDBySlug("non-existent-slug-12345")
+	nonExistentID, err := ts.app.modelStore.Articles.GetIDBySlug("non-existent-slug-12345", nil)
 	require.Error(t, err)
 	require.Equal(t, data.ErrRecordNotFound, err, "Should return ErrRecordNotFound for non-existent slug")
 	require.Zero(t, nonExistentID, "ID should be zero for non-existent article")
@@ -1711,3 +1711,491 @@ func TestFeedArticlesHandler(t *testing.T) {
 		}
 	})
 }
+
+func TestArticleDraftsAndScheduling(t *testing.T) {
+	t.Parallel()
+
+	ts := newTestServer(t)
+
+	registerUser(t, ts, "alice", "alice@example.com", "password123")
+	registerUser(t, ts, "bob", "bob@example.com", "password123")
+	aliceToken := loginUser(t, ts, "alice@example.com", "password123")
+	bobToken := loginUser(t, ts, "bob@example.com", "password123")
+	aliceHeader := map[string]string{"Authorization": "Token " + aliceToken}
+	bobHeader := map[string]string{"Authorization": "Token " + bobToken}
+
+	createWithStatus := func(t *testing.T, body string) data.Article {
+		t.Helper()
+		res, err := ts.executeRequest(http.MethodPost, "/articles", body, aliceHeader)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		require.Equal(t, http.StatusCreated, res.StatusCode)
+
+		var response getArticleResponse
+		readJsonResponse(t, res.Body, &response)
+		return response.Article
+	}
+
+	listArticles := func(t *testing.T, urlPath string, headers map[string]string) []data.Article {
+		t.Helper()
+		res, err := ts.executeRequest(http.MethodGet, urlPath, "", headers)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		var response struct {
+			Articles      []data.Article `json:"articles"`
+			ArticlesCount int            `json:"articlesCount"`
+		}
+		readJsonResponse(t, res.Body, &response)
+		return response.Articles
+	}
+
+	createArticle(t, ts, aliceToken, "Published Article", "Description", "Body", nil)
+	draft := createWithStatus(t, `{"article": {"title": "Draft Article", "description": "Description", "body": "Body", "status": "draft"}}`)
+	assert.Equal(t, data.ArticleStatusDraft, draft.Status)
+	assert.Nil(t, draft.PublishAt)
+	assert.Nil(t, draft.PublishedAt)
+
+	// The application's clock runs an hour behind the database's, so that an article can be scheduled
+	// for a time that is already due when scheduled articles are published.
+	now := time.Now()
+	ts.app.now = func() time.Time { return now.Add(-time.Hour) }
+
+	publishAt := now.Add(-time.Minute).UTC()
+	scheduled := createWithStatus(t, `{"article": {"title": "Scheduled Article", "description": "Description", "body": "Body", "status": "scheduled", "publishAt": "`+publishAt.Format(time.RFC3339Nano)+`"}}`)
+	assert.Equal(t, data.ArticleStatusScheduled, scheduled.Status)
+	require.NotNil(t, scheduled.PublishAt)
+
+	later := createWithStatus(t, `{"article": {"title": "Later Article", "description": "Description", "body": "Body", "status": "scheduled", "publishAt": "`+now.Add(time.Hour).UTC().Format(time.RFC3339Nano)+`"}}`)
+	assert.Equal(t, data.ArticleStatusScheduled, later.Status)
+
+	followUser(t, ts, bobToken, "alice")
+
+	testcases := []handlerTestcase{
+		{
+			name:                   "Author can view own draft",
+			requestMethodType:      http.MethodGet,
+			requestUrlPath:         "/articles/" + draft.Slug,
+			requestHeader:          aliceHeader,
+			wantResponseStatusCode: http.StatusOK,
+		},
+		{
+			name:                   "Other users cannot view a draft",
+			requestMethodType:      http.MethodGet,
+			requestUrlPath:         "/articles/" + draft.Slug,
+			requestHeader:          bobHeader,
+			wantResponseStatusCode: http.StatusNotFound,
+		},
+		{
+			name:                   "Anonymous users cannot view a scheduled article",
+			requestMethodType:      http.MethodGet,
+			requestUrlPath:         "/articles/" + scheduled.Slug,
+			wantResponseStatusCode: http.StatusNotFound,
+		},
+		{
+			name:                   "Drafts cannot be favorited",
+			requestMethodType:      http.MethodPost,
+			requestUrlPath:         "/articles/" + draft.Slug + "/favorite",
+			requestHeader:          bobHeader,
+			wantResponseStatusCode: http.StatusNotFound,
+		},
+		{
+			name:                   "Invalid status",
+			requestMethodType:      http.MethodPost,
+			requestUrlPath:         "/articles",
+			requestHeader:          aliceHeader,
+			requestBody:            `{"article": {"title": "Title", "description": "Description", "body": "Body", "status": "archived"}}`,
+			wantResponseStatusCode: http.StatusUnprocessableEntity,
+			wantResponse: errorResponse{
+				Errors: []string{"Status must be one of draft, published or scheduled"},
+			},
+		},
+		{
+			name:                   "Scheduled article without publishAt",
+			requestMethodType:      http.MethodPost,
+			requestUrlPath:         "/articles",
+			requestHeader:          aliceHeader,
+			requestBody:            `{"article": {"title": "Title", "description": "Description", "body": "Body", "status": "scheduled"}}`,
+			wantResponseStatusCode: http.StatusUnprocessableEntity,
+			wantResponse: errorResponse{
+				Errors: []string{"PublishAt must be provided for scheduled articles"},
+			},
+		},
+		{
+			name:                   "Scheduled article with publishAt in the past",
+			requestMethodType:      http.MethodPost,
+			requestUrlPath:         "/articles",
+			requestHeader:          aliceHeader,
+			requestBody:            `{"article": {"title": "Title", "description": "Description", "body": "Body", "status": "scheduled", "publishAt": "2020-01-01T00:00:00Z"}}`,
+			wantResponseStatusCode: http.StatusUnprocessableEntity,
+			wantResponse: errorResponse{
+				Errors: []string{"PublishAt must be in the future"},
+			},
+		},
+	}
+
+	testHandler(t, ts, testcases...)
+
+	t.Run("Lists and feeds only contain published articles", func(t *testing.T) {
+		for _, articles := range [][]data.Article{
+			listArticles(t, "/articles", aliceHeader),
+			listArticles(t, "/articles", nil),
+			listArticles(t, "/articles/feed", bobHeader),
+		} {
+			require.Len(t, articles, 1)
+			assert.Equal(t, "Published Article", articles[0].Title)
+		}
+	})
+
+	t.Run("Authors can list their own drafts", func(t *testing.T) {
+		articles := listArticles(t, "/articles?status=draft", aliceHeader)
+		require.Len(t, articles, 1)
+		assert.Equal(t, draft.Slug, articles[0].Slug)
+
+		assert.Empty(t, listArticles(t, "/articles?status=draft", bobHeader))
+		assert.Empty(t, listArticles(t, "/articles?status=draft", nil))
+	})
+
+	t.Run("Publishing a draft makes it visible", func(t *testing.T) {
+		res, err := ts.executeRequest(http.MethodPut, "/articles/"+draft.Slug, `{"article": {"status": "published"}}`, aliceHeader)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		res, err = ts.executeRequest(http.MethodGet, "/articles/"+draft.Slug, "", bobHeader)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+	})
+
+	t.Run("Scheduled articles that have fallen due can still be edited", func(t *testing.T) {
+		ts.app.now = func() time.Time { return now }
+
+		res, err := ts.executeRequest(http.MethodPut, "/articles/"+scheduled.Slug, `{"article": {"body": "Edited Body"}}`, aliceHeader)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		var response getArticleResponse
+		readJsonResponse(t, res.Body, &response)
+		assert.Equal(t, data.ArticleStatusScheduled, response.Article.Status)
+		assert.Equal(t, "Edited Body", response.Article.Body)
+
+		// Rescheduling still has to be in the future
+		res, err = ts.executeRequest(http.MethodPut, "/articles/"+scheduled.Slug, `{"article": {"publishAt": "2020-01-01T00:00:00Z"}}`, aliceHeader)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+	})
+
+	t.Run("Scheduled articles are published once due", func(t *testing.T) {
+		count, err := ts.app.modelStore.Articles.PublishScheduled()
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), count, "Only the article that has fallen due should be published")
+
+		article, err := ts.app.modelStore.Articles.GetBySlug(scheduled.Slug, data.AnonymousUser)
+		require.NoError(t, err)
+		assert.Equal(t, data.ArticleStatusPublished, article.Status)
+		assert.Nil(t, article.PublishAt)
+
+		// The article is dated by when it was scheduled to be published rather than when it was drafted
+		require.NotNil(t, article.PublishedAt)
+		assert.WithinDuration(t, publishAt, *article.PublishedAt, time.Millisecond)
+
+		res, err := ts.executeRequest(http.MethodGet, "/articles/"+later.Slug, "", aliceHeader)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		var response getArticleResponse
+		readJsonResponse(t, res.Body, &response)
+		assert.Equal(t, data.ArticleStatusScheduled, response.Article.Status)
+	})
+}
+
+func TestArticleSlugHistory(t *testing.T) {
+	t.Parallel()
+
+	ts := newTestServer(t)
+
+	registerUser(t, ts, "alice", "alice@example.com", "password123")
+	registerUser(t, ts, "bob", "bob@example.com", "password123")
+	aliceToken := loginUser(t, ts, "alice@example.com", "password123")
+	bobToken := loginUser(t, ts, "bob@example.com", "password123")
+
+	oldLocation := createArticle(t, ts, aliceToken, "Original Title", "Description", "Body", nil)
+	oldSlug := strings.TrimPrefix(oldLocation, "/articles/")
+	newLocation := updateArticleHelper(t, ts, aliceToken, oldSlug, `{"article": {"title": "Renamed Title"}}`)
+	newSlug := strings.TrimPrefix(newLocation, "/articles/")
+	require.NotEqual(t, oldSlug, newSlug)
+
+	res, err := ts.executeRequest(http.MethodPost, "/articles",
+		`{"article": {"title": "Draft Title", "description": "Description", "body": "Body", "status": "draft"}}`,
+		map[string]string{"Authorization": "Token " + aliceToken})
+	require.NoError(t, err)
+	res.Body.Close() // nolint: errcheck
+	require.Equal(t, http.StatusCreated, res.StatusCode)
+	oldDraftLocation := res.Header.Get("Location")
+	newDraftLocation := updateArticleHelper(t, ts, aliceToken, strings.TrimPrefix(oldDraftLocation, "/articles/"), `{"article": {"title": "Renamed Draft"}}`)
+
+	testcases := []handlerTestcase{
+		{
+			name:                   "GET with an old slug redirects to the canonical URL",
+			requestMethodType:      http.MethodGet,
+			requestUrlPath:         oldLocation,
+			wantResponseStatusCode: http.StatusMovedPermanently,
+			wantResponseHeader:     map[string]string{"Location": newLocation},
+		},
+		{
+			name:                   "GET on a nested route keeps the path and query string",
+			requestMethodType:      http.MethodGet,
+			requestUrlPath:         oldLocation + "/revisions/diff?from=1&to=2",
+			wantResponseStatusCode: http.StatusMovedPermanently,
+			wantResponseHeader:     map[string]string{"Location": newLocation + "/revisions/diff?from=1&to=2"},
+		},
+		{
+			name:                   "GET with the current slug is served directly",
+			requestMethodType:      http.MethodGet,
+			requestUrlPath:         newLocation,
+			wantResponseStatusCode: http.StatusOK,
+		},
+		{
+			name:                   "Non-GET requests with an old slug act on the current article",
+			requestMethodType:      http.MethodPost,
+			requestUrlPath:         oldLocation + "/favorite",
+			requestHeader:          map[string]string{"Authorization": "Token " + bobToken},
+			wantResponseStatusCode: http.StatusOK,
+			additionalChecks: func(t *testing.T, res *http.Response) {
+				var response getArticleResponse
+				readJsonResponse(t, res.Body, &response)
+				assert.Equal(t, newSlug, response.Article.Slug)
+				assert.True(t, response.Article.Favorited)
+			},
+		},
+		{
+			name:                   "Unknown slugs are not found",
+			requestMethodType:      http.MethodGet,
+			requestUrlPath:         "/articles/never-existed",
+			wantResponseStatusCode: http.StatusNotFound,
+		},
+		{
+			name:                   "Old slugs of a draft redirect its author",
+			requestMethodType:      http.MethodGet,
+			requestUrlPath:         oldDraftLocation,
+			requestHeader:          map[string]string{"Authorization": "Token " + aliceToken},
+			wantResponseStatusCode: http.StatusMovedPermanently,
+			wantResponseHeader:     map[string]string{"Location": newDraftLocation},
+		},
+		{
+			name:                   "Old slugs of a draft are not found for other users",
+			requestMethodType:      http.MethodGet,
+			requestUrlPath:         oldDraftLocation,
+			requestHeader:          map[string]string{"Authorization": "Token " + bobToken},
+			wantResponseStatusCode: http.StatusNotFound,
+		},
+	}
+
+	testHandler(t, ts, testcases...)
+
+	t.Run("Slug stays unchanged when configured", func(t *testing.T) {
+		ts.app.config.articles.keepSlugOnTitleChange = true
+
+		location := updateArticleHelper(t, ts, aliceToken, newSlug, `{"article": {"title": "Another Title"}}`)
+		assert.Equal(t, newLocation, location)
+
+		article, err := ts.app.modelStore.Articles.GetBySlug(newSlug, data.AnonymousUser)
+		require.NoError(t, err)
+		assert.Equal(t, "Another Title", article.Title)
+	})
+}
+
+func TestArticleSlugCollisions(t *testing.T) {
+	t.Parallel()
+
+	ts := newTestServer(t)
+
+	registerUser(t, ts, "alice", "alice@example.com", "password123")
+	aliceToken := loginUser(t, ts, "alice@example.com", "password123")
+
+	first := createArticle(t, ts, aliceToken, "Same Title", "Description", "Body", nil)
+	second := createArticle(t, ts, aliceToken, "Same Title", "Description", "Body", nil)
+	third := createArticle(t, ts, aliceToken, "Same Title!", "Description", "Body", nil)
+
+	assert.Equal(t, "/articles/same-title", first)
+	assert.Equal(t, "/articles/same-title-2", second)
+	assert.Equal(t, "/articles/same-title-3", third)
+
+	t.Run("Renaming to a taken title picks the next free suffix", func(t *testing.T) {
+		other := createArticle(t, ts, aliceToken, "Other Title", "Description", "Body", nil)
+		location := updateArticleHelper(t, ts, aliceToken, strings.TrimPrefix(other, "/articles/"), `{"article": {"title": "Same Title"}}`)
+		assert.Equal(t, "/articles/same-title-4", location)
+	})
+
+	t.Run("Previous slugs of other articles are not reused", func(t *testing.T) {
+		renamed := createArticle(t, ts, aliceToken, "First Name", "Description", "Body", nil)
+		updateArticleHelper(t, ts, aliceToken, strings.TrimPrefix(renamed, "/articles/"), `{"article": {"title": "Second Name"}}`)
+
+		location := createArticle(t, ts, aliceToken, "First Name", "Description", "Body", nil)
+		assert.Equal(t, "/articles/first-name-2", location)
+
+		// The old slug still leads to the renamed article
+		res, err := ts.executeRequest(http.MethodGet, renamed, "", nil)
+		require.NoError(t, err)
+		defer res.Body.Close() // nolint: errcheck
+		assert.Equal(t, http.StatusMovedPermanently, res.StatusCode)
+		assert.Equal(t, "/articles/second-name", res.Header.Get("Location"))
+	})
+
+	t.Run("Non-Latin titles are transliterated", func(t *testing.T) {
+		location := createArticle(t, ts, aliceToken, "Привет мир", "Description", "Body", nil)
+		assert.Equal(t, "/articles/privet-mir", location)
+	})
+}
+
+func TestGetArticleHandler_RenderHTML(t *testing.T) {
+	t.Parallel()
+
+	ts := newTestServer(t)
+	registerUser(t, ts, "alice", "alice@example.com", "password123")
+	aliceToken := loginUser(t, ts, "alice@example.com", "password123")
+	location := createArticle(t, ts, aliceToken, "Markdown Article", "Description",
+		`# Heading\n\n[link](javascript:alert(1)) <script>alert(1)</script>`, nil)
+
+	testcases := []handlerTestcase{
+		{
+			name:                   "Article includes sanitized HTML when requested",
+			requestMethodType:      http.MethodGet,
+			requestUrlPath:         location + "?render=html",
+			wantResponseStatusCode: http.StatusOK,
+			additionalChecks: func(t *testing.T, res *http.Response) {
+				var response getArticleResponse
+				readJsonResponse(t, res.Body, &response)
+
+				assert.Contains(t, response.Article.BodyHTML, "<h1>Heading</h1>")
+				assert.NotContains(t, response.Article.BodyHTML, "javascript:")
+				assert.NotContains(t, response.Article.BodyHTML, "<script")
+			},
+		},
+		{
+			name:                   "Article omits HTML by default",
+			requestMethodType:      http.MethodGet,
+			requestUrlPath:         location,
+			wantResponseStatusCode: http.StatusOK,
+			additionalChecks: func(t *testing.T, res *http.Response) {
+				var response getArticleResponse
+				readJsonResponse(t, res.Body, &response)
+
+				assert.Empty(t, response.Article.BodyHTML)
+			},
+		},
+	}
+
+	testHandler(t, ts, testcases...)
+}
+
+func TestArticleBookmarks(t *testing.T) {
+	t.Parallel()
+
+	ts := newTestServer(t)
+
+	registerUser(t, ts, "alice", "alice@example.com", "password123")
+	registerUser(t, ts, "bob", "bob@example.com", "password123")
+	aliceToken := loginUser(t, ts, "alice@example.com", "password123")
+	bobToken := loginUser(t, ts, "bob@example.com", "password123")
+	bobHeaders := map[string]string{"Authorization": "Token " + bobToken}
+
+	first := createArticle(t, ts, aliceToken, "First Article", "Test description", "Test body", []string{"test"})
+	second := createArticle(t, ts, aliceToken, "Second Article", "Test description", "Test body", []string{"test"})
+	createArticle(t, ts, aliceToken, "Third Article", "Test description", "Test body", []string{"test"})
+
+	bookmark := func(t *testing.T, method, location string) getArticleResponse {
+		t.Helper()
+		res, err := ts.executeRequest(method, location+"/bookmark", "", bobHeaders)
+		require.NoError(t, err)
+		defer res.Body.Close() // nolint: errcheck
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		var response getArticleResponse
+		readJsonResponse(t, res.Body, &response)
+		return response
+	}
+
+	listBookmarks := func(t *testing.T) []string {
+		t.Helper()
+		res, err := ts.executeRequest(http.MethodGet, "/user/bookmarks", "", bobHeaders)
+		require.NoError(t, err)
+		defer res.Body.Close() // nolint: errcheck
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		var response struct {
+			Articles      []data.Article `json:"articles"`
+			ArticlesCount int            `json:"articlesCount"`
+		}
+		readJsonResponse(t, res.Body, &response)
+		require.Equal(t, len(response.Articles), response.ArticlesCount)
+
+		var titles []string
+		for _, article := range response.Articles {
+			assert.True(t, article.Bookmarked)
+			titles = append(titles, article.Title)
+		}
+		return titles
+	}
+
+	t.Run("Bookmarking does not affect favorites", func(t *testing.T) {
+		response := bookmark(t, http.MethodPost, second)
+		assert.True(t, response.Article.Bookmarked)
+		assert.False(t, response.Article.Favorited)
+		assert.Equal(t, 0, response.Article.FavoritesCount)
+
+		// Bookmarking twice is idempotent
+		response = bookmark(t, http.MethodPost, second)
+		assert.True(t, response.Article.Bookmarked)
+	})
+
+	t.Run("Reading list is ordered by bookmark time", func(t *testing.T) {
+		bookmark(t, http.MethodPost, first)
+		assert.Equal(t, []string{"First Article", "Second Article"}, listBookmarks(t))
+	})
+
+	t.Run("Bookmarks are private", func(t *testing.T) {
+		res, err := ts.executeRequest(http.MethodGet, first, "", map[string]string{"Authorization": "Token " + aliceToken})
+		require.NoError(t, err)
+		defer res.Body.Close() // nolint: errcheck
+
+		var response getArticleResponse
+		readJsonResponse(t, res.Body, &response)
+		assert.False(t, response.Article.Bookmarked, "Bob's bookmark should not show up for Alice")
+	})
+
+	t.Run("Removing a bookmark", func(t *testing.T) {
+		response := bookmark(t, http.MethodDelete, second)
+		assert.False(t, response.Article.Bookmarked)
+		assert.Equal(t, []string{"First Article"}, listBookmarks(t))
+	})
+
+	testcases := []handlerTestcase{
+		{
+			name:                   "Bookmarking requires authentication",
+			requestMethodType:      http.MethodPost,
+			requestUrlPath:         first + "/bookmark",
+			wantResponseStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:                   "Listing bookmarks requires authentication",
+			requestMethodType:      http.MethodGet,
+			requestUrlPath:         "/user/bookmarks",
+			wantResponseStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:                   "Bookmarking a non-existent article",
+			requestMethodType:      http.MethodPost,
+			requestUrlPath:         "/articles/does-not-exist/bookmark",
+			requestHeader:          bobHeaders,
+			wantResponseStatusCode: http.StatusNotFound,
+		},
+	}
+
+	testHandler(t, ts, testcases...)
+}
@@ -14,7 +14,8 @@ func (app *application) createCommentHandler(w http.ResponseWriter, r *http.Requ
 
 	var input struct {
 		Comment struct {
-			Body string `json:"body"`
+			Body     string `json:"body"`
+			ParentID *int64 `json:"parentId"`
 		} `json:"comment"`
 	}
 
@@ -25,7 +26,7 @@ func (app *application) createCommentHandler(w http.ResponseWriter, r *http.Requ
 	}
 
 	// Get the article ID by slug
-	articleID, err := app.modelStore.Articles.GetIDBySlug(slug)
+	articleID, err := app.modelStore.Articles.GetIDBySlug(slug, app.contextGetUser(r))
 	if err != nil {
 		if errors.Is(err, data.ErrRecordNotFound) {
 			app.notFoundResponse(w, r)
@@ -39,6 +40,7 @@ func (app *application) createCommentHandler(w http.ResponseWriter, r *http.Requ
 		Body:      input.Comment.Body,
 		ArticleID: articleID,
 		AuthorID:  app.contextGetUser(r).ID,
+		ParentID:  input.Comment.ParentID,
 	}
 
 	v := validator.New()
@@ -48,11 +50,54 @@ func (app *application) createCommentHandler(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
+	// Replies must attach to an existing comment on the same article, within the depth limit
+	if comment.ParentID != nil {
+		parent, err := app.modelStore.Comments.GetByID(*comment.ParentID)
+		if err != nil {
+			if errors.Is(err, data.ErrRecordNotFound) {
+				app.failedValidationResponse(w, r, []string{"parentId must refer to an existing comment"})
+				return
+			}
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+
+		if data.ValidateReply(v, comment, parent); !v.Valid() {
+			app.failedValidationResponse(w, r, v.Errors)
+			return
+		}
+	}
+
+	var ok bool
+	comment.HoldReasons, ok = app.screenContent(w, r, comment.Body)
+	if !ok {
+		return
+	}
+
 	currentUser := app.contextGetUser(r)
 
 	// Insert comment and get complete comment with author in a single operation
 	// Uses currentUser from context instead of querying database
 	createdComment, err := app.modelStore.Comments.InsertAndReturn(comment, currentUser)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrBlocked):
+			app.blockedResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	// Comments held for moderation are not announced until a moderator has approved them
+	if !createdComment.Hidden {
+		app.notify(func() error {
+			return app.modelStore.Notifications.NotifyArticleAuthor(data.NotificationComment, currentUser.ID, articleID)
+		})
+		app.publishCommentCreated(slug, *createdComment)
+	}
+
+	err = app.renderCommentHTML(r, createdComment)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 		return
@@ -67,9 +112,10 @@ func (app *application) createCommentHandler(w http.ResponseWriter, r *http.Requ
 
 func (app *application) getCommentsHandler(w http.ResponseWriter, r *http.Request) {
 	slug := chi.URLParam(r, "slug")
+	currentUser := app.contextGetUser(r)
 
 	// Get the article ID by slug (verifies article exists)
-	articleID, err := app.modelStore.Articles.GetIDBySlug(slug)
+	articleID, err := app.modelStore.Articles.GetIDBySlug(slug, currentUser)
 	if err != nil {
 		if errors.Is(err, data.ErrRecordNotFound) {
 			app.notFoundResponse(w, r)
@@ -79,15 +125,35 @@ func (app *application) getCommentsHandler(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	// Get all comments for the article (includes author details via JOIN)
-	comments, err := app.modelStore.Comments.GetByArticleID(articleID)
+	// Read pagination and ordering parameters; the cursor replaces offset-based paging
+	pagination := app.readPagination(r, 20, 100)
+	qs := r.URL.Query()
+	filters := data.CommentFilters{
+		Limit: pagination.Limit,
+		Sort:  qs.Get("sort"),
+	}
+	if filters.Sort == "" {
+		filters.Sort = data.CommentSortNewest
+	}
+
+	v := validator.New()
+	if c := qs.Get("cursor"); c != "" {
+		filters.Cursor, err = data.DecodeCommentCursor(c)
+		v.Check(err == nil, "Cursor is invalid")
+	}
+	if filters.Validate(v); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	// Get one page of comments for the article (includes author details via JOIN)
+	comments, commentsCount, next, err := app.modelStore.Comments.GetByArticleID(articleID, filters, currentUser)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 		return
 	}
 
 	// Set following status if user is authenticated (single bulk query)
-	currentUser := app.contextGetUser(r)
 	if !currentUser.IsAnonymous() {
 		err = app.modelStore.Comments.SetFollowingStatus(comments, currentUser.ID)
 		if err != nil {
@@ -96,9 +162,70 @@ func (app *application) getCommentsHandler(w http.ResponseWriter, r *http.Reques
 		}
 	}
 
-	err = app.writeJSON(w, http.StatusOK, envelope{"comments": comments}, nil)
+	err = app.modelStore.Comments.SetReactions(comments, currentUser.ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	for i := range comments {
+		err = app.renderCommentHTML(r, &comments[i])
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+	}
+
+	var nextCursor *string
+	if next != nil {
+		encoded := next.Encode()
+		nextCursor = &encoded
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{
+		"comments":      comments,
+		"commentsCount": commentsCount,
+		"nextCursor":    nextCursor,
+	}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+}
+
+// deleteCommentHandler deletes the authenticated user's comment on an article, or any comment
+// if the user may delete other users' comments.
+// Comments with replies are tombstoned rather than removed so that the thread stays intact.
+func (app *application) deleteCommentHandler(w http.ResponseWriter, r *http.Request) {
+	slug := chi.URLParam(r, "slug")
+	user := app.contextGetUser(r)
+
+	commentID, err := app.readIDParam(r, "id")
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	articleID, err := app.modelStore.Articles.GetIDBySlug(slug, user)
 	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			app.notFoundResponse(w, r)
+			return
+		}
 		app.serverErrorResponse(w, r, err)
 		return
 	}
+
+	err = app.modelStore.Comments.DeleteByID(commentID, articleID, user)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
 }
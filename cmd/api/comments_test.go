@@ -2,9 +2,12 @@ package main
 
 import (
 	"net/http"
+	"net/url"
+	"strconv"
 	"testing"
 	"time"
 
+	"github.com/96malhar/realworld-backend/internal/data"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -14,11 +17,24 @@ type commentResponse struct {
 }
 
 type comment struct {
-	ID        int64     `json:"id"`
-	Body      string    `json:"body"`
-	CreatedAt time.Time `json:"createdAt"`
-	UpdatedAt time.Time `json:"updatedAt"`
-	Author    profile   `json:"author"`
+	ID          int64          `json:"id"`
+	Body        string         `json:"body"`
+	BodyHTML    string         `json:"bodyHtml,omitempty"`
+	ParentID    *int64         `json:"parentId"`
+	ReplyCount  int            `json:"replyCount"`
+	Deleted     bool           `json:"deleted"`
+	Hidden      bool           `json:"hidden,omitempty"`
+	Reactions   map[string]int `json:"reactions,omitempty"`
+	MyReactions []string       `json:"myReactions,omitempty"`
+	CreatedAt   time.Time      `json:"createdAt"`
+	UpdatedAt   time.Time      `json:"updatedAt"`
+	Author      profile        `json:"author"`
+}
+
+type commentsResponse struct {
+	Comments      []comment `json:"comments"`
+	CommentsCount int       `json:"commentsCount"`
+	NextCursor    *string   `json:"nextCursor"`
 }
 
 func TestCreateCommentHandler(t *testing.T) {
@@ -131,9 +147,7 @@ func TestGetCommentsHandler_EmptyAndNotFound(t *testing.T) {
 			requestMethodType:      http.MethodGet,
 			requestUrlPath:         articleLocation + "/comments",
 			wantResponseStatusCode: http.StatusOK,
-			wantResponse: struct {
-				Comments []comment `json:"comments"`
-			}{
+			wantResponse: commentsResponse{
 				Comments: []comment{},
 			},
 		},
@@ -192,9 +206,7 @@ func TestGetCommentsHandler_WithoutAuthentication(t *testing.T) {
 
 	assert.Equal(t, http.StatusOK, res.StatusCode)
 
-	var resp struct {
-		Comments []comment `json:"comments"`
-	}
+	var resp commentsResponse
 	readJsonResponse(t, res.Body, &resp)
 
 	assert.Len(t, resp.Comments, 8, "Should have 8 comments")
@@ -278,9 +290,7 @@ func TestGetCommentsHandler_WithAuthentication(t *testing.T) {
 
 	assert.Equal(t, http.StatusOK, res.StatusCode)
 
-	var resp struct {
-		Comments []comment `json:"comments"`
-	}
+	var resp commentsResponse
 	readJsonResponse(t, res.Body, &resp)
 
 	assert.Len(t, resp.Comments, 7)
@@ -361,9 +371,7 @@ func TestGetCommentsHandler_DifferentUserPerspectives(t *testing.T) {
 
 	assert.Equal(t, http.StatusOK, res.StatusCode)
 
-	var resp struct {
-		Comments []comment `json:"comments"`
-	}
+	var resp commentsResponse
 	readJsonResponse(t, res.Body, &resp)
 
 	assert.Len(t, resp.Comments, 8)
@@ -402,3 +410,275 @@ func TestGetCommentsHandler_DifferentUserPerspectives(t *testing.T) {
 	assert.Equal(t, 2, eveComments, "Eve should have 2 comments")
 	assert.Equal(t, 1, aliceComments, "Alice should have 1 comment")
 }
+
+func TestGetCommentsHandler_RenderHTML(t *testing.T) {
+	t.Parallel()
+
+	ts := newTestServer(t)
+
+	registerUser(t, ts, "alice", "alice@example.com", "password123")
+	aliceToken := loginUser(t, ts, "alice@example.com", "password123")
+	articleLocation := createArticle(t, ts, aliceToken, "Test Article", "Test description", "Test body", nil)
+	createCommentHelper(t, ts, aliceToken, articleLocation, `**bold** <img src=x onerror=alert(1)>`)
+
+	testcases := []handlerTestcase{
+		{
+			name:                   "Comments include sanitized HTML when requested",
+			requestMethodType:      http.MethodGet,
+			requestUrlPath:         articleLocation + "/comments?render=html",
+			wantResponseStatusCode: http.StatusOK,
+			additionalChecks: func(t *testing.T, res *http.Response) {
+				var resp commentsResponse
+				readJsonResponse(t, res.Body, &resp)
+
+				require.Len(t, resp.Comments, 1)
+				assert.Contains(t, resp.Comments[0].BodyHTML, "<strong>bold</strong>")
+				assert.NotContains(t, resp.Comments[0].BodyHTML, "onerror")
+			},
+		},
+		{
+			name:                   "Comments omit HTML by default",
+			requestMethodType:      http.MethodGet,
+			requestUrlPath:         articleLocation + "/comments",
+			wantResponseStatusCode: http.StatusOK,
+			additionalChecks: func(t *testing.T, res *http.Response) {
+				var resp commentsResponse
+				readJsonResponse(t, res.Body, &resp)
+
+				require.Len(t, resp.Comments, 1)
+				assert.Empty(t, resp.Comments[0].BodyHTML)
+			},
+		},
+	}
+
+	testHandler(t, ts, testcases...)
+}
+
+// postComment is a test helper that creates a comment, optionally as a reply, and returns its ID
+func postComment(t *testing.T, ts *testServer, token, articleLocation, body string, parentID int64) int64 {
+	t.Helper()
+
+	requestBody := `{"comment": {"body": "` + body + `"}}`
+	if parentID != 0 {
+		requestBody = `{"comment": {"body": "` + body + `", "parentId": ` + strconv.FormatInt(parentID, 10) + `}}`
+	}
+
+	res, err := ts.executeRequest(http.MethodPost, articleLocation+"/comments", requestBody, map[string]string{"Authorization": "Token " + token})
+	require.NoError(t, err)
+	defer res.Body.Close() //nolint: errcheck
+	require.Equal(t, http.StatusCreated, res.StatusCode)
+
+	var resp commentResponse
+	readJsonResponse(t, res.Body, &resp)
+	return resp.Comment.ID
+}
+
+func TestCommentThreads(t *testing.T) {
+	t.Parallel()
+
+	ts := newTestServer(t)
+
+	registerUser(t, ts, "alice", "alice@example.com", "password123")
+	registerUser(t, ts, "bob", "bob@example.com", "password123")
+	aliceToken := loginUser(t, ts, "alice@example.com", "password123")
+	bobToken := loginUser(t, ts, "bob@example.com", "password123")
+	articleLocation := createArticle(t, ts, aliceToken, "Threaded Article", "Description", "Body", nil)
+	otherArticleLocation := createArticle(t, ts, aliceToken, "Other Article", "Description", "Body", nil)
+
+	rootID := postComment(t, ts, aliceToken, articleLocation, "root", 0)
+	replyID := postComment(t, ts, bobToken, articleLocation, "reply", rootID)
+	leafID := postComment(t, ts, aliceToken, articleLocation, "leaf", replyID)
+	otherID := postComment(t, ts, aliceToken, otherArticleLocation, "elsewhere", 0)
+
+	// Build a chain that reaches the maximum depth
+	deepestID := leafID
+	for depth := 3; depth <= data.MaxCommentDepth; depth++ {
+		deepestID = postComment(t, ts, aliceToken, articleLocation, "deeper", deepestID)
+	}
+
+	getComments := func(t *testing.T) map[int64]comment {
+		t.Helper()
+		res, err := ts.executeRequest(http.MethodGet, articleLocation+"/comments", "", nil)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		var resp commentsResponse
+		readJsonResponse(t, res.Body, &resp)
+
+		byID := make(map[int64]comment)
+		for _, c := range resp.Comments {
+			byID[c.ID] = c
+		}
+		return byID
+	}
+
+	t.Run("Comments include parent IDs and reply counts", func(t *testing.T) {
+		comments := getComments(t)
+
+		assert.Nil(t, comments[rootID].ParentID)
+		assert.Equal(t, 1, comments[rootID].ReplyCount)
+		require.NotNil(t, comments[replyID].ParentID)
+		assert.Equal(t, rootID, *comments[replyID].ParentID)
+		assert.Equal(t, 1, comments[replyID].ReplyCount)
+		assert.Equal(t, replyID, *comments[leafID].ParentID)
+	})
+
+	authHeader := map[string]string{"Authorization": "Token " + bobToken}
+	testcases := []handlerTestcase{
+		{
+			name:                   "Reply beyond the depth limit",
+			requestMethodType:      http.MethodPost,
+			requestUrlPath:         articleLocation + "/comments",
+			requestHeader:          authHeader,
+			requestBody:            `{"comment": {"body": "too deep", "parentId": ` + strconv.FormatInt(deepestID, 10) + `}}`,
+			wantResponseStatusCode: http.StatusUnprocessableEntity,
+			wantResponse: errorResponse{
+				Errors: []string{"replies must not be nested more than 5 levels deep"},
+			},
+		},
+		{
+			name:                   "Reply to a comment on another article",
+			requestMethodType:      http.MethodPost,
+			requestUrlPath:         articleLocation + "/comments",
+			requestHeader:          authHeader,
+			requestBody:            `{"comment": {"body": "wrong article", "parentId": ` + strconv.FormatInt(otherID, 10) + `}}`,
+			wantResponseStatusCode: http.StatusUnprocessableEntity,
+			wantResponse: errorResponse{
+				Errors: []string{"parentId must refer to a comment on the same article"},
+			},
+		},
+		{
+			name:                   "Reply to a non-existent comment",
+			requestMethodType:      http.MethodPost,
+			requestUrlPath:         articleLocation + "/comments",
+			requestHeader:          authHeader,
+			requestBody:            `{"comment": {"body": "orphan", "parentId": 999999}}`,
+			wantResponseStatusCode: http.StatusUnprocessableEntity,
+			wantResponse: errorResponse{
+				Errors: []string{"parentId must refer to an existing comment"},
+			},
+		},
+		{
+			name:                   "Only the author can delete a comment",
+			requestMethodType:      http.MethodDelete,
+			requestUrlPath:         articleLocation + "/comments/" + strconv.FormatInt(rootID, 10),
+			requestHeader:          authHeader,
+			wantResponseStatusCode: http.StatusNotFound,
+		},
+		{
+			name:                   "Deleting a comment requires authentication",
+			requestMethodType:      http.MethodDelete,
+			requestUrlPath:         articleLocation + "/comments/" + strconv.FormatInt(rootID, 10),
+			wantResponseStatusCode: http.StatusUnauthorized,
+		},
+	}
+
+	testHandler(t, ts, testcases...)
+
+	t.Run("Deleting a comment with replies tombstones it", func(t *testing.T) {
+		res, err := ts.executeRequest(http.MethodDelete, articleLocation+"/comments/"+strconv.FormatInt(rootID, 10), "", map[string]string{"Authorization": "Token " + aliceToken})
+		require.NoError(t, err)
+		defer res.Body.Close()
+		require.Equal(t, http.StatusNoContent, res.StatusCode)
+
+		comments := getComments(t)
+		require.Contains(t, comments, rootID)
+		assert.True(t, comments[rootID].Deleted)
+		assert.Empty(t, comments[rootID].Body)
+		assert.Equal(t, 1, comments[rootID].ReplyCount)
+		assert.Contains(t, comments, replyID, "Replies should be kept")
+	})
+
+	t.Run("Deleting a comment without replies removes it", func(t *testing.T) {
+		res, err := ts.executeRequest(http.MethodDelete, articleLocation+"/comments/"+strconv.FormatInt(deepestID, 10), "", map[string]string{"Authorization": "Token " + aliceToken})
+		require.NoError(t, err)
+		defer res.Body.Close()
+		require.Equal(t, http.StatusNoContent, res.StatusCode)
+
+		assert.NotContains(t, getComments(t), deepestID)
+	})
+}
+
+func TestGetCommentsHandler_Pagination(t *testing.T) {
+	t.Parallel()
+
+	ts := newTestServer(t)
+
+	registerUser(t, ts, "alice", "alice@example.com", "password123")
+	aliceToken := loginUser(t, ts, "alice@example.com", "password123")
+	articleLocation := createArticle(t, ts, aliceToken, "Test Article", "Test description", "Test body", []string{"test"})
+
+	for i := 1; i <= 5; i++ {
+		createCommentHelper(t, ts, aliceToken, articleLocation, "Comment "+strconv.Itoa(i))
+	}
+
+	getPage := func(t *testing.T, query string) commentsResponse {
+		t.Helper()
+		res, err := ts.executeRequest(http.MethodGet, articleLocation+"/comments?"+query, "", nil)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		var resp commentsResponse
+		readJsonResponse(t, res.Body, &resp)
+		return resp
+	}
+
+	// walk follows nextCursor until the last page and returns the comment bodies in order
+	walk := func(t *testing.T, query string) []string {
+		t.Helper()
+		var bodies []string
+		page := getPage(t, query)
+		for {
+			assert.Equal(t, 5, page.CommentsCount)
+			assert.LessOrEqual(t, len(page.Comments), 2)
+			for _, c := range page.Comments {
+				bodies = append(bodies, c.Body)
+			}
+			if page.NextCursor == nil {
+				return bodies
+			}
+			page = getPage(t, query+"&cursor="+url.QueryEscape(*page.NextCursor))
+		}
+	}
+
+	t.Run("Newest first by default", func(t *testing.T) {
+		bodies := walk(t, "limit=2")
+		assert.Equal(t, []string{"Comment 5", "Comment 4", "Comment 3", "Comment 2", "Comment 1"}, bodies)
+	})
+
+	t.Run("Oldest first", func(t *testing.T) {
+		bodies := walk(t, "limit=2&sort=oldest")
+		assert.Equal(t, []string{"Comment 1", "Comment 2", "Comment 3", "Comment 4", "Comment 5"}, bodies)
+	})
+
+	t.Run("Last page has no cursor", func(t *testing.T) {
+		page := getPage(t, "limit=5")
+		assert.Len(t, page.Comments, 5)
+		assert.Nil(t, page.NextCursor)
+	})
+
+	testcases := []handlerTestcase{
+		{
+			name:                   "Invalid sort order",
+			requestMethodType:      http.MethodGet,
+			requestUrlPath:         articleLocation + "/comments?sort=popular",
+			wantResponseStatusCode: http.StatusUnprocessableEntity,
+			wantResponse: errorResponse{
+				Errors: []string{"Sort must be one of newest or oldest"},
+			},
+		},
+		{
+			name:                   "Invalid cursor",
+			requestMethodType:      http.MethodGet,
+			requestUrlPath:         articleLocation + "/comments?cursor=not-a-cursor",
+			wantResponseStatusCode: http.StatusUnprocessableEntity,
+			wantResponse: errorResponse{
+				Errors: []string{"Cursor is invalid"},
+			},
+		},
+	}
+
+	testHandler(t, ts, testcases...)
+}
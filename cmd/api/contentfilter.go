@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/96malhar/realworld-backend/internal/contentfilter"
+	"github.com/96malhar/realworld-backend/internal/data"
+)
+
+// newContentFilter builds the chain of filters that screens articles and comments from the configuration.
+func newContentFilter(cfg contentFilterConfig) (contentfilter.Filter, error) {
+	var chain contentfilter.Chain
+
+	if cfg.wordlist != "" {
+		action, err := contentfilter.ParseAction(cfg.wordlistAction)
+		if err != nil {
+			return nil, err
+		}
+
+		f, err := os.Open(cfg.wordlist)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close() //nolint: errcheck
+
+		words, err := contentfilter.ReadWordlist(f)
+		if err != nil {
+			return nil, fmt.Errorf("reading wordlist: %w", err)
+		}
+		chain = append(chain, contentfilter.NewWordlist(words, action))
+	}
+
+	chain = append(chain, contentfilter.SpamScorer{HoldScore: cfg.spamHoldScore, RejectScore: cfg.spamRejectScore})
+
+	if cfg.newAccountAge > 0 {
+		chain = append(chain, contentfilter.NewAccountRestriction{MinAge: cfg.newAccountAge})
+	}
+
+	return chain, nil
+}
+
+// screenContent runs the content filter over the fields of an article or comment submitted by the
+// current user. If the filter rejects the content, it sends a 422 response listing the reasons and
+// returns false. Otherwise it returns the reasons to hold the content for moderation, if any.
+// Content from moderators is not screened.
+func (app *application) screenContent(w http.ResponseWriter, r *http.Request, text ...string) ([]string, bool) {
+	user := app.contextGetUser(r)
+	if user.HasPermission(data.PermissionContentModerate) {
+		return nil, true
+	}
+
+	verdict := app.contentFilter.Check(contentfilter.Content{Author: user, Text: text})
+	switch verdict.Action {
+	case contentfilter.Reject:
+		app.failedValidationResponse(w, r, verdict.Reasons)
+		return nil, false
+	case contentfilter.Hold:
+		return verdict.Reasons, true
+	default:
+		return nil, true
+	}
+}
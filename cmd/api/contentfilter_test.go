@@ -0,0 +1,161 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/96malhar/realworld-backend/internal/contentfilter"
+	"github.com/96malhar/realworld-backend/internal/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContentFilter(t *testing.T) {
+	t.Parallel()
+
+	ts := newTestServer(t)
+	ts.app.contentFilter = contentfilter.Chain{
+		contentfilter.NewWordlist([]string{"darn"}, contentfilter.Reject),
+		contentfilter.NewAccountRestriction{MinAge: time.Hour},
+	}
+
+	registerUser(t, ts, "alice", "alice@example.com", "password123")
+	registerUser(t, ts, "mod", "mod@example.com", "password123")
+	setRole(t, ts, "mod", data.RoleModerator)
+	aliceToken := loginUser(t, ts, "alice@example.com", "password123")
+	modToken := loginUser(t, ts, "mod@example.com", "password123")
+
+	aliceHeader := map[string]string{"Authorization": "Token " + aliceToken}
+	modHeader := map[string]string{"Authorization": "Token " + modToken}
+
+	modArticle := createArticle(t, ts, modToken, "Moderator Article", "Test description", "See https://example.com", []string{"test"})
+
+	testHandler(t, ts,
+		handlerTestcase{
+			name:                   "Articles with blocked words are rejected",
+			requestMethodType:      http.MethodPost,
+			requestUrlPath:         "/articles",
+			requestBody:            `{"article": {"title": "Darn", "description": "Test description", "body": "Test body"}}`,
+			requestHeader:          aliceHeader,
+			wantResponseStatusCode: http.StatusUnprocessableEntity,
+			wantResponse:           errorResponse{Errors: []string{"content contains blocked words: darn"}},
+		},
+		handlerTestcase{
+			name:                   "Comments with blocked words are rejected",
+			requestMethodType:      http.MethodPost,
+			requestUrlPath:         modArticle + "/comments",
+			requestBody:            `{"comment": {"body": "Well, darn"}}`,
+			requestHeader:          aliceHeader,
+			wantResponseStatusCode: http.StatusUnprocessableEntity,
+			wantResponse:           errorResponse{Errors: []string{"content contains blocked words: darn"}},
+		},
+		handlerTestcase{
+			name:                   "Content from moderators is not screened",
+			requestMethodType:      http.MethodGet,
+			requestUrlPath:         modArticle,
+			wantResponseStatusCode: http.StatusOK,
+			additionalChecks: func(t *testing.T, res *http.Response) {
+				var resp getArticleResponse
+				readJsonResponse(t, res.Body, &resp)
+				assert.False(t, resp.Article.Hidden)
+			},
+		},
+	)
+
+	// Alice's account is new, so her content with links is held for moderation
+	res, err := ts.executeRequest(http.MethodPost, "/articles",
+		`{"article": {"title": "Held Article", "description": "Test description", "body": "Visit https://example.com"}}`, aliceHeader)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, res.StatusCode)
+	var created getArticleResponse
+	readJsonResponse(t, res.Body, &created)
+	assert.True(t, created.Article.Hidden)
+	heldLocation := "/articles/" + created.Article.Slug
+
+	res, err = ts.executeRequest(http.MethodGet, heldLocation, "", nil)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, res.StatusCode, "held articles are hidden from other users")
+
+	res, err = ts.executeRequest(http.MethodPut, heldLocation, `{"article": {"body": "Well, darn"}}`, aliceHeader)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode, "edits are screened too")
+
+	commentID := postComment(t, ts, aliceToken, modArticle, "More at https://example.com", 0)
+	res, err = ts.executeRequest(http.MethodGet, modArticle+"/comments", "", nil)
+	require.NoError(t, err)
+	var comments commentsResponse
+	readJsonResponse(t, res.Body, &comments)
+	assert.Equal(t, 0, comments.CommentsCount, "held comments are hidden from other users")
+
+	queue := listReports(t, ts, modToken, "")
+	require.Equal(t, 2, queue.ReportsCount)
+	for _, report := range queue.Reports {
+		assert.Empty(t, report.Reporter, "held content is reported by the filter")
+		assert.Equal(t, data.ReportReasonContentFilter, report.Reason)
+		assert.Equal(t, "links from new accounts are held for moderation", report.Details)
+		assert.Equal(t, "alice", report.TargetAuthor)
+	}
+	require.Equal(t, data.ReportTargetArticle, queue.Reports[0].TargetType)
+	require.Equal(t, data.ReportTargetComment, queue.Reports[1].TargetType)
+	assert.Equal(t, commentID, *queue.Reports[1].CommentID)
+
+	res, err = ts.executeRequest(http.MethodPost, "/admin/reports/"+strconv.FormatInt(queue.Reports[0].ID, 10)+"/dismiss", "", modHeader)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+	var errResp errorResponse
+	readJsonResponse(t, res.Body, &errResp)
+	assert.Equal(t, []string{"content held by the content filter must be approved or deleted"}, errResp.Errors)
+	assert.Equal(t, 2, listReports(t, ts, modToken, data.ReportStatusOpen).ReportsCount, "held content stays in the queue")
+
+	// Approving held content publishes it and announces it like new content
+	ts.app.dispatchOutbox()
+	recorder := recordOutboxEvents(ts)
+	for _, report := range queue.Reports {
+		res, err := ts.executeRequest(http.MethodPost, "/admin/reports/"+strconv.FormatInt(report.ID, 10)+"/approve", "", modHeader)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		var resp reportResponse
+		readJsonResponse(t, res.Body, &resp)
+		assert.Equal(t, data.ReportStatusApproved, resp.Report.Status)
+	}
+
+	res, err = ts.executeRequest(http.MethodGet, heldLocation, "", nil)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	res, err = ts.executeRequest(http.MethodGet, modArticle+"/comments", "", nil)
+	require.NoError(t, err)
+	readJsonResponse(t, res.Body, &comments)
+	assert.Equal(t, 1, comments.CommentsCount)
+
+	assert.Equal(t, 2, ts.app.dispatchOutbox())
+	assert.Equal(t, []string{data.EventArticlePublished, data.EventCommentCreated}, recorder.types())
+
+	res, err = ts.executeRequest(http.MethodGet, "/user/notifications", "", modHeader)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	var notifications notificationsResponse
+	readJsonResponse(t, res.Body, &notifications)
+	require.Equal(t, 1, notifications.NotificationsCount, "the article's author is notified of approved comments")
+	assert.Equal(t, data.NotificationComment, notifications.Notifications[0].Type)
+
+	// Restoring a revision is screened like an edit, so the held text cannot be brought back unscreened
+	res, err = ts.executeRequest(http.MethodPut, heldLocation, `{"article": {"body": "No links here"}}`, aliceHeader)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	var updated getArticleResponse
+	readJsonResponse(t, res.Body, &updated)
+	assert.False(t, updated.Article.Hidden)
+
+	res, err = ts.executeRequest(http.MethodPost, heldLocation+"/revisions/1/restore", "", aliceHeader)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	var restored getArticleResponse
+	readJsonResponse(t, res.Body, &restored)
+	assert.Equal(t, "Visit https://example.com", restored.Article.Body)
+	assert.True(t, restored.Article.Hidden, "the restored text is held again")
+	assert.Equal(t, 1, listReports(t, ts, modToken, data.ReportStatusOpen).ReportsCount)
+}
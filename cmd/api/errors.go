@@ -71,7 +71,48 @@ func (app *application) invalidAuthenticationTokenResponse(w http.ResponseWriter
 	app.errorResponse(w, r, http.StatusUnauthorized, message)
 }
 
+// editConflictResponse will be used to send a 409 Conflict status code and JSON response to the client.
+func (app *application) editConflictResponse(w http.ResponseWriter, r *http.Request) {
+	message := "unable to update the record due to an edit conflict, please try again"
+	app.errorResponse(w, r, http.StatusConflict, message)
+}
+
 func (app *application) notPermittedResponse(w http.ResponseWriter, r *http.Request) {
 	message := "your user account doesn't have the necessary permissions to access/modify this resource"
 	app.errorResponse(w, r, http.StatusForbidden, message)
 }
+
+// blockedResponse will be used to send a 403 Forbidden status code and JSON response to the client
+// when the owner of a resource has blocked the user.
+func (app *application) blockedResponse(w http.ResponseWriter, r *http.Request) {
+	message := "you have been blocked from interacting with this user"
+	app.errorResponse(w, r, http.StatusForbidden, message)
+}
+
+// accountSuspendedResponse will be used to send a 403 Forbidden status code and JSON response to the client
+// when the user's account has been suspended.
+func (app *application) accountSuspendedResponse(w http.ResponseWriter, r *http.Request) {
+	message := "your user account has been suspended"
+	app.errorResponse(w, r, http.StatusForbidden, message)
+}
+
+// passwordResetRequiredResponse will be used to send a 403 Forbidden status code and JSON response to the client
+// when the user must change their password before doing anything else.
+func (app *application) passwordResetRequiredResponse(w http.ResponseWriter, r *http.Request) {
+	message := "you must change your password before continuing"
+	app.errorResponse(w, r, http.StatusForbidden, message)
+}
+
+// tooManyAttemptsResponse will be used to send a 429 Too Many Requests status code and JSON response to the client
+// when the user is locked out after entering too many wrong two-factor codes.
+func (app *application) tooManyAttemptsResponse(w http.ResponseWriter, r *http.Request) {
+	message := "too many failed attempts, please try again later"
+	app.errorResponse(w, r, http.StatusTooManyRequests, message)
+}
+
+// reauthenticationRequiredResponse will be used to send a 401 Unauthorized status code and JSON response to the client
+// when a user without a password must log in again to confirm a sensitive change to their account.
+func (app *application) reauthenticationRequiredResponse(w http.ResponseWriter, r *http.Request) {
+	message := "log in again to confirm this change"
+	app.errorResponse(w, r, http.StatusUnauthorized, message)
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/96malhar/realworld-backend/internal/data"
+)
+
+// Event types pushed on the user event stream.
+const (
+	eventArticleCreated   = "article"
+	eventCommentCreated   = "comment"
+	eventFavoritesChanged = "favorites"
+)
+
+// eventsHandler streams real-time updates for the current user as server-sent events:
+// new articles from followed authors, new comments on the user's articles and changes
+// to their articles' favorite counts. A comment line is sent every heartbeat interval
+// to keep idle connections open, and clients that reconnect with a Last-Event-ID header
+// receive the retained events they missed.
+func (app *application) eventsHandler(w http.ResponseWriter, r *http.Request) {
+	user := app.contextGetUser(r)
+
+	var lastEventID int64
+	if header := r.Header.Get("Last-Event-ID"); header != "" {
+		id, err := strconv.ParseInt(header, 10, 64)
+		if err != nil || id < 0 {
+			app.failedValidationResponse(w, r, []string{"Last-Event-ID must be a non-negative integer"})
+			return
+		}
+		lastEventID = id
+	}
+
+	// The stream is meant to outlive the server's WriteTimeout, so lift the deadline for this response
+	rc := http.NewResponseController(w)
+	err := rc.SetWriteDeadline(time.Time{})
+	if err != nil && !errors.Is(err, http.ErrNotSupported) {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	sub := app.events.Subscribe(user.ID, lastEventID)
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(app.config.events.heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-app.shutdown:
+			// End the stream so that graceful shutdown isn't held up; clients reconnect and resume
+			return
+		case <-heartbeat.C:
+			_, err = io.WriteString(w, ": heartbeat\n\n")
+		case event, ok := <-sub.Events:
+			if !ok {
+				// The client fell too far behind; it will reconnect and resume from its last event
+				return
+			}
+			_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, event.Data)
+		}
+
+		if err == nil {
+			err = rc.Flush()
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// publishEvent pushes an event to the streams of the given users. Failures are logged, since the
+// action that produced the event has already succeeded.
+func (app *application) publishEvent(eventType string, payload any, recipients ...int64) {
+	if err := app.events.Publish(eventType, payload, recipients...); err != nil {
+		app.logger.Error("failed to publish event", "type", eventType, "error", err)
+	}
+}
+
+// publishArticleCreated pushes a newly published article to its author's followers in the background.
+// Articles held for moderation are not pushed.
+func (app *application) publishArticleCreated(article data.Article) {
+	if !article.IsPublished() || article.Hidden {
+		return
+	}
+
+	app.background(func() {
+		followers, err := app.modelStore.Users.GetFollowerIDs(article.AuthorID)
+		if err != nil {
+			app.logger.Error("failed to look up followers for event", "error", err)
+			return
+		}
+		app.publishEvent(eventArticleCreated, envelope{"article": article}, followers...)
+	})
+}
+
+// handleArticlePublished pushes a draft or scheduled article that was just published to its author's
+// followers, like a newly created one. It handles article.published outbox events, which only carry a
+// summary, so the article is loaded as anonymous users see it; articles that have since been hidden,
+// unpublished, deleted or renamed are skipped.
+func (app *application) handleArticlePublished(_ context.Context, event data.OutboxEvent) error {
+	var payload struct {
+		Article struct {
+			Slug string `json:"slug"`
+		} `json:"article"`
+	}
+	if err := json.Unmarshal(event.Payload, &payload); err != nil {
+		return err
+	}
+
+	article, err := app.modelStore.Articles.GetBySlug(payload.Article.Slug, data.AnonymousUser)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	followers, err := app.modelStore.Users.GetFollowerIDs(article.AuthorID)
+	if err != nil {
+		return err
+	}
+	app.publishEvent(eventArticleCreated, envelope{"article": article}, followers...)
+	return nil
+}
+
+// handleCommentApproved notifies the author of the article of a comment that was held for moderation
+// and has been approved, like a new comment. It handles comment.created outbox events, skipping those of
+// new comments, which createCommentHandler announces itself, and comments that have since been hidden
+// or deleted.
+func (app *application) handleCommentApproved(_ context.Context, event data.OutboxEvent) error {
+	var payload struct {
+		ArticleSlug string       `json:"articleSlug"`
+		Comment     data.Comment `json:"comment"`
+		Approved    bool         `json:"approved"`
+	}
+	if err := json.Unmarshal(event.Payload, &payload); err != nil {
+		return err
+	}
+	if !payload.Approved {
+		return nil
+	}
+
+	comment, err := app.modelStore.Comments.GetByID(payload.Comment.ID)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if comment.Hidden || comment.Deleted {
+		return nil
+	}
+
+	article, err := app.modelStore.Articles.GetBySlug(payload.ArticleSlug, data.AnonymousUser)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if article.AuthorID == comment.AuthorID {
+		return nil
+	}
+
+	err = app.modelStore.Notifications.NotifyArticleAuthor(data.NotificationComment, comment.AuthorID, article.ID)
+	if err != nil {
+		return err
+	}
+	payload.Comment.AuthorID = comment.AuthorID
+	app.publishEvent(eventCommentCreated, envelope{"articleSlug": article.Slug, "comment": payload.Comment}, article.AuthorID)
+	return nil
+}
+
+// publishCommentCreated pushes a new comment to the author of the article in the background.
+func (app *application) publishCommentCreated(slug string, comment data.Comment) {
+	app.background(func() {
+		article, err := app.modelStore.Articles.GetBySlug(slug, data.AnonymousUser)
+		if err != nil {
+			app.logger.Error("failed to look up article for event", "error", err)
+			return
+		}
+		if article.AuthorID == comment.AuthorID {
+			return
+		}
+		app.publishEvent(eventCommentCreated, envelope{"articleSlug": article.Slug, "comment": comment}, article.AuthorID)
+	})
+}
+
+// publishFavoritesChanged pushes the new favorite count of an article to its author.
+func (app *application) publishFavoritesChanged(article *data.Article) {
+	app.publishEvent(eventFavoritesChanged, envelope{
+		"articleSlug":    article.Slug,
+		"favoritesCount": article.FavoritesCount,
+	}, article.AuthorID)
+}
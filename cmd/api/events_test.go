@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type sseEvent struct {
+	ID   int64
+	Type string
+	Data string
+}
+
+// openEventStream connects to the user's event stream on a real HTTP server, since the stream
+// never completes, and returns a channel of the events received.
+func openEventStream(t *testing.T, server *httptest.Server, token string, lastEventID int64) <-chan sseEvent {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/user/events", nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Token "+token)
+	if lastEventID > 0 {
+		req.Header.Set("Last-Event-ID", strconv.FormatInt(lastEventID, 10))
+	}
+
+	res, err := server.Client().Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { res.Body.Close() }) //nolint: errcheck
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	require.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+
+	events := make(chan sseEvent, 16)
+	go func() {
+		defer close(events)
+		var event sseEvent
+		scanner := bufio.NewScanner(res.Body)
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case line == "":
+				if event.Type != "" {
+					events <- event
+				}
+				event = sseEvent{}
+			case strings.HasPrefix(line, "id: "):
+				event.ID, _ = strconv.ParseInt(strings.TrimPrefix(line, "id: "), 10, 64)
+			case strings.HasPrefix(line, "event: "):
+				event.Type = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				event.Data = strings.TrimPrefix(line, "data: ")
+			}
+		}
+	}()
+	return events
+}
+
+func nextEvent(t *testing.T, events <-chan sseEvent) sseEvent {
+	t.Helper()
+	select {
+	case event, ok := <-events:
+		require.True(t, ok, "event stream closed unexpectedly")
+		return event
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for event")
+		return sseEvent{}
+	}
+}
+
+func TestEventsHandler(t *testing.T) {
+	t.Parallel()
+
+	ts := newTestServer(t)
+	server := httptest.NewServer(ts.router)
+	t.Cleanup(server.Close)
+
+	registerUser(t, ts, "alice", "alice@example.com", "password123")
+	registerUser(t, ts, "bob", "bob@example.com", "password123")
+	aliceToken := loginUser(t, ts, "alice@example.com", "password123")
+	bobToken := loginUser(t, ts, "bob@example.com", "password123")
+	bobHeader := map[string]string{"Authorization": "Token " + bobToken}
+
+	followUser(t, ts, aliceToken, "bob")
+	aliceArticle := createArticle(t, ts, aliceToken, "Alice Article", "Test description", "Test body", []string{"test"})
+
+	stream := openEventStream(t, server, aliceToken, 0)
+
+	createArticle(t, ts, bobToken, "Bob Article", "Test description", "Test body", []string{"test"})
+	articleEvent := nextEvent(t, stream)
+	assert.Equal(t, "article", articleEvent.Type)
+	var articlePayload getArticleResponse
+	require.NoError(t, json.Unmarshal([]byte(articleEvent.Data), &articlePayload))
+	assert.Equal(t, "Bob Article", articlePayload.Article.Title)
+
+	createCommentHelper(t, ts, bobToken, aliceArticle, "Nice article")
+	commentEvent := nextEvent(t, stream)
+	assert.Equal(t, "comment", commentEvent.Type)
+	assert.Contains(t, commentEvent.Data, "Nice article")
+
+	res, err := ts.executeRequest(http.MethodPost, aliceArticle+"/favorite", "", bobHeader)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	favoritesEvent := nextEvent(t, stream)
+	assert.Equal(t, "favorites", favoritesEvent.Type)
+	assert.JSONEq(t, `{"articleSlug": "alice-article", "favoritesCount": 1}`, favoritesEvent.Data)
+
+	assert.Greater(t, commentEvent.ID, articleEvent.ID)
+	assert.Greater(t, favoritesEvent.ID, commentEvent.ID)
+
+	t.Run("Reconnecting with Last-Event-ID replays missed events", func(t *testing.T) {
+		resumed := openEventStream(t, server, aliceToken, articleEvent.ID)
+		assert.Equal(t, commentEvent, nextEvent(t, resumed))
+		assert.Equal(t, favoritesEvent, nextEvent(t, resumed))
+	})
+
+	t.Run("Other users do not receive the events", func(t *testing.T) {
+		bobStream := openEventStream(t, server, bobToken, articleEvent.ID-1)
+		select {
+		case event := <-bobStream:
+			t.Fatalf("unexpected event for bob: %+v", event)
+		case <-time.After(200 * time.Millisecond):
+		}
+	})
+
+	testHandler(t, ts, handlerTestcase{
+		name:                   "Event stream requires authentication",
+		requestMethodType:      http.MethodGet,
+		requestUrlPath:         "/user/events",
+		wantResponseStatusCode: http.StatusUnauthorized,
+	})
+}
+
+func TestEventsHandler_PublishedDrafts(t *testing.T) {
+	t.Parallel()
+
+	ts := newTestServer(t)
+	server := httptest.NewServer(ts.router)
+	t.Cleanup(server.Close)
+
+	registerUser(t, ts, "alice", "alice@example.com", "password123")
+	registerUser(t, ts, "bob", "bob@example.com", "password123")
+	aliceToken := loginUser(t, ts, "alice@example.com", "password123")
+	bobToken := loginUser(t, ts, "bob@example.com", "password123")
+	bobHeader := map[string]string{"Authorization": "Token " + bobToken}
+
+	followUser(t, ts, aliceToken, "bob")
+	stream := openEventStream(t, server, aliceToken, 0)
+
+	res, err := ts.executeRequest(http.MethodPost, "/articles",
+		`{"article": {"title": "Bob Draft", "description": "Description", "body": "Body", "status": "draft"}}`, bobHeader)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, res.StatusCode)
+	draft := res.Header.Get("Location")
+
+	ts.app.dispatchOutbox()
+	select {
+	case event := <-stream:
+		t.Fatalf("unexpected event for an unpublished draft: %+v", event)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	updateArticleHelper(t, ts, bobToken, strings.TrimPrefix(draft, "/articles/"), `{"article": {"status": "published"}}`)
+	ts.app.dispatchOutbox()
+
+	event := nextEvent(t, stream)
+	assert.Equal(t, "article", event.Type)
+	var payload getArticleResponse
+	require.NoError(t, json.Unmarshal([]byte(event.Data), &payload))
+	assert.Equal(t, "Bob Draft", payload.Article.Title)
+}
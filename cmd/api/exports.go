@@ -0,0 +1,78 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/96malhar/realworld-backend/internal/data"
+)
+
+// getExportHandler returns the state of the current user's data export. If they have no export, or
+// their last one has expired, a new one is built in the background. It responds with 202 Accepted
+// while the export is being built; once it is ready, the archive can be downloaded from
+// /user/export/download.
+func (app *application) getExportHandler(w http.ResponseWriter, r *http.Request) {
+	user := app.contextGetUser(r)
+
+	export, err := app.modelStore.Exports.Get(user.ID)
+	if err != nil && !errors.Is(err, data.ErrRecordNotFound) {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	if export == nil || export.Stale(app.now()) {
+		export, err = app.modelStore.Exports.Start(user.ID)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+		app.buildExport(user.ID)
+	}
+
+	status := http.StatusAccepted
+	if export.Status == data.ExportStatusReady {
+		status = http.StatusOK
+	}
+
+	err = app.writeJSON(w, status, envelope{"export": export}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// buildExport builds the user's pending data export in the background. If the build fails, the
+// export is dropped so that the user's next request starts a new one.
+func (app *application) buildExport(userID int64) {
+	app.background(func() {
+		err := app.modelStore.Exports.Build(userID, app.config.users.exportTTL)
+		if err == nil {
+			return
+		}
+		app.logger.Error("failed to build data export", "userID", userID, "error", err)
+
+		if err := app.modelStore.Exports.Delete(userID); err != nil {
+			app.logger.Error("failed to drop data export", "userID", userID, "error", err)
+		}
+	})
+}
+
+// downloadExportHandler sends the archive of the current user's ready data export as a JSON file.
+func (app *application) downloadExportHandler(w http.ResponseWriter, r *http.Request) {
+	user := app.contextGetUser(r)
+
+	archive, err := app.modelStore.Exports.GetArchive(user.ID)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			app.notFoundResponse(w, r)
+			return
+		}
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-export.json"`, user.Username))
+	w.WriteHeader(http.StatusOK)
+	w.Write(archive) //nolint:errcheck
+}
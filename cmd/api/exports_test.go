@@ -0,0 +1,167 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/96malhar/realworld-backend/internal/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type userExport struct {
+	Status      string     `json:"status"`
+	RequestedAt time.Time  `json:"requestedAt"`
+	CompletedAt *time.Time `json:"completedAt"`
+	ExpiresAt   *time.Time `json:"expiresAt"`
+}
+
+type exportResponse struct {
+	Export userExport `json:"export"`
+}
+
+type userArchive struct {
+	ExportedAt time.Time `json:"exportedAt"`
+	Profile    struct {
+		Username  string    `json:"username"`
+		Email     string    `json:"email"`
+		Bio       string    `json:"bio"`
+		Image     string    `json:"image"`
+		CreatedAt time.Time `json:"createdAt"`
+	} `json:"profile"`
+	Articles []struct {
+		Slug        string     `json:"slug"`
+		Title       string     `json:"title"`
+		Description string     `json:"description"`
+		Body        string     `json:"body"`
+		TagList     []string   `json:"tagList"`
+		Status      string     `json:"status"`
+		PublishAt   *time.Time `json:"publishAt"`
+		CreatedAt   time.Time  `json:"createdAt"`
+		UpdatedAt   time.Time  `json:"updatedAt"`
+	} `json:"articles"`
+	Comments []struct {
+		ID          int64     `json:"id"`
+		ArticleSlug string    `json:"articleSlug"`
+		ParentID    *int64    `json:"parentId"`
+		Body        string    `json:"body"`
+		CreatedAt   time.Time `json:"createdAt"`
+		UpdatedAt   time.Time `json:"updatedAt"`
+	} `json:"comments"`
+	Favorites []struct {
+		ArticleSlug string `json:"articleSlug"`
+		Title       string `json:"title"`
+	} `json:"favorites"`
+	Following []archivedFollow `json:"following"`
+	Followers []archivedFollow `json:"followers"`
+}
+
+type archivedFollow struct {
+	Username string    `json:"username"`
+	Since    time.Time `json:"since"`
+}
+
+func TestExportHandlers(t *testing.T) {
+	t.Parallel()
+
+	ts := newTestServer(t)
+
+	registerUser(t, ts, "alice", "alice@example.com", "password123")
+	registerUser(t, ts, "bob", "bob@example.com", "password123")
+	aliceToken := loginUser(t, ts, "alice@example.com", "password123")
+	bobToken := loginUser(t, ts, "bob@example.com", "password123")
+	aliceHeader := map[string]string{"Authorization": "Token " + aliceToken}
+	bobHeader := map[string]string{"Authorization": "Token " + bobToken}
+
+	aliceArticle := createArticle(t, ts, aliceToken, "Alice Article", "Test description", "Test body", []string{"go"})
+	bobArticle := createArticle(t, ts, bobToken, "Bob Article", "Test description", "Test body", nil)
+	commentID := postComment(t, ts, aliceToken, bobArticle, "Alice comment", 0)
+	favoriteArticleHelper(t, ts, aliceToken, strings.TrimPrefix(bobArticle, "/articles/"))
+	followUser(t, ts, aliceToken, "bob")
+	followUser(t, ts, bobToken, "alice")
+
+	testHandler(t, ts,
+		handlerTestcase{
+			name:                   "Exports require authentication",
+			requestMethodType:      http.MethodGet,
+			requestUrlPath:         "/user/export",
+			wantResponseStatusCode: http.StatusUnauthorized,
+		},
+		handlerTestcase{
+			name:                   "Nothing to download before an export was requested",
+			requestMethodType:      http.MethodGet,
+			requestUrlPath:         "/user/export/download",
+			requestHeader:          bobHeader,
+			wantResponseStatusCode: http.StatusNotFound,
+		},
+	)
+
+	// The first request starts building the export in the background
+	res, err := ts.executeRequest(http.MethodGet, "/user/export", "", aliceHeader)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusAccepted, res.StatusCode)
+	var resp exportResponse
+	readJsonResponse(t, res.Body, &resp)
+	assert.Equal(t, data.ExportStatusPending, resp.Export.Status)
+	assert.Nil(t, resp.Export.ExpiresAt)
+
+	require.Eventually(t, func() bool {
+		res, err := ts.executeRequest(http.MethodGet, "/user/export", "", aliceHeader)
+		require.NoError(t, err)
+		readJsonResponse(t, res.Body, &resp)
+		return res.StatusCode == http.StatusOK
+	}, 5*time.Second, 50*time.Millisecond)
+	assert.Equal(t, data.ExportStatusReady, resp.Export.Status)
+	require.NotNil(t, resp.Export.ExpiresAt)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), *resp.Export.ExpiresAt, time.Minute)
+
+	res, err = ts.executeRequest(http.MethodGet, "/user/export/download", "", aliceHeader)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "application/json", res.Header.Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="alice-export.json"`, res.Header.Get("Content-Disposition"))
+
+	var archive userArchive
+	dec := json.NewDecoder(res.Body)
+	dec.DisallowUnknownFields()
+	require.NoError(t, dec.Decode(&archive))
+
+	assert.Equal(t, "alice", archive.Profile.Username)
+	assert.Equal(t, "alice@example.com", archive.Profile.Email)
+
+	require.Len(t, archive.Articles, 1)
+	assert.Equal(t, strings.TrimPrefix(aliceArticle, "/articles/"), archive.Articles[0].Slug)
+	assert.Equal(t, "Test body", archive.Articles[0].Body)
+	assert.Equal(t, []string{"go"}, archive.Articles[0].TagList)
+
+	require.Len(t, archive.Comments, 1)
+	assert.Equal(t, commentID, archive.Comments[0].ID)
+	assert.Equal(t, strings.TrimPrefix(bobArticle, "/articles/"), archive.Comments[0].ArticleSlug)
+	assert.Equal(t, "Alice comment", archive.Comments[0].Body)
+
+	require.Len(t, archive.Favorites, 1)
+	assert.Equal(t, "Bob Article", archive.Favorites[0].Title)
+
+	require.Len(t, archive.Following, 1)
+	assert.Equal(t, "bob", archive.Following[0].Username)
+	require.Len(t, archive.Followers, 1)
+	assert.Equal(t, "bob", archive.Followers[0].Username)
+
+	// Other users cannot download the export
+	res, err = ts.executeRequest(http.MethodGet, "/user/export/download", "", bobHeader)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+
+	// Once the export has expired, the next request builds a new one
+	expiresAt := *resp.Export.ExpiresAt
+	ts.app.now = func() time.Time { return expiresAt.Add(time.Second) }
+	res, err = ts.executeRequest(http.MethodGet, "/user/export", "", aliceHeader)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusAccepted, res.StatusCode)
+	readJsonResponse(t, res.Body, &resp)
+	assert.Equal(t, data.ExportStatusPending, resp.Export.Status)
+	ts.app.wg.Wait()
+}
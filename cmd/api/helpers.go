@@ -8,6 +8,9 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+
+	"github.com/96malhar/realworld-backend/internal/data"
+	"github.com/go-chi/chi/v5"
 )
 
 // writeJSON is a helper that writes the provided data to the client in JSON format.
@@ -140,6 +143,17 @@ func (app *application) readInt(s string, defaultValue int) int {
 	return i
 }
 
+// readIDParam reads the named URL parameter and converts it to a positive integer.
+// An error is returned if the parameter is missing or not a positive integer.
+func (app *application) readIDParam(r *http.Request, name string) (int64, error) {
+	id, err := strconv.ParseInt(chi.URLParam(r, name), 10, 64)
+	if err != nil || id < 1 {
+		return 0, fmt.Errorf("invalid %s parameter", name)
+	}
+
+	return id, nil
+}
+
 // Pagination holds pagination parameters with validation.
 // This struct can be used across different endpoints to maintain consistent pagination logic.
 type Pagination struct {
@@ -188,3 +202,58 @@ func (app *application) readPagination(r *http.Request, defaultLimit, maxLimit i
 		Offset: offset,
 	}
 }
+
+// background runs the provided function in a background goroutine tracked by the
+// application's WaitGroup, so that graceful shutdown waits for it to finish.
+// Any panic in the function is recovered and logged instead of crashing the server.
+func (app *application) background(fn func()) {
+	app.wg.Add(1)
+
+	go func() {
+		defer app.wg.Done()
+
+		defer func() {
+			if err := recover(); err != nil {
+				app.logger.Error(fmt.Sprintf("%v", err))
+			}
+		}()
+
+		fn()
+	}()
+}
+
+// wantsRenderedHTML returns true if the client asked for Markdown bodies to be rendered
+// to sanitized HTML using the "render=html" query string parameter.
+func (app *application) wantsRenderedHTML(r *http.Request) bool {
+	return r.URL.Query().Get("render") == "html"
+}
+
+// renderArticleHTML fills in the article's BodyHTML field if the client asked for rendered HTML.
+func (app *application) renderArticleHTML(r *http.Request, article *data.Article) error {
+	if !app.wantsRenderedHTML(r) {
+		return nil
+	}
+
+	bodyHTML, err := app.markdown.Render(article.Body)
+	if err != nil {
+		return err
+	}
+	article.BodyHTML = bodyHTML
+
+	return nil
+}
+
+// renderCommentHTML fills in the comment's BodyHTML field if the client asked for rendered HTML.
+func (app *application) renderCommentHTML(r *http.Request, comment *data.Comment) error {
+	if !app.wantsRenderedHTML(r) {
+		return nil
+	}
+
+	bodyHTML, err := app.markdown.Render(comment.Body)
+	if err != nil {
+		return err
+	}
+	comment.BodyHTML = bodyHTML
+
+	return nil
+}
@@ -41,6 +41,42 @@ func parseConfig() appConfig {
 	flag.StringVar(&cfg.jwtMaker.issuer, "jwt-issuer", os.Getenv("JWT_ISSUER"), "JWT issuer")
 	flag.DurationVar(&cfg.jwtMaker.accessDuration, "jwt-access-duration", 24*time.Hour, "JWT access token duration")
 
+	flag.DurationVar(&cfg.articles.publishInterval, "articles-publish-interval", time.Minute, "Interval at which scheduled articles are checked for publishing")
+	flag.BoolVar(&cfg.articles.keepSlugOnTitleChange, "articles-keep-slug-on-title-change", false, "Keep an article's slug unchanged when its title is edited")
+	flag.DurationVar(&cfg.articles.trashRetention, "articles-trash-retention", 30*24*time.Hour, "How long deleted articles stay in the trash before they are permanently deleted")
+
+	flag.DurationVar(&cfg.events.heartbeatInterval, "events-heartbeat-interval", 15*time.Second, "Interval between heartbeats on server-sent event streams")
+	flag.IntVar(&cfg.events.historySize, "events-history-size", 1000, "Number of recent events kept for clients resuming with Last-Event-ID")
+
+	flag.DurationVar(&cfg.webhooks.pollInterval, "webhooks-poll-interval", 5*time.Second, "Interval at which due webhook deliveries are sent")
+	flag.DurationVar(&cfg.webhooks.timeout, "webhooks-timeout", 10*time.Second, "Timeout of a single webhook delivery attempt")
+	flag.IntVar(&cfg.webhooks.maxAttempts, "webhooks-max-attempts", 8, "Number of attempts before a webhook delivery is marked as failed")
+	flag.DurationVar(&cfg.webhooks.retryBackoff, "webhooks-retry-backoff", 30*time.Second, "Delay before the first webhook retry; doubles with every attempt")
+	flag.BoolVar(&cfg.webhooks.allowPrivateNetworks, "webhooks-allow-private-networks", false, "Allow webhook deliveries to loopback, private and link-local addresses")
+
+	flag.DurationVar(&cfg.outbox.pollInterval, "outbox-poll-interval", time.Second, "Interval at which outbox events are published")
+	flag.DurationVar(&cfg.outbox.retryBackoff, "outbox-retry-backoff", 5*time.Second, "Delay before retrying an outbox event that failed to publish; doubles with every attempt")
+	flag.DurationVar(&cfg.outbox.retention, "outbox-retention", 7*24*time.Hour, "How long published outbox events are kept")
+
+	flag.StringVar(&cfg.filter.wordlist, "filter-wordlist", "", "Path to a file of words that are not allowed in articles and comments, one per line")
+	flag.StringVar(&cfg.filter.wordlistAction, "filter-wordlist-action", "reject", "What to do with content containing a word from the wordlist (hold|reject)")
+	flag.IntVar(&cfg.filter.spamHoldScore, "filter-spam-hold-score", 3, "Spam score at which content is held for moderation (0 disables)")
+	flag.IntVar(&cfg.filter.spamRejectScore, "filter-spam-reject-score", 6, "Spam score at which content is rejected (0 disables)")
+	flag.DurationVar(&cfg.filter.newAccountAge, "filter-new-account-age", 24*time.Hour, "Content with links from accounts younger than this is held for moderation (0 disables)")
+
+	flag.StringVar(&cfg.users.deletionPolicy, "users-deletion-policy", "delete", "What happens to the content of users who delete their account (delete|anonymize)")
+	flag.DurationVar(&cfg.users.exportTTL, "users-export-ttl", 24*time.Hour, "How long a user's data export can be downloaded")
+
+	flag.UintVar(&cfg.passwords.argon2Memory, "password-argon2-memory", 64*1024, "Memory in KiB used to hash a password with Argon2id")
+	flag.UintVar(&cfg.passwords.argon2Iterations, "password-argon2-iterations", 3, "Number of Argon2id passes over the memory")
+	flag.UintVar(&cfg.passwords.argon2Parallelism, "password-argon2-parallelism", 4, "Number of threads used to hash a password with Argon2id")
+
+	flag.IntVar(&cfg.passwords.minScore, "password-min-score", 3, "Lowest acceptable strength score of a new password, from 0 (any) to 4")
+	flag.BoolVar(&cfg.passwords.rejectUserInputs, "password-reject-user-inputs", true, "Reject new passwords containing the username or email address")
+	flag.StringVar(&cfg.passwords.breachedList, "password-breached-list", "", "Path to a file of SHA-1 hashes of breached passwords, one per line, which are rejected as new passwords")
+
+	flag.StringVar(&cfg.oidc.providers, "oidc-providers", "", "Path to a JSON file configuring the OpenID Connect providers users can log in with")
+
 	// Create a new version boolean flag with the default value of false.
 	displayVersion := flag.Bool("version", false, "Display version and exit")
 
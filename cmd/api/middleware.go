@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/96malhar/realworld-backend/internal/data"
+	"github.com/go-chi/chi/v5"
 )
 
 // recoverPanic recovers from a panic, logs the details, and sends a 500 internal server error response.
@@ -66,6 +67,18 @@ func (app *application) authenticate(next http.Handler) http.Handler {
 			return
 		}
 
+		// Suspension takes effect immediately, even for tokens issued before it
+		if user.IsSuspended() {
+			app.accountSuspendedResponse(w, r)
+			return
+		}
+
+		// Users who must reset their password can only view and update their own account until they do
+		if user.PasswordResetRequired && strings.TrimSuffix(r.URL.Path, "/") != "/user" {
+			app.passwordResetRequiredResponse(w, r)
+			return
+		}
+
 		// Set the token (not cached, as it's request-specific)
 		user.Token = tokenString
 		r = app.contextSetUser(r, user)
@@ -86,3 +99,62 @@ func (app *application) requireAuthenticatedUser(next http.Handler) http.Handler
 		next.ServeHTTP(w, r)
 	})
 }
+
+// requirePermission checks that the user is authenticated and that their role grants the given permission.
+// If not, it sends a 401 unauthorized or 403 forbidden response.
+func (app *application) requirePermission(code string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return app.requireAuthenticatedUser(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user := app.contextGetUser(r)
+			if !user.HasPermission(code) {
+				app.notPermittedResponse(w, r)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		}))
+	}
+}
+
+// resolveArticleSlug resolves the {slug} URL parameter to the article's current slug, so that links
+// using a slug the article had before its title changed keep working. GET requests are answered
+// with a 301 redirect to the canonical URL; other requests are served using the current slug.
+func (app *application) resolveArticleSlug(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		slug := chi.URLParam(r, "slug")
+
+		current, err := app.modelStore.Articles.ResolveSlug(slug, app.contextGetUser(r))
+		if err != nil {
+			// Unknown slugs are left for the handler to report as not found
+			if errors.Is(err, data.ErrRecordNotFound) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+
+		if current == slug {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if r.Method == http.MethodGet {
+			canonical := *r.URL
+			canonical.Path = "/articles/" + current + strings.TrimPrefix(r.URL.Path, "/articles/"+slug)
+			canonical.RawPath = ""
+
+			headers := make(http.Header)
+			headers.Set("Location", canonical.RequestURI())
+			err = app.writeJSON(w, http.StatusMovedPermanently, envelope{"location": canonical.RequestURI()}, headers)
+			if err != nil {
+				app.serverErrorResponse(w, r, err)
+			}
+			return
+		}
+
+		// Later URL parameters take precedence, so this overrides the old slug for the handler
+		chi.RouteContext(r.Context()).URLParams.Add("slug", current)
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/96malhar/realworld-backend/internal/data"
+)
+
+// notify records a notification in the background so the request that triggered it isn't slowed down.
+// Failures are logged, since the triggering action has already succeeded.
+func (app *application) notify(record func() error) {
+	app.background(func() {
+		if err := record(); err != nil {
+			app.logger.Error("failed to record notification", "error", err)
+		}
+	})
+}
+
+// listNotificationsHandler returns a page of the current user's notifications, most recent first,
+// with the number of unread notifications. Pass unread=true to only list unread notifications.
+func (app *application) listNotificationsHandler(w http.ResponseWriter, r *http.Request) {
+	user := app.contextGetUser(r)
+	pagination := app.readPagination(r, 20, 100)
+	unreadOnly := r.URL.Query().Get("unread") == "true"
+
+	notifications, totalCount, err := app.modelStore.Notifications.GetAllForUser(user.ID, unreadOnly, pagination.Limit, pagination.Offset)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	unreadCount, err := app.modelStore.Notifications.UnreadCount(user.ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{
+		"notifications":      notifications,
+		"notificationsCount": totalCount,
+		"unreadCount":        unreadCount,
+	}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// markNotificationReadHandler marks one of the current user's notifications as read.
+func (app *application) markNotificationReadHandler(w http.ResponseWriter, r *http.Request) {
+	user := app.contextGetUser(r)
+
+	id, err := app.readIDParam(r, "id")
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	err = app.modelStore.Notifications.MarkRead(id, user.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	app.writeUnreadCount(w, r, user.ID)
+}
+
+// markAllNotificationsReadHandler marks all of the current user's notifications as read.
+func (app *application) markAllNotificationsReadHandler(w http.ResponseWriter, r *http.Request) {
+	user := app.contextGetUser(r)
+
+	err := app.modelStore.Notifications.MarkAllRead(user.ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	app.writeUnreadCount(w, r, user.ID)
+}
+
+// writeUnreadCount responds with the user's remaining number of unread notifications.
+func (app *application) writeUnreadCount(w http.ResponseWriter, r *http.Request, userID int64) {
+	unreadCount, err := app.modelStore.Notifications.UnreadCount(userID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"unreadCount": unreadCount}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/96malhar/realworld-backend/internal/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type notificationsResponse struct {
+	Notifications      []data.Notification `json:"notifications"`
+	NotificationsCount int                 `json:"notificationsCount"`
+	UnreadCount        int                 `json:"unreadCount"`
+}
+
+type unreadCountResponse struct {
+	UnreadCount int `json:"unreadCount"`
+}
+
+func TestNotifications(t *testing.T) {
+	t.Parallel()
+
+	ts := newTestServer(t)
+
+	registerUser(t, ts, "alice", "alice@example.com", "password123")
+	registerUser(t, ts, "bob", "bob@example.com", "password123")
+	registerUser(t, ts, "charlie", "charlie@example.com", "password123")
+	aliceToken := loginUser(t, ts, "alice@example.com", "password123")
+	bobToken := loginUser(t, ts, "bob@example.com", "password123")
+	charlieToken := loginUser(t, ts, "charlie@example.com", "password123")
+	aliceHeader := map[string]string{"Authorization": "Token " + aliceToken}
+
+	articleLocation := createArticle(t, ts, aliceToken, "Test Article", "Test description", "Test body", []string{"test"})
+
+	// act performs a request and waits for the notifications it triggers to be recorded,
+	// so that their order is deterministic
+	act := func(t *testing.T, method, path, body, token string) {
+		t.Helper()
+		res, err := ts.executeRequest(method, path, body, map[string]string{"Authorization": "Token " + token})
+		require.NoError(t, err)
+		require.Less(t, res.StatusCode, 300)
+		ts.app.wg.Wait()
+	}
+
+	getNotifications := func(t *testing.T, query string) notificationsResponse {
+		t.Helper()
+		res, err := ts.executeRequest(http.MethodGet, "/user/notifications"+query, "", aliceHeader)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		var response notificationsResponse
+		readJsonResponse(t, res.Body, &response)
+		return response
+	}
+
+	act(t, http.MethodPost, articleLocation+"/favorite", "", bobToken)
+	act(t, http.MethodPost, articleLocation+"/favorite", "", charlieToken)
+	act(t, http.MethodPost, articleLocation+"/favorite", "", aliceToken)
+	act(t, http.MethodPost, articleLocation+"/comments", `{"comment": {"body": "Nice"}}`, bobToken)
+	act(t, http.MethodPost, "/profiles/alice/follow", "", bobToken)
+
+	var favoriteID int64
+
+	t.Run("Activity is coalesced into unread notifications", func(t *testing.T) {
+		response := getNotifications(t, "")
+		require.Len(t, response.Notifications, 3)
+		assert.Equal(t, 3, response.NotificationsCount)
+		assert.Equal(t, 3, response.UnreadCount)
+
+		follow, comment, favorite := response.Notifications[0], response.Notifications[1], response.Notifications[2]
+
+		assert.Equal(t, data.NotificationFollow, follow.Type)
+		assert.Equal(t, "bob followed you", follow.Message)
+		assert.Empty(t, follow.ArticleSlug)
+
+		assert.Equal(t, data.NotificationComment, comment.Type)
+		assert.Equal(t, `bob commented on your article "Test Article"`, comment.Message)
+
+		assert.Equal(t, data.NotificationFavorite, favorite.Type)
+		assert.Equal(t, 2, favorite.ActorsCount, "Alice favoriting her own article is not a notification")
+		assert.Equal(t, "charlie", favorite.LastActor)
+		assert.Equal(t, `2 people favorited your article "Test Article"`, favorite.Message)
+		assert.Equal(t, "/articles/"+favorite.ArticleSlug, articleLocation)
+		assert.False(t, favorite.Read)
+
+		favoriteID = favorite.ID
+	})
+
+	t.Run("Pagination", func(t *testing.T) {
+		response := getNotifications(t, "?limit=1&offset=1")
+		require.Len(t, response.Notifications, 1)
+		assert.Equal(t, data.NotificationComment, response.Notifications[0].Type)
+		assert.Equal(t, 3, response.NotificationsCount)
+	})
+
+	testcases := []handlerTestcase{
+		{
+			name:                   "Mark a notification as read",
+			requestMethodType:      http.MethodPost,
+			requestUrlPath:         "/user/notifications/" + strconv.FormatInt(favoriteID, 10) + "/read",
+			requestHeader:          aliceHeader,
+			wantResponseStatusCode: http.StatusOK,
+			wantResponse:           unreadCountResponse{UnreadCount: 2},
+		},
+		{
+			name:                   "Cannot mark another user's notification as read",
+			requestMethodType:      http.MethodPost,
+			requestUrlPath:         "/user/notifications/" + strconv.FormatInt(favoriteID, 10) + "/read",
+			requestHeader:          map[string]string{"Authorization": "Token " + bobToken},
+			wantResponseStatusCode: http.StatusNotFound,
+		},
+		{
+			name:                   "Listing notifications requires authentication",
+			requestMethodType:      http.MethodGet,
+			requestUrlPath:         "/user/notifications",
+			wantResponseStatusCode: http.StatusUnauthorized,
+		},
+	}
+
+	testHandler(t, ts, testcases...)
+
+	t.Run("Unread filter", func(t *testing.T) {
+		response := getNotifications(t, "?unread=true")
+		assert.Len(t, response.Notifications, 2)
+		assert.Equal(t, 2, response.UnreadCount)
+	})
+
+	t.Run("New activity after reading starts a new notification", func(t *testing.T) {
+		act(t, http.MethodPost, "/user/notifications/read", "", aliceToken)
+		assert.Equal(t, 0, getNotifications(t, "").UnreadCount)
+
+		act(t, http.MethodPost, articleLocation+"/comments", `{"comment": {"body": "Another"}}`, charlieToken)
+
+		response := getNotifications(t, "?unread=true")
+		require.Len(t, response.Notifications, 1)
+		assert.Equal(t, `charlie commented on your article "Test Article"`, response.Notifications[0].Message)
+		assert.Equal(t, 4, getNotifications(t, "").NotificationsCount)
+	})
+
+	t.Run("Repeated favorites and follows do not notify again", func(t *testing.T) {
+		act(t, http.MethodDelete, articleLocation+"/favorite", "", bobToken)
+		act(t, http.MethodPost, articleLocation+"/favorite", "", bobToken)
+		act(t, http.MethodDelete, "/profiles/alice/follow", "", bobToken)
+		act(t, http.MethodPost, "/profiles/alice/follow", "", bobToken)
+
+		response := getNotifications(t, "?unread=true")
+		require.Len(t, response.Notifications, 1)
+		assert.Equal(t, data.NotificationComment, response.Notifications[0].Type)
+		assert.Equal(t, 4, getNotifications(t, "").NotificationsCount)
+	})
+}
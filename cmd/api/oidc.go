@@ -0,0 +1,284 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/96malhar/realworld-backend/internal/data"
+	"github.com/96malhar/realworld-backend/internal/oidc"
+	"github.com/96malhar/realworld-backend/internal/validator"
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	// oidcRequestTimeout bounds each request to a provider.
+	oidcRequestTimeout = 10 * time.Second
+	// oidcLoginTimeout is how long a user has to log in at the provider and come back.
+	oidcLoginTimeout = 10 * time.Minute
+)
+
+// providerNameRX matches provider names, which appear in URLs.
+var providerNameRX = regexp.MustCompile(`^[a-z0-9-]+$`)
+
+// newOIDCProviders discovers the OpenID Connect providers listed in the configured file.
+func newOIDCProviders(cfg oidcConfig) (map[string]*oidc.Provider, error) {
+	providers := make(map[string]*oidc.Provider)
+	if cfg.providers == "" {
+		return providers, nil
+	}
+
+	f, err := os.Open(cfg.providers)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close() //nolint: errcheck
+
+	var configs []oidc.Config
+	if err := json.NewDecoder(f).Decode(&configs); err != nil {
+		return nil, fmt.Errorf("reading OpenID Connect providers: %w", err)
+	}
+
+	client := &http.Client{Timeout: oidcRequestTimeout}
+	for _, config := range configs {
+		if !providerNameRX.MatchString(config.Name) {
+			return nil, fmt.Errorf("provider name %q must be lowercase letters, digits and hyphens", config.Name)
+		}
+		if _, ok := providers[config.Name]; ok {
+			return nil, fmt.Errorf("provider %q is configured twice", config.Name)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), oidcRequestTimeout)
+		provider, err := oidc.Discover(ctx, client, config)
+		cancel()
+		if err != nil {
+			return nil, err
+		}
+		providers[config.Name] = provider
+	}
+
+	return providers, nil
+}
+
+// listOIDCProvidersHandler returns the names of the providers users can log in with.
+func (app *application) listOIDCProvidersHandler(w http.ResponseWriter, r *http.Request) {
+	names := make([]string, 0, len(app.oidcProviders))
+	for name := range app.oidcProviders {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	err := app.writeJSON(w, http.StatusOK, envelope{"providers": names}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// startOIDCLoginHandler starts a login at a provider and returns the URL of its login page, which
+// the client sends the user to. If the request is authenticated, the identity the user logs in
+// with is linked to their account instead.
+func (app *application) startOIDCLoginHandler(w http.ResponseWriter, r *http.Request) {
+	provider, ok := app.oidcProviders[chi.URLParam(r, "provider")]
+	if !ok {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	request := &data.AuthRequest{Provider: provider.Name()}
+	for _, token := range []*string{&request.State, &request.Nonce, &request.CodeVerifier} {
+		var err error
+		if *token, err = oidc.RandomToken(); err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+	}
+	if user := app.contextGetUser(r); !user.IsAnonymous() {
+		request.UserID = &user.ID
+	}
+
+	err := app.modelStore.Identities.SaveAuthRequest(request, oidcLoginTimeout)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	authURL := provider.AuthCodeURL(request.State, request.Nonce, request.CodeVerifier)
+	err = app.writeJSON(w, http.StatusOK, envelope{"oidc": envelope{"authorizationUrl": authURL}}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// oidcCallbackHandler completes a login at a provider with the code and state the provider sent
+// the user back with. Users logging in with an identity for the first time get a new account,
+// unless the login was started to link the identity to an existing one. The response is the same
+// as that of loginUserHandler.
+func (app *application) oidcCallbackHandler(w http.ResponseWriter, r *http.Request) {
+	provider, ok := app.oidcProviders[chi.URLParam(r, "provider")]
+	if !ok {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	var input struct {
+		OIDC struct {
+			Code  string `json:"code"`
+			State string `json:"state"`
+		} `json:"oidc"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+	v.Check(input.OIDC.Code != "", "code must be provided")
+	v.Check(input.OIDC.State != "", "state must be provided")
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	request, err := app.modelStore.Identities.TakeAuthRequest(input.OIDC.State, oidcLoginTimeout)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.invalidCredentialsResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	if request.Provider != provider.Name() {
+		app.invalidCredentialsResponse(w, r)
+		return
+	}
+
+	// Links are completed by the user who started them, so that nobody can link their identity to
+	// another user's account by getting them to complete the login
+	if user := app.contextGetUser(r); request.UserID != nil && (user.IsAnonymous() || user.ID != *request.UserID) {
+		app.invalidAuthenticationTokenResponse(w, r)
+		return
+	}
+
+	claims, err := provider.Exchange(r.Context(), input.OIDC.Code, request.CodeVerifier, request.Nonce)
+	if err != nil {
+		switch {
+		case errors.Is(err, oidc.ErrRejected):
+			app.logger.Info("OpenID Connect login rejected", "provider", provider.Name(), "error", err)
+			app.invalidCredentialsResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	if request.UserID != nil {
+		app.linkIdentity(w, r, app.contextGetUser(r), provider.Name(), claims)
+		return
+	}
+
+	identity, err := app.modelStore.Identities.Get(provider.Name(), claims.Subject)
+	switch {
+	case err == nil:
+		user, err := app.modelStore.Users.GetByID(identity.UserID)
+		if err != nil {
+			// A deleted account cannot be logged in to
+			if errors.Is(err, data.ErrRecordNotFound) {
+				app.invalidCredentialsResponse(w, r)
+				return
+			}
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+		app.completeLogin(w, r, user, http.StatusOK)
+	case errors.Is(err, data.ErrRecordNotFound):
+		app.createOIDCUser(w, r, provider.Name(), claims)
+	default:
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// createOIDCUser creates an account for a user logging in with a new identity. The account has no
+// password until the user sets one. The provider must have verified the email address, which would
+// otherwise be claimed for an account by whoever entered it at the provider.
+func (app *application) createOIDCUser(w http.ResponseWriter, r *http.Request, provider string, claims *oidc.Claims) {
+	if !claims.EmailVerified {
+		app.failedValidationResponse(w, r, []string{"the identity provider has not verified your email address; verify it there and log in again"})
+		return
+	}
+
+	user := &data.User{
+		Email: claims.Email,
+		Image: claims.Picture,
+	}
+	user.Password.SetUnusable()
+
+	local, _, _ := strings.Cut(claims.Email, "@")
+	user.Username = data.GenerateUsername(claims.PreferredUsername, claims.Name, local)
+
+	v := validator.New()
+	if data.ValidateUser(v, *user); !v.Valid() {
+		// The only value that can be invalid is the email address reported by the provider
+		app.failedValidationResponse(w, r, []string{"the identity provider did not share a valid email address"})
+		return
+	}
+
+	identity := &data.Identity{Provider: provider, Subject: claims.Subject, Email: claims.Email}
+	err := app.modelStore.Identities.CreateUser(user, identity)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrDuplicateEmail):
+			// Linking to an existing account requires being logged in to it, so an identity cannot take it over
+			app.failedValidationResponse(w, r, []string{"a user with this email address already exists; log in and link this provider to your account instead"})
+		case errors.Is(err, data.ErrIdentityLinked):
+			// The same identity logged in twice at once; the other request created the account
+			app.editConflictResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	app.completeLogin(w, r, user, http.StatusCreated)
+}
+
+// linkIdentity links an identity to the account of the current user and responds with the user.
+func (app *application) linkIdentity(w http.ResponseWriter, r *http.Request, user *data.User, provider string, claims *oidc.Claims) {
+	existing, err := app.modelStore.Identities.Get(provider, claims.Subject)
+	switch {
+	case err == nil && existing.UserID != user.ID:
+		app.failedValidationResponse(w, r, []string{"this identity is already linked to another account"})
+		return
+	case err == nil:
+		// Already linked to this account
+	case errors.Is(err, data.ErrRecordNotFound):
+		err = app.modelStore.Identities.Link(&data.Identity{Provider: provider, Subject: claims.Subject, UserID: user.ID, Email: claims.Email})
+		if err != nil {
+			switch {
+			case errors.Is(err, data.ErrIdentityLinked):
+				app.failedValidationResponse(w, r, []string{"your account is already linked to another identity at this provider"})
+			default:
+				app.serverErrorResponse(w, r, err)
+			}
+			return
+		}
+	default:
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"user": user}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
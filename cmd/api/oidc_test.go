@@ -0,0 +1,269 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/96malhar/realworld-backend/internal/oidc"
+	"github.com/96malhar/realworld-backend/internal/oidc/oidctest"
+	"github.com/96malhar/realworld-backend/internal/totp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOIDCLogin(t *testing.T) {
+	t.Parallel()
+
+	ts := newTestServer(t)
+
+	mock := oidctest.NewServer(t)
+	provider, err := oidc.Discover(context.Background(), http.DefaultClient, mock.Config("mock"))
+	require.NoError(t, err)
+	ts.app.oidcProviders = map[string]*oidc.Provider{"mock": provider}
+
+	// login logs in at the mock provider as the given user and returns the code and state it
+	// redirects back with.
+	login := func(t *testing.T, user oidctest.User, headers map[string]string) (string, string) {
+		t.Helper()
+		res, err := ts.executeRequest(http.MethodPost, "/users/oidc/mock", "", headers)
+		require.NoError(t, err)
+		defer res.Body.Close() // nolint: errcheck
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		var response struct {
+			OIDC struct {
+				AuthorizationURL string `json:"authorizationUrl"`
+			} `json:"oidc"`
+		}
+		readJsonResponse(t, res.Body, &response)
+
+		mock.SetUser(user)
+		code, state, err := mock.Authorize(response.OIDC.AuthorizationURL)
+		require.NoError(t, err)
+		return code, state
+	}
+
+	callback := func(t *testing.T, code, state string, headers map[string]string) *http.Response {
+		t.Helper()
+		body := `{"oidc":{"code":"` + code + `","state":"` + state + `"}}`
+		res, err := ts.executeRequest(http.MethodPost, "/users/oidc/mock/callback", body, headers)
+		require.NoError(t, err)
+		t.Cleanup(func() { res.Body.Close() }) // nolint: errcheck
+		return res
+	}
+
+	readUser := func(t *testing.T, res *http.Response) user {
+		t.Helper()
+		var response userResponse
+		readJsonResponse(t, res.Body, &response)
+		return response.User
+	}
+
+	jane := oidctest.User{Subject: "jane-1", Email: "jane@example.com", EmailVerified: true, Name: "Jane Doe", PreferredUsername: "jane"}
+
+	t.Run("List providers", func(t *testing.T) {
+		res, err := ts.executeRequest(http.MethodGet, "/users/oidc", "", nil)
+		require.NoError(t, err)
+		defer res.Body.Close() // nolint: errcheck
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		var response struct {
+			Providers []string `json:"providers"`
+		}
+		readJsonResponse(t, res.Body, &response)
+		assert.Equal(t, []string{"mock"}, response.Providers)
+	})
+
+	t.Run("First login creates an account", func(t *testing.T) {
+		code, state := login(t, jane, nil)
+		res := callback(t, code, state, nil)
+		require.Equal(t, http.StatusCreated, res.StatusCode)
+
+		created := readUser(t, res)
+		assert.Equal(t, "jane", created.Username)
+		assert.Equal(t, "jane@example.com", created.Email)
+
+		res, err := ts.executeRequest(http.MethodGet, "/user", "", map[string]string{"Authorization": "Token " + created.Token})
+		require.NoError(t, err)
+		defer res.Body.Close() // nolint: errcheck
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+	})
+
+	t.Run("Later logins use the linked account", func(t *testing.T) {
+		// The provider may report a different email address later; the subject identifies the user
+		changed := jane
+		changed.Email = "jane.doe@example.com"
+
+		code, state := login(t, changed, nil)
+		res := callback(t, code, state, nil)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, "jane", readUser(t, res).Username)
+	})
+
+	t.Run("Accounts created by a provider have no password", func(t *testing.T) {
+		res, err := ts.executeRequest(http.MethodPost, "/users/login", `{"user":{"email":"jane@example.com","password":"password123"}}`, nil)
+		require.NoError(t, err)
+		defer res.Body.Close() // nolint: errcheck
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	})
+
+	t.Run("Usernames are made unique", func(t *testing.T) {
+		registerUser(t, ts, "bob", "bob@example.com", "password123")
+
+		for i, want := range []string{"bob-2", "bob-3"} {
+			other := oidctest.User{Subject: "bob-" + want, Email: want + "@example.org", EmailVerified: true, PreferredUsername: "Bob"}
+			code, state := login(t, other, nil)
+			res := callback(t, code, state, nil)
+			require.Equal(t, http.StatusCreated, res.StatusCode, "login %d", i)
+			assert.Equal(t, want, readUser(t, res).Username)
+		}
+	})
+
+	t.Run("Unverified email addresses are refused", func(t *testing.T) {
+		unverified := oidctest.User{Subject: "carol-1", Email: "carol@example.com", PreferredUsername: "carol"}
+		code, state := login(t, unverified, nil)
+		res := callback(t, code, state, nil)
+		require.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+
+		var response errorResponse
+		readJsonResponse(t, res.Body, &response)
+		assert.Equal(t, []string{"the identity provider has not verified your email address; verify it there and log in again"}, response.Errors)
+
+		// No account was created, so logging in once the address is verified creates it
+		unverified.EmailVerified = true
+		code, state = login(t, unverified, nil)
+		res = callback(t, code, state, nil)
+		require.Equal(t, http.StatusCreated, res.StatusCode)
+		assert.Equal(t, "carol", readUser(t, res).Username)
+	})
+
+	t.Run("Existing email addresses are not taken over", func(t *testing.T) {
+		registerUser(t, ts, "alice", "alice@example.com", "password123")
+
+		alice := oidctest.User{Subject: "alice-1", Email: "alice@example.com", EmailVerified: true, PreferredUsername: "alice"}
+		code, state := login(t, alice, nil)
+		res := callback(t, code, state, nil)
+		require.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+	})
+
+	t.Run("Logged in users link identities", func(t *testing.T) {
+		aliceHeaders := map[string]string{"Authorization": "Token " + loginUser(t, ts, "alice@example.com", "password123")}
+		alice := oidctest.User{Subject: "alice-1", Email: "alice@example.com", EmailVerified: true, PreferredUsername: "alice"}
+
+		// The user who started the link must complete it
+		code, state := login(t, alice, aliceHeaders)
+		res := callback(t, code, state, nil)
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+
+		code, state = login(t, alice, aliceHeaders)
+		res = callback(t, code, state, aliceHeaders)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, "alice", readUser(t, res).Username)
+
+		code, state = login(t, alice, nil)
+		res = callback(t, code, state, nil)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, "alice", readUser(t, res).Username)
+
+		// An identity can only be linked to one account
+		code, state = login(t, jane, aliceHeaders)
+		res = callback(t, code, state, aliceHeaders)
+		assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+	})
+
+	t.Run("Users without a password confirm changes differently", func(t *testing.T) {
+		dave := oidctest.User{Subject: "dave-1", Email: "dave@example.com", EmailVerified: true, PreferredUsername: "dave"}
+		code, state := login(t, dave, nil)
+		res := callback(t, code, state, nil)
+		require.Equal(t, http.StatusCreated, res.StatusCode)
+		daveHeaders := map[string]string{"Authorization": "Token " + readUser(t, res).Token}
+
+		send := func(t *testing.T, method, path, body string) *http.Response {
+			t.Helper()
+			res, err := ts.executeRequest(method, path, body, daveHeaders)
+			require.NoError(t, err)
+			t.Cleanup(func() { res.Body.Close() }) // nolint: errcheck
+			return res
+		}
+
+		// Long after logging in, the token alone is not enough
+		now := time.Now().Add(recentLoginWindow + time.Minute)
+		ts.app.now = func() time.Time { return now }
+		t.Cleanup(func() { ts.app.now = time.Now })
+
+		res = send(t, http.MethodDelete, "/user", `{"user":{}}`)
+		require.Equal(t, http.StatusUnauthorized, res.StatusCode)
+		var errResp errorResponse
+		readJsonResponse(t, res.Body, &errResp)
+		assert.Equal(t, []string{"log in again to confirm this change"}, errResp.Errors)
+
+		res = send(t, http.MethodPost, "/user/2fa/enroll", `{"user":{}}`)
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode, "enrolling needs a recent login too")
+
+		// With two-factor authentication, a code is required instead
+		now = time.Now()
+		res = send(t, http.MethodPost, "/user/2fa/enroll", `{"user":{}}`)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		var enrollment struct {
+			TwoFactor twoFactorEnrollment `json:"twoFactor"`
+		}
+		readJsonResponse(t, res.Body, &enrollment)
+		secret := enrollment.TwoFactor.Secret
+
+		totpCode := func(t *testing.T) string {
+			t.Helper()
+			code, err := totp.Code(secret, totp.Step(now))
+			require.NoError(t, err)
+			return code
+		}
+
+		res = send(t, http.MethodPost, "/user/2fa/confirm", `{"twoFactor":{"code":"`+totpCode(t)+`"}}`)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		now = now.Add(recentLoginWindow + time.Minute)
+		res = send(t, http.MethodDelete, "/user/2fa", `{"user":{}}`)
+		assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+
+		res = send(t, http.MethodDelete, "/user/2fa", `{"user":{"code":"000000"}}`)
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+
+		res = send(t, http.MethodDelete, "/user/2fa", `{"user":{"code":"`+totpCode(t)+`"}}`)
+		require.Equal(t, http.StatusNoContent, res.StatusCode)
+
+		// Without it, a recent login is enough
+		ts.app.now = time.Now
+		res = send(t, http.MethodDelete, "/user", `{"user":{}}`)
+		assert.Equal(t, http.StatusNoContent, res.StatusCode)
+	})
+
+	t.Run("Logins can only be completed once", func(t *testing.T) {
+		code, state := login(t, jane, nil)
+		res := callback(t, code, state, nil)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		res = callback(t, code, state, nil)
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	})
+
+	t.Run("Rejected logins", func(t *testing.T) {
+		code, _ := login(t, jane, nil)
+		res := callback(t, code, "unknown-state", nil)
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+
+		_, state := login(t, jane, nil)
+		res = callback(t, "unknown-code", state, nil)
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+
+		code, state = login(t, jane, nil)
+		mock.SetNonce("replayed")
+		res = callback(t, code, state, nil)
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+
+		res, err := ts.executeRequest(http.MethodPost, "/users/oidc/unknown", "", nil)
+		require.NoError(t, err)
+		defer res.Body.Close() // nolint: errcheck
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"time"
+
+	"github.com/96malhar/realworld-backend/internal/outbox"
+)
+
+// outboxPruneInterval is how often published outbox events older than the retention period are removed.
+const outboxPruneInterval = time.Hour
+
+// runOutboxDispatcher periodically publishes outbox events and prunes those published long ago.
+// It blocks until the application starts shutting down, so it should be run with app.background.
+func (app *application) runOutboxDispatcher() {
+	ticker := time.NewTicker(app.config.outbox.pollInterval)
+	defer ticker.Stop()
+
+	pruneTicker := time.NewTicker(outboxPruneInterval)
+	defer pruneTicker.Stop()
+
+	for {
+		select {
+		case <-app.shutdown:
+			return
+		case <-pruneTicker.C:
+			app.pruneOutbox()
+		case <-ticker.C:
+			// Keep going while full batches are published, so a backlog drains without waiting for the next tick
+			for app.dispatchOutbox() == outbox.BatchSize {
+				select {
+				case <-app.shutdown:
+					return
+				default:
+				}
+			}
+		}
+	}
+}
+
+// dispatchOutbox publishes a batch of due outbox events and returns how many were published.
+func (app *application) dispatchOutbox() int {
+	published, err := app.outbox.Dispatch()
+	if err != nil {
+		app.logger.Error("failed to dispatch outbox events", "error", err)
+	}
+	return published
+}
+
+func (app *application) pruneOutbox() {
+	deleted, err := app.modelStore.Outbox.DeletePublished(app.config.outbox.retention)
+	if err != nil {
+		app.logger.Error("failed to prune outbox events", "error", err)
+		return
+	}
+	if deleted > 0 {
+		app.logger.Info("pruned published outbox events", "count", deleted)
+	}
+}
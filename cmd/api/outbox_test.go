@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/96malhar/realworld-backend/internal/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// eventRecorder subscribes to the outbox and records the events it receives.
+type eventRecorder struct {
+	mu     sync.Mutex
+	events []data.OutboxEvent
+}
+
+func recordOutboxEvents(ts *testServer) *eventRecorder {
+	recorder := &eventRecorder{}
+	ts.app.subscribers.Subscribe(func(_ context.Context, event data.OutboxEvent) error {
+		recorder.mu.Lock()
+		defer recorder.mu.Unlock()
+		recorder.events = append(recorder.events, event)
+		return nil
+	})
+	return recorder
+}
+
+func (r *eventRecorder) types() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	types := make([]string, len(r.events))
+	for i, e := range r.events {
+		types[i] = e.Type
+	}
+	return types
+}
+
+func TestOutboxEvents(t *testing.T) {
+	t.Parallel()
+
+	ts := newTestServer(t)
+	recorder := recordOutboxEvents(ts)
+
+	registerUser(t, ts, "alice", "alice@example.com", "password123")
+	registerUser(t, ts, "bob", "bob@example.com", "password123")
+	aliceToken := loginUser(t, ts, "alice@example.com", "password123")
+	bobToken := loginUser(t, ts, "bob@example.com", "password123")
+	aliceHeader := map[string]string{"Authorization": "Token " + aliceToken}
+
+	followUser(t, ts, bobToken, "alice")
+	articleLocation := createArticle(t, ts, aliceToken, "Alice Article", "Test description", "Test body", []string{"test"})
+	res, err := ts.executeRequest(http.MethodPut, articleLocation, `{"article": {"body": "Updated body"}}`, aliceHeader)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	res, err = ts.executeRequest(http.MethodPost, articleLocation+"/favorite", "", map[string]string{"Authorization": "Token " + bobToken})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	// Favoriting again changes nothing, so no event is written
+	res, err = ts.executeRequest(http.MethodPost, articleLocation+"/favorite", "", map[string]string{"Authorization": "Token " + bobToken})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	createCommentHelper(t, ts, bobToken, articleLocation, "Nice article")
+	res, err = ts.executeRequest(http.MethodDelete, articleLocation, "", aliceHeader)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNoContent, res.StatusCode)
+
+	// Nothing is published until the dispatcher runs
+	assert.Empty(t, recorder.types())
+
+	assert.Equal(t, 8, ts.app.dispatchOutbox())
+	assert.Zero(t, ts.app.dispatchOutbox(), "published events are not published again")
+
+	assert.Equal(t, []string{
+		data.EventUserRegistered,
+		data.EventUserRegistered,
+		data.EventUserFollowed,
+		data.EventArticleCreated,
+		data.EventArticleUpdated,
+		data.EventArticleFavorited,
+		data.EventCommentCreated,
+		data.EventArticleDeleted,
+	}, recorder.types())
+
+	for _, e := range recorder.events {
+		switch e.Type {
+		case data.EventArticleUpdated:
+			var payload getArticleResponse
+			require.NoError(t, json.Unmarshal(e.Payload, &payload))
+			assert.Equal(t, "Updated body", payload.Article.Body)
+		case data.EventArticleFavorited:
+			assert.JSONEq(t, `{"articleSlug": "alice-article", "userId": 2, "favoritesCount": 1}`, string(e.Payload))
+		case data.EventUserRegistered:
+			assert.NotContains(t, string(e.Payload), "@example.com", "email addresses are not part of events")
+		}
+	}
+}
+
+func TestOutboxFailedEventsAreRetried(t *testing.T) {
+	t.Parallel()
+
+	ts := newTestServer(t)
+
+	var mu sync.Mutex
+	attempts := map[string]int{}
+	ts.app.subscribers.Subscribe(func(_ context.Context, event data.OutboxEvent) error {
+		mu.Lock()
+		defer mu.Unlock()
+		attempts[event.Type]++
+		if event.Type == data.EventArticleCreated && attempts[event.Type] == 1 {
+			return assert.AnError
+		}
+		return nil
+	}, data.EventArticleCreated, data.EventArticleUpdated)
+
+	registerUser(t, ts, "alice", "alice@example.com", "password123")
+	aliceToken := loginUser(t, ts, "alice@example.com", "password123")
+	articleLocation := createArticle(t, ts, aliceToken, "Alice Article", "Test description", "Test body", []string{"test"})
+	res, err := ts.executeRequest(http.MethodPut, articleLocation, `{"article": {"body": "Updated body"}}`,
+		map[string]string{"Authorization": "Token " + aliceToken})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	// The update waits behind the failed creation of the same article
+	assert.Equal(t, 1, ts.app.dispatchOutbox(), "only user.registered is published")
+	assert.Equal(t, map[string]int{data.EventArticleCreated: 1}, attempts)
+
+	require.Eventually(t, func() bool {
+		return ts.app.dispatchOutbox() > 0
+	}, 5*time.Second, 20*time.Millisecond)
+	assert.Equal(t, map[string]int{data.EventArticleCreated: 2, data.EventArticleUpdated: 1}, attempts)
+}
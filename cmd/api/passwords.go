@@ -0,0 +1,53 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+
+	"github.com/96malhar/realworld-backend/internal/passwords"
+)
+
+// newPasswordHasher builds the hasher for user passwords from the configured Argon2id parameters.
+func newPasswordHasher(cfg passwordsConfig) (passwords.Hasher, error) {
+	if cfg.argon2Memory > math.MaxUint32 || cfg.argon2Iterations > math.MaxUint32 || cfg.argon2Parallelism > math.MaxUint8 {
+		return nil, errors.New("argon2id parameters are out of range")
+	}
+
+	params := passwords.DefaultArgon2id
+	params.Memory = uint32(cfg.argon2Memory)
+	params.Iterations = uint32(cfg.argon2Iterations)
+	params.Parallelism = uint8(cfg.argon2Parallelism)
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+
+	return passwords.NewDefault(params), nil
+}
+
+// newPasswordPolicy builds the policy for new passwords from the configuration.
+func newPasswordPolicy(cfg passwordsConfig) (passwords.Policy, error) {
+	policy := passwords.Policy{
+		MinScore:         cfg.minScore,
+		RejectUserInputs: cfg.rejectUserInputs,
+	}
+	if cfg.minScore < 0 || cfg.minScore > 4 {
+		return policy, fmt.Errorf("password min score must be between 0 and 4, got %d", cfg.minScore)
+	}
+
+	if cfg.breachedList != "" {
+		f, err := os.Open(cfg.breachedList)
+		if err != nil {
+			return policy, err
+		}
+		defer f.Close() //nolint: errcheck
+
+		policy.Breached, err = passwords.LoadBreachedList(f)
+		if err != nil {
+			return policy, fmt.Errorf("reading breached password list: %w", err)
+		}
+	}
+
+	return policy, nil
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"time"
+)
+
+// runScheduledPublisher periodically publishes scheduled articles whose publish time has passed.
+// It blocks until the application starts shutting down, so it should be run with app.background.
+func (app *application) runScheduledPublisher() {
+	ticker := time.NewTicker(app.config.articles.publishInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-app.shutdown:
+			return
+		case <-ticker.C:
+			count, err := app.modelStore.Articles.PublishScheduled()
+			if err != nil {
+				app.logger.Error("failed to publish scheduled articles", "error", err)
+				continue
+			}
+			if count > 0 {
+				app.logger.Info("published scheduled articles", "count", count)
+			}
+		}
+	}
+}
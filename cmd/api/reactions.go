@@ -0,0 +1,139 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/96malhar/realworld-backend/internal/data"
+	"github.com/96malhar/realworld-backend/internal/validator"
+	"github.com/go-chi/chi/v5"
+)
+
+// addArticleReactionHandler adds the current user's reaction to a published article.
+func (app *application) addArticleReactionHandler(w http.ResponseWriter, r *http.Request) {
+	target, ok := app.articleReactionTarget(w, r)
+	if !ok {
+		return
+	}
+	app.updateReaction(w, r, target, true)
+}
+
+// removeArticleReactionHandler removes the current user's reaction from a published article.
+func (app *application) removeArticleReactionHandler(w http.ResponseWriter, r *http.Request) {
+	target, ok := app.articleReactionTarget(w, r)
+	if !ok {
+		return
+	}
+	app.updateReaction(w, r, target, false)
+}
+
+// addCommentReactionHandler adds the current user's reaction to a comment.
+func (app *application) addCommentReactionHandler(w http.ResponseWriter, r *http.Request) {
+	target, ok := app.commentReactionTarget(w, r)
+	if !ok {
+		return
+	}
+	app.updateReaction(w, r, target, true)
+}
+
+// removeCommentReactionHandler removes the current user's reaction from a comment.
+func (app *application) removeCommentReactionHandler(w http.ResponseWriter, r *http.Request) {
+	target, ok := app.commentReactionTarget(w, r)
+	if !ok {
+		return
+	}
+	app.updateReaction(w, r, target, false)
+}
+
+// articleReactionTarget resolves the article in the URL. It writes a response and returns false
+// if the article does not exist or is not published.
+func (app *application) articleReactionTarget(w http.ResponseWriter, r *http.Request) (data.ReactionTarget, bool) {
+	articleID, err := app.modelStore.Articles.GetIDBySlug(chi.URLParam(r, "slug"), app.contextGetUser(r))
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			app.notFoundResponse(w, r)
+			return data.ReactionTarget{}, false
+		}
+		app.serverErrorResponse(w, r, err)
+		return data.ReactionTarget{}, false
+	}
+
+	return data.ArticleReactionTarget(articleID), true
+}
+
+// commentReactionTarget resolves the comment in the URL. It writes a response and returns false
+// if the comment does not exist, belongs to another article, or has been deleted.
+func (app *application) commentReactionTarget(w http.ResponseWriter, r *http.Request) (data.ReactionTarget, bool) {
+	commentID, err := app.readIDParam(r, "id")
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return data.ReactionTarget{}, false
+	}
+
+	articleID, err := app.modelStore.Articles.GetIDBySlug(chi.URLParam(r, "slug"), app.contextGetUser(r))
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			app.notFoundResponse(w, r)
+			return data.ReactionTarget{}, false
+		}
+		app.serverErrorResponse(w, r, err)
+		return data.ReactionTarget{}, false
+	}
+
+	comment, err := app.modelStore.Comments.GetByID(commentID)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			app.notFoundResponse(w, r)
+			return data.ReactionTarget{}, false
+		}
+		app.serverErrorResponse(w, r, err)
+		return data.ReactionTarget{}, false
+	}
+
+	if comment.ArticleID != articleID || comment.Deleted {
+		app.notFoundResponse(w, r)
+		return data.ReactionTarget{}, false
+	}
+
+	return data.CommentReactionTarget(commentID), true
+}
+
+// updateReaction adds or removes the reaction named in the URL and responds with the target's
+// updated reaction counts and the current user's own reactions. Both operations are idempotent.
+func (app *application) updateReaction(w http.ResponseWriter, r *http.Request, target data.ReactionTarget, add bool) {
+	reactionType := chi.URLParam(r, "type")
+	user := app.contextGetUser(r)
+
+	v := validator.New()
+	if data.ValidateReactionType(v, reactionType); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	var err error
+	if add {
+		err = app.modelStore.Reactions.Add(target, user.ID, reactionType)
+	} else {
+		err = app.modelStore.Reactions.Remove(target, user.ID, reactionType)
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrBlocked):
+			app.blockedResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	summary, err := app.modelStore.Reactions.Summarize(target, user.ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"reactions": summary.Counts, "myReactions": summary.Mine}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/96malhar/realworld-backend/internal/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type reactionsResponse struct {
+	Reactions   map[string]int `json:"reactions"`
+	MyReactions []string       `json:"myReactions"`
+}
+
+func TestArticleReactions(t *testing.T) {
+	t.Parallel()
+
+	ts := newTestServer(t)
+
+	registerUser(t, ts, "alice", "alice@example.com", "password123")
+	registerUser(t, ts, "bob", "bob@example.com", "password123")
+	aliceToken := loginUser(t, ts, "alice@example.com", "password123")
+	bobToken := loginUser(t, ts, "bob@example.com", "password123")
+	aliceHeader := map[string]string{"Authorization": "Token " + aliceToken}
+	bobHeader := map[string]string{"Authorization": "Token " + bobToken}
+
+	articleLocation := createArticle(t, ts, aliceToken, "Test Article", "Test description", "Test body", []string{"test"})
+
+	testcases := []handlerTestcase{
+		{
+			name:                   "Add a reaction",
+			requestMethodType:      http.MethodPost,
+			requestUrlPath:         articleLocation + "/reactions/like",
+			requestHeader:          aliceHeader,
+			wantResponseStatusCode: http.StatusOK,
+			wantResponse: reactionsResponse{
+				Reactions:   map[string]int{"like": 1},
+				MyReactions: []string{"like"},
+			},
+		},
+		{
+			name:                   "Adding the same reaction twice is idempotent",
+			requestMethodType:      http.MethodPost,
+			requestUrlPath:         articleLocation + "/reactions/like",
+			requestHeader:          aliceHeader,
+			wantResponseStatusCode: http.StatusOK,
+			wantResponse: reactionsResponse{
+				Reactions:   map[string]int{"like": 1},
+				MyReactions: []string{"like"},
+			},
+		},
+		{
+			name:                   "Another user adds two reactions",
+			requestMethodType:      http.MethodPost,
+			requestUrlPath:         articleLocation + "/reactions/funny",
+			requestHeader:          bobHeader,
+			wantResponseStatusCode: http.StatusOK,
+			wantResponse: reactionsResponse{
+				Reactions:   map[string]int{"like": 1, "funny": 1},
+				MyReactions: []string{"funny"},
+			},
+		},
+		{
+			name:                   "Another user adds a reaction that already exists",
+			requestMethodType:      http.MethodPost,
+			requestUrlPath:         articleLocation + "/reactions/like",
+			requestHeader:          bobHeader,
+			wantResponseStatusCode: http.StatusOK,
+			wantResponse: reactionsResponse{
+				Reactions:   map[string]int{"like": 2, "funny": 1},
+				MyReactions: []string{"funny", "like"},
+			},
+		},
+		{
+			name:                   "Remove a reaction",
+			requestMethodType:      http.MethodDelete,
+			requestUrlPath:         articleLocation + "/reactions/funny",
+			requestHeader:          bobHeader,
+			wantResponseStatusCode: http.StatusOK,
+			wantResponse: reactionsResponse{
+				Reactions:   map[string]int{"like": 2},
+				MyReactions: []string{"like"},
+			},
+		},
+		{
+			name:                   "Unknown reaction type",
+			requestMethodType:      http.MethodPost,
+			requestUrlPath:         articleLocation + "/reactions/angry",
+			requestHeader:          aliceHeader,
+			wantResponseStatusCode: http.StatusUnprocessableEntity,
+			wantResponse: errorResponse{
+				Errors: []string{"Reaction must be one of like, love, insightful, funny or celebrate"},
+			},
+		},
+		{
+			name:                   "Reacting requires authentication",
+			requestMethodType:      http.MethodPost,
+			requestUrlPath:         articleLocation + "/reactions/like",
+			wantResponseStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:                   "Reacting to a non-existent article",
+			requestMethodType:      http.MethodPost,
+			requestUrlPath:         "/articles/does-not-exist/reactions/like",
+			requestHeader:          aliceHeader,
+			wantResponseStatusCode: http.StatusNotFound,
+		},
+	}
+
+	testHandler(t, ts, testcases...)
+
+	t.Run("Reactions are included when getting and listing articles", func(t *testing.T) {
+		res, err := ts.executeRequest(http.MethodGet, articleLocation, "", aliceHeader)
+		require.NoError(t, err)
+		defer res.Body.Close() //nolint: errcheck
+
+		var got getArticleResponse
+		readJsonResponse(t, res.Body, &got)
+		assert.Equal(t, map[string]int{"like": 2}, got.Article.Reactions)
+		assert.Equal(t, []string{"like"}, got.Article.MyReactions)
+
+		res, err = ts.executeRequest(http.MethodGet, "/articles", "", nil)
+		require.NoError(t, err)
+		defer res.Body.Close() //nolint: errcheck
+
+		var list struct {
+			Articles      []data.Article `json:"articles"`
+			ArticlesCount int            `json:"articlesCount"`
+		}
+		readJsonResponse(t, res.Body, &list)
+		require.Len(t, list.Articles, 1)
+		assert.Equal(t, map[string]int{"like": 2}, list.Articles[0].Reactions)
+		assert.Empty(t, list.Articles[0].MyReactions, "Anonymous users have no reactions of their own")
+	})
+}
+
+func TestCommentReactions(t *testing.T) {
+	t.Parallel()
+
+	ts := newTestServer(t)
+
+	registerUser(t, ts, "alice", "alice@example.com", "password123")
+	aliceToken := loginUser(t, ts, "alice@example.com", "password123")
+	aliceHeader := map[string]string{"Authorization": "Token " + aliceToken}
+
+	articleLocation := createArticle(t, ts, aliceToken, "Test Article", "Test description", "Test body", []string{"test"})
+	otherLocation := createArticle(t, ts, aliceToken, "Other Article", "Test description", "Test body", []string{"test"})
+	commentID := postComment(t, ts, aliceToken, articleLocation, "Nice", 0)
+	commentPath := articleLocation + "/comments/" + strconv.FormatInt(commentID, 10)
+
+	testcases := []handlerTestcase{
+		{
+			name:                   "Add a reaction to a comment",
+			requestMethodType:      http.MethodPost,
+			requestUrlPath:         commentPath + "/reactions/insightful",
+			requestHeader:          aliceHeader,
+			wantResponseStatusCode: http.StatusOK,
+			wantResponse: reactionsResponse{
+				Reactions:   map[string]int{"insightful": 1},
+				MyReactions: []string{"insightful"},
+			},
+		},
+		{
+			name:                   "Comment must belong to the article",
+			requestMethodType:      http.MethodPost,
+			requestUrlPath:         otherLocation + "/comments/" + strconv.FormatInt(commentID, 10) + "/reactions/like",
+			requestHeader:          aliceHeader,
+			wantResponseStatusCode: http.StatusNotFound,
+		},
+		{
+			name:                   "Reacting to a non-existent comment",
+			requestMethodType:      http.MethodPost,
+			requestUrlPath:         articleLocation + "/comments/999999/reactions/like",
+			requestHeader:          aliceHeader,
+			wantResponseStatusCode: http.StatusNotFound,
+		},
+	}
+
+	testHandler(t, ts, testcases...)
+
+	t.Run("Reactions are included when listing comments", func(t *testing.T) {
+		res, err := ts.executeRequest(http.MethodGet, articleLocation+"/comments", "", aliceHeader)
+		require.NoError(t, err)
+		defer res.Body.Close() //nolint: errcheck
+
+		var resp commentsResponse
+		readJsonResponse(t, res.Body, &resp)
+		require.Len(t, resp.Comments, 1)
+		assert.Equal(t, map[string]int{"insightful": 1}, resp.Comments[0].Reactions)
+		assert.Equal(t, []string{"insightful"}, resp.Comments[0].MyReactions)
+	})
+}
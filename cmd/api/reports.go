@@ -0,0 +1,186 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/96malhar/realworld-backend/internal/data"
+	"github.com/96malhar/realworld-backend/internal/validator"
+	"github.com/go-chi/chi/v5"
+)
+
+// reportArticleHandler reports the article in the URL to the moderators.
+func (app *application) reportArticleHandler(w http.ResponseWriter, r *http.Request) {
+	user := app.contextGetUser(r)
+
+	article, err := app.modelStore.Articles.GetBySlug(chi.URLParam(r, "slug"), user)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			app.notFoundResponse(w, r)
+			return
+		}
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	report := &data.Report{ArticleID: article.ID}
+	app.fileReport(w, r, report, article.AuthorID)
+}
+
+// reportCommentHandler reports the comment in the URL to the moderators.
+func (app *application) reportCommentHandler(w http.ResponseWriter, r *http.Request) {
+	user := app.contextGetUser(r)
+
+	commentID, err := app.readIDParam(r, "id")
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	articleID, err := app.modelStore.Articles.GetIDBySlug(chi.URLParam(r, "slug"), user)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			app.notFoundResponse(w, r)
+			return
+		}
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	comment, err := app.modelStore.Comments.GetByID(commentID)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			app.notFoundResponse(w, r)
+			return
+		}
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	// Hidden comments are only visible to their author, who cannot report them, and moderators
+	if comment.ArticleID != articleID || comment.Deleted || (comment.Hidden && !user.HasPermission(data.PermissionContentModerate)) {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	report := &data.Report{ArticleID: articleID, CommentID: &comment.ID}
+	app.fileReport(w, r, report, comment.AuthorID)
+}
+
+// fileReport reads the reason for the report from the request body and files it on behalf of the
+// current user. Users cannot report their own content, nor report the same content twice while
+// their earlier report is open.
+func (app *application) fileReport(w http.ResponseWriter, r *http.Request, report *data.Report, authorID int64) {
+	user := app.contextGetUser(r)
+
+	var input struct {
+		Report struct {
+			Reason  string `json:"reason"`
+			Details string `json:"details"`
+		} `json:"report"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	report.ReporterID = user.ID
+	report.Reason = input.Report.Reason
+	report.Details = input.Report.Details
+
+	v := validator.New()
+	data.ValidateReport(v, report)
+	v.Check(authorID != user.ID, "you cannot report your own content")
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = app.modelStore.Reports.Insert(report)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrDuplicateReport):
+			app.failedValidationResponse(w, r, []string{"you have already reported this content"})
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusCreated, envelope{"report": report}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// listReportsHandler returns a page of the moderation queue, oldest first. Only open reports are
+// listed unless another status is requested.
+func (app *application) listReportsHandler(w http.ResponseWriter, r *http.Request) {
+	pagination := app.readPagination(r, 20, 100)
+
+	status := r.URL.Query().Get("status")
+	if status == "" {
+		status = data.ReportStatusOpen
+	}
+
+	v := validator.New()
+	if data.ValidateReportStatus(v, status); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	reports, totalCount, err := app.modelStore.Reports.List(status, pagination.Limit, pagination.Offset)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"reports": reports, "reportsCount": totalCount}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// resolveReportHandler returns a handler that resolves the report in the URL with the given status:
+// dismissing it, or hiding, deleting or approving the reported content.
+func (app *application) resolveReportHandler(status string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := app.readIDParam(r, "id")
+		if err != nil {
+			app.notFoundResponse(w, r)
+			return
+		}
+
+		report, err := app.modelStore.Reports.Get(id)
+		if err != nil {
+			if errors.Is(err, data.ErrRecordNotFound) {
+				app.notFoundResponse(w, r)
+				return
+			}
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+
+		err = app.modelStore.Reports.Resolve(report, status, app.contextGetUser(r))
+		if err != nil {
+			switch {
+			case errors.Is(err, data.ErrReportResolved):
+				app.failedValidationResponse(w, r, []string{"report has already been resolved"})
+			case errors.Is(err, data.ErrHeldContent):
+				app.failedValidationResponse(w, r, []string{"content held by the content filter must be approved or deleted"})
+			case errors.Is(err, data.ErrRecordNotFound):
+				app.failedValidationResponse(w, r, []string{"the reported content no longer exists"})
+			default:
+				app.serverErrorResponse(w, r, err)
+			}
+			return
+		}
+
+		err = app.writeJSON(w, http.StatusOK, envelope{"report": report}, nil)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+		}
+	}
+}
@@ -0,0 +1,359 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/96malhar/realworld-backend/internal/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type report struct {
+	ID           int64      `json:"id"`
+	Reporter     string     `json:"reporter"`
+	TargetType   string     `json:"targetType"`
+	ArticleSlug  string     `json:"articleSlug"`
+	CommentID    *int64     `json:"commentId,omitempty"`
+	TargetAuthor string     `json:"targetAuthor"`
+	Reason       string     `json:"reason"`
+	Details      string     `json:"details"`
+	Status       string     `json:"status"`
+	ResolvedBy   string     `json:"resolvedBy,omitempty"`
+	ResolvedAt   *time.Time `json:"resolvedAt,omitempty"`
+	CreatedAt    time.Time  `json:"createdAt"`
+}
+
+type reportResponse struct {
+	Report report `json:"report"`
+}
+
+type reportsResponse struct {
+	Reports      []report `json:"reports"`
+	ReportsCount int      `json:"reportsCount"`
+}
+
+func fileReport(t *testing.T, ts *testServer, token, location, reason string) report {
+	t.Helper()
+
+	body := fmt.Sprintf(`{"report": {"reason": %q}}`, reason)
+	res, err := ts.executeRequest(http.MethodPost, location+"/report", body, map[string]string{"Authorization": "Token " + token})
+	require.NoError(t, err)
+	defer res.Body.Close() //nolint: errcheck
+	require.Equal(t, http.StatusCreated, res.StatusCode)
+
+	var resp reportResponse
+	readJsonResponse(t, res.Body, &resp)
+	return resp.Report
+}
+
+func listReports(t *testing.T, ts *testServer, token, status string) reportsResponse {
+	t.Helper()
+
+	res, err := ts.executeRequest(http.MethodGet, "/admin/reports?status="+status, "", map[string]string{"Authorization": "Token " + token})
+	require.NoError(t, err)
+	defer res.Body.Close() //nolint: errcheck
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	var resp reportsResponse
+	readJsonResponse(t, res.Body, &resp)
+	return resp
+}
+
+func TestReportHandlers(t *testing.T) {
+	t.Parallel()
+
+	ts := newTestServer(t)
+
+	registerUser(t, ts, "alice", "alice@example.com", "password123")
+	registerUser(t, ts, "bob", "bob@example.com", "password123")
+	aliceToken := loginUser(t, ts, "alice@example.com", "password123")
+	bobToken := loginUser(t, ts, "bob@example.com", "password123")
+
+	articleLocation := createArticle(t, ts, aliceToken, "Alice Article", "Test description", "Test body", []string{"test"})
+	commentID := postComment(t, ts, bobToken, articleLocation, "Buy cheap watches", 0)
+	commentLocation := articleLocation + "/comments/" + strconv.FormatInt(commentID, 10)
+
+	aliceHeader := map[string]string{"Authorization": "Token " + aliceToken}
+	bobHeader := map[string]string{"Authorization": "Token " + bobToken}
+
+	testHandler(t, ts,
+		handlerTestcase{
+			name:                   "Users can report other users' articles",
+			requestMethodType:      http.MethodPost,
+			requestUrlPath:         articleLocation + "/report",
+			requestBody:            `{"report": {"reason": "misinformation", "details": "The body is wrong"}}`,
+			requestHeader:          bobHeader,
+			wantResponseStatusCode: http.StatusCreated,
+			additionalChecks: func(t *testing.T, res *http.Response) {
+				var resp reportResponse
+				readJsonResponse(t, res.Body, &resp)
+				assert.Equal(t, "bob", resp.Report.Reporter)
+				assert.Equal(t, data.ReportTargetArticle, resp.Report.TargetType)
+				assert.Equal(t, "alice-article", resp.Report.ArticleSlug)
+				assert.Nil(t, resp.Report.CommentID)
+				assert.Equal(t, "alice", resp.Report.TargetAuthor)
+				assert.Equal(t, "misinformation", resp.Report.Reason)
+				assert.Equal(t, "The body is wrong", resp.Report.Details)
+				assert.Equal(t, data.ReportStatusOpen, resp.Report.Status)
+			},
+		},
+		handlerTestcase{
+			name:                   "Users can report other users' comments",
+			requestMethodType:      http.MethodPost,
+			requestUrlPath:         commentLocation + "/report",
+			requestBody:            `{"report": {"reason": "spam"}}`,
+			requestHeader:          aliceHeader,
+			wantResponseStatusCode: http.StatusCreated,
+			additionalChecks: func(t *testing.T, res *http.Response) {
+				var resp reportResponse
+				readJsonResponse(t, res.Body, &resp)
+				assert.Equal(t, data.ReportTargetComment, resp.Report.TargetType)
+				require.NotNil(t, resp.Report.CommentID)
+				assert.Equal(t, commentID, *resp.Report.CommentID)
+				assert.Equal(t, "bob", resp.Report.TargetAuthor)
+			},
+		},
+		handlerTestcase{
+			name:                   "Users cannot report the same content twice",
+			requestMethodType:      http.MethodPost,
+			requestUrlPath:         articleLocation + "/report",
+			requestBody:            `{"report": {"reason": "spam"}}`,
+			requestHeader:          bobHeader,
+			wantResponseStatusCode: http.StatusUnprocessableEntity,
+			wantResponse:           errorResponse{Errors: []string{"you have already reported this content"}},
+		},
+		handlerTestcase{
+			name:                   "Users cannot report their own content",
+			requestMethodType:      http.MethodPost,
+			requestUrlPath:         articleLocation + "/report",
+			requestBody:            `{"report": {"reason": "spam"}}`,
+			requestHeader:          aliceHeader,
+			wantResponseStatusCode: http.StatusUnprocessableEntity,
+			wantResponse:           errorResponse{Errors: []string{"you cannot report your own content"}},
+		},
+		handlerTestcase{
+			name:                   "Invalid reason",
+			requestMethodType:      http.MethodPost,
+			requestUrlPath:         commentLocation + "/report",
+			requestBody:            `{"report": {"reason": "boring"}}`,
+			requestHeader:          bobHeader,
+			wantResponseStatusCode: http.StatusUnprocessableEntity,
+			wantResponse: errorResponse{Errors: []string{
+				"reason must be one of spam, harassment, hate_speech, misinformation or other",
+				"you cannot report your own content",
+			}},
+		},
+		handlerTestcase{
+			name:                   "Reporting a comment that does not exist",
+			requestMethodType:      http.MethodPost,
+			requestUrlPath:         articleLocation + "/comments/999999/report",
+			requestBody:            `{"report": {"reason": "spam"}}`,
+			requestHeader:          aliceHeader,
+			wantResponseStatusCode: http.StatusNotFound,
+		},
+		handlerTestcase{
+			name:                   "Anonymous users cannot report content",
+			requestMethodType:      http.MethodPost,
+			requestUrlPath:         articleLocation + "/report",
+			requestBody:            `{"report": {"reason": "spam"}}`,
+			wantResponseStatusCode: http.StatusUnauthorized,
+		},
+		handlerTestcase{
+			name:                   "Regular users cannot see the moderation queue",
+			requestMethodType:      http.MethodGet,
+			requestUrlPath:         "/admin/reports",
+			requestHeader:          aliceHeader,
+			wantResponseStatusCode: http.StatusForbidden,
+		},
+	)
+}
+
+func TestModerationQueue(t *testing.T) {
+	t.Parallel()
+
+	ts := newTestServer(t)
+
+	registerUser(t, ts, "alice", "alice@example.com", "password123")
+	registerUser(t, ts, "bob", "bob@example.com", "password123")
+	registerUser(t, ts, "carol", "carol@example.com", "password123")
+	registerUser(t, ts, "mod", "mod@example.com", "password123")
+	registerUser(t, ts, "admin", "admin@example.com", "password123")
+	setRole(t, ts, "mod", data.RoleModerator)
+	setRole(t, ts, "admin", data.RoleAdmin)
+	aliceToken := loginUser(t, ts, "alice@example.com", "password123")
+	bobToken := loginUser(t, ts, "bob@example.com", "password123")
+	carolToken := loginUser(t, ts, "carol@example.com", "password123")
+	modToken := loginUser(t, ts, "mod@example.com", "password123")
+	adminToken := loginUser(t, ts, "admin@example.com", "password123")
+
+	modHeader := map[string]string{"Authorization": "Token " + modToken}
+
+	hiddenLocation := createArticle(t, ts, aliceToken, "Hidden Article", "Test description", "Test body", []string{"test"})
+	deletedLocation := createArticle(t, ts, aliceToken, "Deleted Article", "Test description", "Test body", []string{"test"})
+	keptLocation := createArticle(t, ts, aliceToken, "Kept Article", "Test description", "Test body", []string{"test"})
+	commentID := postComment(t, ts, bobToken, keptLocation, "Buy cheap watches", 0)
+	commentLocation := keptLocation + "/comments/" + strconv.FormatInt(commentID, 10)
+
+	hideReport := fileReport(t, ts, bobToken, hiddenLocation, data.ReportReasonHarassment)
+	sameTargetReport := fileReport(t, ts, carolToken, hiddenLocation, data.ReportReasonSpam)
+	deleteReport := fileReport(t, ts, bobToken, deletedLocation, data.ReportReasonSpam)
+	dismissReport := fileReport(t, ts, bobToken, keptLocation, data.ReportReasonOther)
+	commentReport := fileReport(t, ts, aliceToken, commentLocation, data.ReportReasonSpam)
+
+	queue := listReports(t, ts, modToken, "")
+	require.Equal(t, 5, queue.ReportsCount)
+	require.Len(t, queue.Reports, 5)
+	assert.Equal(t, hideReport.ID, queue.Reports[0].ID, "the queue is oldest first")
+
+	resolve := func(t *testing.T, id int64, action string) *http.Response {
+		t.Helper()
+
+		res, err := ts.executeRequest(http.MethodPost, fmt.Sprintf("/admin/reports/%d/%s", id, action), "", modHeader)
+		require.NoError(t, err)
+		t.Cleanup(func() { res.Body.Close() }) //nolint: errcheck
+		return res
+	}
+
+	t.Run("Dismissing a report leaves the content visible", func(t *testing.T) {
+		res := resolve(t, dismissReport.ID, "dismiss")
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		var resp reportResponse
+		readJsonResponse(t, res.Body, &resp)
+		assert.Equal(t, data.ReportStatusDismissed, resp.Report.Status)
+		assert.Equal(t, "mod", resp.Report.ResolvedBy)
+		assert.NotNil(t, resp.Report.ResolvedAt)
+
+		res, err := ts.executeRequest(http.MethodGet, keptLocation, "", nil)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		res = resolve(t, dismissReport.ID, "hide")
+		assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode, "resolved reports cannot be resolved again")
+	})
+
+	t.Run("Hiding an article resolves all of its reports", func(t *testing.T) {
+		res := resolve(t, hideReport.ID, "hide")
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		hidden := listReports(t, ts, modToken, data.ReportStatusHidden)
+		require.Equal(t, 2, hidden.ReportsCount)
+		assert.ElementsMatch(t, []int64{hideReport.ID, sameTargetReport.ID}, []int64{hidden.Reports[0].ID, hidden.Reports[1].ID})
+
+		for name, token := range map[string]string{"anonymous users": "", "other users": bobToken} {
+			var header map[string]string
+			if token != "" {
+				header = map[string]string{"Authorization": "Token " + token}
+			}
+
+			res, err := ts.executeRequest(http.MethodGet, hiddenLocation, "", header)
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusNotFound, res.StatusCode, "hidden articles are not visible to %s", name)
+
+			res, err = ts.executeRequest(http.MethodGet, hiddenLocation+"/comments", "", header)
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusNotFound, res.StatusCode, "the comments of hidden articles are not visible to %s", name)
+
+			res, err = ts.executeRequest(http.MethodGet, "/articles?author=alice", "", header)
+			require.NoError(t, err)
+			var list struct {
+				Articles      []data.Article `json:"articles"`
+				ArticlesCount int            `json:"articlesCount"`
+			}
+			readJsonResponse(t, res.Body, &list)
+			assert.Equal(t, 2, list.ArticlesCount, "hidden articles are not listed for %s", name)
+		}
+
+		for name, token := range map[string]string{"the author": aliceToken, "moderators": modToken} {
+			res, err := ts.executeRequest(http.MethodGet, hiddenLocation, "", map[string]string{"Authorization": "Token " + token})
+			require.NoError(t, err)
+			require.Equal(t, http.StatusOK, res.StatusCode, "hidden articles are visible to %s", name)
+
+			var resp getArticleResponse
+			readJsonResponse(t, res.Body, &resp)
+			assert.True(t, resp.Article.Hidden)
+
+			res, err = ts.executeRequest(http.MethodGet, hiddenLocation+"/comments", "", map[string]string{"Authorization": "Token " + token})
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusOK, res.StatusCode, "the comments of hidden articles are visible to %s", name)
+		}
+	})
+
+	t.Run("Approving a hidden article's report un-hides it", func(t *testing.T) {
+		res := resolve(t, hideReport.ID, "dismiss")
+		assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode, "hidden reports can only be approved")
+
+		res = resolve(t, sameTargetReport.ID, "approve")
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		var resp reportResponse
+		readJsonResponse(t, res.Body, &resp)
+		assert.Equal(t, data.ReportStatusApproved, resp.Report.Status)
+
+		approved := listReports(t, ts, modToken, data.ReportStatusApproved)
+		require.Equal(t, 2, approved.ReportsCount)
+		assert.Equal(t, 0, listReports(t, ts, modToken, data.ReportStatusHidden).ReportsCount)
+
+		res, err := ts.executeRequest(http.MethodGet, hiddenLocation, "", nil)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		res = resolve(t, hideReport.ID, "approve")
+		assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode, "approved reports cannot be resolved again")
+	})
+
+	t.Run("Hiding a comment removes it from the article's comments", func(t *testing.T) {
+		res := resolve(t, commentReport.ID, "hide")
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		getComments := func(token string) commentsResponse {
+			res, err := ts.executeRequest(http.MethodGet, keptLocation+"/comments", "", map[string]string{"Authorization": "Token " + token})
+			require.NoError(t, err)
+			require.Equal(t, http.StatusOK, res.StatusCode)
+
+			var resp commentsResponse
+			readJsonResponse(t, res.Body, &resp)
+			return resp
+		}
+
+		comments := getComments(aliceToken)
+		assert.Equal(t, 0, comments.CommentsCount)
+		assert.Empty(t, comments.Comments)
+
+		for _, token := range []string{bobToken, modToken} {
+			comments := getComments(token)
+			assert.Equal(t, 1, comments.CommentsCount)
+			require.Len(t, comments.Comments, 1)
+			assert.True(t, comments.Comments[0].Hidden)
+		}
+	})
+
+	t.Run("Deleting reported content", func(t *testing.T) {
+		res := resolve(t, deleteReport.ID, "delete")
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		res, err := ts.executeRequest(http.MethodGet, deletedLocation, "", modHeader)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+
+		deleted := listReports(t, ts, modToken, data.ReportStatusDeleted)
+		require.Equal(t, 1, deleted.ReportsCount)
+		assert.Empty(t, deleted.Reports[0].ArticleSlug, "reports outlive the reported content")
+	})
+
+	assert.Equal(t, 0, listReports(t, ts, modToken, data.ReportStatusOpen).ReportsCount)
+
+	var actions []string
+	for _, entry := range getAuditLog(t, ts, adminToken).Entries {
+		actions = append(actions, entry.Action)
+	}
+	assert.Equal(t, []string{
+		data.AuditArticleDeleted, data.AuditCommentHidden, data.AuditArticleApproved, data.AuditArticleHidden,
+		data.AuditReportDismissed,
+		data.AuditUserRoleChanged, data.AuditUserRoleChanged,
+	}, actions)
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/96malhar/realworld-backend/internal/data"
+	"github.com/96malhar/realworld-backend/internal/diff"
+	"github.com/96malhar/realworld-backend/internal/validator"
+	"github.com/go-chi/chi/v5"
+)
+
+// diffContextLines is the number of unchanged lines shown around each change in a revision diff.
+const diffContextLines = 3
+
+// listRevisionsHandler returns the revision history of an article, newest first.
+func (app *application) listRevisionsHandler(w http.ResponseWriter, r *http.Request) {
+	slug := chi.URLParam(r, "slug")
+
+	article, err := app.modelStore.Articles.GetBySlug(slug, app.contextGetUser(r))
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			app.notFoundResponse(w, r)
+			return
+		}
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	revisions, err := app.modelStore.Revisions.GetAllByArticleID(article.ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"revisions": revisions, "revisionsCount": len(revisions)}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// getRevisionHandler returns a single revision of an article, including its body.
+func (app *application) getRevisionHandler(w http.ResponseWriter, r *http.Request) {
+	slug := chi.URLParam(r, "slug")
+
+	version, err := app.readIDParam(r, "version")
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	article, err := app.modelStore.Articles.GetBySlug(slug, app.contextGetUser(r))
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			app.notFoundResponse(w, r)
+			return
+		}
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	revision, err := app.modelStore.Revisions.Get(article.ID, int(version))
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			app.notFoundResponse(w, r)
+			return
+		}
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"revision": revision}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// diffRevisionsHandler returns a unified diff of the title, description and body
+// between the revisions given by the "from" and "to" query parameters.
+// Fields that did not change between the two revisions have an empty diff.
+func (app *application) diffRevisionsHandler(w http.ResponseWriter, r *http.Request) {
+	slug := chi.URLParam(r, "slug")
+
+	qs := r.URL.Query()
+	from := app.readInt(qs.Get("from"), 0)
+	to := app.readInt(qs.Get("to"), 0)
+
+	v := validator.New()
+	v.Check(from > 0, "from must be a positive integer")
+	v.Check(to > 0, "to must be a positive integer")
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	article, err := app.modelStore.Articles.GetBySlug(slug, app.contextGetUser(r))
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			app.notFoundResponse(w, r)
+			return
+		}
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	fromRevision, err := app.modelStore.Revisions.Get(article.ID, from)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			app.notFoundResponse(w, r)
+			return
+		}
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	toRevision, err := app.modelStore.Revisions.Get(article.ID, to)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			app.notFoundResponse(w, r)
+			return
+		}
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	fieldDiff := func(field, a, b string) string {
+		return diff.Unified(fmt.Sprintf("%s@%d", field, from), fmt.Sprintf("%s@%d", field, to), a, b, diffContextLines)
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{
+		"diff": map[string]any{
+			"from":        from,
+			"to":          to,
+			"title":       fieldDiff("title", fromRevision.Title, toRevision.Title),
+			"description": fieldDiff("description", fromRevision.Description, toRevision.Description),
+			"body":        fieldDiff("body", fromRevision.Body, toRevision.Body),
+		},
+	}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// restoreRevisionHandler lets the author, or a user who may edit any article, restore the content of an earlier revision.
+// The restored content is saved as a new revision, so the history is never rewritten, and is screened
+// by the content filter like an edit.
+func (app *application) restoreRevisionHandler(w http.ResponseWriter, r *http.Request) {
+	slug := chi.URLParam(r, "slug")
+	user := app.contextGetUser(r)
+
+	version, err := app.readIDParam(r, "version")
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	article, err := app.modelStore.Articles.GetBySlug(slug, user)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			app.notFoundResponse(w, r)
+			return
+		}
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	if article.Author.Username != user.Username && !user.HasPermission(data.PermissionArticlesUpdateAny) {
+		app.notPermittedResponse(w, r)
+		return
+	}
+
+	revision, err := app.modelStore.Revisions.Get(article.ID, int(version))
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			app.notFoundResponse(w, r)
+			return
+		}
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	app.setArticleTitle(article, revision.Title)
+	article.Description = revision.Description
+	article.Body = revision.Body
+
+	// Restored text is screened like an edit, so that text held or rejected before cannot be brought back
+	var ok bool
+	article.HoldReasons, ok = app.screenContent(w, r, articleText(article)...)
+	if !ok {
+		return
+	}
+
+	err = app.modelStore.Articles.Update(article, user)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrEditConflict):
+			app.editConflictResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	headers := make(http.Header)
+	headers.Set("Location", "/articles/"+article.Slug)
+	err = app.writeJSON(w, http.StatusOK, envelope{"article": article}, headers)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/96malhar/realworld-backend/internal/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type revisionsResponse struct {
+	Revisions      []data.ArticleRevision `json:"revisions"`
+	RevisionsCount int                    `json:"revisionsCount"`
+}
+
+type revisionResponse struct {
+	Revision data.ArticleRevision `json:"revision"`
+}
+
+type revisionDiffResponse struct {
+	Diff struct {
+		From        int    `json:"from"`
+		To          int    `json:"to"`
+		Title       string `json:"title"`
+		Description string `json:"description"`
+		Body        string `json:"body"`
+	} `json:"diff"`
+}
+
+// updateArticleHelper is a test helper that updates an article and returns its new location
+func updateArticleHelper(t *testing.T, ts *testServer, token, slug, requestBody string) string {
+	t.Helper()
+
+	headers := map[string]string{"Authorization": "Token " + token}
+	res, err := ts.executeRequest(http.MethodPut, "/articles/"+slug, requestBody, headers)
+	require.NoError(t, err)
+	defer res.Body.Close() //nolint: errcheck
+
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	return res.Header.Get("Location")
+}
+
+func TestArticleRevisionHandlers(t *testing.T) {
+	t.Parallel()
+
+	ts := newTestServer(t)
+
+	registerUser(t, ts, "alice", "alice@example.com", "password123")
+	registerUser(t, ts, "bob", "bob@example.com", "password123")
+	aliceToken := loginUser(t, ts, "alice@example.com", "password123")
+	bobToken := loginUser(t, ts, "bob@example.com", "password123")
+
+	location := createArticle(t, ts, aliceToken, "First Title", "First description", "line one", nil)
+	slug := strings.TrimPrefix(location, "/articles/")
+	location = updateArticleHelper(t, ts, aliceToken, slug, `{"article": {"body": "line one\nline two"}}`)
+	slug = strings.TrimPrefix(location, "/articles/")
+	location = updateArticleHelper(t, ts, aliceToken, slug, `{"article": {"title": "Second Title"}}`)
+	slug = strings.TrimPrefix(location, "/articles/")
+
+	testcases := []handlerTestcase{
+		{
+			name:                   "List revisions",
+			requestMethodType:      http.MethodGet,
+			requestUrlPath:         "/articles/" + slug + "/revisions",
+			wantResponseStatusCode: http.StatusOK,
+			additionalChecks: func(t *testing.T, res *http.Response) {
+				var response revisionsResponse
+				readJsonResponse(t, res.Body, &response)
+
+				require.Equal(t, 3, response.RevisionsCount)
+				require.Len(t, response.Revisions, 3)
+				assert.Equal(t, 3, response.Revisions[0].Version)
+				assert.Equal(t, "Second Title", response.Revisions[0].Title)
+				assert.Equal(t, 1, response.Revisions[2].Version)
+				assert.Equal(t, "First Title", response.Revisions[2].Title)
+				assert.Empty(t, response.Revisions[0].Body, "Body should be excluded from the revision list")
+			},
+		},
+		{
+			name:                   "Get a single revision",
+			requestMethodType:      http.MethodGet,
+			requestUrlPath:         "/articles/" + slug + "/revisions/1",
+			wantResponseStatusCode: http.StatusOK,
+			additionalChecks: func(t *testing.T, res *http.Response) {
+				var response revisionResponse
+				readJsonResponse(t, res.Body, &response)
+
+				assert.Equal(t, 1, response.Revision.Version)
+				assert.Equal(t, "First Title", response.Revision.Title)
+				assert.Equal(t, "First description", response.Revision.Description)
+				assert.Equal(t, "line one", response.Revision.Body)
+				assert.WithinDuration(t, time.Now().UTC(), response.Revision.CreatedAt, 5*time.Second)
+			},
+		},
+		{
+			name:                   "Get a non-existent revision",
+			requestMethodType:      http.MethodGet,
+			requestUrlPath:         "/articles/" + slug + "/revisions/42",
+			wantResponseStatusCode: http.StatusNotFound,
+		},
+		{
+			name:                   "Get a revision with an invalid version",
+			requestMethodType:      http.MethodGet,
+			requestUrlPath:         "/articles/" + slug + "/revisions/abc",
+			wantResponseStatusCode: http.StatusNotFound,
+		},
+		{
+			name:                   "Diff between two revisions",
+			requestMethodType:      http.MethodGet,
+			requestUrlPath:         "/articles/" + slug + "/revisions/diff?from=1&to=3",
+			wantResponseStatusCode: http.StatusOK,
+			additionalChecks: func(t *testing.T, res *http.Response) {
+				var response revisionDiffResponse
+				readJsonResponse(t, res.Body, &response)
+
+				assert.Equal(t, 1, response.Diff.From)
+				assert.Equal(t, 3, response.Diff.To)
+				assert.Equal(t, "--- title@1\n+++ title@3\n@@ -1 +1 @@\n-First Title\n+Second Title\n", response.Diff.Title)
+				assert.Empty(t, response.Diff.Description)
+				assert.Equal(t, "--- body@1\n+++ body@3\n@@ -1 +1,2 @@\n line one\n+line two\n", response.Diff.Body)
+			},
+		},
+		{
+			name:                   "Diff without versions",
+			requestMethodType:      http.MethodGet,
+			requestUrlPath:         "/articles/" + slug + "/revisions/diff",
+			wantResponseStatusCode: http.StatusUnprocessableEntity,
+			wantResponse: errorResponse{
+				Errors: []string{"from must be a positive integer", "to must be a positive integer"},
+			},
+		},
+		{
+			name:                   "Revisions of a non-existent article",
+			requestMethodType:      http.MethodGet,
+			requestUrlPath:         "/articles/non-existent-article/revisions",
+			wantResponseStatusCode: http.StatusNotFound,
+		},
+		{
+			name:                   "Only the author can restore a revision",
+			requestMethodType:      http.MethodPost,
+			requestUrlPath:         "/articles/" + slug + "/revisions/1/restore",
+			requestHeader:          map[string]string{"Authorization": "Token " + bobToken},
+			wantResponseStatusCode: http.StatusForbidden,
+		},
+		{
+			name:                   "Restoring requires authentication",
+			requestMethodType:      http.MethodPost,
+			requestUrlPath:         "/articles/" + slug + "/revisions/1/restore",
+			wantResponseStatusCode: http.StatusUnauthorized,
+		},
+	}
+
+	testHandler(t, ts, testcases...)
+
+	t.Run("Author restores an earlier revision as a new revision", func(t *testing.T) {
+		headers := map[string]string{"Authorization": "Token " + aliceToken}
+		res, err := ts.executeRequest(http.MethodPost, "/articles/"+slug+"/revisions/1/restore", "", headers)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		var response getArticleResponse
+		readJsonResponse(t, res.Body, &response)
+		assert.Equal(t, "First Title", response.Article.Title)
+		assert.Equal(t, "line one", response.Article.Body)
+		assert.Equal(t, "/articles/"+response.Article.Slug, res.Header.Get("Location"))
+
+		articleID, err := ts.app.modelStore.Articles.GetIDBySlug(response.Article.Slug, nil)
+		require.NoError(t, err)
+		revision, err := ts.app.modelStore.Revisions.Get(articleID, 4)
+		require.NoError(t, err)
+		assert.Equal(t, "First Title", revision.Title)
+		assert.Equal(t, "line one", revision.Body)
+	})
+}
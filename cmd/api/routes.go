@@ -1,6 +1,7 @@
 package main
 
 import (
+	"github.com/96malhar/realworld-backend/internal/data"
 	"github.com/go-chi/chi/v5"
 )
 
@@ -18,34 +19,104 @@ func (app *application) routes() *chi.Mux {
 	r.Route("/users", func(r chi.Router) {
 		r.Post("/", app.registerUserHandler)
 		r.Post("/login", app.loginUserHandler)
+		r.Post("/login/2fa", app.loginTwoFactorHandler)
+		r.Get("/oidc", app.listOIDCProvidersHandler)
+		r.Post("/oidc/{provider}", app.startOIDCLoginHandler)
+		r.Post("/oidc/{provider}/callback", app.oidcCallbackHandler)
 	})
 
 	r.Route("/user", func(r chi.Router) {
 		r.Use(app.requireAuthenticatedUser)
 		r.Get("/", app.getCurrentUserHandler)
 		r.Put("/", app.updateUserHandler)
+		r.Delete("/", app.deleteUserHandler)
+		r.Get("/export", app.getExportHandler)
+		r.Get("/export/download", app.downloadExportHandler)
+		r.Get("/2fa", app.getTwoFactorHandler)
+		r.Delete("/2fa", app.disableTwoFactorHandler)
+		r.Post("/2fa/enroll", app.enrollTwoFactorHandler)
+		r.Post("/2fa/confirm", app.confirmTwoFactorHandler)
+		r.Get("/bookmarks", app.listBookmarksHandler)
+		r.Get("/trash", app.listTrashHandler)
+		r.Get("/notifications", app.listNotificationsHandler)
+		r.Get("/events", app.eventsHandler)
+		r.Post("/notifications/read", app.markAllNotificationsReadHandler)
+		r.Post("/notifications/{id}/read", app.markNotificationReadHandler)
+		r.Get("/webhooks", app.listWebhooksHandler)
+		r.Post("/webhooks", app.createWebhookHandler)
+		r.Delete("/webhooks/{id}", app.deleteWebhookHandler)
+		r.Get("/webhooks/{id}/deliveries", app.listWebhookDeliveriesHandler)
 	})
 
 	r.Route("/profiles/{username}", func(r chi.Router) {
 		r.Get("/", app.getProfileHandler)
+		r.Get("/followers", app.getFollowersHandler)
+		r.Get("/following", app.getFollowingHandler)
 		r.With(app.requireAuthenticatedUser).Post("/follow", app.followUserHandler)
 		r.With(app.requireAuthenticatedUser).Delete("/follow", app.unfollowUserHandler)
+		r.With(app.requireAuthenticatedUser).Post("/block", app.blockUserHandler)
+		r.With(app.requireAuthenticatedUser).Delete("/block", app.unblockUserHandler)
+		r.With(app.requireAuthenticatedUser).Post("/mute", app.muteUserHandler)
+		r.With(app.requireAuthenticatedUser).Delete("/mute", app.unmuteUserHandler)
 	})
 
 	r.Route("/articles", func(r chi.Router) {
 		r.Get("/", app.listArticlesHandler)
 		r.With(app.requireAuthenticatedUser).Get("/feed", app.feedArticlesHandler)
 		r.With(app.requireAuthenticatedUser).Post("/", app.createArticleHandler)
-		r.Get("/{slug}", app.getArticleHandler)
-		r.With(app.requireAuthenticatedUser).Put("/{slug}", app.updateArticleHandler)
-		r.With(app.requireAuthenticatedUser).Delete("/{slug}", app.deleteArticleHandler)
-		r.With(app.requireAuthenticatedUser).Post("/{slug}/favorite", app.favoriteArticleHandler)
-		r.With(app.requireAuthenticatedUser).Delete("/{slug}/favorite", app.unfavoriteArticleHandler)
-		r.With(app.requireAuthenticatedUser).Post("/{slug}/comments", app.createCommentHandler)
-		r.Get("/{slug}/comments", app.getCommentsHandler)
+
+		r.Route("/{slug}", func(r chi.Router) {
+			r.Use(app.resolveArticleSlug)
+
+			r.Get("/", app.getArticleHandler)
+			r.With(app.requireAuthenticatedUser).Put("/", app.updateArticleHandler)
+			r.With(app.requireAuthenticatedUser).Delete("/", app.deleteArticleHandler)
+			r.With(app.requireAuthenticatedUser).Post("/restore", app.restoreArticleHandler)
+			r.With(app.requireAuthenticatedUser).Post("/report", app.reportArticleHandler)
+			r.With(app.requireAuthenticatedUser).Post("/favorite", app.favoriteArticleHandler)
+			r.With(app.requireAuthenticatedUser).Delete("/favorite", app.unfavoriteArticleHandler)
+			r.With(app.requireAuthenticatedUser).Post("/bookmark", app.bookmarkArticleHandler)
+			r.With(app.requireAuthenticatedUser).Delete("/bookmark", app.unbookmarkArticleHandler)
+			r.With(app.requireAuthenticatedUser).Post("/comments", app.createCommentHandler)
+			r.Get("/comments", app.getCommentsHandler)
+			r.With(app.requireAuthenticatedUser).Delete("/comments/{id}", app.deleteCommentHandler)
+			r.With(app.requireAuthenticatedUser).Post("/comments/{id}/report", app.reportCommentHandler)
+			r.With(app.requireAuthenticatedUser).Post("/reactions/{type}", app.addArticleReactionHandler)
+			r.With(app.requireAuthenticatedUser).Delete("/reactions/{type}", app.removeArticleReactionHandler)
+			r.With(app.requireAuthenticatedUser).Post("/comments/{id}/reactions/{type}", app.addCommentReactionHandler)
+			r.With(app.requireAuthenticatedUser).Delete("/comments/{id}/reactions/{type}", app.removeCommentReactionHandler)
+			r.Get("/revisions", app.listRevisionsHandler)
+			r.Get("/revisions/diff", app.diffRevisionsHandler)
+			r.Get("/revisions/{version}", app.getRevisionHandler)
+			r.With(app.requireAuthenticatedUser).Post("/revisions/{version}/restore", app.restoreRevisionHandler)
+		})
 	})
 
 	r.Get("/tags", app.getTagsHandler)
 
+	r.Route("/admin", func(r chi.Router) {
+		r.With(app.requirePermission(data.PermissionAuditLogRead)).Get("/audit-log", app.listAuditLogHandler)
+
+		r.Route("/reports", func(r chi.Router) {
+			r.Use(app.requirePermission(data.PermissionContentModerate))
+			r.Get("/", app.listReportsHandler)
+			r.Post("/{id}/dismiss", app.resolveReportHandler(data.ReportStatusDismissed))
+			r.Post("/{id}/hide", app.resolveReportHandler(data.ReportStatusHidden))
+			r.Post("/{id}/delete", app.resolveReportHandler(data.ReportStatusDeleted))
+			r.Post("/{id}/approve", app.resolveReportHandler(data.ReportStatusApproved))
+		})
+
+		r.Route("/users", func(r chi.Router) {
+			r.Use(app.requirePermission(data.PermissionUsersManage))
+			r.Get("/", app.listUsersHandler)
+			r.Get("/{username}", app.getUserAccountHandler)
+			r.Delete("/{username}", app.deleteUserAccountHandler)
+			r.Post("/{username}/suspend", app.suspendUserHandler)
+			r.Delete("/{username}/suspend", app.unsuspendUserHandler)
+			r.Post("/{username}/password-reset", app.forcePasswordResetHandler)
+			r.With(app.requirePermission(data.PermissionUsersRoles)).Put("/{username}/role", app.updateUserRoleHandler)
+		})
+	})
+
 	return r
 }
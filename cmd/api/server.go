@@ -35,6 +35,9 @@ func (app *application) serve() error {
 
 		app.logger.Info("shutting down server", "signal", s.String())
 
+		// Tell background workers to stop before waiting for them below.
+		close(app.shutdown)
+
 		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 		defer cancel()
 
@@ -49,6 +52,11 @@ func (app *application) serve() error {
 
 	}()
 
+	app.background(app.runScheduledPublisher)
+	app.background(app.runTrashPurger)
+	app.background(app.runOutboxDispatcher)
+	app.background(app.runWebhookDispatcher)
+
 	app.logger.Info("starting server", "properties", app.config)
 
 	err := srv.ListenAndServe()
@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"github.com/96malhar/realworld-backend/internal/auth"
+	"github.com/96malhar/realworld-backend/internal/data"
 	"github.com/golang-migrate/migrate/v4"
 	"github.com/golang-migrate/migrate/v4/database/pgx/v5"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
@@ -84,6 +85,33 @@ func newTestServer(t *testing.T) *testServer {
 			issuer:         "conduit_tests",
 			accessDuration: 24 * time.Hour,
 		},
+		events: eventsConfig{
+			heartbeatInterval: 15 * time.Second,
+			historySize:       100,
+		},
+		webhooks: webhooksConfig{
+			pollInterval: time.Second,
+			timeout:      5 * time.Second,
+			maxAttempts:  3,
+			retryBackoff: 50 * time.Millisecond,
+			// Test receivers listen on the loopback interface
+			allowPrivateNetworks: true,
+		},
+		outbox: outboxConfig{
+			pollInterval: time.Second,
+			retryBackoff: 50 * time.Millisecond,
+			retention:    time.Hour,
+		},
+		users: usersConfig{
+			deletionPolicy: data.DeletionPolicyDelete,
+			exportTTL:      time.Hour,
+		},
+		// Cheap parameters keep the tests fast
+		passwords: passwordsConfig{
+			argon2Memory:      8 * 1024,
+			argon2Iterations:  1,
+			argon2Parallelism: 1,
+		},
 	}
 
 	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
@@ -148,6 +176,14 @@ func (d *dummyJWTMaker) VerifyToken(tokenString string) (*auth.Claims, error) {
 	return &auth.Claims{UserID: 1}, nil
 }
 
+func (d *dummyJWTMaker) CreateMFAToken(userID int64, duration time.Duration) (string, error) {
+	return d.CreateToken(userID, duration)
+}
+
+func (d *dummyJWTMaker) VerifyMFAToken(tokenString string) (*auth.Claims, error) {
+	return d.VerifyToken(tokenString)
+}
+
 // createCommentHelper is a test helper that creates a comment on an article
 func createCommentHelper(t *testing.T, ts *testServer, token, articleLocation, body string) {
 	t.Helper()
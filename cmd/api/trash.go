@@ -0,0 +1,102 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/96malhar/realworld-backend/internal/data"
+	"github.com/go-chi/chi/v5"
+)
+
+// trashPurgeInterval is how often articles in the trash for longer than the retention period are purged.
+const trashPurgeInterval = time.Hour
+
+// listTrashHandler returns the current user's deleted articles, most recently deleted first.
+func (app *application) listTrashHandler(w http.ResponseWriter, r *http.Request) {
+	pagination := app.readPagination(r, 20, 100)
+	currentUser := app.contextGetUser(r)
+
+	filters := data.ArticleFilters{
+		Trashed: true,
+		Limit:   pagination.Limit,
+		Offset:  pagination.Offset,
+	}
+
+	articles, totalCount, err := app.modelStore.Articles.List(filters, currentUser)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{
+		"articles":      articles,
+		"articlesCount": totalCount,
+	}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+}
+
+// restoreArticleHandler moves one of the current user's articles out of the trash and returns it.
+func (app *application) restoreArticleHandler(w http.ResponseWriter, r *http.Request) {
+	slug := chi.URLParam(r, "slug")
+	user := app.contextGetUser(r)
+
+	err := app.modelStore.Articles.Restore(slug, user)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	article, err := app.modelStore.Articles.GetBySlug(slug, user)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.renderArticleHTML(r, article)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"article": article}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+}
+
+// runTrashPurger periodically deletes articles that have been in the trash for longer than the retention period.
+// It blocks until the application starts shutting down, so it should be run with app.background.
+func (app *application) runTrashPurger() {
+	ticker := time.NewTicker(trashPurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-app.shutdown:
+			return
+		case <-ticker.C:
+			app.purgeTrash()
+		}
+	}
+}
+
+func (app *application) purgeTrash() {
+	count, err := app.modelStore.Articles.PurgeTrash(app.config.articles.trashRetention)
+	if err != nil {
+		app.logger.Error("failed to purge trashed articles", "error", err)
+		return
+	}
+	if count > 0 {
+		app.logger.Info("purged trashed articles", "count", count)
+	}
+}
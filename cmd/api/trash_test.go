@@ -0,0 +1,179 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/96malhar/realworld-backend/internal/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArticleTrash(t *testing.T) {
+	t.Parallel()
+
+	ts := newTestServer(t)
+
+	registerUser(t, ts, "alice", "alice@example.com", "password123")
+	registerUser(t, ts, "bob", "bob@example.com", "password123")
+	aliceToken := loginUser(t, ts, "alice@example.com", "password123")
+	bobToken := loginUser(t, ts, "bob@example.com", "password123")
+	aliceHeaders := map[string]string{"Authorization": "Token " + aliceToken}
+	bobHeaders := map[string]string{"Authorization": "Token " + bobToken}
+
+	first := createArticle(t, ts, aliceToken, "First Article", "Test description", "Test body", nil)
+	second := createArticle(t, ts, aliceToken, "Second Article", "Test description", "Test body", nil)
+	postComment(t, ts, bobToken, first, "Bob comment", 0)
+	favoriteArticleHelper(t, ts, bobToken, strings.TrimPrefix(first, "/articles/"))
+
+	deleteArticle := func(t *testing.T, location string, headers map[string]string) int {
+		t.Helper()
+		res, err := ts.executeRequest(http.MethodDelete, location, "", headers)
+		require.NoError(t, err)
+		defer res.Body.Close() // nolint: errcheck
+		return res.StatusCode
+	}
+
+	listTrash := func(t *testing.T) []data.Article {
+		t.Helper()
+		res, err := ts.executeRequest(http.MethodGet, "/user/trash", "", aliceHeaders)
+		require.NoError(t, err)
+		defer res.Body.Close() // nolint: errcheck
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		var response struct {
+			Articles      []data.Article `json:"articles"`
+			ArticlesCount int            `json:"articlesCount"`
+		}
+		readJsonResponse(t, res.Body, &response)
+		require.Equal(t, len(response.Articles), response.ArticlesCount)
+		return response.Articles
+	}
+
+	t.Run("Deleted articles are moved to the trash", func(t *testing.T) {
+		require.Equal(t, http.StatusNoContent, deleteArticle(t, first, aliceHeaders))
+		require.Equal(t, http.StatusNoContent, deleteArticle(t, second, aliceHeaders))
+
+		trash := listTrash(t)
+		require.Len(t, trash, 2)
+		assert.Equal(t, "Second Article", trash[0].Title, "most recently deleted first")
+		assert.Equal(t, "First Article", trash[1].Title)
+		require.NotNil(t, trash[0].DeletedAt)
+
+		// Deleting an article already in the trash does not find it
+		assert.Equal(t, http.StatusNotFound, deleteArticle(t, second, aliceHeaders))
+	})
+
+	t.Run("Articles in the trash are hidden", func(t *testing.T) {
+		res, err := ts.executeRequest(http.MethodGet, first, "", aliceHeaders)
+		require.NoError(t, err)
+		defer res.Body.Close() // nolint: errcheck
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+
+		res, err = ts.executeRequest(http.MethodGet, "/articles?author=alice", "", aliceHeaders)
+		require.NoError(t, err)
+		defer res.Body.Close() // nolint: errcheck
+		var response struct {
+			Articles      []data.Article `json:"articles"`
+			ArticlesCount int            `json:"articlesCount"`
+		}
+		readJsonResponse(t, res.Body, &response)
+		assert.Zero(t, response.ArticlesCount)
+
+		ts.app.wg.Wait()
+		res, err = ts.executeRequest(http.MethodGet, "/user/notifications", "", aliceHeaders)
+		require.NoError(t, err)
+		defer res.Body.Close() // nolint: errcheck
+		var notifications notificationsResponse
+		readJsonResponse(t, res.Body, &notifications)
+		require.NotEmpty(t, notifications.Notifications)
+		for _, notification := range notifications.Notifications {
+			assert.Empty(t, notification.ArticleSlug, "notifications do not link to articles in the trash")
+		}
+	})
+
+	t.Run("Trash is private", func(t *testing.T) {
+		res, err := ts.executeRequest(http.MethodGet, "/user/trash", "", bobHeaders)
+		require.NoError(t, err)
+		defer res.Body.Close() // nolint: errcheck
+		var response struct {
+			Articles      []data.Article `json:"articles"`
+			ArticlesCount int            `json:"articlesCount"`
+		}
+		readJsonResponse(t, res.Body, &response)
+		assert.Zero(t, response.ArticlesCount)
+	})
+
+	t.Run("Restoring keeps comments and favorites", func(t *testing.T) {
+		res, err := ts.executeRequest(http.MethodPost, first+"/restore", "", aliceHeaders)
+		require.NoError(t, err)
+		defer res.Body.Close() // nolint: errcheck
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		var response getArticleResponse
+		readJsonResponse(t, res.Body, &response)
+		assert.Equal(t, "First Article", response.Article.Title)
+		assert.Equal(t, 1, response.Article.FavoritesCount)
+		assert.Nil(t, response.Article.DeletedAt)
+
+		res, err = ts.executeRequest(http.MethodGet, first+"/comments", "", nil)
+		require.NoError(t, err)
+		defer res.Body.Close() // nolint: errcheck
+		var comments commentsResponse
+		readJsonResponse(t, res.Body, &comments)
+		assert.Equal(t, 1, comments.CommentsCount)
+
+		trash := listTrash(t)
+		require.Len(t, trash, 1)
+		assert.Equal(t, "Second Article", trash[0].Title)
+	})
+
+	t.Run("Trash is purged after the retention period", func(t *testing.T) {
+		purged, err := ts.app.modelStore.Articles.PurgeTrash(time.Hour)
+		require.NoError(t, err)
+		assert.Zero(t, purged)
+
+		purged, err = ts.app.modelStore.Articles.PurgeTrash(0)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), purged)
+		assert.Empty(t, listTrash(t))
+
+		res, err := ts.executeRequest(http.MethodPost, second+"/restore", "", aliceHeaders)
+		require.NoError(t, err)
+		defer res.Body.Close() // nolint: errcheck
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+
+	t.Run("Articles deleted by moderators are not moved to the trash", func(t *testing.T) {
+		setRole(t, ts, "bob", data.RoleModerator)
+
+		require.Equal(t, http.StatusNoContent, deleteArticle(t, first, bobHeaders))
+		assert.Empty(t, listTrash(t))
+	})
+
+	testcases := []handlerTestcase{
+		{
+			name:                   "Listing the trash requires authentication",
+			requestMethodType:      http.MethodGet,
+			requestUrlPath:         "/user/trash",
+			wantResponseStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:                   "Restoring requires authentication",
+			requestMethodType:      http.MethodPost,
+			requestUrlPath:         "/articles/does-not-exist/restore",
+			wantResponseStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:                   "Restoring a non-existent article",
+			requestMethodType:      http.MethodPost,
+			requestUrlPath:         "/articles/does-not-exist/restore",
+			requestHeader:          aliceHeaders,
+			wantResponseStatusCode: http.StatusNotFound,
+		},
+	}
+
+	testHandler(t, ts, testcases...)
+}
@@ -0,0 +1,361 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/96malhar/realworld-backend/internal/data"
+	"github.com/96malhar/realworld-backend/internal/totp"
+	"github.com/96malhar/realworld-backend/internal/validator"
+)
+
+const (
+	// totpIssuer names the service in authenticator apps.
+	totpIssuer = "Conduit"
+	// mfaTokenDuration is how long a user has to enter their code after entering their password.
+	mfaTokenDuration = 5 * time.Minute
+	// recentLoginWindow is how recently users without a password or two-factor authentication must
+	// have logged in to confirm sensitive changes to their account.
+	recentLoginWindow = 5 * time.Minute
+)
+
+// getTwoFactorHandler reports whether the current user has enabled two-factor authentication.
+func (app *application) getTwoFactorHandler(w http.ResponseWriter, r *http.Request) {
+	user := app.contextGetUser(r)
+
+	enabled, err := app.twoFactorEnabled(user.ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"twoFactor": envelope{"enabled": enabled}}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// enrollTwoFactorHandler starts the current user's enrollment in two-factor authentication. It
+// returns a new secret, its otpauth URI for authenticator apps and a set of recovery codes, which
+// are only ever shown here. Two-factor authentication is enabled once the user confirms the
+// enrollment with a code from their app; enrolling again before that replaces the secret and codes.
+// Like disabling it, enrolling requires the user to confirm it is them (see confirmUser), so that
+// someone holding their token cannot lock them out with a secret of their own.
+func (app *application) enrollTwoFactorHandler(w http.ResponseWriter, r *http.Request) {
+	user := app.contextGetUser(r)
+
+	var input struct {
+		User struct {
+			Password string `json:"password"`
+			Code     string `json:"code"`
+		} `json:"user"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if !app.confirmUser(w, r, user, input.User.Password, input.User.Code) {
+		return
+	}
+
+	secret, err := totp.NewSecret()
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	recoveryCodes, err := data.NewRecoveryCodes()
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.modelStore.TwoFactor.Enroll(user.ID, secret, recoveryCodes)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrTwoFactorEnabled):
+			app.failedValidationResponse(w, r, []string{"two-factor authentication is already enabled"})
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	resp := envelope{"twoFactor": envelope{
+		"secret":        secret,
+		"otpauthUri":    totp.URI(totpIssuer, user.Email, secret),
+		"recoveryCodes": recoveryCodes,
+	}}
+	err = app.writeJSON(w, http.StatusOK, resp, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// confirmTwoFactorHandler enables the current user's pending two-factor authentication once they
+// enter a valid code from their authenticator app.
+func (app *application) confirmTwoFactorHandler(w http.ResponseWriter, r *http.Request) {
+	user := app.contextGetUser(r)
+
+	var input struct {
+		TwoFactor struct {
+			Code string `json:"code"`
+		} `json:"twoFactor"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+	if v.Check(input.TwoFactor.Code != "", "code must be provided"); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	twoFactor, err := app.modelStore.TwoFactor.Get(user.ID)
+	if err != nil && !errors.Is(err, data.ErrRecordNotFound) {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	if twoFactor == nil || twoFactor.Enabled() {
+		app.failedValidationResponse(w, r, []string{"there is no pending two-factor enrollment"})
+		return
+	}
+
+	step, ok := totp.Match(twoFactor.Secret, input.TwoFactor.Code, app.now())
+	if !ok {
+		app.failedValidationResponse(w, r, []string{"code is invalid"})
+		return
+	}
+
+	err = app.modelStore.TwoFactor.Confirm(user.ID, step)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.failedValidationResponse(w, r, []string{"there is no pending two-factor enrollment"})
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"twoFactor": envelope{"enabled": true}}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// disableTwoFactorHandler turns off the current user's two-factor authentication once they have
+// confirmed it is them (see confirmUser).
+func (app *application) disableTwoFactorHandler(w http.ResponseWriter, r *http.Request) {
+	user := app.contextGetUser(r)
+
+	var input struct {
+		User struct {
+			Password string `json:"password"`
+			Code     string `json:"code"`
+		} `json:"user"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if !app.confirmUser(w, r, user, input.User.Password, input.User.Code) {
+		return
+	}
+
+	err = app.modelStore.TwoFactor.Disable(user.ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// loginTwoFactorHandler completes the login of a user with two-factor authentication. It exchanges
+// the MFA token returned by loginUserHandler, together with a code from the user's authenticator
+// app or one of their recovery codes, for an access token. Each code can only be used once, and users
+// who enter data.MaxTwoFactorAttempts wrong codes in a row are locked out for data.TwoFactorLockout.
+func (app *application) loginTwoFactorHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		User struct {
+			MFAToken string `json:"mfaToken"`
+			Code     string `json:"code"`
+		} `json:"user"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+	v.Check(input.User.MFAToken != "", "mfaToken must be provided")
+	v.Check(input.User.Code != "", "code must be provided")
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	claims, err := app.jwtMaker.VerifyMFAToken(input.User.MFAToken)
+	if err != nil {
+		app.invalidCredentialsResponse(w, r)
+		return
+	}
+
+	user, err := app.modelStore.Users.GetByID(claims.UserID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.invalidCredentialsResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	if user.IsSuspended() {
+		app.accountSuspendedResponse(w, r)
+		return
+	}
+
+	twoFactor, err := app.modelStore.TwoFactor.Get(user.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.invalidCredentialsResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	if !twoFactor.Enabled() {
+		app.invalidCredentialsResponse(w, r)
+		return
+	}
+	if !app.checkTwoFactorCode(w, r, twoFactor, input.User.Code) {
+		return
+	}
+
+	token, err := app.jwtMaker.CreateToken(user.ID, app.config.jwtMaker.accessDuration)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	user.Token = token
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"user": user}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// checkTwoFactorCode uses a code from the user's authenticator app or one of their recovery codes. Wrong
+// codes count towards locking the user out. It writes a response and returns false if the code is not
+// accepted.
+func (app *application) checkTwoFactorCode(w http.ResponseWriter, r *http.Request, twoFactor *data.TwoFactor, code string) bool {
+	if twoFactor.Locked {
+		app.tooManyAttemptsResponse(w, r)
+		return false
+	}
+
+	var valid bool
+	var err error
+	if step, ok := totp.Match(twoFactor.Secret, code, app.now()); ok {
+		valid, err = app.modelStore.TwoFactor.UseStep(twoFactor.UserID, step)
+	} else {
+		valid, err = app.modelStore.TwoFactor.UseRecoveryCode(twoFactor.UserID, code)
+	}
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return false
+	}
+	if !valid {
+		locked, err := app.modelStore.TwoFactor.RecordFailedAttempt(twoFactor.UserID)
+		switch {
+		case err != nil:
+			app.serverErrorResponse(w, r, err)
+		case locked:
+			app.tooManyAttemptsResponse(w, r)
+		default:
+			app.invalidCredentialsResponse(w, r)
+		}
+		return false
+	}
+
+	err = app.modelStore.TwoFactor.ResetFailedAttempts(twoFactor.UserID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return false
+	}
+	return true
+}
+
+// confirmUser checks that a sensitive change to the user's account is made by the user themselves rather
+// than by someone holding their token. Users with a password confirm with it. Users without one, whose
+// accounts were created by an identity provider, confirm with a code if they have enabled two-factor
+// authentication, and otherwise by having logged in within recentLoginWindow. It writes a response and
+// returns false if the user is not confirmed.
+func (app *application) confirmUser(w http.ResponseWriter, r *http.Request, user *data.User, password, code string) bool {
+	if user.HasPassword() {
+		v := validator.New()
+		if v.Check(password != "", "password must be provided"); !v.Valid() {
+			app.failedValidationResponse(w, r, v.Errors)
+			return false
+		}
+
+		matches, err := user.Password.Matches(app.passwords, password)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return false
+		}
+		if !matches {
+			app.invalidCredentialsResponse(w, r)
+			return false
+		}
+		return true
+	}
+
+	twoFactor, err := app.modelStore.TwoFactor.Get(user.ID)
+	if err != nil && !errors.Is(err, data.ErrRecordNotFound) {
+		app.serverErrorResponse(w, r, err)
+		return false
+	}
+	if err == nil && twoFactor.Enabled() {
+		v := validator.New()
+		if v.Check(code != "", "code must be provided"); !v.Valid() {
+			app.failedValidationResponse(w, r, v.Errors)
+			return false
+		}
+		return app.checkTwoFactorCode(w, r, twoFactor, code)
+	}
+
+	claims, err := app.jwtMaker.VerifyToken(user.Token)
+	if err != nil || claims.IssuedAt == nil || app.now().Sub(claims.IssuedAt.Time) > recentLoginWindow {
+		app.reauthenticationRequiredResponse(w, r)
+		return false
+	}
+	return true
+}
+
+// twoFactorEnabled reports whether the user has confirmed their enrollment in two-factor authentication.
+func (app *application) twoFactorEnabled(userID int64) (bool, error) {
+	twoFactor, err := app.modelStore.TwoFactor.Get(userID)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return twoFactor.Enabled(), nil
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/96malhar/realworld-backend/internal/data"
+	"github.com/96malhar/realworld-backend/internal/totp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type twoFactorEnrollment struct {
+	Secret        string   `json:"secret"`
+	OtpauthURI    string   `json:"otpauthUri"`
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+type mfaResponse struct {
+	MFA struct {
+		Token string `json:"token"`
+	} `json:"mfa"`
+}
+
+func TestTwoFactorAuthentication(t *testing.T) {
+	t.Parallel()
+
+	ts := newTestServer(t)
+
+	// A fake clock lets the test compute the codes the server expects
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	ts.app.now = func() time.Time { return now }
+
+	registerUser(t, ts, "alice", "alice@example.com", "password123")
+	token := loginUser(t, ts, "alice@example.com", "password123")
+	headers := map[string]string{"Authorization": "Token " + token}
+
+	post := func(t *testing.T, path, body string, headers map[string]string) *http.Response {
+		t.Helper()
+		res, err := ts.executeRequest(http.MethodPost, path, body, headers)
+		require.NoError(t, err)
+		t.Cleanup(func() { res.Body.Close() }) // nolint: errcheck
+		return res
+	}
+
+	code := func(t *testing.T, secret string, at time.Time) string {
+		t.Helper()
+		code, err := totp.Code(secret, totp.Step(at))
+		require.NoError(t, err)
+		return code
+	}
+
+	enroll := func(t *testing.T) twoFactorEnrollment {
+		t.Helper()
+		res := post(t, "/user/2fa/enroll", `{"user":{"password":"password123"}}`, headers)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		var response struct {
+			TwoFactor twoFactorEnrollment `json:"twoFactor"`
+		}
+		readJsonResponse(t, res.Body, &response)
+		return response.TwoFactor
+	}
+
+	confirm := func(t *testing.T, code string) *http.Response {
+		t.Helper()
+		return post(t, "/user/2fa/confirm", `{"twoFactor":{"code":"`+code+`"}}`, headers)
+	}
+
+	// startLogin logs in with the password and returns the MFA token.
+	startLogin := func(t *testing.T) string {
+		t.Helper()
+		res := post(t, "/users/login", `{"user":{"email":"alice@example.com","password":"password123"}}`, nil)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		var response mfaResponse
+		readJsonResponse(t, res.Body, &response)
+		require.NotEmpty(t, response.MFA.Token)
+		return response.MFA.Token
+	}
+
+	finishLogin := func(t *testing.T, mfaToken, code string) *http.Response {
+		t.Helper()
+		return post(t, "/users/login/2fa", `{"user":{"mfaToken":"`+mfaToken+`","code":"`+code+`"}}`, nil)
+	}
+
+	var enrollment twoFactorEnrollment
+
+	t.Run("Enrolling requires the password", func(t *testing.T) {
+		res := post(t, "/user/2fa/enroll", `{"user":{}}`, headers)
+		assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+
+		res = post(t, "/user/2fa/enroll", `{"user":{"password":"wrong-password"}}`, headers)
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+
+		res, err := ts.executeRequest(http.MethodGet, "/user/2fa", "", headers)
+		require.NoError(t, err)
+		defer res.Body.Close() // nolint: errcheck
+		var response struct {
+			TwoFactor struct {
+				Enabled bool `json:"enabled"`
+			} `json:"twoFactor"`
+		}
+		readJsonResponse(t, res.Body, &response)
+		assert.False(t, response.TwoFactor.Enabled)
+	})
+
+	t.Run("Enrollment must be confirmed", func(t *testing.T) {
+		first := enroll(t)
+		assert.Len(t, first.RecoveryCodes, 10)
+		assert.Contains(t, first.OtpauthURI, "otpauth://totp/Conduit:alice@example.com?")
+		assert.Contains(t, first.OtpauthURI, "secret="+first.Secret)
+
+		// Enrolling again before confirming replaces the secret
+		enrollment = enroll(t)
+		assert.NotEqual(t, first.Secret, enrollment.Secret)
+
+		// Two-factor authentication is not enabled yet, so logging in returns an access token
+		loginUser(t, ts, "alice@example.com", "password123")
+
+		res := confirm(t, code(t, first.Secret, now))
+		assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+
+		res = confirm(t, code(t, enrollment.Secret, now))
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		res = post(t, "/user/2fa/enroll", `{"user":{"password":"password123"}}`, headers)
+		assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+	})
+
+	t.Run("Login requires a code", func(t *testing.T) {
+		mfaToken := startLogin(t)
+
+		// The MFA token is not an access token
+		res, err := ts.executeRequest(http.MethodGet, "/user", "", map[string]string{"Authorization": "Token " + mfaToken})
+		require.NoError(t, err)
+		defer res.Body.Close() // nolint: errcheck
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+
+		res = finishLogin(t, mfaToken, "000000")
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+
+		// The code used to confirm the enrollment cannot be used again
+		res = finishLogin(t, mfaToken, code(t, enrollment.Secret, now))
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+
+		now = now.Add(totp.Period)
+		res = finishLogin(t, mfaToken, code(t, enrollment.Secret, now))
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		var response userResponse
+		readJsonResponse(t, res.Body, &response)
+		assert.Equal(t, "alice", response.User.Username)
+
+		res, err = ts.executeRequest(http.MethodGet, "/user", "", map[string]string{"Authorization": "Token " + response.User.Token})
+		require.NoError(t, err)
+		defer res.Body.Close() // nolint: errcheck
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		// Nor can the code used to log in
+		res = finishLogin(t, startLogin(t), code(t, enrollment.Secret, now))
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	})
+
+	t.Run("Codes are accepted within one period of the clock", func(t *testing.T) {
+		now = now.Add(10 * totp.Period)
+
+		res := finishLogin(t, startLogin(t), code(t, enrollment.Secret, now.Add(-2*totp.Period)))
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+
+		res = finishLogin(t, startLogin(t), code(t, enrollment.Secret, now.Add(totp.Period)))
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+	})
+
+	t.Run("Recovery codes can be used once", func(t *testing.T) {
+		recoveryCode := enrollment.RecoveryCodes[0]
+
+		res := finishLogin(t, startLogin(t), recoveryCode)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		res = finishLogin(t, startLogin(t), recoveryCode)
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+
+		// Recovery codes are accepted regardless of case and dashes
+		res = finishLogin(t, startLogin(t), " "+strings.ToUpper(strings.ReplaceAll(enrollment.RecoveryCodes[1], "-", "")))
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+	})
+
+	t.Run("Access tokens are not MFA tokens", func(t *testing.T) {
+		now = now.Add(10 * totp.Period)
+		res := finishLogin(t, token, code(t, enrollment.Secret, now))
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	})
+
+	t.Run("Too many wrong codes lock the user out", func(t *testing.T) {
+		mfaToken := startLogin(t)
+		for range data.MaxTwoFactorAttempts - 1 {
+			res := finishLogin(t, mfaToken, "000000")
+			assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+		}
+
+		// Logging in successfully forgets the wrong codes
+		now = now.Add(10 * totp.Period)
+		res := finishLogin(t, mfaToken, code(t, enrollment.Secret, now))
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		for range data.MaxTwoFactorAttempts - 1 {
+			res := finishLogin(t, mfaToken, "000000")
+			assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+		}
+		res = finishLogin(t, mfaToken, "000000")
+		require.Equal(t, http.StatusTooManyRequests, res.StatusCode)
+		var errResp errorResponse
+		readJsonResponse(t, res.Body, &errResp)
+		assert.Equal(t, []string{"too many failed attempts, please try again later"}, errResp.Errors)
+
+		// Not even a valid code is accepted during the lockout
+		now = now.Add(10 * totp.Period)
+		res = finishLogin(t, startLogin(t), code(t, enrollment.Secret, now))
+		assert.Equal(t, http.StatusTooManyRequests, res.StatusCode)
+	})
+
+	t.Run("Disabling requires the password", func(t *testing.T) {
+		res, err := ts.executeRequest(http.MethodDelete, "/user/2fa", `{"user":{"password":"wrong-password"}}`, headers)
+		require.NoError(t, err)
+		defer res.Body.Close() // nolint: errcheck
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+
+		res, err = ts.executeRequest(http.MethodDelete, "/user/2fa", `{"user":{"password":"password123"}}`, headers)
+		require.NoError(t, err)
+		defer res.Body.Close() // nolint: errcheck
+		assert.Equal(t, http.StatusNoContent, res.StatusCode)
+
+		res, err = ts.executeRequest(http.MethodGet, "/user/2fa", "", headers)
+		require.NoError(t, err)
+		defer res.Body.Close() // nolint: errcheck
+		var response struct {
+			TwoFactor struct {
+				Enabled bool `json:"enabled"`
+			} `json:"twoFactor"`
+		}
+		readJsonResponse(t, res.Body, &response)
+		assert.False(t, response.TwoFactor.Enabled)
+
+		loginUser(t, ts, "alice@example.com", "password123")
+	})
+}
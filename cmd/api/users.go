@@ -29,7 +29,7 @@ func (app *application) registerUserHandler(w http.ResponseWriter, r *http.Reque
 		Email:    input.User.Email,
 	}
 
-	err = user.Password.Set(input.User.PasswordPlaintext)
+	err = user.Password.Set(app.passwords, input.User.PasswordPlaintext)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 		return
@@ -42,6 +42,11 @@ func (app *application) registerUserHandler(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	if data.ValidatePasswordPolicy(v, app.passwordPolicy, user); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
 	err = app.modelStore.Users.Insert(&user)
 	if err != nil {
 		switch {
@@ -104,7 +109,7 @@ func (app *application) loginUserHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	matches, err := user.Password.Matches(input.User.Password)
+	matches, err := user.Password.Matches(app.passwords, input.User.Password)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 		return
@@ -114,6 +119,47 @@ func (app *application) loginUserHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	// Replace hashes created with an outdated algorithm or parameters while the plaintext password is at hand.
+	// The login does not depend on it, so a failure is only logged.
+	if user.Password.NeedsRehash(app.passwords) {
+		err = app.modelStore.Users.RehashPassword(user, app.passwords, input.User.Password)
+		if err != nil {
+			app.logger.Error("failed to rehash password", "userID", user.ID, "error", err)
+		}
+	}
+
+	app.completeLogin(w, r, user, http.StatusOK)
+}
+
+// completeLogin responds to a user who proved who they are with an access token, or with an MFA
+// token if they still have to enter a two-factor authentication code.
+func (app *application) completeLogin(w http.ResponseWriter, r *http.Request, user *data.User, status int) {
+	if user.IsSuspended() {
+		app.accountSuspendedResponse(w, r)
+		return
+	}
+
+	// Users with two-factor authentication get a short-lived MFA token instead, which
+	// loginTwoFactorHandler exchanges for an access token once they enter a code.
+	twoFactorEnabled, err := app.twoFactorEnabled(user.ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	if twoFactorEnabled {
+		mfaToken, err := app.jwtMaker.CreateMFAToken(user.ID, mfaTokenDuration)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+
+		err = app.writeJSON(w, http.StatusOK, envelope{"mfa": envelope{"token": mfaToken}}, nil)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
 	// Generate a new JWT token for the user.
 	token, err := app.jwtMaker.CreateToken(user.ID, app.config.jwtMaker.accessDuration)
 	if err != nil {
@@ -122,7 +168,7 @@ func (app *application) loginUserHandler(w http.ResponseWriter, r *http.Request)
 	}
 	user.Token = token
 
-	err = app.writeJSON(w, http.StatusOK, envelope{"user": user}, nil)
+	err = app.writeJSON(w, status, envelope{"user": user}, nil)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 	}
@@ -137,8 +183,39 @@ func (app *application) getCurrentUserHandler(w http.ResponseWriter, r *http.Req
 	}
 }
 
-// getProfileHandler returns a user's profile, including follow status.
+// getProfileHandler returns a user's profile, including follow status and follower, following and article counts.
 func (app *application) getProfileHandler(w http.ResponseWriter, r *http.Request) {
+	username := chi.URLParam(r, "username")
+	profile, err := app.modelStore.Users.GetProfile(username, app.contextGetUser(r))
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			app.notFoundResponse(w, r)
+			return
+		}
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"profile": profile}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// getFollowersHandler returns a page of the users following the given user.
+func (app *application) getFollowersHandler(w http.ResponseWriter, r *http.Request) {
+	app.listFollowProfiles(w, r, app.modelStore.Users.GetFollowers)
+}
+
+// getFollowingHandler returns a page of the users the given user follows.
+func (app *application) getFollowingHandler(w http.ResponseWriter, r *http.Request) {
+	app.listFollowProfiles(w, r, app.modelStore.Users.GetFollowing)
+}
+
+// listFollowProfiles writes a paginated list of profiles fetched by list for the user in the URL.
+// The following flag of each profile reflects the viewing user.
+func (app *application) listFollowProfiles(w http.ResponseWriter, r *http.Request,
+	list func(userID int64, viewer *data.User, limit, offset int) ([]data.Profile, int, error)) {
 	username := chi.URLParam(r, "username")
 	targetUser, err := app.modelStore.Users.GetByUsername(username)
 	if err != nil {
@@ -150,14 +227,17 @@ func (app *application) getProfileHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	var following bool
-	user := app.contextGetUser(r)
-	if !user.IsAnonymous() {
-		following, _ = app.modelStore.Users.IsFollowing(user.ID, targetUser.ID)
+	pagination := app.readPagination(r, 20, 100)
+	profiles, totalCount, err := list(targetUser.ID, app.contextGetUser(r), pagination.Limit, pagination.Offset)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
 	}
 
-	profile := targetUser.ToProfile(following)
-	err = app.writeJSON(w, http.StatusOK, envelope{"profile": profile}, nil)
+	err = app.writeJSON(w, http.StatusOK, envelope{
+		"profiles":      profiles,
+		"profilesCount": totalCount,
+	}, nil)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 	}
@@ -182,9 +262,17 @@ func (app *application) followUserHandler(w http.ResponseWriter, r *http.Request
 	}
 	err = app.modelStore.Users.FollowUser(user.ID, targetUser.ID)
 	if err != nil {
-		app.serverErrorResponse(w, r, err)
+		switch {
+		case errors.Is(err, data.ErrBlocked):
+			app.blockedResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
 		return
 	}
+	app.notify(func() error {
+		return app.modelStore.Notifications.NotifyFollow(user.ID, targetUser.ID)
+	})
 	profile := targetUser.ToProfile(true)
 	err = app.writeJSON(w, http.StatusOK, envelope{"profile": profile}, nil)
 	if err != nil {
@@ -217,16 +305,74 @@ func (app *application) unfollowUserHandler(w http.ResponseWriter, r *http.Reque
 	}
 }
 
+// blockUserHandler lets the authenticated user block another user. Blocked users can no longer
+// follow the blocker or comment on, favorite or react to their articles, and existing follows
+// between the two users are removed.
+func (app *application) blockUserHandler(w http.ResponseWriter, r *http.Request) {
+	app.updateUserRelation(w, r, "block", app.modelStore.Users.BlockUser)
+}
+
+// unblockUserHandler lets the authenticated user unblock another user.
+func (app *application) unblockUserHandler(w http.ResponseWriter, r *http.Request) {
+	app.updateUserRelation(w, r, "unblock", app.modelStore.Users.UnblockUser)
+}
+
+// muteUserHandler lets the authenticated user hide another user's articles and comments.
+func (app *application) muteUserHandler(w http.ResponseWriter, r *http.Request) {
+	app.updateUserRelation(w, r, "mute", app.modelStore.Users.MuteUser)
+}
+
+// unmuteUserHandler lets the authenticated user unmute another user.
+func (app *application) unmuteUserHandler(w http.ResponseWriter, r *http.Request) {
+	app.updateUserRelation(w, r, "unmute", app.modelStore.Users.UnmuteUser)
+}
+
+// updateUserRelation applies update between the authenticated user and the user in the URL
+// and responds with the target's profile.
+func (app *application) updateUserRelation(w http.ResponseWriter, r *http.Request, action string, update func(userID, targetID int64) error) {
+	username := chi.URLParam(r, "username")
+	targetUser, err := app.modelStore.Users.GetByUsername(username)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			app.notFoundResponse(w, r)
+			return
+		}
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	user := app.contextGetUser(r)
+	if user.ID == targetUser.ID {
+		app.failedValidationResponse(w, r, []string{"cannot " + action + " yourself"})
+		return
+	}
+	err = update(user.ID, targetUser.ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	profile, err := app.modelStore.Users.GetProfile(username, user)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	err = app.writeJSON(w, http.StatusOK, envelope{"profile": profile}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
 func (app *application) updateUserHandler(w http.ResponseWriter, r *http.Request) {
 	user := app.contextGetUser(r)
 
 	var input struct {
 		User struct {
-			Email    *string `json:"email"`
-			Password *string `json:"password"`
-			Username *string `json:"username"`
-			Bio      *string `json:"bio"`
-			Image    *string `json:"image"`
+			Email           *string `json:"email"`
+			Password        *string `json:"password"`
+			CurrentPassword *string `json:"currentPassword"`
+			Code            *string `json:"code"`
+			Username        *string `json:"username"`
+			Bio             *string `json:"bio"`
+			Image           *string `json:"image"`
 		} `json:"user"`
 	}
 
@@ -236,6 +382,30 @@ func (app *application) updateUserHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	// A reset is forced when the password may be known to someone else, who may also hold a token, so
+	// completing it takes a new password and confirmation that it is the user, normally their current password
+	if user.PasswordResetRequired {
+		var currentPassword, code string
+		if input.User.CurrentPassword != nil {
+			currentPassword = *input.User.CurrentPassword
+		}
+		if input.User.Code != nil {
+			code = *input.User.Code
+		}
+
+		v := validator.New()
+		v.Check(!user.HasPassword() || currentPassword != "", "currentPassword must be provided to reset your password")
+		v.Check(input.User.Password != nil, "password must be provided to reset your password")
+		if !v.Valid() {
+			app.failedValidationResponse(w, r, v.Errors)
+			return
+		}
+
+		if !app.confirmUser(w, r, user, currentPassword, code) {
+			return
+		}
+	}
+
 	updatedUser := *user
 	if input.User.Email != nil {
 		updatedUser.Email = *input.User.Email
@@ -250,7 +420,7 @@ func (app *application) updateUserHandler(w http.ResponseWriter, r *http.Request
 		updatedUser.Image = *input.User.Image
 	}
 	if input.User.Password != nil {
-		err := updatedUser.Password.Set(*input.User.Password)
+		err := updatedUser.Password.Set(app.passwords, *input.User.Password)
 		if err != nil {
 			app.serverErrorResponse(w, r, err)
 			return
@@ -263,6 +433,12 @@ func (app *application) updateUserHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	// The policy also applies when the password is changed to complete a reset forced by an admin
+	if data.ValidatePasswordPolicy(v, app.passwordPolicy, updatedUser); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
 	err = app.modelStore.Users.Update(&updatedUser)
 	if err != nil {
 		switch {
@@ -292,3 +468,39 @@ func (app *application) updateUserHandler(w http.ResponseWriter, r *http.Request
 		app.serverErrorResponse(w, r, err)
 	}
 }
+
+// deleteUserHandler deletes the current user's account once they have confirmed it is them (see confirmUser).
+// Their content is deleted or anonymized according to the configured deletion policy.
+func (app *application) deleteUserHandler(w http.ResponseWriter, r *http.Request) {
+	user := app.contextGetUser(r)
+
+	var input struct {
+		User struct {
+			Password string `json:"password"`
+			Code     string `json:"code"`
+		} `json:"user"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if !app.confirmUser(w, r, user, input.User.Password, input.User.Code) {
+		return
+	}
+
+	err = app.modelStore.Users.DeleteAccount(user, app.config.users.deletionPolicy)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -1,10 +1,16 @@
 package main
 
 import (
+	"crypto/sha1"
+	"encoding/hex"
 	"net/http"
+	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/96malhar/realworld-backend/internal/auth"
+	"github.com/96malhar/realworld-backend/internal/data"
+	"github.com/96malhar/realworld-backend/internal/passwords"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -22,16 +28,29 @@ type user struct {
 }
 
 type profile struct {
-	Username  string `json:"username"`
-	Bio       string `json:"bio"`
-	Image     string `json:"image"`
-	Following bool   `json:"following"`
+	Username       string `json:"username"`
+	Bio            string `json:"bio"`
+	Image          string `json:"image"`
+	Following      bool   `json:"following"`
+	FollowersCount *int   `json:"followersCount,omitempty"`
+	FollowingCount *int   `json:"followingCount,omitempty"`
+	ArticlesCount  *int   `json:"articlesCount,omitempty"`
 }
 
 type profileResponse struct {
 	Profile profile `json:"profile"`
 }
 
+type profilesResponse struct {
+	Profiles      []profile `json:"profiles"`
+	ProfilesCount int       `json:"profilesCount"`
+}
+
+// counts returns pointers to the follower, following and article counts of a profile.
+func counts(followers, following, articles int) (*int, *int, *int) {
+	return &followers, &following, &articles
+}
+
 var seedUserRequest = `{
 		"user": {
 			"username": "Alice",
@@ -116,6 +135,14 @@ func TestRegisterUserHandler(t *testing.T) {
 				Errors: []string{"username must be provided", "password must be at least 8 bytes long"},
 			},
 		},
+		{
+			name:                   "Reserved username",
+			requestBody:            `{"user":{"username":"Deleted-User-1", "email":"deleted@gmail.com", "password":"pa55word1234"}}`,
+			wantResponseStatusCode: http.StatusUnprocessableEntity,
+			wantResponse: errorResponse{
+				Errors: []string{"username is reserved"},
+			},
+		},
 		{
 			name:                   "Duplicate email",
 			requestBody:            `{"user":{"username":"alice_new", "email":"alice@gmail.com", "password":"pa55word1234"}}`,
@@ -268,6 +295,132 @@ func TestLoginUserHandler(t *testing.T) {
 	}
 }
 
+func TestLoginRehashesPassword(t *testing.T) {
+	t.Parallel()
+	ts := newTestServer(t)
+
+	current := passwords.Argon2id{Memory: 8 * 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+	legacy := passwords.Bcrypt{Cost: 4}
+
+	getUser := func(t *testing.T) *data.User {
+		t.Helper()
+		user, err := ts.app.modelStore.Users.GetByEmail("alice@example.com")
+		require.NoError(t, err)
+		return user
+	}
+
+	// Register with the bcrypt hashing used before Argon2id was introduced
+	ts.app.passwords = passwords.NewUpgrading(legacy)
+	registerUser(t, ts, "alice", "alice@example.com", "password123")
+	ts.app.passwords = passwords.NewDefault(current)
+	require.True(t, getUser(t).Password.NeedsRehash(ts.app.passwords))
+
+	t.Run("Outdated algorithm is replaced", func(t *testing.T) {
+		loginUser(t, ts, "alice@example.com", "password123")
+		assert.False(t, getUser(t).Password.NeedsRehash(ts.app.passwords))
+		loginUser(t, ts, "alice@example.com", "password123")
+	})
+
+	t.Run("Outdated parameters are replaced", func(t *testing.T) {
+		stronger := current
+		stronger.Iterations = 2
+		ts.app.passwords = passwords.NewDefault(stronger)
+		require.True(t, getUser(t).Password.NeedsRehash(ts.app.passwords))
+
+		loginUser(t, ts, "alice@example.com", "password123")
+		assert.False(t, getUser(t).Password.NeedsRehash(ts.app.passwords))
+	})
+
+	t.Run("Passwords longer than 72 bytes", func(t *testing.T) {
+		longPassword := strings.Repeat("a", 100)
+		registerUser(t, ts, "bob", "bob@example.com", longPassword)
+		loginUser(t, ts, "bob@example.com", longPassword)
+
+		res, err := ts.executeRequest(http.MethodPost, "/users/login",
+			`{"user":{"email":"bob@example.com", "password":"`+longPassword[:72]+`"}}`, nil)
+		require.NoError(t, err)
+		defer res.Body.Close() // nolint: errcheck
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	})
+}
+
+func TestPasswordPolicy(t *testing.T) {
+	t.Parallel()
+	ts := newTestServer(t)
+
+	breached, err := passwords.LoadBreachedList(strings.NewReader(sha1Hex("velvet-orbit-lantern-57") + ":3\n"))
+	require.NoError(t, err)
+	ts.app.passwordPolicy = passwords.Policy{MinScore: 3, RejectUserInputs: true, Breached: breached}
+
+	registerUser(t, ts, "alice", "alice@example.com", "copper-meadow-signal-82")
+	aliceToken := loginUser(t, ts, "alice@example.com", "copper-meadow-signal-82")
+
+	testHandler(t, ts,
+		handlerTestcase{
+			name:                   "Weak password is rejected on registration",
+			requestUrlPath:         "/users",
+			requestMethodType:      http.MethodPost,
+			requestBody:            `{"user":{"username":"bob", "email":"bob@example.com", "password":"12345678"}}`,
+			wantResponseStatusCode: http.StatusUnprocessableEntity,
+			wantResponse:           errorResponse{Errors: []string{"password is too easy to guess"}},
+		},
+		handlerTestcase{
+			name:                   "Password containing the username and email is rejected on registration",
+			requestUrlPath:         "/users",
+			requestMethodType:      http.MethodPost,
+			requestBody:            `{"user":{"username":"bobby", "email":"bobby@example.com", "password":"bobby-velvet-orbit-91"}}`,
+			wantResponseStatusCode: http.StatusUnprocessableEntity,
+			wantResponse: errorResponse{Errors: []string{
+				"password must not contain the username",
+				"password must not contain the email address",
+			}},
+		},
+		handlerTestcase{
+			name:                   "Breached password is rejected on password change",
+			requestUrlPath:         "/user",
+			requestMethodType:      http.MethodPut,
+			requestHeader:          map[string]string{"Authorization": "Token " + aliceToken},
+			requestBody:            `{"user":{"password":"velvet-orbit-lantern-57"}}`,
+			wantResponseStatusCode: http.StatusUnprocessableEntity,
+			wantResponse:           errorResponse{Errors: []string{"password has appeared in a data breach"}},
+		},
+		handlerTestcase{
+			name:                   "Password is checked against a new username",
+			requestUrlPath:         "/user",
+			requestMethodType:      http.MethodPut,
+			requestHeader:          map[string]string{"Authorization": "Token " + aliceToken},
+			requestBody:            `{"user":{"username":"harbor", "password":"copper-harbor-signal-82"}}`,
+			wantResponseStatusCode: http.StatusUnprocessableEntity,
+			wantResponse:           errorResponse{Errors: []string{"password must not contain the username"}},
+		},
+		handlerTestcase{
+			name:                   "Other updates do not check the current password",
+			requestUrlPath:         "/user",
+			requestMethodType:      http.MethodPut,
+			requestHeader:          map[string]string{"Authorization": "Token " + aliceToken},
+			requestBody:            `{"user":{"username":"copper"}}`,
+			wantResponseStatusCode: http.StatusOK,
+		},
+		handlerTestcase{
+			name:                   "Strong password is accepted on password change",
+			requestUrlPath:         "/user",
+			requestMethodType:      http.MethodPut,
+			requestHeader:          map[string]string{"Authorization": "Token " + aliceToken},
+			requestBody:            `{"user":{"password":"quiet-falcon-ledger-64"}}`,
+			wantResponseStatusCode: http.StatusOK,
+		},
+	)
+
+	// Logging in is not subject to the policy
+	ts.app.passwordPolicy.MinScore = 4
+	loginUser(t, ts, "alice@example.com", "quiet-falcon-ledger-64")
+}
+
+func sha1Hex(s string) string {
+	sum := sha1.Sum([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
 func TestGetCurrentUserHandler(t *testing.T) {
 	t.Parallel()
 	ts := newTestServer(t)
@@ -356,6 +509,9 @@ func TestGetProfileUserHandler(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, http.StatusOK, resp.StatusCode)
 
+	aliceFollowers, aliceFollowing, aliceArticles := counts(1, 0, 0)
+	charlieFollowers, charlieFollowing, charlieArticles := counts(0, 0, 0)
+
 	testCases := []handlerTestcase{
 		{
 			name:                   "anonymous user gets Alice's profile",
@@ -364,10 +520,13 @@ func TestGetProfileUserHandler(t *testing.T) {
 			wantResponseStatusCode: http.StatusOK,
 			wantResponse: profileResponse{
 				Profile: profile{
-					Username:  "Alice",
-					Bio:       "",
-					Image:     "",
-					Following: false,
+					Username:       "Alice",
+					Bio:            "",
+					Image:          "",
+					Following:      false,
+					FollowersCount: aliceFollowers,
+					FollowingCount: aliceFollowing,
+					ArticlesCount:  aliceArticles,
 				},
 			},
 		},
@@ -379,10 +538,13 @@ func TestGetProfileUserHandler(t *testing.T) {
 			wantResponseStatusCode: http.StatusOK,
 			wantResponse: profileResponse{
 				Profile: profile{
-					Username:  "Alice",
-					Bio:       "",
-					Image:     "",
-					Following: true,
+					Username:       "Alice",
+					Bio:            "",
+					Image:          "",
+					Following:      true,
+					FollowersCount: aliceFollowers,
+					FollowingCount: aliceFollowing,
+					ArticlesCount:  aliceArticles,
 				},
 			},
 		},
@@ -394,10 +556,13 @@ func TestGetProfileUserHandler(t *testing.T) {
 			wantResponseStatusCode: http.StatusOK,
 			wantResponse: profileResponse{
 				Profile: profile{
-					Username:  "Charlie",
-					Bio:       "",
-					Image:     "",
-					Following: false,
+					Username:       "Charlie",
+					Bio:            "",
+					Image:          "",
+					Following:      false,
+					FollowersCount: charlieFollowers,
+					FollowingCount: charlieFollowing,
+					ArticlesCount:  charlieArticles,
 				},
 			},
 		},
@@ -546,6 +711,316 @@ func TestUnfollowUserHandler(t *testing.T) {
 	testHandler(t, ts, testCases...)
 }
 
+func TestFollowListHandlers(t *testing.T) {
+	t.Parallel()
+	ts := newTestServer(t)
+
+	registerUser(t, ts, "Alice", "alice@example.com", "alicepassword")
+	registerUser(t, ts, "Bob", "bob@example.com", "bobpassword")
+	registerUser(t, ts, "Charlie", "charlie@example.com", "charliepassword")
+	aliceToken := loginUser(t, ts, "alice@example.com", "alicepassword")
+	bobToken := loginUser(t, ts, "bob@example.com", "bobpassword")
+	charlieToken := loginUser(t, ts, "charlie@example.com", "charliepassword")
+
+	follow := func(token, username string) {
+		res, err := ts.executeRequest(http.MethodPost, "/profiles/"+username+"/follow", "", map[string]string{"Authorization": "Token " + token})
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+	}
+
+	// Bob and Charlie follow Alice; Alice follows Charlie back
+	follow(bobToken, "Alice")
+	follow(charlieToken, "Alice")
+	follow(aliceToken, "Charlie")
+
+	testCases := []handlerTestcase{
+		{
+			name:                   "anonymous user lists Alice's followers, newest first",
+			requestUrlPath:         "/profiles/Alice/followers",
+			requestMethodType:      http.MethodGet,
+			wantResponseStatusCode: http.StatusOK,
+			wantResponse: profilesResponse{
+				Profiles: []profile{
+					{Username: "Charlie"},
+					{Username: "Bob"},
+				},
+				ProfilesCount: 2,
+			},
+		},
+		{
+			name:                   "following flag reflects the viewing user",
+			requestUrlPath:         "/profiles/Alice/followers",
+			requestMethodType:      http.MethodGet,
+			requestHeader:          map[string]string{"Authorization": "Token " + aliceToken},
+			wantResponseStatusCode: http.StatusOK,
+			wantResponse: profilesResponse{
+				Profiles: []profile{
+					{Username: "Charlie", Following: true},
+					{Username: "Bob", Following: false},
+				},
+				ProfilesCount: 2,
+			},
+		},
+		{
+			name:                   "followers are paginated",
+			requestUrlPath:         "/profiles/Alice/followers?limit=1&offset=1",
+			requestMethodType:      http.MethodGet,
+			wantResponseStatusCode: http.StatusOK,
+			wantResponse: profilesResponse{
+				Profiles:      []profile{{Username: "Bob"}},
+				ProfilesCount: 2,
+			},
+		},
+		{
+			name:                   "list users Alice follows",
+			requestUrlPath:         "/profiles/Alice/following",
+			requestMethodType:      http.MethodGet,
+			requestHeader:          map[string]string{"Authorization": "Token " + bobToken},
+			wantResponseStatusCode: http.StatusOK,
+			wantResponse: profilesResponse{
+				Profiles:      []profile{{Username: "Charlie"}},
+				ProfilesCount: 1,
+			},
+		},
+		{
+			name:                   "user without follows has empty lists",
+			requestUrlPath:         "/profiles/Bob/followers",
+			requestMethodType:      http.MethodGet,
+			wantResponseStatusCode: http.StatusOK,
+			wantResponse: profilesResponse{
+				Profiles:      []profile{},
+				ProfilesCount: 0,
+			},
+		},
+		{
+			name:                   "followers of non-existent user returns 404",
+			requestUrlPath:         "/profiles/nonexistent/followers",
+			requestMethodType:      http.MethodGet,
+			wantResponseStatusCode: http.StatusNotFound,
+		},
+	}
+	testHandler(t, ts, testCases...)
+}
+
+func TestBlockUserHandler(t *testing.T) {
+	t.Parallel()
+	ts := newTestServer(t)
+
+	registerUser(t, ts, "Alice", "alice@example.com", "alicepassword")
+	registerUser(t, ts, "Bob", "bob@example.com", "bobpassword")
+	aliceToken := loginUser(t, ts, "alice@example.com", "alicepassword")
+	bobToken := loginUser(t, ts, "bob@example.com", "bobpassword")
+	aliceHeader := map[string]string{"Authorization": "Token " + aliceToken}
+	bobHeader := map[string]string{"Authorization": "Token " + bobToken}
+
+	articleLocation := createArticle(t, ts, aliceToken, "Alice's Article", "Test description", "Test body", []string{"test"})
+
+	// Alice comments on Carol's article, where only Alice's block applies to replies
+	registerUser(t, ts, "Carol", "carol@example.com", "carolpassword")
+	carolArticle := createArticle(t, ts, loginUser(t, ts, "carol@example.com", "carolpassword"), "Carol's Article", "Test description", "Test body", nil)
+	aliceComment := postComment(t, ts, aliceToken, carolArticle, "Alice's comment", 0)
+
+	// Bob follows Alice before being blocked
+	res, err := ts.executeRequest(http.MethodPost, "/profiles/Alice/follow", "", bobHeader)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	blocked := errorResponse{Errors: []string{"you have been blocked from interacting with this user"}}
+	aliceFollowers, aliceFollowing, aliceArticles := counts(0, 0, 1)
+
+	testCases := []handlerTestcase{
+		{
+			name:                   "Alice blocks Bob, which removes Bob's follow",
+			requestUrlPath:         "/profiles/Bob/block",
+			requestMethodType:      http.MethodPost,
+			requestHeader:          aliceHeader,
+			wantResponseStatusCode: http.StatusOK,
+		},
+		{
+			name:                   "Bob no longer follows Alice",
+			requestUrlPath:         "/profiles/Alice",
+			requestMethodType:      http.MethodGet,
+			requestHeader:          bobHeader,
+			wantResponseStatusCode: http.StatusOK,
+			wantResponse: profileResponse{
+				Profile: profile{
+					Username:       "Alice",
+					FollowersCount: aliceFollowers,
+					FollowingCount: aliceFollowing,
+					ArticlesCount:  aliceArticles,
+				},
+			},
+		},
+		{
+			name:                   "Bob cannot follow Alice",
+			requestUrlPath:         "/profiles/Alice/follow",
+			requestMethodType:      http.MethodPost,
+			requestHeader:          bobHeader,
+			wantResponseStatusCode: http.StatusForbidden,
+			wantResponse:           blocked,
+		},
+		{
+			name:                   "Bob cannot comment on Alice's article",
+			requestUrlPath:         articleLocation + "/comments",
+			requestMethodType:      http.MethodPost,
+			requestBody:            `{"comment": {"body": "Hello"}}`,
+			requestHeader:          bobHeader,
+			wantResponseStatusCode: http.StatusForbidden,
+			wantResponse:           blocked,
+		},
+		{
+			name:                   "Bob cannot reply to Alice's comment on another user's article",
+			requestUrlPath:         carolArticle + "/comments",
+			requestMethodType:      http.MethodPost,
+			requestBody:            `{"comment": {"body": "Hello", "parentId": ` + strconv.FormatInt(aliceComment, 10) + `}}`,
+			requestHeader:          bobHeader,
+			wantResponseStatusCode: http.StatusForbidden,
+			wantResponse:           blocked,
+		},
+		{
+			name:                   "Bob can still comment on that article",
+			requestUrlPath:         carolArticle + "/comments",
+			requestMethodType:      http.MethodPost,
+			requestBody:            `{"comment": {"body": "Hello"}}`,
+			requestHeader:          bobHeader,
+			wantResponseStatusCode: http.StatusCreated,
+		},
+		{
+			name:                   "Bob cannot favorite Alice's article",
+			requestUrlPath:         articleLocation + "/favorite",
+			requestMethodType:      http.MethodPost,
+			requestHeader:          bobHeader,
+			wantResponseStatusCode: http.StatusForbidden,
+			wantResponse:           blocked,
+		},
+		{
+			name:                   "Bob cannot react to Alice's article",
+			requestUrlPath:         articleLocation + "/reactions/like",
+			requestMethodType:      http.MethodPost,
+			requestHeader:          bobHeader,
+			wantResponseStatusCode: http.StatusForbidden,
+			wantResponse:           blocked,
+		},
+		{
+			name:                   "user cannot block themselves",
+			requestUrlPath:         "/profiles/Alice/block",
+			requestMethodType:      http.MethodPost,
+			requestHeader:          aliceHeader,
+			wantResponseStatusCode: http.StatusUnprocessableEntity,
+			wantResponse: errorResponse{
+				Errors: []string{"cannot block yourself"},
+			},
+		},
+		{
+			name:                   "anonymous user cannot block",
+			requestUrlPath:         "/profiles/Bob/block",
+			requestMethodType:      http.MethodPost,
+			wantResponseStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:                   "Alice unblocks Bob",
+			requestUrlPath:         "/profiles/Bob/block",
+			requestMethodType:      http.MethodDelete,
+			requestHeader:          aliceHeader,
+			wantResponseStatusCode: http.StatusOK,
+		},
+		{
+			name:                   "Bob can follow Alice again",
+			requestUrlPath:         "/profiles/Alice/follow",
+			requestMethodType:      http.MethodPost,
+			requestHeader:          bobHeader,
+			wantResponseStatusCode: http.StatusOK,
+		},
+	}
+	testHandler(t, ts, testCases...)
+}
+
+func TestMuteUserHandler(t *testing.T) {
+	t.Parallel()
+	ts := newTestServer(t)
+
+	registerUser(t, ts, "Alice", "alice@example.com", "alicepassword")
+	registerUser(t, ts, "Bob", "bob@example.com", "bobpassword")
+	registerUser(t, ts, "Charlie", "charlie@example.com", "charliepassword")
+	aliceToken := loginUser(t, ts, "alice@example.com", "alicepassword")
+	bobToken := loginUser(t, ts, "bob@example.com", "bobpassword")
+	charlieToken := loginUser(t, ts, "charlie@example.com", "charliepassword")
+	aliceHeader := map[string]string{"Authorization": "Token " + aliceToken}
+
+	bobArticle := createArticle(t, ts, bobToken, "Bob's Article", "Test description", "Test body", []string{"test"})
+	createArticle(t, ts, charlieToken, "Charlie's Article", "Test description", "Test body", []string{"test"})
+	createCommentHelper(t, ts, bobToken, bobArticle, "Bob's comment")
+	createCommentHelper(t, ts, charlieToken, bobArticle, "Charlie's comment")
+
+	for _, username := range []string{"Bob", "Charlie"} {
+		res, err := ts.executeRequest(http.MethodPost, "/profiles/"+username+"/follow", "", aliceHeader)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+	}
+
+	res, err := ts.executeRequest(http.MethodPost, "/profiles/Bob/mute", "", aliceHeader)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	articleTitles := func(t *testing.T, path string, header map[string]string) []string {
+		t.Helper()
+		res, err := ts.executeRequest(http.MethodGet, path, "", header)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		var response struct {
+			Articles      []data.Article `json:"articles"`
+			ArticlesCount int            `json:"articlesCount"`
+		}
+		readJsonResponse(t, res.Body, &response)
+
+		var titles []string
+		for _, article := range response.Articles {
+			titles = append(titles, article.Title)
+		}
+		return titles
+	}
+
+	commentBodies := func(t *testing.T, header map[string]string) ([]string, int) {
+		t.Helper()
+		res, err := ts.executeRequest(http.MethodGet, bobArticle+"/comments", "", header)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		var response commentsResponse
+		readJsonResponse(t, res.Body, &response)
+
+		var bodies []string
+		for _, c := range response.Comments {
+			bodies = append(bodies, c.Body)
+		}
+		return bodies, response.CommentsCount
+	}
+
+	t.Run("Muted user's articles are hidden from lists and the feed", func(t *testing.T) {
+		assert.Equal(t, []string{"Charlie's Article"}, articleTitles(t, "/articles", aliceHeader))
+		assert.Equal(t, []string{"Charlie's Article"}, articleTitles(t, "/articles/feed", aliceHeader))
+		assert.Equal(t, []string{"Charlie's Article", "Bob's Article"}, articleTitles(t, "/articles", nil),
+			"Mutes only apply to the user who muted")
+	})
+
+	t.Run("Muted user's comments are hidden", func(t *testing.T) {
+		bodies, count := commentBodies(t, aliceHeader)
+		assert.Equal(t, []string{"Charlie's comment"}, bodies)
+		assert.Equal(t, 1, count)
+	})
+
+	t.Run("Unmuting restores the muted user's content", func(t *testing.T) {
+		res, err := ts.executeRequest(http.MethodDelete, "/profiles/Bob/mute", "", aliceHeader)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		assert.Equal(t, []string{"Charlie's Article", "Bob's Article"}, articleTitles(t, "/articles", aliceHeader))
+		_, count := commentBodies(t, aliceHeader)
+		assert.Equal(t, 2, count)
+	})
+}
+
 func TestUpdateUserHandler(t *testing.T) {
 	t.Parallel()
 	ts := newTestServer(t)
@@ -648,3 +1123,141 @@ func TestUpdateUserHandler(t *testing.T) {
 	}
 	testHandler(t, ts, testCases...)
 }
+
+func TestDeleteUserHandler(t *testing.T) {
+	t.Parallel()
+
+	ts := newTestServer(t)
+
+	registerUser(t, ts, "alice", "alice@example.com", "password123")
+	registerUser(t, ts, "bob", "bob@example.com", "password123")
+	aliceToken := loginUser(t, ts, "alice@example.com", "password123")
+	bobToken := loginUser(t, ts, "bob@example.com", "password123")
+	aliceHeader := map[string]string{"Authorization": "Token " + aliceToken}
+
+	aliceArticle := createArticle(t, ts, aliceToken, "Alice Article", "Test description", "Test body", nil)
+	bobArticle := createArticle(t, ts, bobToken, "Bob Article", "Test description", "Test body", nil)
+	favoriteArticleHelper(t, ts, aliceToken, strings.TrimPrefix(bobArticle, "/articles/"))
+	followUser(t, ts, aliceToken, "bob")
+
+	testHandler(t, ts,
+		handlerTestcase{
+			name:                   "Unauthenticated",
+			requestMethodType:      http.MethodDelete,
+			requestUrlPath:         "/user",
+			requestBody:            `{"user": {"password": "password123"}}`,
+			wantResponseStatusCode: http.StatusUnauthorized,
+			wantResponse:           errorResponse{Errors: []string{"invalid or missing authentication token"}},
+		},
+		handlerTestcase{
+			name:                   "Missing password",
+			requestMethodType:      http.MethodDelete,
+			requestUrlPath:         "/user",
+			requestBody:            `{"user": {}}`,
+			requestHeader:          aliceHeader,
+			wantResponseStatusCode: http.StatusUnprocessableEntity,
+			wantResponse:           errorResponse{Errors: []string{"password must be provided"}},
+		},
+		handlerTestcase{
+			name:                   "Wrong password",
+			requestMethodType:      http.MethodDelete,
+			requestUrlPath:         "/user",
+			requestBody:            `{"user": {"password": "wrong-password"}}`,
+			requestHeader:          aliceHeader,
+			wantResponseStatusCode: http.StatusUnauthorized,
+			wantResponse:           errorResponse{Errors: []string{"invalid authentication credentials"}},
+		},
+	)
+
+	res, err := ts.executeRequest(http.MethodDelete, "/user", `{"user": {"password": "password123"}}`, aliceHeader)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNoContent, res.StatusCode)
+
+	res, err = ts.executeRequest(http.MethodGet, "/user", "", aliceHeader)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode, "tokens of deleted accounts are rejected")
+
+	res, err = ts.executeRequest(http.MethodPost, "/users/login", `{"user":{"email":"alice@example.com","password":"password123"}}`, nil)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+
+	res, err = ts.executeRequest(http.MethodGet, aliceArticle, "", nil)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, res.StatusCode, "the user's articles are deleted")
+
+	res, err = ts.executeRequest(http.MethodGet, bobArticle, "", nil)
+	require.NoError(t, err)
+	var article getArticleResponse
+	readJsonResponse(t, res.Body, &article)
+	assert.Equal(t, 0, article.Article.FavoritesCount, "the user's favorites are removed from the count")
+
+	res, err = ts.executeRequest(http.MethodGet, "/profiles/bob", "", nil)
+	require.NoError(t, err)
+	var bob profileResponse
+	readJsonResponse(t, res.Body, &bob)
+	assert.Equal(t, 0, *bob.Profile.FollowersCount)
+
+	// The email address and username are free to be used again
+	registerUser(t, ts, "alice", "alice@example.com", "password123")
+}
+
+func TestDeleteUserHandlerAnonymize(t *testing.T) {
+	t.Parallel()
+
+	ts := newTestServer(t)
+	ts.app.config.users.deletionPolicy = data.DeletionPolicyAnonymize
+
+	registerUser(t, ts, "alice", "alice@example.com", "password123")
+	registerUser(t, ts, "bob", "bob@example.com", "password123")
+	aliceToken := loginUser(t, ts, "alice@example.com", "password123")
+	bobToken := loginUser(t, ts, "bob@example.com", "password123")
+	aliceHeader := map[string]string{"Authorization": "Token " + aliceToken}
+
+	aliceArticle := createArticle(t, ts, aliceToken, "Alice Article", "Test description", "Test body", nil)
+	bobArticle := createArticle(t, ts, bobToken, "Bob Article", "Test description", "Test body", nil)
+	postComment(t, ts, aliceToken, bobArticle, "Alice comment", 0)
+	favoriteArticleHelper(t, ts, aliceToken, strings.TrimPrefix(bobArticle, "/articles/"))
+	followUser(t, ts, aliceToken, "bob")
+
+	res, err := ts.executeRequest(http.MethodDelete, "/user", `{"user": {"password": "password123"}}`, aliceHeader)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNoContent, res.StatusCode)
+
+	res, err = ts.executeRequest(http.MethodGet, "/user", "", aliceHeader)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode, "tokens of anonymized accounts are rejected")
+
+	res, err = ts.executeRequest(http.MethodGet, aliceArticle, "", nil)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, res.StatusCode, "the user's articles are kept")
+	var article getArticleResponse
+	readJsonResponse(t, res.Body, &article)
+	author := article.Article.Author.Username
+	assert.True(t, strings.HasPrefix(author, "deleted-user-"), "articles are attributed to the anonymized account")
+	assert.Empty(t, article.Article.Author.Bio)
+
+	res, err = ts.executeRequest(http.MethodGet, bobArticle+"/comments", "", nil)
+	require.NoError(t, err)
+	var comments commentsResponse
+	readJsonResponse(t, res.Body, &comments)
+	require.Equal(t, 1, comments.CommentsCount, "the user's comments are kept")
+	assert.Equal(t, author, comments.Comments[0].Author.Username)
+
+	res, err = ts.executeRequest(http.MethodGet, bobArticle, "", nil)
+	require.NoError(t, err)
+	readJsonResponse(t, res.Body, &article)
+	assert.Equal(t, 1, article.Article.FavoritesCount, "the user's favorites are kept")
+
+	res, err = ts.executeRequest(http.MethodGet, "/profiles/bob", "", nil)
+	require.NoError(t, err)
+	var bob profileResponse
+	readJsonResponse(t, res.Body, &bob)
+	assert.Equal(t, 0, *bob.Profile.FollowersCount, "the user's follows are removed")
+
+	res, err = ts.executeRequest(http.MethodGet, "/profiles/"+author, "", nil)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, res.StatusCode, "anonymized accounts have no profile")
+
+	// The email address and username are free to be used again
+	registerUser(t, ts, "alice", "alice@example.com", "password123")
+}
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/96malhar/realworld-backend/internal/data"
+	"github.com/96malhar/realworld-backend/internal/validator"
+	"github.com/96malhar/realworld-backend/internal/webhooks"
+)
+
+// webhookBatchSize is the number of due deliveries claimed and sent concurrently at a time.
+const webhookBatchSize = 50
+
+// createWebhookHandler registers a webhook for the current user. The response includes the
+// secret used to sign deliveries, which is not shown again.
+func (app *application) createWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Webhook struct {
+			URL    string   `json:"url"`
+			Events []string `json:"events"`
+		} `json:"webhook"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	webhook := &data.Webhook{
+		UserID: app.contextGetUser(r).ID,
+		URL:    input.Webhook.URL,
+		Events: input.Webhook.Events,
+	}
+
+	v := validator.New()
+	if data.ValidateWebhook(v, webhook); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	// Deliveries refuse to connect to internal addresses, which also covers host names that resolve
+	// to them; URLs that name one directly are rejected here so that the mistake is reported
+	if u, _ := url.Parse(webhook.URL); !app.config.webhooks.allowPrivateNetworks && !webhooks.IsPublicHost(u.Hostname()) {
+		app.failedValidationResponse(w, r, []string{"URL must not point to an internal address"})
+		return
+	}
+
+	webhook.Secret, err = webhooks.NewSecret()
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.modelStore.Webhooks.Insert(webhook)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusCreated, envelope{"webhook": webhook}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// listWebhooksHandler returns the current user's webhooks.
+func (app *application) listWebhooksHandler(w http.ResponseWriter, r *http.Request) {
+	hooks, err := app.modelStore.Webhooks.GetAllForUser(app.contextGetUser(r).ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"webhooks": hooks}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// deleteWebhookHandler removes one of the current user's webhooks and discards its pending deliveries.
+func (app *application) deleteWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(r, "id")
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	err = app.modelStore.Webhooks.Delete(id, app.contextGetUser(r).ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// listWebhookDeliveriesHandler returns a page of deliveries of one of the current user's webhooks,
+// most recent first, with the outcome of every attempt.
+func (app *application) listWebhookDeliveriesHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(r, "id")
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	webhook, err := app.modelStore.Webhooks.Get(id, app.contextGetUser(r).ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	pagination := app.readPagination(r, 20, 100)
+	deliveries, totalCount, err := app.modelStore.Webhooks.GetDeliveries(webhook.ID, pagination.Limit, pagination.Offset)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"deliveries": deliveries, "deliveriesCount": totalCount}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// runWebhookDispatcher periodically sends webhook deliveries that are due.
+// It blocks until the application starts shutting down, so it should be run with app.background.
+func (app *application) runWebhookDispatcher() {
+	ticker := time.NewTicker(app.config.webhooks.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-app.shutdown:
+			return
+		case <-ticker.C:
+			// Keep going while full batches are claimed, so a backlog drains without waiting for the next tick
+			for app.dispatchWebhooks() == webhookBatchSize {
+				select {
+				case <-app.shutdown:
+					return
+				default:
+				}
+			}
+		}
+	}
+}
+
+// dispatchWebhooks claims a batch of due deliveries, attempts them concurrently and records the outcomes.
+// It returns the number of deliveries attempted.
+func (app *application) dispatchWebhooks() int {
+	// Leave enough time for an attempt to finish before the delivery can be claimed again
+	lease := 2 * app.config.webhooks.timeout
+
+	deliveries, err := app.modelStore.Webhooks.ClaimDue(webhookBatchSize, lease)
+	if err != nil {
+		app.logger.Error("failed to claim webhook deliveries", "error", err)
+		return 0
+	}
+
+	var wg sync.WaitGroup
+	for _, delivery := range deliveries {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			app.deliverWebhook(delivery)
+		}()
+	}
+	wg.Wait()
+
+	return len(deliveries)
+}
+
+// deliverWebhook makes one attempt to send a delivery. Deliveries that don't receive a 2xx response
+// are retried with exponential backoff until they run out of attempts.
+func (app *application) deliverWebhook(delivery data.WebhookDelivery) {
+	attempt := data.WebhookAttempt{Attempt: delivery.Attempts + 1}
+
+	start := time.Now()
+	statusCode, err := app.sendWebhook(delivery)
+	attempt.DurationMs = time.Since(start).Milliseconds()
+
+	status := data.DeliveryPending
+	nextAttemptAt := time.Now().Add(webhooks.Backoff(app.config.webhooks.retryBackoff, attempt.Attempt))
+
+	if err != nil {
+		attempt.Error = err.Error()
+	} else {
+		attempt.StatusCode = &statusCode
+	}
+
+	if err == nil && statusCode >= 200 && statusCode < 300 {
+		status = data.DeliverySucceeded
+	} else if attempt.Attempt >= app.config.webhooks.maxAttempts {
+		status = data.DeliveryFailed
+	}
+
+	if status != data.DeliverySucceeded {
+		app.logger.Warn("webhook delivery attempt failed",
+			"delivery", delivery.ID, "attempt", attempt.Attempt, "status", status, "statusCode", statusCode, "error", attempt.Error)
+	}
+
+	err = app.modelStore.Webhooks.RecordAttempt(delivery.ID, attempt, status, nextAttemptAt)
+	if err != nil {
+		app.logger.Error("failed to record webhook delivery attempt", "delivery", delivery.ID, "error", err)
+	}
+}
+
+// sendWebhook posts a signed delivery to its webhook's URL and returns the response status code.
+func (app *application) sendWebhook(delivery data.WebhookDelivery) (int, error) {
+	body, err := json.Marshal(envelope{
+		"id":        delivery.ID,
+		"event":     delivery.EventType,
+		"createdAt": delivery.CreatedAt,
+		"data":      delivery.Payload,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, delivery.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Conduit-Webhooks/"+version)
+	req.Header.Set(webhooks.HeaderEvent, delivery.EventType)
+	req.Header.Set(webhooks.HeaderDelivery, fmt.Sprint(delivery.ID))
+	req.Header.Set(webhooks.HeaderTimestamp, fmt.Sprint(timestamp))
+	req.Header.Set(webhooks.HeaderSignature, webhooks.Sign(delivery.Secret, timestamp, body))
+
+	res, err := app.webhookClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close() //nolint:errcheck
+
+	// Drain a bounded amount of the response so the connection can be reused
+	io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10)) //nolint:errcheck
+
+	return res.StatusCode, nil
+}
@@ -0,0 +1,411 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/96malhar/realworld-backend/internal/data"
+	"github.com/96malhar/realworld-backend/internal/webhooks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type webhookResponse struct {
+	Webhook data.Webhook `json:"webhook"`
+}
+
+type webhooksResponse struct {
+	Webhooks []data.Webhook `json:"webhooks"`
+}
+
+type deliveriesResponse struct {
+	Deliveries      []data.WebhookDelivery `json:"deliveries"`
+	DeliveriesCount int                    `json:"deliveriesCount"`
+}
+
+// receivedWebhook is a delivery as seen by the receiving server.
+type receivedWebhook struct {
+	Event    string
+	Delivery string
+	Valid    bool
+	Body     struct {
+		ID        int64           `json:"id"`
+		Event     string          `json:"event"`
+		CreatedAt string          `json:"createdAt"`
+		Data      json.RawMessage `json:"data"`
+	}
+}
+
+// webhookReceiver is an httptest server that records the deliveries it receives and checks their
+// signatures. Responses are taken from statuses in order, then 200 once they run out.
+type webhookReceiver struct {
+	*httptest.Server
+	secret   string
+	mu       sync.Mutex
+	statuses []int
+	received []receivedWebhook
+}
+
+func newWebhookReceiver(t *testing.T, statuses ...int) *webhookReceiver {
+	t.Helper()
+
+	wr := &webhookReceiver{statuses: statuses}
+	wr.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		timestamp, err := strconv.ParseInt(r.Header.Get(webhooks.HeaderTimestamp), 10, 64)
+		require.NoError(t, err)
+
+		wr.mu.Lock()
+		defer wr.mu.Unlock()
+
+		received := receivedWebhook{
+			Event:    r.Header.Get(webhooks.HeaderEvent),
+			Delivery: r.Header.Get(webhooks.HeaderDelivery),
+			Valid:    webhooks.Verify(wr.secret, timestamp, body, r.Header.Get(webhooks.HeaderSignature)),
+		}
+		require.NoError(t, json.Unmarshal(body, &received.Body))
+		wr.received = append(wr.received, received)
+
+		status := http.StatusOK
+		if len(wr.statuses) > 0 {
+			status, wr.statuses = wr.statuses[0], wr.statuses[1:]
+		}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(wr.Close)
+	return wr
+}
+
+// setSecret sets the secret used to verify signatures, once the webhook has been registered.
+func (wr *webhookReceiver) setSecret(secret string) {
+	wr.mu.Lock()
+	defer wr.mu.Unlock()
+	wr.secret = secret
+}
+
+func (wr *webhookReceiver) deliveries() []receivedWebhook {
+	wr.mu.Lock()
+	defer wr.mu.Unlock()
+	return append([]receivedWebhook(nil), wr.received...)
+}
+
+// registerWebhook creates a webhook for the user and returns it, including its secret.
+func registerWebhook(t *testing.T, ts *testServer, token, url string, events ...string) data.Webhook {
+	t.Helper()
+
+	body, err := json.Marshal(envelope{"webhook": envelope{"url": url, "events": events}})
+	require.NoError(t, err)
+
+	res, err := ts.executeRequest(http.MethodPost, "/user/webhooks", string(body), map[string]string{"Authorization": "Token " + token})
+	require.NoError(t, err)
+	defer res.Body.Close() //nolint: errcheck
+	require.Equal(t, http.StatusCreated, res.StatusCode)
+
+	var response webhookResponse
+	readJsonResponse(t, res.Body, &response)
+	return response.Webhook
+}
+
+func getDeliveries(t *testing.T, ts *testServer, token string, webhookID int64) deliveriesResponse {
+	t.Helper()
+
+	path := "/user/webhooks/" + strconv.FormatInt(webhookID, 10) + "/deliveries"
+	res, err := ts.executeRequest(http.MethodGet, path, "", map[string]string{"Authorization": "Token " + token})
+	require.NoError(t, err)
+	defer res.Body.Close() //nolint: errcheck
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	var response deliveriesResponse
+	readJsonResponse(t, res.Body, &response)
+	return response
+}
+
+func TestWebhookHandlers(t *testing.T) {
+	t.Parallel()
+
+	ts := newTestServer(t)
+
+	registerUser(t, ts, "alice", "alice@example.com", "password123")
+	registerUser(t, ts, "bob", "bob@example.com", "password123")
+	aliceToken := loginUser(t, ts, "alice@example.com", "password123")
+	bobToken := loginUser(t, ts, "bob@example.com", "password123")
+	aliceHeader := map[string]string{"Authorization": "Token " + aliceToken}
+	bobHeader := map[string]string{"Authorization": "Token " + bobToken}
+
+	webhook := registerWebhook(t, ts, aliceToken, "https://example.com/hooks", data.WebhookArticleCreated, data.WebhookCommentCreated)
+	assert.NotZero(t, webhook.ID)
+	assert.Equal(t, "https://example.com/hooks", webhook.URL)
+	assert.Equal(t, []string{data.WebhookArticleCreated, data.WebhookCommentCreated}, webhook.Events)
+	assert.NotEmpty(t, webhook.Secret)
+
+	webhookPath := "/user/webhooks/" + strconv.FormatInt(webhook.ID, 10)
+
+	testHandler(t, ts,
+		handlerTestcase{
+			name:                   "Invalid URL and events",
+			requestMethodType:      http.MethodPost,
+			requestUrlPath:         "/user/webhooks",
+			requestBody:            `{"webhook": {"url": "ftp://example.com", "events": ["article.created", "article.created", "user.created"]}}`,
+			requestHeader:          aliceHeader,
+			wantResponseStatusCode: http.StatusUnprocessableEntity,
+			wantResponse: errorResponse{Errors: []string{
+				"URL must be an absolute http or https URL",
+				"Events must not contain duplicate values",
+				"Events must only contain article.created, article.published, article.updated, article.deleted or comment.created",
+			}},
+		},
+		handlerTestcase{
+			name:                   "Events are required",
+			requestMethodType:      http.MethodPost,
+			requestUrlPath:         "/user/webhooks",
+			requestBody:            `{"webhook": {"url": "https://example.com/hooks"}}`,
+			requestHeader:          aliceHeader,
+			wantResponseStatusCode: http.StatusUnprocessableEntity,
+			wantResponse:           errorResponse{Errors: []string{"Events must contain at least one event type"}},
+		},
+		handlerTestcase{
+			name:                   "Listing webhooks hides their secrets",
+			requestMethodType:      http.MethodGet,
+			requestUrlPath:         "/user/webhooks",
+			requestHeader:          aliceHeader,
+			wantResponseStatusCode: http.StatusOK,
+			additionalChecks: func(t *testing.T, res *http.Response) {
+				var response webhooksResponse
+				readJsonResponse(t, res.Body, &response)
+				require.Len(t, response.Webhooks, 1)
+				assert.Equal(t, webhook.ID, response.Webhooks[0].ID)
+				assert.Empty(t, response.Webhooks[0].Secret)
+			},
+		},
+		handlerTestcase{
+			name:                   "Other users do not see the webhook",
+			requestMethodType:      http.MethodGet,
+			requestUrlPath:         "/user/webhooks",
+			requestHeader:          bobHeader,
+			wantResponseStatusCode: http.StatusOK,
+			wantResponse:           webhooksResponse{Webhooks: []data.Webhook{}},
+		},
+		handlerTestcase{
+			name:                   "Other users cannot see the deliveries",
+			requestMethodType:      http.MethodGet,
+			requestUrlPath:         webhookPath + "/deliveries",
+			requestHeader:          bobHeader,
+			wantResponseStatusCode: http.StatusNotFound,
+		},
+		handlerTestcase{
+			name:                   "Other users cannot delete the webhook",
+			requestMethodType:      http.MethodDelete,
+			requestUrlPath:         webhookPath,
+			requestHeader:          bobHeader,
+			wantResponseStatusCode: http.StatusNotFound,
+		},
+		handlerTestcase{
+			name:                   "Managing webhooks requires authentication",
+			requestMethodType:      http.MethodGet,
+			requestUrlPath:         "/user/webhooks",
+			wantResponseStatusCode: http.StatusUnauthorized,
+		},
+	)
+
+	t.Run("Delete a webhook", func(t *testing.T) {
+		res, err := ts.executeRequest(http.MethodDelete, webhookPath, "", aliceHeader)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNoContent, res.StatusCode)
+
+		res, err = ts.executeRequest(http.MethodGet, webhookPath+"/deliveries", "", aliceHeader)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+	t.Run("Internal addresses are rejected", func(t *testing.T) {
+		ts.app.config.webhooks.allowPrivateNetworks = false
+
+		for _, url := range []string{"http://127.0.0.1:8080/hooks", "http://localhost/hooks", "http://[::1]/hooks", "http://169.254.169.254/latest"} {
+			res, err := ts.executeRequest(http.MethodPost, "/user/webhooks", `{"webhook": {"url": "`+url+`", "events": ["article.created"]}}`, aliceHeader)
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode, url)
+		}
+	})
+}
+
+func TestWebhookDelivery(t *testing.T) {
+	t.Parallel()
+
+	ts := newTestServer(t)
+	receiver := newWebhookReceiver(t)
+
+	registerUser(t, ts, "alice", "alice@example.com", "password123")
+	registerUser(t, ts, "bob", "bob@example.com", "password123")
+	aliceToken := loginUser(t, ts, "alice@example.com", "password123")
+	bobToken := loginUser(t, ts, "bob@example.com", "password123")
+	bobHeader := map[string]string{"Authorization": "Token " + bobToken}
+
+	webhook := registerWebhook(t, ts, aliceToken, receiver.URL, data.WebhookArticleCreated, data.WebhookArticleDeleted, data.WebhookCommentCreated)
+	receiver.setSecret(webhook.Secret)
+
+	// Events about published content by followed users are delivered, drafts and other users' content are not
+	followUser(t, ts, aliceToken, "bob")
+	registerUser(t, ts, "carol", "carol@example.com", "password123")
+	createArticle(t, ts, loginUser(t, ts, "carol@example.com", "password123"), "Carol Article", "Test description", "Test body", nil)
+	articleLocation := createArticle(t, ts, bobToken, "Bob Article", "Test description", "Test body", []string{"test"})
+	res, err := ts.executeRequest(http.MethodPost, "/articles",
+		`{"article": {"title": "Draft", "description": "d", "body": "b", "status": "draft"}}`, bobHeader)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, res.StatusCode)
+	// Updates are not subscribed to
+	res, err = ts.executeRequest(http.MethodPut, articleLocation, `{"article": {"body": "Updated body"}}`, bobHeader)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	createCommentHelper(t, ts, aliceToken, articleLocation, "Nice article")
+	res, err = ts.executeRequest(http.MethodDelete, articleLocation, "", bobHeader)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNoContent, res.StatusCode)
+
+	// Deliveries are queued when the outbox events are published
+	assert.Zero(t, ts.app.dispatchWebhooks())
+	ts.app.dispatchOutbox()
+	assert.Equal(t, 3, ts.app.dispatchWebhooks())
+	assert.Zero(t, ts.app.dispatchWebhooks(), "delivered events are not sent again")
+
+	received := receiver.deliveries()
+	require.Len(t, received, 3)
+	events := make([]string, len(received))
+	for i, r := range received {
+		events[i] = r.Event
+		assert.True(t, r.Valid, "signature of %s should be valid", r.Event)
+		assert.Equal(t, r.Event, r.Body.Event)
+		assert.Equal(t, strconv.FormatInt(r.Body.ID, 10), r.Delivery)
+	}
+	assert.ElementsMatch(t, []string{data.WebhookArticleCreated, data.WebhookCommentCreated, data.WebhookArticleDeleted}, events)
+
+	for _, r := range received {
+		switch r.Event {
+		case data.WebhookArticleCreated:
+			var payload getArticleResponse
+			require.NoError(t, json.Unmarshal(r.Body.Data, &payload))
+			assert.Equal(t, "Bob Article", payload.Article.Title)
+			assert.Equal(t, "bob", payload.Article.Author.Username)
+		case data.WebhookCommentCreated:
+			assert.Contains(t, string(r.Body.Data), `"articleSlug":"bob-article"`)
+			assert.Contains(t, string(r.Body.Data), "Nice article")
+		case data.WebhookArticleDeleted:
+			assert.JSONEq(t, `{"article": {"slug": "bob-article", "title": "Bob Article", "status": "published"}}`, string(r.Body.Data))
+		}
+	}
+
+	deliveries := getDeliveries(t, ts, aliceToken, webhook.ID)
+	require.Len(t, deliveries.Deliveries, 3)
+	assert.Equal(t, 3, deliveries.DeliveriesCount)
+	for _, d := range deliveries.Deliveries {
+		assert.Equal(t, data.DeliverySucceeded, d.Status)
+		assert.Equal(t, 1, d.Attempts)
+		assert.Nil(t, d.NextAttemptAt)
+		require.Len(t, d.AttemptLog, 1)
+		require.NotNil(t, d.AttemptLog[0].StatusCode)
+		assert.Equal(t, http.StatusOK, *d.AttemptLog[0].StatusCode)
+	}
+}
+
+func TestWebhookRetries(t *testing.T) {
+	t.Parallel()
+
+	ts := newTestServer(t)
+
+	registerUser(t, ts, "alice", "alice@example.com", "password123")
+	aliceToken := loginUser(t, ts, "alice@example.com", "password123")
+
+	// dispatchUntilIdle sends due deliveries, waiting out the backoff, until none are left pending
+	dispatchUntilIdle := func(t *testing.T, webhookID int64) []data.WebhookDelivery {
+		t.Helper()
+		require.Eventually(t, func() bool {
+			ts.app.dispatchWebhooks()
+			for _, d := range getDeliveries(t, ts, aliceToken, webhookID).Deliveries {
+				if d.Status == data.DeliveryPending {
+					return false
+				}
+			}
+			return true
+		}, 5*time.Second, 20*time.Millisecond)
+		return getDeliveries(t, ts, aliceToken, webhookID).Deliveries
+	}
+
+	t.Run("Failed attempts are retried with backoff", func(t *testing.T) {
+		receiver := newWebhookReceiver(t, http.StatusInternalServerError, http.StatusServiceUnavailable)
+		webhook := registerWebhook(t, ts, aliceToken, receiver.URL, data.WebhookArticleCreated)
+		receiver.setSecret(webhook.Secret)
+
+		createArticle(t, ts, aliceToken, "Retried Article", "Test description", "Test body", []string{"test"})
+		ts.app.dispatchOutbox()
+
+		require.Equal(t, 1, ts.app.dispatchWebhooks())
+		pending := getDeliveries(t, ts, aliceToken, webhook.ID).Deliveries
+		require.Len(t, pending, 1)
+		assert.Equal(t, data.DeliveryPending, pending[0].Status)
+		assert.NotNil(t, pending[0].NextAttemptAt)
+
+		deliveries := dispatchUntilIdle(t, webhook.ID)
+		require.Len(t, deliveries, 1)
+		assert.Equal(t, data.DeliverySucceeded, deliveries[0].Status)
+		assert.Equal(t, 3, deliveries[0].Attempts)
+
+		require.Len(t, deliveries[0].AttemptLog, 3)
+		for i, want := range []int{http.StatusInternalServerError, http.StatusServiceUnavailable, http.StatusOK} {
+			assert.Equal(t, i+1, deliveries[0].AttemptLog[i].Attempt)
+			require.NotNil(t, deliveries[0].AttemptLog[i].StatusCode)
+			assert.Equal(t, want, *deliveries[0].AttemptLog[i].StatusCode)
+		}
+
+		// Every attempt of a delivery is sent with the same body
+		received := receiver.deliveries()
+		require.Len(t, received, 3)
+		assert.Equal(t, received[0].Body, received[2].Body)
+	})
+
+	t.Run("Deliveries fail after running out of attempts", func(t *testing.T) {
+		receiver := newWebhookReceiver(t, http.StatusInternalServerError, http.StatusInternalServerError, http.StatusInternalServerError)
+		webhook := registerWebhook(t, ts, aliceToken, receiver.URL, data.WebhookArticleUpdated)
+		receiver.setSecret(webhook.Secret)
+
+		articleLocation := createArticle(t, ts, aliceToken, "Failing Article", "Test description", "Test body", []string{"test"})
+		res, err := ts.executeRequest(http.MethodPut, articleLocation, `{"article": {"body": "Updated body"}}`,
+			map[string]string{"Authorization": "Token " + aliceToken})
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		ts.app.dispatchOutbox()
+
+		deliveries := dispatchUntilIdle(t, webhook.ID)
+		require.Len(t, deliveries, 1)
+		assert.Equal(t, data.DeliveryFailed, deliveries[0].Status)
+		assert.Equal(t, ts.app.config.webhooks.maxAttempts, deliveries[0].Attempts)
+		assert.Len(t, deliveries[0].AttemptLog, ts.app.config.webhooks.maxAttempts)
+		assert.Len(t, receiver.deliveries(), ts.app.config.webhooks.maxAttempts)
+	})
+
+	t.Run("Connection errors are logged", func(t *testing.T) {
+		receiver := newWebhookReceiver(t)
+		webhook := registerWebhook(t, ts, aliceToken, receiver.URL, data.WebhookArticleDeleted)
+		// Nothing is listening once the receiver is closed
+		receiver.Close()
+
+		articleLocation := createArticle(t, ts, aliceToken, "Deleted Article", "Test description", "Test body", []string{"test"})
+		res, err := ts.executeRequest(http.MethodDelete, articleLocation, "", map[string]string{"Authorization": "Token " + aliceToken})
+		require.NoError(t, err)
+		require.Equal(t, http.StatusNoContent, res.StatusCode)
+		ts.app.dispatchOutbox()
+
+		deliveries := dispatchUntilIdle(t, webhook.ID)
+		require.Len(t, deliveries, 1)
+		assert.Equal(t, data.DeliveryFailed, deliveries[0].Status)
+		require.Len(t, deliveries[0].AttemptLog, ts.app.config.webhooks.maxAttempts)
+		for _, attempt := range deliveries[0].AttemptLog {
+			assert.Nil(t, attempt.StatusCode)
+			assert.NotEmpty(t, attempt.Error)
+		}
+	})
+}
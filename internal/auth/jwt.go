@@ -43,16 +43,30 @@ func NewJWTMaker(secretKey string, issuer string) (*JWTMaker, error) {
 	}, nil
 }
 
+// mfaAudienceSuffix is appended to the audience of MFA pending tokens, so that they are not
+// accepted as access tokens.
+const mfaAudienceSuffix = ":mfa"
+
 // CreateToken generates a new JWT access token for the given user ID and duration.
 // It signs the token with the secret key and includes standard claims (iss, aud, sub, jti).
 // It uses the HS256 signing method.
 func (maker *JWTMaker) CreateToken(userID int64, duration time.Duration) (string, error) {
+	return maker.createToken(userID, duration, maker.audience)
+}
+
+// CreateMFAToken generates a token showing that the user entered their password but still has to
+// complete two-factor authentication. It cannot be used as an access token.
+func (maker *JWTMaker) CreateMFAToken(userID int64, duration time.Duration) (string, error) {
+	return maker.createToken(userID, duration, maker.audience+mfaAudienceSuffix)
+}
+
+func (maker *JWTMaker) createToken(userID int64, duration time.Duration, audience string) (string, error) {
 	now := time.Now()
 	claims := Claims{
 		UserID: userID,
 		RegisteredClaims: jwt.RegisteredClaims{
 			Subject:   fmt.Sprintf("%d", userID),             // Standard way to identify the user
-			Audience:  jwt.ClaimStrings{maker.audience},      // Who can use this token
+			Audience:  jwt.ClaimStrings{audience},            // Who can use this token
 			ExpiresAt: jwt.NewNumericDate(now.Add(duration)), // Token expiration
 			IssuedAt:  jwt.NewNumericDate(now),               // When token was issued
 			NotBefore: jwt.NewNumericDate(now),               // Token not valid before this time
@@ -69,6 +83,15 @@ func (maker *JWTMaker) CreateToken(userID int64, duration time.Duration) (string
 // It validates the signing algorithm, issuer, and audience claims.
 // It returns an error if the token is invalid or expired.
 func (maker *JWTMaker) VerifyToken(tokenString string) (*Claims, error) {
+	return maker.verifyToken(tokenString, maker.audience)
+}
+
+// VerifyMFAToken checks the validity of a token created by CreateMFAToken and returns its claims.
+func (maker *JWTMaker) VerifyMFAToken(tokenString string) (*Claims, error) {
+	return maker.verifyToken(tokenString, maker.audience+mfaAudienceSuffix)
+}
+
+func (maker *JWTMaker) verifyToken(tokenString, expectedAudience string) (*Claims, error) {
 	keyFunc := func(token *jwt.Token) (any, error) {
 		// Prevent algorithm confusion attacks by validating the signing method
 		if token.Method.Alg() != maker.signingMethod.Alg() {
@@ -96,7 +119,6 @@ func (maker *JWTMaker) VerifyToken(tokenString string) (*Claims, error) {
 	}
 
 	// Validate audience (access tokens should have the standard audience)
-	expectedAudience := maker.audience
 	validAudience := false
 	for _, aud := range claims.Audience {
 		if aud == expectedAudience {
@@ -138,3 +138,24 @@ func TestJWTMaker_VerifyToken(t *testing.T) {
 		})
 	}
 }
+
+func TestJWTMaker_MFAToken(t *testing.T) {
+	maker, err := NewJWTMaker("this-is-a-valid-secret-key-32-chars", "test-issuer")
+	require.NoError(t, err)
+
+	mfaToken, err := maker.CreateMFAToken(123, 5*time.Minute)
+	require.NoError(t, err)
+
+	claims, err := maker.VerifyMFAToken(mfaToken)
+	require.NoError(t, err)
+	assert.Equal(t, int64(123), claims.UserID)
+
+	// MFA pending tokens are not access tokens, and the other way round
+	_, err = maker.VerifyToken(mfaToken)
+	assert.Equal(t, ErrInvalidToken, err)
+
+	accessToken, err := maker.CreateToken(123, 5*time.Minute)
+	require.NoError(t, err)
+	_, err = maker.VerifyMFAToken(accessToken)
+	assert.Equal(t, ErrInvalidToken, err)
+}
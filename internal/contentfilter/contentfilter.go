@@ -0,0 +1,271 @@
+// Package contentfilter screens articles and comments before they are published.
+//
+// Each filter returns a verdict on the submitted content: allow it, hold it back until a moderator
+// has looked at it, or reject it outright. Filters are combined with a Chain, which returns the
+// strictest verdict of its filters.
+package contentfilter
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/96malhar/realworld-backend/internal/data"
+)
+
+// Action is what happens to content after it has been screened. Actions are ordered from the
+// most to the least permissive.
+type Action int
+
+const (
+	Allow Action = iota
+	Hold
+	Reject
+)
+
+func (a Action) String() string {
+	switch a {
+	case Hold:
+		return "hold"
+	case Reject:
+		return "reject"
+	default:
+		return "allow"
+	}
+}
+
+// ParseAction parses the name of an action, as used in configuration.
+func ParseAction(s string) (Action, error) {
+	switch s {
+	case "allow":
+		return Allow, nil
+	case "hold":
+		return Hold, nil
+	case "reject":
+		return Reject, nil
+	}
+	return Allow, fmt.Errorf("unknown content filter action %q", s)
+}
+
+// Content is the text of an article or comment submitted by a user.
+type Content struct {
+	Author *data.User
+	// Text holds the fields of the content, such as an article's title, description and body.
+	Text []string
+}
+
+// Verdict is the outcome of screening content. Reasons explain why content was held or rejected,
+// and are shown to the user when it is rejected.
+type Verdict struct {
+	Action  Action
+	Reasons []string
+}
+
+// Filter screens content.
+type Filter interface {
+	Check(content Content) Verdict
+}
+
+// Chain runs every filter and returns the strictest verdict, along with the reasons
+// of every filter that reached it.
+type Chain []Filter
+
+func (c Chain) Check(content Content) Verdict {
+	var verdict Verdict
+	for _, filter := range c {
+		v := filter.Check(content)
+		switch {
+		case v.Action > verdict.Action:
+			verdict = Verdict{Action: v.Action, Reasons: slices.Clone(v.Reasons)}
+		case v.Action == verdict.Action && v.Action != Allow:
+			verdict.Reasons = append(verdict.Reasons, v.Reasons...)
+		}
+	}
+	return verdict
+}
+
+// Wordlist flags content that contains any of a list of words. Words are matched as whole words, ignoring case.
+type Wordlist struct {
+	words  map[string]bool
+	action Action
+}
+
+// NewWordlist creates a filter that applies action to content containing any of the words.
+func NewWordlist(words []string, action Action) *Wordlist {
+	w := &Wordlist{words: make(map[string]bool, len(words)), action: action}
+	for _, word := range words {
+		w.words[strings.ToLower(word)] = true
+	}
+	return w
+}
+
+// ReadWordlist reads a wordlist with one word per line. Blank lines and lines starting with # are skipped.
+func ReadWordlist(r io.Reader) ([]string, error) {
+	var words []string
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		words = append(words, line)
+	}
+
+	return words, scanner.Err()
+}
+
+func (w *Wordlist) Check(content Content) Verdict {
+	var found []string
+	for _, text := range content.Text {
+		for _, word := range words(text) {
+			word = strings.ToLower(word)
+			if w.words[word] && !slices.Contains(found, word) {
+				found = append(found, word)
+			}
+		}
+	}
+
+	if len(found) == 0 {
+		return Verdict{}
+	}
+	return Verdict{Action: w.action, Reasons: []string{"content contains blocked words: " + strings.Join(found, ", ")}}
+}
+
+// words splits text into words made up of letters and digits.
+func words(text string) []string {
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+var linkRX = regexp.MustCompile(`(?i)\b(?:https?://|www\.)\S+`)
+
+// countLinks returns the number of links in the content.
+func countLinks(content Content) int {
+	n := 0
+	for _, text := range content.Text {
+		n += len(linkRX.FindAllStringIndex(text, -1))
+	}
+	return n
+}
+
+// SpamScorer scores content on heuristics common to spam, such as many links or shouting, and holds
+// or rejects content that scores at least HoldScore or RejectScore respectively. A zero score disables
+// the corresponding action.
+type SpamScorer struct {
+	HoldScore   int
+	RejectScore int
+}
+
+// Links beyond freeLinks add one point each to the spam score.
+const freeLinks = 2
+
+func (s SpamScorer) Check(content Content) Verdict {
+	score, signals := 0, []string(nil)
+
+	if links := countLinks(content); links > freeLinks {
+		score += links - freeLinks
+		signals = append(signals, fmt.Sprintf("%d links", links))
+	}
+
+	// Links are scored above, so the remaining heuristics only look at the prose
+	text := linkRX.ReplaceAllString(strings.Join(content.Text, "\n"), " ")
+	if shouting(text) {
+		score += 2
+		signals = append(signals, "mostly capital letters")
+	}
+	if repeatedCharacters(text) {
+		score++
+		signals = append(signals, "long runs of a repeated character")
+	}
+	if repetitive(text) {
+		score += 2
+		signals = append(signals, "the same word over and over")
+	}
+
+	var action Action
+	switch {
+	case s.RejectScore > 0 && score >= s.RejectScore:
+		action = Reject
+	case s.HoldScore > 0 && score >= s.HoldScore:
+		action = Hold
+	default:
+		return Verdict{}
+	}
+	return Verdict{Action: action, Reasons: []string{"content looks like spam: " + strings.Join(signals, ", ")}}
+}
+
+// shouting reports whether most letters of a non-trivial text are capitals.
+func shouting(text string) bool {
+	letters, upper := 0, 0
+	for _, r := range text {
+		if unicode.IsLetter(r) {
+			letters++
+			if unicode.IsUpper(r) {
+				upper++
+			}
+		}
+	}
+	return letters >= 20 && upper*10 > letters*7
+}
+
+// repeatedCharacters reports whether text contains a run of ten or more of the same character, other than whitespace.
+func repeatedCharacters(text string) bool {
+	var last rune
+	run := 0
+	for _, r := range text {
+		if r == last && !unicode.IsSpace(r) {
+			run++
+			if run >= 10 {
+				return true
+			}
+			continue
+		}
+		last, run = r, 1
+	}
+	return false
+}
+
+// repetitive reports whether a single word makes up half or more of a text of at least ten words.
+func repetitive(text string) bool {
+	ws := words(text)
+	if len(ws) < 10 {
+		return false
+	}
+
+	counts := make(map[string]int)
+	for _, w := range ws {
+		w = strings.ToLower(w)
+		counts[w]++
+		if counts[w]*2 >= len(ws) {
+			return true
+		}
+	}
+	return false
+}
+
+// NewAccountRestriction holds content containing links from accounts younger than MinAge,
+// a common pattern of accounts registered to spam.
+type NewAccountRestriction struct {
+	MinAge time.Duration
+	// Now returns the current time; time.Now is used if nil.
+	Now func() time.Time
+}
+
+func (n NewAccountRestriction) Check(content Content) Verdict {
+	now := time.Now
+	if n.Now != nil {
+		now = n.Now
+	}
+
+	if content.Author == nil || now().Sub(content.Author.CreatedAt) >= n.MinAge || countLinks(content) == 0 {
+		return Verdict{}
+	}
+	return Verdict{Action: Hold, Reasons: []string{"links from new accounts are held for moderation"}}
+}
@@ -0,0 +1,155 @@
+package contentfilter
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/96malhar/realworld-backend/internal/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func text(fields ...string) Content {
+	return Content{Author: &data.User{}, Text: fields}
+}
+
+func TestWordlist(t *testing.T) {
+	t.Parallel()
+
+	filter := NewWordlist([]string{"Darn", "heck"}, Reject)
+
+	tests := []struct {
+		name    string
+		content Content
+		want    Verdict
+	}{
+		{
+			name:    "No blocked words",
+			content: text("A title", "Nothing to see here"),
+			want:    Verdict{},
+		},
+		{
+			name:    "Blocked words in any field, ignoring case",
+			content: text("Darn it", "What the HECK, darn"),
+			want:    Verdict{Action: Reject, Reasons: []string{"content contains blocked words: darn, heck"}},
+		},
+		{
+			name:    "Only whole words match",
+			content: text("Checking the darnedest things"),
+			want:    Verdict{},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, filter.Check(tc.content))
+		})
+	}
+}
+
+func TestReadWordlist(t *testing.T) {
+	t.Parallel()
+
+	words, err := ReadWordlist(strings.NewReader("# profanity\ndarn\n\n  heck  \n"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"darn", "heck"}, words)
+}
+
+func TestSpamScorer(t *testing.T) {
+	t.Parallel()
+
+	scorer := SpamScorer{HoldScore: 3, RejectScore: 6}
+
+	tests := []struct {
+		name       string
+		content    Content
+		wantAction Action
+		wantReason string
+	}{
+		{
+			name:       "Ordinary text with a couple of links",
+			content:    text("See https://go.dev and www.example.com for details"),
+			wantAction: Allow,
+		},
+		{
+			name:       "Many links",
+			content:    text("https://a.example https://b.example https://c.example https://d.example https://e.example"),
+			wantAction: Hold,
+			wantReason: "content looks like spam: 5 links",
+		},
+		{
+			name:       "Shouting with repeated characters",
+			content:    text("BUY NOW!!!!!!!!!!!! THE BEST WATCHES IN TOWN"),
+			wantAction: Hold,
+			wantReason: "content looks like spam: mostly capital letters, long runs of a repeated character",
+		},
+		{
+			name: "Repetitive shouting with links",
+			content: text("CHEAP CHEAP CHEAP CHEAP CHEAP CHEAP CHEAP WATCHES AT LOW PRICES",
+				"http://a.example http://b.example http://c.example http://d.example"),
+			wantAction: Reject,
+			wantReason: "content looks like spam: 4 links, mostly capital letters, the same word over and over",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			verdict := scorer.Check(tc.content)
+			assert.Equal(t, tc.wantAction, verdict.Action)
+			if tc.wantReason != "" {
+				assert.Equal(t, []string{tc.wantReason}, verdict.Reasons)
+			}
+		})
+	}
+
+	assert.Equal(t, Verdict{}, SpamScorer{}.Check(text(strings.Repeat("SPAM ", 20))), "zero scores disable the scorer")
+}
+
+func TestNewAccountRestriction(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	filter := NewAccountRestriction{MinAge: 24 * time.Hour, Now: func() time.Time { return now }}
+
+	newUser := &data.User{CreatedAt: now.Add(-time.Hour)}
+	oldUser := &data.User{CreatedAt: now.Add(-48 * time.Hour)}
+
+	held := filter.Check(Content{Author: newUser, Text: []string{"Visit https://example.com"}})
+	assert.Equal(t, Hold, held.Action)
+	assert.Equal(t, []string{"links from new accounts are held for moderation"}, held.Reasons)
+
+	assert.Equal(t, Verdict{}, filter.Check(Content{Author: newUser, Text: []string{"No links here"}}))
+	assert.Equal(t, Verdict{}, filter.Check(Content{Author: oldUser, Text: []string{"Visit https://example.com"}}))
+}
+
+type fixedFilter Verdict
+
+func (f fixedFilter) Check(Content) Verdict { return Verdict(f) }
+
+func TestChain(t *testing.T) {
+	t.Parallel()
+
+	hold1 := fixedFilter{Action: Hold, Reasons: []string{"first"}}
+	hold2 := fixedFilter{Action: Hold, Reasons: []string{"second"}}
+	reject := fixedFilter{Action: Reject, Reasons: []string{"rejected"}}
+	allow := fixedFilter{}
+
+	assert.Equal(t, Verdict{}, Chain{}.Check(text("anything")))
+	assert.Equal(t, Verdict{}, Chain{allow}.Check(text("anything")))
+	assert.Equal(t, Verdict{Action: Hold, Reasons: []string{"first", "second"}}, Chain{hold1, allow, hold2}.Check(text("anything")))
+	assert.Equal(t, Verdict{Action: Reject, Reasons: []string{"rejected"}}, Chain{hold1, reject, hold2}.Check(text("anything")))
+}
+
+func TestParseAction(t *testing.T) {
+	t.Parallel()
+
+	for _, action := range []Action{Allow, Hold, Reject} {
+		parsed, err := ParseAction(action.String())
+		require.NoError(t, err)
+		assert.Equal(t, action, parsed)
+	}
+
+	_, err := ParseAction("quarantine")
+	assert.Error(t, err)
+}
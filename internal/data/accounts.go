@@ -0,0 +1,371 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/96malhar/realworld-backend/internal/validator"
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+)
+
+// UserAccount is the view of a user's account shown to admins.
+type UserAccount struct {
+	ID                    int64      `json:"id"`
+	Username              string     `json:"username"`
+	Email                 string     `json:"email"`
+	Bio                   string     `json:"bio"`
+	Image                 string     `json:"image"`
+	Role                  string     `json:"role"`
+	CreatedAt             time.Time  `json:"createdAt"`
+	SuspendedAt           *time.Time `json:"suspendedAt"`
+	SuspensionReason      string     `json:"suspensionReason,omitempty"`
+	PasswordResetRequired bool       `json:"passwordResetRequired"`
+}
+
+// Account returns the admin view of the user's account.
+func (u *User) Account() UserAccount {
+	return UserAccount{
+		ID:                    u.ID,
+		Username:              u.Username,
+		Email:                 u.Email,
+		Bio:                   u.Bio,
+		Image:                 u.Image,
+		Role:                  u.Role,
+		CreatedAt:             u.CreatedAt,
+		SuspendedAt:           u.SuspendedAt,
+		SuspensionReason:      u.SuspensionReason,
+		PasswordResetRequired: u.PasswordResetRequired,
+	}
+}
+
+// Account statuses users can be filtered by.
+const (
+	AccountStatusActive    = "active"
+	AccountStatusSuspended = "suspended"
+)
+
+// UserFilters holds filtering and pagination parameters for listing users.
+type UserFilters struct {
+	Search string // Case-insensitive substring of the username or email address
+	Role   string // Filter by role
+	Status string // Filter by account status, active or suspended
+	Limit  int    // Maximum number of users to return
+	Offset int    // Number of users to skip (for pagination)
+}
+
+// Validate checks that the UserFilters fields are valid.
+// Pagination parameters are validated and normalized by the readPagination helper.
+func (f UserFilters) Validate(v *validator.Validator) {
+	v.Check(len(f.Search) <= 100, "search must not be more than 100 characters")
+	if f.Role != "" {
+		ValidateRole(v, f.Role)
+	}
+	if f.Status != "" {
+		v.Check(validator.PermittedValue(f.Status, AccountStatusActive, AccountStatusSuspended),
+			"status must be one of active or suspended")
+	}
+}
+
+// likeEscaper escapes the wildcard characters of LIKE patterns.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// List returns a page of user accounts matching the filters, newest first, and the total number of matches.
+func (s UserStore) List(filters UserFilters) ([]UserAccount, int, error) {
+	qb := sq.Select(
+		"id", "username", "email", "image", "bio", "role",
+		"created_at", "suspended_at", "suspension_reason", "password_reset_required",
+		"COUNT(*) OVER() AS total_count",
+	).
+		From("users").
+		Where("deleted_at IS NULL").
+		PlaceholderFormat(sq.Dollar)
+
+	if filters.Search != "" {
+		pattern := "%" + likeEscaper.Replace(filters.Search) + "%"
+		qb = qb.Where("(username ILIKE ? OR email ILIKE ?)", pattern, pattern)
+	}
+	if filters.Role != "" {
+		qb = qb.Where("role = ?", filters.Role)
+	}
+	switch filters.Status {
+	case AccountStatusActive:
+		qb = qb.Where("suspended_at IS NULL")
+	case AccountStatusSuspended:
+		qb = qb.Where("suspended_at IS NOT NULL")
+	}
+
+	qb = qb.OrderBy("created_at DESC", "id DESC").
+		Limit(uint64(filters.Limit)).
+		Offset(uint64(filters.Offset))
+
+	query, args, err := qb.ToSql()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	rows, err := s.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	accounts := []UserAccount{}
+	totalCount := 0
+	for rows.Next() {
+		var a UserAccount
+		err := rows.Scan(&a.ID, &a.Username, &a.Email, &a.Image, &a.Bio, &a.Role,
+			&a.CreatedAt, &a.SuspendedAt, &a.SuspensionReason, &a.PasswordResetRequired, &totalCount)
+		if err != nil {
+			return nil, 0, err
+		}
+		accounts = append(accounts, a)
+	}
+
+	return accounts, totalCount, rows.Err()
+}
+
+// changeAccount runs an admin action against the user's account in a transaction together with its
+// audit entry, and drops the user from the cache so that the change applies to their next request.
+// A nil actor records an action taken by the system. If change reports that nothing changed, no
+// audit entry is written.
+func (s UserStore) changeAccount(user *User, actor *User, action string, details any, change func(ctx context.Context, tx pgx.Tx) (bool, error)) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	err := pgx.BeginFunc(ctx, s.db, func(tx pgx.Tx) error {
+		changed, err := change(ctx, tx)
+		if err != nil || !changed {
+			return err
+		}
+		return insertAuditEntry(ctx, tx, actor, action, AuditTargetUser, user.ID, details)
+	})
+	if err != nil {
+		return err
+	}
+
+	if s.userCache != nil {
+		s.userCache.Delete(user.ID)
+	}
+
+	return nil
+}
+
+// updateAccount runs query, which must return the user's version, as an admin action against the account.
+// A query that matches no rows means the account already was in the requested state.
+func (s UserStore) updateAccount(user *User, actor *User, action string, details any, query string, args ...any) error {
+	return s.changeAccount(user, actor, action, details, func(ctx context.Context, tx pgx.Tx) (bool, error) {
+		err := tx.QueryRow(ctx, query, args...).Scan(&user.Version)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return false, nil
+			}
+			return false, err
+		}
+		return true, nil
+	})
+}
+
+// SetRole changes the user's role on behalf of actor and records the change in the audit log.
+// A nil actor records a change made by the system, such as when setting up the first admin.
+// Setting the role a user already has is a no-op and is not audited.
+func (s UserStore) SetRole(user *User, role string, actor *User) error {
+	query := `
+		UPDATE users
+		SET role = $1, version = version + 1
+		WHERE id = $2 AND role <> $1
+		RETURNING version`
+
+	details := map[string]string{"username": user.Username, "from": user.Role, "to": role}
+	err := s.updateAccount(user, actor, AuditUserRoleChanged, details, query, role, user.ID)
+	if err != nil {
+		return err
+	}
+
+	user.Role = role
+	return nil
+}
+
+// Suspend suspends the user's account, which rejects their requests until it is unsuspended.
+// Suspending a suspended account keeps the original suspension.
+func (s UserStore) Suspend(user *User, reason string, actor *User) error {
+	query := `
+		UPDATE users
+		SET suspended_at = NOW() AT TIME ZONE 'UTC', suspension_reason = $1, version = version + 1
+		WHERE id = $2 AND suspended_at IS NULL
+		RETURNING version, suspended_at, suspension_reason`
+
+	details := map[string]string{"username": user.Username, "reason": reason}
+	return s.changeAccount(user, actor, AuditUserSuspended, details, func(ctx context.Context, tx pgx.Tx) (bool, error) {
+		err := tx.QueryRow(ctx, query, reason, user.ID).Scan(&user.Version, &user.SuspendedAt, &user.SuspensionReason)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return false, nil
+			}
+			return false, err
+		}
+		return true, nil
+	})
+}
+
+// Unsuspend lifts the suspension of the user's account.
+func (s UserStore) Unsuspend(user *User, actor *User) error {
+	query := `
+		UPDATE users
+		SET suspended_at = NULL, suspension_reason = '', version = version + 1
+		WHERE id = $1 AND suspended_at IS NOT NULL
+		RETURNING version`
+
+	details := map[string]string{"username": user.Username}
+	err := s.updateAccount(user, actor, AuditUserUnsuspended, details, query, user.ID)
+	if err != nil {
+		return err
+	}
+
+	user.SuspendedAt = nil
+	user.SuspensionReason = ""
+	return nil
+}
+
+// RequirePasswordReset makes the user change their password before they can do anything else.
+func (s UserStore) RequirePasswordReset(user *User, actor *User) error {
+	query := `
+		UPDATE users
+		SET password_reset_required = TRUE, version = version + 1
+		WHERE id = $1 AND NOT password_reset_required
+		RETURNING version`
+
+	details := map[string]string{"username": user.Username}
+	err := s.updateAccount(user, actor, AuditUserPasswordResetForced, details, query, user.ID)
+	if err != nil {
+		return err
+	}
+
+	user.PasswordResetRequired = true
+	return nil
+}
+
+// Delete removes the user's account. Their articles, comments, follows and favorites are deleted with it.
+func (s UserStore) Delete(user *User, actor *User) error {
+	details := map[string]string{"username": user.Username, "email": user.Email}
+	return s.changeAccount(user, actor, AuditUserDeleted, details, func(ctx context.Context, tx pgx.Tx) (bool, error) {
+		if err := deleteUser(ctx, tx, user.ID); err != nil {
+			return false, err
+		}
+		return true, insertOutboxEvent(ctx, tx, AggregateUser, user.ID, EventUserDeleted, newUserEvent(user))
+	})
+}
+
+// Policies for what happens to the content of users who delete their own account.
+const (
+	// DeletionPolicyDelete deletes the account together with everything the user created.
+	DeletionPolicyDelete = "delete"
+	// DeletionPolicyAnonymize scrubs the account of personal data and removes the user's follows, blocks,
+	// mutes, bookmarks, notifications and webhooks. Their articles, comments, favorites and reactions are
+	// kept and stay attributed to the anonymized account.
+	DeletionPolicyAnonymize = "anonymize"
+)
+
+var DeletionPolicies = []string{DeletionPolicyDelete, DeletionPolicyAnonymize}
+
+// deletedUsernamePrefix starts the username of anonymized accounts. Users cannot choose usernames
+// with this prefix, so anonymizing an account never conflicts with an existing username.
+const deletedUsernamePrefix = "deleted-user-"
+
+// DeleteAccount deletes the user's own account under the deletion policy. Deleting your own account
+// is not a privileged action, so it is not recorded in the audit log.
+func (s UserStore) DeleteAccount(user *User, policy string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	err := pgx.BeginFunc(ctx, s.db, func(tx pgx.Tx) error {
+		var err error
+		if policy == DeletionPolicyAnonymize {
+			err = anonymizeUser(ctx, tx, user.ID)
+		} else {
+			err = deleteUser(ctx, tx, user.ID)
+		}
+		if err != nil {
+			return err
+		}
+		return insertOutboxEvent(ctx, tx, AggregateUser, user.ID, EventUserDeleted, newUserEvent(user))
+	})
+	if err != nil {
+		return err
+	}
+
+	if s.userCache != nil {
+		s.userCache.Delete(user.ID)
+	}
+
+	return nil
+}
+
+// deleteUser deletes the user, and with them everything they created. The favorite counts of the
+// articles they favorited are kept by the application, so they are decremented first.
+func deleteUser(ctx context.Context, tx pgx.Tx, userID int64) error {
+	query := `
+		UPDATE articles
+		SET favorites_count = GREATEST(favorites_count - 1, 0)
+		WHERE id IN (SELECT article_id FROM favorites WHERE user_id = $1)`
+
+	_, err := tx.Exec(ctx, query, userID)
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.Exec(ctx, `DELETE FROM users WHERE id = $1`, userID)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrRecordNotFound
+	}
+	return nil
+}
+
+// anonymizeUser scrubs the user's account of personal data and removes their relations to other users
+// under the anonymize policy. The account can no longer be logged in to or looked up, and only its
+// published articles are kept.
+func anonymizeUser(ctx context.Context, tx pgx.Tx, userID int64) error {
+	query := `
+		UPDATE users
+		SET username = $2 || id, email = $2 || id, password_hash = ''::bytea, bio = '', image = '',
+		    suspension_reason = '', deleted_at = NOW() AT TIME ZONE 'UTC', version = version + 1
+		WHERE id = $1 AND deleted_at IS NULL`
+
+	result, err := tx.Exec(ctx, query, userID, deletedUsernamePrefix)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrRecordNotFound
+	}
+
+	cleanup := []string{
+		// Nobody can publish the user's drafts anymore, and scheduled articles must not be published
+		`DELETE FROM articles WHERE author_id = $1 AND status <> 'published'`,
+		`DELETE FROM follows WHERE follower_id = $1 OR followed_id = $1`,
+		`DELETE FROM blocks WHERE blocker_id = $1 OR blocked_id = $1`,
+		`DELETE FROM mutes WHERE muter_id = $1 OR muted_id = $1`,
+		`DELETE FROM bookmarks WHERE user_id = $1`,
+		`DELETE FROM notifications WHERE recipient_id = $1`,
+		`DELETE FROM webhooks WHERE user_id = $1`,
+		`DELETE FROM user_exports WHERE user_id = $1`,
+		`DELETE FROM user_two_factor WHERE user_id = $1`,
+		`DELETE FROM user_recovery_codes WHERE user_id = $1`,
+		`DELETE FROM user_identities WHERE user_id = $1`,
+		`DELETE FROM oidc_auth_requests WHERE user_id = $1`,
+	}
+	for _, query := range cleanup {
+		if _, err := tx.Exec(ctx, query, userID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -3,7 +3,7 @@ package data
 import (
 	"context"
 	"errors"
-	"math/rand"
+	"fmt"
 	"regexp"
 	"sort"
 	"strings"
@@ -11,24 +11,51 @@ import (
 
 	"github.com/96malhar/realworld-backend/internal/validator"
 	sq "github.com/Masterminds/squirrel"
+	"github.com/gosimple/unidecode"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type Article struct {
-	ID             int64     `json:"-"`
-	Slug           string    `json:"slug"`
-	Title          string    `json:"title"`
-	Description    string    `json:"description"`
-	Body           string    `json:"body,omitempty"`
-	TagList        []string  `json:"tagList"`
-	CreatedAt      time.Time `json:"createdAt"`
-	UpdatedAt      time.Time `json:"updatedAt"`
-	FavoritesCount int       `json:"favoritesCount"`
-	Favorited      bool      `json:"favorited"`
-	AuthorID       int64     `json:"-"`
-	Author         Profile   `json:"author"`
-	Version        int       `json:"-"`
+	ID             int64          `json:"-"`
+	Slug           string         `json:"slug"`
+	Title          string         `json:"title"`
+	Description    string         `json:"description"`
+	Body           string         `json:"body,omitempty"`
+	BodyHTML       string         `json:"bodyHtml,omitempty"`
+	TagList        []string       `json:"tagList"`
+	CreatedAt      time.Time      `json:"createdAt"`
+	UpdatedAt      time.Time      `json:"updatedAt"`
+	FavoritesCount int            `json:"favoritesCount"`
+	Favorited      bool           `json:"favorited"`
+	Bookmarked     bool           `json:"bookmarked"`
+	Reactions      map[string]int `json:"reactions,omitempty"`
+	MyReactions    []string       `json:"myReactions,omitempty"`
+	AuthorID       int64          `json:"-"`
+	Author         Profile        `json:"author"`
+	Version        int            `json:"-"`
+	Status         string         `json:"status"`
+	PublishAt      *time.Time     `json:"publishAt,omitempty"`
+	PublishedAt    *time.Time     `json:"publishedAt,omitempty"`
+	Hidden         bool           `json:"hidden,omitempty"`
+	DeletedAt      *time.Time     `json:"deletedAt,omitempty"`
+
+	// HoldReasons are the reasons the content filter gave for holding the article back for moderation.
+	// When set, saving the article hides it and files a report on behalf of the filter.
+	HoldReasons []string `json:"-"`
+}
+
+// Article publication statuses. Only published articles are visible to users other than the author.
+const (
+	ArticleStatusDraft     = "draft"
+	ArticleStatusPublished = "published"
+	ArticleStatusScheduled = "scheduled"
+)
+
+// IsPublished returns true if the article is visible to everyone.
+func (a *Article) IsPublished() bool {
+	return a.Status == ArticleStatusPublished
 }
 
 func ValidateArticle(v *validator.Validator, article *Article) {
@@ -41,38 +68,68 @@ func ValidateArticle(v *validator.Validator, article *Article) {
 		"Body must not be empty or whitespace only")
 
 	v.Check(validator.Unique(article.TagList), "TagList must not contain duplicate tags")
+
+	v.Check(validator.PermittedValue(article.Status, ArticleStatusDraft, ArticleStatusPublished, ArticleStatusScheduled),
+		"Status must be one of draft, published or scheduled")
+	if article.Status == ArticleStatusScheduled {
+		v.Check(article.PublishAt != nil, "PublishAt must be provided for scheduled articles")
+	}
 }
 
+// ValidatePublishAt checks that a scheduled article is due to be published after now. It is only
+// checked when an article is scheduled, as a scheduled article that has fallen due must still be editable.
+func ValidatePublishAt(v *validator.Validator, article *Article, now time.Time) {
+	if article.Status == ArticleStatusScheduled && article.PublishAt != nil {
+		v.Check(article.PublishAt.After(now), "PublishAt must be in the future")
+	}
+}
+
+// MaxSlugLength is the maximum length of a generated slug, excluding any numeric suffix
+// added to keep it unique.
+const MaxSlugLength = 80
+
+// nonAlphanumericRX matches runs of characters that are not allowed in a slug.
+var nonAlphanumericRX = regexp.MustCompile(`[^a-z0-9]+`)
+
 // GenerateSlug generates a URL-friendly slug from the article title.
+// The title is transliterated to ASCII, so titles in other scripts still produce readable slugs.
+// Slugs are deterministic; the store appends a numeric suffix if the slug is already taken.
 func (a *Article) GenerateSlug() {
-	slug := strings.ToLower(a.Title)
-	slug = strings.ReplaceAll(slug, " ", "-")
-
-	// Remove non-alphanumeric characters except hyphens
-	reg := regexp.MustCompile(`[^a-z0-9\-]`)
-	slug = reg.ReplaceAllString(slug, "")
+	slug := unidecode.Unidecode(a.Title)
+	slug = strings.ToLower(slug)
 
-	// Remove multiple consecutive hyphens
-	reg = regexp.MustCompile(`-+`)
-	slug = reg.ReplaceAllString(slug, "-")
+	// Drop apostrophes so that "Alice's" becomes "alices" rather than "alice-s"
+	slug = strings.NewReplacer("'", "", "’", "").Replace(slug)
 
-	// Trim hyphens from start and end
+	// Collapse everything else that is not alphanumeric into single hyphens
+	slug = nonAlphanumericRX.ReplaceAllString(slug, "-")
 	slug = strings.Trim(slug, "-")
 
-	// Append a random string to ensure uniqueness
-	slug = slug + "-" + randomString(6)
+	if len(slug) > MaxSlugLength {
+		slug = slug[:MaxSlugLength]
+		// Cut at a word boundary unless that would discard most of the slug
+		if i := strings.LastIndex(slug, "-"); i > MaxSlugLength/2 {
+			slug = slug[:i]
+		}
+		slug = strings.Trim(slug, "-")
+	}
+
+	// Titles made up entirely of symbols have nothing to transliterate
+	if slug == "" {
+		slug = "article"
+	}
 
 	a.Slug = slug
 }
 
-// RandomString generates a random string of specified length using lowercase letters and numbers
-func randomString(length int) string {
-	const charset = "abcdefghijklmnopqrstuvwxyz0123456789"
-	b := make([]byte, length)
-	for i := range b {
-		b[i] = charset[rand.Intn(len(charset))]
+// utcTime converts an optional timestamp to UTC before it is written to a TIMESTAMP column,
+// which stores wall-clock time without a time zone.
+func utcTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
 	}
-	return string(b)
+	utc := t.UTC()
+	return &utc
 }
 
 // SortTags sorts the article's tags alphabetically for consistent ordering
@@ -93,44 +150,63 @@ func (s *ArticleStore) InsertAndReturn(article *Article, currentUser *User) (*Ar
 	article.GenerateSlug()
 	article.SortTags()
 
-	// Insert the article - only return fields we don't already have
+	// Insert the article and record its first revision - only return fields we don't already have
 	query := `
-		INSERT INTO articles (slug, title, description, body, tag_list, author_id)
-		VALUES ($1, $2, $3, $4, $5, $6)
-		RETURNING id, created_at, updated_at, favorites_count, version
+		WITH inserted AS (
+			INSERT INTO articles (slug, title, description, body, tag_list, author_id, status, publish_at, published_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, CASE WHEN $7 = 'published' THEN (NOW() AT TIME ZONE 'UTC') END)
+			RETURNING id, title, description, body, created_at, updated_at, published_at, favorites_count, version
+		),
+		revision AS (
+			INSERT INTO article_revisions (article_id, version, title, description, body, created_at)
+			SELECT id, version, title, description, body, created_at FROM inserted
+		)
+		SELECT id, created_at, updated_at, published_at, favorites_count, version FROM inserted
 	`
 
-	args := []any{
-		article.Slug, article.Title, article.Description, article.Body,
-		article.TagList, article.AuthorID,
-	}
-
 	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
 	defer cancel()
 
-	// Scan only the fields we don't already have into the input object
-	err := s.db.QueryRow(ctx, query, args...).Scan(
-		&article.ID,
-		&article.CreatedAt,
-		&article.UpdatedAt,
-		&article.FavoritesCount,
-		&article.Version,
-	)
-	if err != nil {
-		return nil, err
-	}
-
 	// Use author information from currentUser context instead of querying database
 	// Following is always false for newly created articles (user doesn't follow themselves)
 	article.Author = currentUser.ToProfile(false)
 	// Newly created articles cannot be favorited yet
 	article.Favorited = false
 
-	// Insert tags into tags table synchronously
-	if len(article.TagList) > 0 {
-		if err := s.InsertTags(article.TagList...); err != nil {
-			return nil, err
-		}
+	// The article, its tags and the outbox event are written in one transaction;
+	// the whole transaction is retried if the slug is taken
+	err := s.withUniqueSlug(ctx, article, func() error {
+		return pgx.BeginFunc(ctx, s.db, func(tx pgx.Tx) error {
+			args := []any{
+				article.Slug, article.Title, article.Description, article.Body,
+				article.TagList, article.AuthorID, article.Status, utcTime(article.PublishAt),
+			}
+			// Scan only the fields we don't already have into the input object
+			err := tx.QueryRow(ctx, query, args...).Scan(
+				&article.ID,
+				&article.CreatedAt,
+				&article.UpdatedAt,
+				&article.PublishedAt,
+				&article.FavoritesCount,
+				&article.Version,
+			)
+			if err != nil {
+				return err
+			}
+
+			if err := insertTags(ctx, tx, article.TagList); err != nil {
+				return err
+			}
+
+			if err := holdArticle(ctx, tx, article); err != nil {
+				return err
+			}
+
+			return insertOutboxEvent(ctx, tx, AggregateArticle, article.ID, EventArticleCreated, newArticleEvent(article))
+		})
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return article, nil
@@ -138,15 +214,21 @@ func (s *ArticleStore) InsertAndReturn(article *Article, currentUser *User) (*Ar
 
 // GetIDBySlug retrieves just the article ID by its slug.
 // This is a lightweight alternative to GetBySlug when only the ID is needed.
-func (s *ArticleStore) GetIDBySlug(slug string) (int64, error) {
-	query := `SELECT id FROM articles WHERE slug = $1`
+// Only published articles are resolved, since drafts and trashed articles cannot be commented on.
+// Like GetBySlug, hidden articles are only resolved for their author and moderators.
+func (s *ArticleStore) GetIDBySlug(slug string, currentUser *User) (int64, error) {
+	query := `
+		SELECT id FROM articles
+		WHERE slug = $1 AND status = 'published' AND deleted_at IS NULL
+		  AND (hidden_at IS NULL OR author_id = $2 OR $3)
+	`
 
 	var articleID int64
 
 	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
 	defer cancel()
 
-	err := s.db.QueryRow(ctx, query, slug).Scan(&articleID)
+	err := s.db.QueryRow(ctx, query, slug, viewerID(currentUser), seesHiddenContent(currentUser)).Scan(&articleID)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return 0, ErrRecordNotFound
@@ -157,14 +239,48 @@ func (s *ArticleStore) GetIDBySlug(slug string) (int64, error) {
 	return articleID, nil
 }
 
+// ResolveSlug returns the current slug of the article identified by slug, which may be
+// either its current slug or one it had before its title changed. Like GetBySlug, articles
+// that are not yet published are only resolved for their author, and articles in the trash not at all.
+func (s *ArticleStore) ResolveSlug(slug string, currentUser *User) (string, error) {
+	query := `
+		SELECT COALESCE(
+			(SELECT slug FROM articles WHERE slug = $1 AND deleted_at IS NULL AND (status = 'published' OR author_id = $2)),
+			(SELECT a.slug FROM article_slugs h JOIN articles a ON a.id = h.article_id
+			 WHERE h.slug = $1 AND a.deleted_at IS NULL AND (a.status = 'published' OR a.author_id = $2))
+		)
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	var current *string
+	err := s.db.QueryRow(ctx, query, slug, viewerID(currentUser)).Scan(&current)
+	if err != nil {
+		return "", err
+	}
+
+	if current == nil {
+		return "", ErrRecordNotFound
+	}
+
+	return *current, nil
+}
+
 // GetBySlug retrieves an article by its slug.
+// Articles that are not yet published are only visible to their author, and hidden
+// articles only to their author and moderators. Articles in the trash are not visible to anyone.
 func (s *ArticleStore) GetBySlug(slug string, currentUser *User) (*Article, error) {
 	query := `
 		SELECT a.id, a.slug, a.title, a.description, a.body, a.tag_list, a.created_at, a.updated_at, 
-		       a.favorites_count, a.version, u.id, u.username, u.bio, u.image
+		       a.favorites_count, a.version, a.status, a.publish_at, a.published_at, a.hidden_at IS NOT NULL,
+		       u.id, u.username, u.bio, u.image,
+		       bm.user_id IS NOT NULL AS bookmarked
 		FROM articles a
 		JOIN users u ON a.author_id = u.id
-		WHERE a.slug = $1
+		LEFT JOIN bookmarks bm ON a.id = bm.article_id AND bm.user_id = $2
+		WHERE a.slug = $1 AND a.deleted_at IS NULL AND (a.status = 'published' OR a.author_id = $2)
+		  AND (a.hidden_at IS NULL OR a.author_id = $2 OR $3)
 	`
 
 	var article Article
@@ -173,7 +289,7 @@ func (s *ArticleStore) GetBySlug(slug string, currentUser *User) (*Article, erro
 	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
 	defer cancel()
 
-	err := s.db.QueryRow(ctx, query, slug).Scan(
+	err := s.db.QueryRow(ctx, query, slug, viewerID(currentUser), seesHiddenContent(currentUser)).Scan(
 		&article.ID,
 		&article.Slug,
 		&article.Title,
@@ -184,10 +300,15 @@ func (s *ArticleStore) GetBySlug(slug string, currentUser *User) (*Article, erro
 		&article.UpdatedAt,
 		&article.FavoritesCount,
 		&article.Version,
+		&article.Status,
+		&article.PublishAt,
+		&article.PublishedAt,
+		&article.Hidden,
 		&article.AuthorID,
 		&author.Username,
 		&author.Bio,
 		&author.Image,
+		&article.Bookmarked,
 	)
 	if err != nil {
 		switch {
@@ -208,7 +329,12 @@ func (s *ArticleStore) GetBySlug(slug string, currentUser *User) (*Article, erro
 		}
 		article.Favorited = favorited
 	}
-	return &article, nil
+
+	articles := []Article{article}
+	if err := setArticleReactions(ctx, s.db, articles, viewerID(currentUser)); err != nil {
+		return nil, err
+	}
+	return &articles[0], nil
 }
 
 func (s *ArticleStore) checkArticleFavorited(articleID, userID int64) (bool, error) {
@@ -226,19 +352,22 @@ func (s *ArticleStore) checkArticleFavorited(articleID, userID int64) (bool, err
 }
 
 // FavoriteBySlug favorites an article for the given user and returns the updated article.
-// Uses a single CTE query for optimal performance - no separate transaction needed.
+// Returns ErrBlocked if the author of the article has blocked the user.
+// The favorite and its outbox event, if the favorite changed, are written in one transaction.
 func (s *ArticleStore) FavoriteBySlug(slug string, userID int64) (*Article, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
 	defer cancel()
 
 	// Single optimized query using CTE to:
-	// 1. Look up article ID from slug
+	// 1. Look up article ID from slug, unless the author has blocked the user
 	// 2. Insert favorite (idempotent with ON CONFLICT DO NOTHING)
 	// 3. Update favorites_count only if a new favorite was inserted
 	// 4. Return complete article with author, favorited, and following status
 	query := `
 		WITH article_lookup AS (
-			SELECT id FROM articles WHERE slug = $1
+			SELECT id FROM articles
+			WHERE slug = $1 AND status = 'published' AND deleted_at IS NULL
+			  AND NOT EXISTS (SELECT 1 FROM blocks WHERE blocker_id = articles.author_id AND blocked_id = $2)
 		),
 		favorite_insert AS (
 			INSERT INTO favorites (user_id, article_id)
@@ -252,7 +381,7 @@ func (s *ArticleStore) FavoriteBySlug(slug string, userID int64) (*Article, erro
 			FROM favorite_insert fi
 			WHERE a.id = fi.article_id
 			RETURNING a.id, a.slug, a.title, a.description, a.body, a.tag_list,
-			          a.created_at, a.updated_at, a.favorites_count, a.version, a.author_id
+			          a.created_at, a.updated_at, a.favorites_count, a.version, a.author_id, a.status, a.published_at
 		)
 		SELECT COALESCE(uc.id, a.id), 
 		       COALESCE(uc.slug, a.slug),
@@ -265,27 +394,42 @@ func (s *ArticleStore) FavoriteBySlug(slug string, userID int64) (*Article, erro
 		       COALESCE(uc.favorites_count, a.favorites_count),
 		       COALESCE(uc.version, a.version),
 		       COALESCE(uc.author_id, a.author_id),
+		       COALESCE(uc.status, a.status),
+		       COALESCE(uc.published_at, a.published_at),
 		       u.username, u.bio, u.image,
 		       true AS favorited,
-		       EXISTS(SELECT 1 FROM follows WHERE followed_id = a.author_id AND follower_id = $2) AS following
+		       EXISTS(SELECT 1 FROM follows WHERE followed_id = a.author_id AND follower_id = $2) AS following,
+		       EXISTS(SELECT 1 FROM bookmarks WHERE article_id = a.id AND user_id = $2) AS bookmarked,
+		       EXISTS(SELECT 1 FROM blocks WHERE blocker_id = a.author_id AND blocked_id = $2) AS blocked,
+		       EXISTS(SELECT 1 FROM favorite_insert) AS changed
 		FROM articles a
 		LEFT JOIN update_count uc ON a.slug = $1
 		JOIN users u ON a.author_id = u.id
-		WHERE a.slug = $1
+		WHERE a.slug = $1 AND a.status = 'published' AND a.deleted_at IS NULL
 	`
 
 	var article Article
 	var author Profile
-	var following bool
-
-	err := s.db.QueryRow(ctx, query, slug, userID).Scan(
-		&article.ID, &article.Slug, &article.Title, &article.Description,
-		&article.Body, &article.TagList, &article.CreatedAt, &article.UpdatedAt,
-		&article.FavoritesCount, &article.Version, &article.AuthorID,
-		&author.Username, &author.Bio, &author.Image,
-		&article.Favorited,
-		&following,
-	)
+	var following, blocked bool
+
+	err := pgx.BeginFunc(ctx, s.db, func(tx pgx.Tx) error {
+		var changed bool
+		err := tx.QueryRow(ctx, query, slug, userID).Scan(
+			&article.ID, &article.Slug, &article.Title, &article.Description,
+			&article.Body, &article.TagList, &article.CreatedAt, &article.UpdatedAt,
+			&article.FavoritesCount, &article.Version, &article.AuthorID, &article.Status, &article.PublishedAt,
+			&author.Username, &author.Bio, &author.Image,
+			&article.Favorited,
+			&following,
+			&article.Bookmarked,
+			&blocked,
+			&changed,
+		)
+		if err != nil || !changed {
+			return err
+		}
+		return insertOutboxEvent(ctx, tx, AggregateArticle, article.ID, EventArticleFavorited, newFavoriteEvent(&article, userID))
+	})
 
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -294,6 +438,10 @@ func (s *ArticleStore) FavoriteBySlug(slug string, userID int64) (*Article, erro
 		return nil, err
 	}
 
+	if blocked {
+		return nil, ErrBlocked
+	}
+
 	author.Following = following
 	article.Author = author
 
@@ -301,7 +449,7 @@ func (s *ArticleStore) FavoriteBySlug(slug string, userID int64) (*Article, erro
 }
 
 // UnfavoriteBySlug unfavorites an article for the given user and returns the updated article.
-// Uses a single CTE query for optimal performance - no separate transaction needed.
+// The removal and its outbox event, if a favorite was removed, are written in one transaction.
 func (s *ArticleStore) UnfavoriteBySlug(slug string, userID int64) (*Article, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
 	defer cancel()
@@ -313,7 +461,7 @@ func (s *ArticleStore) UnfavoriteBySlug(slug string, userID int64) (*Article, er
 	// 4. Return complete article with author, favorited, and following status
 	query := `
 		WITH article_lookup AS (
-			SELECT id FROM articles WHERE slug = $1
+			SELECT id FROM articles WHERE slug = $1 AND status = 'published' AND deleted_at IS NULL
 		),
 		favorite_delete AS (
 			DELETE FROM favorites
@@ -327,7 +475,7 @@ func (s *ArticleStore) UnfavoriteBySlug(slug string, userID int64) (*Article, er
 			FROM favorite_delete fd
 			WHERE a.id = fd.article_id
 			RETURNING a.id, a.slug, a.title, a.description, a.body, a.tag_list,
-			          a.created_at, a.updated_at, a.favorites_count, a.version, a.author_id
+			          a.created_at, a.updated_at, a.favorites_count, a.version, a.author_id, a.status, a.published_at
 		)
 		SELECT COALESCE(uc.id, a.id),
 		       COALESCE(uc.slug, a.slug),
@@ -340,27 +488,40 @@ func (s *ArticleStore) UnfavoriteBySlug(slug string, userID int64) (*Article, er
 		       COALESCE(uc.favorites_count, a.favorites_count),
 		       COALESCE(uc.version, a.version),
 		       COALESCE(uc.author_id, a.author_id),
+		       COALESCE(uc.status, a.status),
+		       COALESCE(uc.published_at, a.published_at),
 		       u.username, u.bio, u.image,
 		       false AS favorited,
-		       EXISTS(SELECT 1 FROM follows WHERE followed_id = a.author_id AND follower_id = $2) AS following
+		       EXISTS(SELECT 1 FROM follows WHERE followed_id = a.author_id AND follower_id = $2) AS following,
+		       EXISTS(SELECT 1 FROM bookmarks WHERE article_id = a.id AND user_id = $2) AS bookmarked,
+		       EXISTS(SELECT 1 FROM favorite_delete) AS changed
 		FROM articles a
 		LEFT JOIN update_count uc ON a.slug = $1
 		JOIN users u ON a.author_id = u.id
-		WHERE a.slug = $1
+		WHERE a.slug = $1 AND a.status = 'published' AND a.deleted_at IS NULL
 	`
 
 	var article Article
 	var author Profile
 	var following bool
 
-	err := s.db.QueryRow(ctx, query, slug, userID).Scan(
-		&article.ID, &article.Slug, &article.Title, &article.Description,
-		&article.Body, &article.TagList, &article.CreatedAt, &article.UpdatedAt,
-		&article.FavoritesCount, &article.Version, &article.AuthorID,
-		&author.Username, &author.Bio, &author.Image,
-		&article.Favorited,
-		&following,
-	)
+	err := pgx.BeginFunc(ctx, s.db, func(tx pgx.Tx) error {
+		var changed bool
+		err := tx.QueryRow(ctx, query, slug, userID).Scan(
+			&article.ID, &article.Slug, &article.Title, &article.Description,
+			&article.Body, &article.TagList, &article.CreatedAt, &article.UpdatedAt,
+			&article.FavoritesCount, &article.Version, &article.AuthorID, &article.Status, &article.PublishedAt,
+			&author.Username, &author.Bio, &author.Image,
+			&article.Favorited,
+			&following,
+			&article.Bookmarked,
+			&changed,
+		)
+		if err != nil || !changed {
+			return err
+		}
+		return insertOutboxEvent(ctx, tx, AggregateArticle, article.ID, EventArticleUnfavorited, newFavoriteEvent(&article, userID))
+	})
 
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -375,87 +536,372 @@ func (s *ArticleStore) UnfavoriteBySlug(slug string, userID int64) (*Article, er
 	return &article, nil
 }
 
-func (s *ArticleStore) DeleteBySlug(slug string, authorID int64) error {
+// Bookmark adds the article to the user's private reading list.
+// Bookmarking an article twice is a no-op.
+func (s *ArticleStore) Bookmark(articleID, userID int64) error {
 	query := `
-		DELETE FROM articles
-		WHERE slug = $1 AND author_id = $2
+		INSERT INTO bookmarks (user_id, article_id)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id, article_id) DO NOTHING
 	`
 
 	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
 	defer cancel()
 
-	result, err := s.db.Exec(ctx, query, slug, authorID)
+	_, err := s.db.Exec(ctx, query, userID, articleID)
+	return err
+}
+
+// Unbookmark removes the article from the user's reading list, if it is there.
+func (s *ArticleStore) Unbookmark(articleID, userID int64) error {
+	query := `DELETE FROM bookmarks WHERE user_id = $1 AND article_id = $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	_, err := s.db.Exec(ctx, query, userID, articleID)
+	return err
+}
+
+// DeleteBySlug deletes the article with the given slug on behalf of actor. Articles deleted by their
+// author are moved to the trash, from which the author can restore them until they are purged.
+// Actors with the articles:delete:any permission may also delete other users' articles, which
+// deletes them permanently and is recorded in the audit log.
+func (s *ArticleStore) DeleteBySlug(slug string, actor *User) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	return pgx.BeginFunc(ctx, s.db, func(tx pgx.Tx) error {
+		return deleteArticle(ctx, tx, slug, actor)
+	})
+}
+
+// deleteArticle deletes an article as part of the transaction, as described by DeleteBySlug.
+// Articles already in the trash can only be deleted, permanently, by other users.
+func deleteArticle(ctx context.Context, tx pgx.Tx, slug string, actor *User) error {
+	query := `
+		SELECT id, slug, title, status, author_id, deleted_at IS NOT NULL
+		FROM articles
+		WHERE slug = $1 AND (author_id = $2 OR $3)
+		FOR UPDATE
+	`
+
+	var deleted articleSummary
+	var authorID int64
+	var trashed bool
+	err := tx.QueryRow(ctx, query, slug, actor.ID, actor.HasPermission(PermissionArticlesDeleteAny)).
+		Scan(&deleted.ID, &deleted.Slug, &deleted.Title, &deleted.Status, &authorID, &trashed)
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrRecordNotFound
+		}
 		return err
 	}
 
-	if result.RowsAffected() == 0 {
-		return ErrRecordNotFound
+	if authorID == actor.ID {
+		if trashed {
+			return ErrRecordNotFound
+		}
+		_, err := tx.Exec(ctx, `UPDATE articles SET deleted_at = (NOW() AT TIME ZONE 'UTC') WHERE id = $1`, deleted.ID)
+		if err != nil {
+			return err
+		}
+	} else {
+		if _, err := tx.Exec(ctx, `DELETE FROM articles WHERE id = $1`, deleted.ID); err != nil {
+			return err
+		}
+		err := insertAuditEntry(ctx, tx, actor, AuditArticleDeleted, AuditTargetArticle, deleted.ID, deleted)
+		if err != nil {
+			return err
+		}
 	}
 
-	return nil
+	// Subscribers were told about the deletion when the article was moved to the trash
+	if trashed {
+		return nil
+	}
+	return insertOutboxEvent(ctx, tx, AggregateArticle, deleted.ID, EventArticleDeleted, articleSummaryEvent{deleted})
 }
 
-func (s *ArticleStore) Update(article *Article) error {
+// Restore moves the author's article with the given slug out of the trash.
+func (s *ArticleStore) Restore(slug string, author *User) error {
 	query := `
 		UPDATE articles
-		SET title = $1, description = $2, body = $3, slug = $4, updated_at = (NOW() AT TIME ZONE 'UTC'), version = version + 1
-		WHERE id = $5 AND version = $6
-		RETURNING updated_at, version
+		SET deleted_at = NULL
+		WHERE slug = $1 AND author_id = $2 AND deleted_at IS NOT NULL
+		RETURNING id, slug, title, status
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	return pgx.BeginFunc(ctx, s.db, func(tx pgx.Tx) error {
+		var restored articleSummary
+		err := tx.QueryRow(ctx, query, slug, author.ID).Scan(&restored.ID, &restored.Slug, &restored.Title, &restored.Status)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrRecordNotFound
+			}
+			return err
+		}
+		return insertOutboxEvent(ctx, tx, AggregateArticle, restored.ID, EventArticleRestored, articleSummaryEvent{restored})
+	})
+}
+
+// PurgeTrash permanently deletes articles that have been in the trash for longer than retention,
+// together with their comments and favorites, and returns how many were deleted.
+func (s *ArticleStore) PurgeTrash(retention time.Duration) (int64, error) {
+	query := `
+		DELETE FROM articles
+		WHERE deleted_at < (NOW() AT TIME ZONE 'UTC') - make_interval(secs => $1)
 	`
 
-	args := []any{
-		article.Title,
-		article.Description,
-		article.Body,
-		article.Slug,
-		article.ID,
-		article.Version,
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	result, err := s.db.Exec(ctx, query, retention.Seconds())
+	if err != nil {
+		return 0, err
 	}
+	return result.RowsAffected(), nil
+}
+
+// Update saves changes to an article and records the new content as a revision.
+// If the slug changed, the previous slug is kept in the slug history so old links still resolve.
+// Publishing a draft or scheduled article also writes an article.published event.
+// ErrEditConflict is returned if the article was modified since it was read. Changes made by
+// anyone other than the author are recorded in the audit log.
+func (s *ArticleStore) Update(article *Article, actor *User) error {
+	query := `
+		WITH previous AS (
+			SELECT id, slug, status FROM articles WHERE id = $7
+		),
+		updated AS (
+			UPDATE articles
+			SET title = $1, description = $2, body = $3, slug = $4, status = $5, publish_at = $6,
+			    published_at = CASE WHEN $5 = 'published' THEN COALESCE(published_at, (NOW() AT TIME ZONE 'UTC')) END,
+			    updated_at = (NOW() AT TIME ZONE 'UTC'), version = version + 1
+			WHERE id = $7 AND version = $8 AND deleted_at IS NULL
+			RETURNING id, title, description, body, updated_at, published_at, version
+		),
+		revision AS (
+			INSERT INTO article_revisions (article_id, version, title, description, body, created_at)
+			SELECT id, version, title, description, body, updated_at FROM updated
+		),
+		old_slug AS (
+			INSERT INTO article_slugs (slug, article_id)
+			SELECT p.slug, p.id FROM previous p JOIN updated u ON u.id = p.id
+			WHERE p.slug <> $4
+			ON CONFLICT (slug) DO UPDATE SET article_id = EXCLUDED.article_id
+		),
+		reused_slug AS (
+			DELETE FROM article_slugs
+			WHERE slug = $4 AND article_id = $7 AND EXISTS (SELECT 1 FROM updated)
+		)
+		SELECT u.updated_at, u.published_at, u.version, p.status FROM updated u JOIN previous p ON p.id = u.id
+	`
 
 	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
 	defer cancel()
 
-	err := s.db.QueryRow(ctx, query, args...).Scan(&article.UpdatedAt, &article.Version)
+	err := s.withUniqueSlug(ctx, article, func() error {
+		return pgx.BeginFunc(ctx, s.db, func(tx pgx.Tx) error {
+			args := []any{
+				article.Title,
+				article.Description,
+				article.Body,
+				article.Slug,
+				article.Status,
+				utcTime(article.PublishAt),
+				article.ID,
+				article.Version,
+			}
+			var previousStatus string
+			err := tx.QueryRow(ctx, query, args...).Scan(&article.UpdatedAt, &article.PublishedAt, &article.Version, &previousStatus)
+			if err != nil {
+				if errors.Is(err, pgx.ErrNoRows) {
+					return ErrEditConflict
+				}
+				return err
+			}
+
+			if err := insertTags(ctx, tx, article.TagList); err != nil {
+				return err
+			}
+
+			if err := holdArticle(ctx, tx, article); err != nil {
+				return err
+			}
+
+			if article.AuthorID != actor.ID {
+				details := articleSummary{Slug: article.Slug, Title: article.Title, Status: article.Status}
+				err := insertAuditEntry(ctx, tx, actor, AuditArticleUpdated, AuditTargetArticle, article.ID, details)
+				if err != nil {
+					return err
+				}
+			}
+
+			err = insertOutboxEvent(ctx, tx, AggregateArticle, article.ID, EventArticleUpdated, newArticleEvent(article))
+			if err != nil || previousStatus == ArticleStatusPublished || !article.IsPublished() {
+				return err
+			}
+
+			published := articleSummary{ID: article.ID, Slug: article.Slug, Title: article.Title, Status: article.Status, Hidden: article.Hidden}
+			return insertOutboxEvent(ctx, tx, AggregateArticle, article.ID, EventArticlePublished, articleSummaryEvent{published})
+		})
+	})
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return ErrEditConflict
-		}
 		return err
 	}
 
-	if len(article.TagList) > 0 {
-		if err = s.InsertTags(article.TagList...); err != nil {
+	return nil
+}
+
+// holdArticle holds the article back for moderation as part of the transaction if the content filter asked for it.
+func holdArticle(ctx context.Context, tx pgx.Tx, article *Article) error {
+	if len(article.HoldReasons) == 0 {
+		return nil
+	}
+
+	if err := holdContent(ctx, tx, article.ID, nil, article.HoldReasons); err != nil {
+		return err
+	}
+	article.Hidden = true
+	return nil
+}
+
+// maxSlugAttempts bounds how often a write is retried after its slug collided with another article.
+const maxSlugAttempts = 5
+
+// errSlugInHistory is returned by checkSlugHistory when the slug is a previous slug of another article.
+var errSlugInHistory = errors.New("slug is a previous slug of another article")
+
+// withUniqueSlug runs write, which stores article.Slug, and retries it with a numeric suffix
+// appended to the slug whenever the slug is already used by another article, either as its
+// current slug or as one it had before, so that old links keep pointing at the right article.
+func (s *ArticleStore) withUniqueSlug(ctx context.Context, article *Article, write func() error) error {
+	base := article.Slug
+
+	for attempt := 1; ; attempt++ {
+		err := s.checkSlugHistory(ctx, article)
+		if err == nil {
+			err = write()
+		}
+		if !(isSlugConflict(err) || errors.Is(err, errSlugInHistory)) || attempt == maxSlugAttempts {
 			return err
 		}
 
+		suffix, err := s.nextSlugSuffix(ctx, base)
+		if err != nil {
+			return err
+		}
+		article.Slug = fmt.Sprintf("%s-%d", base, suffix)
 	}
+}
+
+// checkSlugHistory returns errSlugInHistory if article.Slug is a previous slug of another article.
+// An article may take back one of its own previous slugs.
+func (s *ArticleStore) checkSlugHistory(ctx context.Context, article *Article) error {
+	query := `SELECT EXISTS(SELECT 1 FROM article_slugs WHERE slug = $1 AND article_id <> $2)`
 
+	var taken bool
+	if err := s.db.QueryRow(ctx, query, article.Slug, article.ID).Scan(&taken); err != nil {
+		return err
+	}
+	if taken {
+		return errSlugInHistory
+	}
 	return nil
 }
 
-func (s *ArticleStore) InsertTags(tags ...string) error {
-	query := `INSERT INTO tags (tag) SELECT UNNEST($1::text[]) ON CONFLICT (tag) DO NOTHING`
+// nextSlugSuffix returns the next free numeric suffix for slugs derived from base,
+// e.g. 3 if "base" and "base-2" are taken as current or previous slugs.
+func (s *ArticleStore) nextSlugSuffix(ctx context.Context, base string) (int, error) {
+	query := `
+		SELECT COALESCE(MAX(SUBSTRING(slug FROM LENGTH($1) + 2)::integer), 1) + 1
+		FROM (SELECT slug FROM articles UNION ALL SELECT slug FROM article_slugs) s
+		WHERE slug LIKE $1 || '-%' AND SUBSTRING(slug FROM LENGTH($1) + 2) ~ '^[0-9]{1,9}$'
+	`
+
+	var suffix int
+	err := s.db.QueryRow(ctx, query, base).Scan(&suffix)
+	return suffix, err
+}
+
+// isSlugConflict reports whether err is a unique violation (SQLSTATE 23505) on the article slug.
+func isSlugConflict(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == "articles_slug_key"
+}
+
+// PublishScheduled publishes every scheduled article whose publish time has passed
+// and returns the number of articles that were published. The articles are dated by their
+// scheduled publish time. Articles in the trash are left scheduled.
+func (s *ArticleStore) PublishScheduled() (int64, error) {
+	query := `
+		UPDATE articles
+		SET status = 'published', published_at = publish_at, publish_at = NULL, updated_at = (NOW() AT TIME ZONE 'UTC')
+		WHERE status = 'scheduled' AND publish_at <= (NOW() AT TIME ZONE 'UTC') AND deleted_at IS NULL
+		RETURNING id, slug, title, status, hidden_at IS NOT NULL
+	`
 
 	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
 	defer cancel()
 
-	_, err := s.db.Exec(ctx, query, tags)
+	var count int64
+	err := pgx.BeginFunc(ctx, s.db, func(tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, query)
+		if err != nil {
+			return err
+		}
+		published, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (articleSummary, error) {
+			var a articleSummary
+			err := row.Scan(&a.ID, &a.Slug, &a.Title, &a.Status, &a.Hidden)
+			return a, err
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, a := range published {
+			if err := insertOutboxEvent(ctx, tx, AggregateArticle, a.ID, EventArticlePublished, articleSummaryEvent{a}); err != nil {
+				return err
+			}
+		}
+
+		count = int64(len(published))
+		return nil
+	})
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	return nil
+	return count, nil
+}
+
+// insertTags adds the article's tags to the tags table as part of the article's transaction.
+func insertTags(ctx context.Context, tx pgx.Tx, tags []string) error {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	query := `INSERT INTO tags (tag) SELECT UNNEST($1::text[]) ON CONFLICT (tag) DO NOTHING`
+
+	_, err := tx.Exec(ctx, query, tags)
+	return err
 }
 
 // ArticleFilters holds filtering and pagination parameters for listing articles
 type ArticleFilters struct {
-	Tag       string // Filter articles by tag name (exact match)
-	Author    string // Filter articles by author username
-	Favorited string // Filter articles favorited by a specific username
-	Feed      bool   // If true, only return articles from users that the current user follows
-	Limit     int    // Maximum number of articles to return
-	Offset    int    // Number of articles to skip (for pagination)
+	Tag        string // Filter articles by tag name (exact match)
+	Author     string // Filter articles by author username
+	Favorited  string // Filter articles favorited by a specific username
+	Feed       bool   // If true, only return articles from users that the current user follows
+	Bookmarked bool   // If true, only return articles bookmarked by the current user
+	Trashed    bool   // If true, only return the current user's articles in the trash, most recently deleted first
+	Status     string // Filter by publication status; non-published statuses only return the current user's articles
+	Limit      int    // Maximum number of articles to return
+	Offset     int    // Number of articles to skip (for pagination)
 }
 
 // alphanumericRX validates strings containing only alphanumeric characters, underscores, and hyphens.
@@ -486,17 +932,24 @@ func (f ArticleFilters) Validate(v *validator.Validator) {
 		v.Check(len(f.Favorited) >= 1, "Favorited username must not be empty")
 		v.Check(alphanumericRX.MatchString(f.Favorited), "Favorited username must contain only alphanumeric characters, hyphens, and underscores")
 	}
+
+	if f.Status != "" {
+		v.Check(validator.PermittedValue(f.Status, ArticleStatusDraft, ArticleStatusPublished, ArticleStatusScheduled),
+			"Status must be one of draft, published or scheduled")
+	}
 }
 
 // List retrieves articles with optional filtering and pagination.
-// Returns articles ordered by most recent first (created_at DESC).
+// Returns articles ordered by most recently published first; unpublished articles by creation time.
 // Uses JOINs to efficiently fetch favorited and following status in a single query.
+// Only published articles are listed unless a draft or scheduled status is requested,
+// in which case the results are restricted to the current user's own articles.
+// Articles by authors the current user has muted are never listed, and hidden articles are
+// only listed for their author and moderators. Articles in the trash are only listed when the
+// trash is requested.
 func (s *ArticleStore) List(filters ArticleFilters, currentUser *User) ([]Article, int, error) {
 	// Use -1 for anonymous users (will never match real user IDs, so JOINs return NULL/false)
-	userID := int64(-1)
-	if currentUser != nil && !currentUser.IsAnonymous() {
-		userID = currentUser.ID
-	}
+	userID := viewerID(currentUser)
 
 	// Build base query using Squirrel - always include favorited and following columns
 	// Note: body is excluded from list results for performance
@@ -504,15 +957,18 @@ func (s *ArticleStore) List(filters ArticleFilters, currentUser *User) ([]Articl
 	qb := sq.Select(
 		"a.id", "a.slug", "a.title", "a.description", "a.tag_list",
 		"a.created_at", "a.updated_at", "a.author_id", "a.version", "a.favorites_count",
+		"a.status", "a.publish_at", "a.published_at", "a.hidden_at IS NOT NULL", "a.deleted_at",
 		"u.username", "u.bio", "u.image",
 		"COALESCE(fav.user_id IS NOT NULL, false) AS favorited",
 		"COALESCE(fol.follower_id IS NOT NULL, false) AS following",
+		"COALESCE(bm.user_id IS NOT NULL, false) AS bookmarked",
 		"COUNT(*) OVER() AS total_count",
 	).
 		From("articles a").
 		Join("users u ON a.author_id = u.id").
 		LeftJoin("favorites fav ON a.id = fav.article_id AND fav.user_id = ?", userID).
 		LeftJoin("follows fol ON a.author_id = fol.followed_id AND fol.follower_id = ?", userID).
+		LeftJoin("bookmarks bm ON a.id = bm.article_id AND bm.user_id = ?", userID).
 		PlaceholderFormat(sq.Dollar)
 
 	// Handle feed filter - only show articles from followed users
@@ -525,6 +981,39 @@ func (s *ArticleStore) List(filters ArticleFilters, currentUser *User) ([]Articl
 		qb = qb.Join("follows f ON a.author_id = f.followed_id AND f.follower_id = ?", userID)
 	}
 
+	// Hidden articles are only listed for their author and moderators
+	if !seesHiddenContent(currentUser) {
+		qb = qb.Where("(a.hidden_at IS NULL OR a.author_id = ?)", userID)
+	}
+
+	// Articles by users the current user has muted are hidden everywhere, including the feed
+	if userID != -1 {
+		qb = qb.Where("NOT EXISTS (SELECT 1 FROM mutes m WHERE m.muter_id = ? AND m.muted_id = a.author_id)", userID)
+	}
+
+	// Handle bookmarks filter - only show articles in the current user's reading list
+	if filters.Bookmarked {
+		// Anonymous users have no bookmarks
+		if userID == -1 {
+			return []Article{}, 0, nil
+		}
+		qb = qb.Where("bm.user_id IS NOT NULL")
+	}
+
+	// Feeds only ever contain published articles; other statuses and the trash are private to their author
+	switch {
+	case filters.Trashed && userID == -1:
+		return []Article{}, 0, nil
+	case filters.Trashed:
+		qb = qb.Where("a.deleted_at IS NOT NULL AND a.author_id = ?", userID)
+	case filters.Feed || filters.Status == "" || filters.Status == ArticleStatusPublished:
+		qb = qb.Where("a.status = ?", ArticleStatusPublished)
+	case userID == -1:
+		return []Article{}, 0, nil
+	default:
+		qb = qb.Where("a.status = ? AND a.author_id = ?", filters.Status, userID)
+	}
+
 	// Add WHERE conditions based on filters
 	if filters.Tag != "" {
 		qb = qb.Where("? = ANY(a.tag_list)", filters.Tag)
@@ -540,9 +1029,21 @@ func (s *ArticleStore) List(filters ArticleFilters, currentUser *User) ([]Articl
 		)`, filters.Favorited))
 	}
 
+	if !filters.Trashed {
+		qb = qb.Where("a.deleted_at IS NULL")
+	}
+
+	// Reading lists show the most recently bookmarked articles first, and the trash the most recently deleted
+	if filters.Bookmarked {
+		qb = qb.OrderBy("bm.created_at DESC")
+	}
+	if filters.Trashed {
+		qb = qb.OrderBy("a.deleted_at DESC")
+	}
+
 	// Add ordering and pagination
 	query, args, err := qb.
-		OrderBy("a.created_at DESC").
+		OrderBy("COALESCE(a.published_at, a.created_at) DESC").
 		Limit(uint64(filters.Limit)).
 		Offset(uint64(filters.Offset)).
 		ToSql()
@@ -567,7 +1068,7 @@ func (s *ArticleStore) List(filters ArticleFilters, currentUser *User) ([]Articl
 	for rows.Next() {
 		var article Article
 		var author Profile
-		var favorited, following bool
+		var favorited, following, bookmarked bool
 
 		err := rows.Scan(
 			&article.ID,
@@ -580,11 +1081,17 @@ func (s *ArticleStore) List(filters ArticleFilters, currentUser *User) ([]Articl
 			&article.AuthorID,
 			&article.Version,
 			&article.FavoritesCount,
+			&article.Status,
+			&article.PublishAt,
+			&article.PublishedAt,
+			&article.Hidden,
+			&article.DeletedAt,
 			&author.Username,
 			&author.Bio,
 			&author.Image,
 			&favorited,
 			&following,
+			&bookmarked,
 			&totalCount,
 		)
 		if err != nil {
@@ -592,6 +1099,7 @@ func (s *ArticleStore) List(filters ArticleFilters, currentUser *User) ([]Articl
 		}
 
 		article.Favorited = favorited
+		article.Bookmarked = bookmarked
 		// Don't set following to true if current user is the author
 		if currentUser != nil && article.AuthorID == currentUser.ID {
 			author.Following = false
@@ -612,5 +1120,10 @@ func (s *ArticleStore) List(filters ArticleFilters, currentUser *User) ([]Articl
 		articles = []Article{}
 	}
 
+	// Reaction counts for the whole page come from one grouped query rather than one per article
+	if err = setArticleReactions(ctx, s.db, articles, userID); err != nil {
+		return nil, 0, err
+	}
+
 	return articles, totalCount, nil
 }
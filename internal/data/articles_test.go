@@ -0,0 +1,77 @@
+package data
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArticle_GenerateSlug(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		title string
+		want  string
+	}{
+		{name: "plain ASCII title", title: "How to Train Your Dragon", want: "how-to-train-your-dragon"},
+		{name: "punctuation is collapsed", title: "Hello,   World!! -- Again?", want: "hello-world-again"},
+		{name: "apostrophes are dropped", title: "Alice's Article", want: "alices-article"},
+		{name: "accented Latin", title: "Crème brûlée à la française", want: "creme-brulee-a-la-francaise"},
+		{name: "German sharp s", title: "Straße", want: "strasse"},
+		{name: "Cyrillic", title: "Привет мир", want: "privet-mir"},
+		{name: "Greek", title: "Ελληνικά", want: "ellenika"},
+		{name: "CJK", title: "你好世界", want: "ni-hao-shi-jie"},
+		{name: "symbols only", title: "!!! ???", want: "article"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			article := Article{Title: tt.title}
+			article.GenerateSlug()
+			assert.Equal(t, tt.want, article.Slug)
+		})
+	}
+
+	t.Run("slug is deterministic", func(t *testing.T) {
+		a := Article{Title: "Same Title"}
+		b := Article{Title: "Same Title"}
+		a.GenerateSlug()
+		b.GenerateSlug()
+		assert.Equal(t, a.Slug, b.Slug)
+	})
+
+	t.Run("long titles are truncated at a word boundary", func(t *testing.T) {
+		article := Article{Title: strings.Repeat("word ", 40)}
+		article.GenerateSlug()
+		assert.LessOrEqual(t, len(article.Slug), MaxSlugLength)
+		assert.False(t, strings.HasSuffix(article.Slug, "-"))
+		assert.True(t, strings.HasSuffix(article.Slug, "word"))
+	})
+}
+
+func TestNewArticleEvent(t *testing.T) {
+	t.Parallel()
+
+	article := &Article{
+		Slug:        "slug",
+		Title:       "Title",
+		Favorited:   true,
+		Bookmarked:  true,
+		MyReactions: []string{"like"},
+		Reactions:   map[string]int{"like": 1},
+		Author:      Profile{Username: "alice", Following: true},
+	}
+
+	event := newArticleEvent(article)
+	assert.False(t, event.Article.Favorited)
+	assert.False(t, event.Article.Bookmarked)
+	assert.Nil(t, event.Article.MyReactions)
+	assert.False(t, event.Article.Author.Following)
+	assert.Equal(t, map[string]int{"like": 1}, event.Article.Reactions, "counts are the same for everyone")
+
+	// The article returned to the editor is left alone
+	assert.True(t, article.Favorited)
+	assert.True(t, article.Author.Following)
+}
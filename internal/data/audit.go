@@ -0,0 +1,108 @@
+package data
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Audited actions. An action is audited when a user exercises a permission beyond acting on their own content.
+const (
+	AuditArticleUpdated          = "article.updated"
+	AuditArticleDeleted          = "article.deleted"
+	AuditArticleHidden           = "article.hidden"
+	AuditArticleApproved         = "article.approved"
+	AuditCommentDeleted          = "comment.deleted"
+	AuditCommentHidden           = "comment.hidden"
+	AuditCommentApproved         = "comment.approved"
+	AuditReportDismissed         = "report.dismissed"
+	AuditUserRoleChanged         = "user.role_changed"
+	AuditUserSuspended           = "user.suspended"
+	AuditUserUnsuspended         = "user.unsuspended"
+	AuditUserPasswordResetForced = "user.password_reset_forced"
+	AuditUserDeleted             = "user.deleted"
+)
+
+// Target types of audit log entries.
+const (
+	AuditTargetArticle = "article"
+	AuditTargetComment = "comment"
+	AuditTargetUser    = "user"
+	AuditTargetReport  = "report"
+)
+
+// AuditEntry records a privileged action. Actor is empty if the action was not taken by a user,
+// or if the acting user has since been deleted.
+type AuditEntry struct {
+	ID         int64           `json:"id"`
+	Actor      string          `json:"actor"`
+	Action     string          `json:"action"`
+	TargetType string          `json:"targetType"`
+	TargetID   int64           `json:"targetId"`
+	Details    json.RawMessage `json:"details"`
+	CreatedAt  time.Time       `json:"createdAt"`
+}
+
+// insertAuditEntry records a privileged action with the JSON encoding of details as part of the transaction,
+// so that the action and its audit entry are committed together. A nil actor records an action taken by the system.
+func insertAuditEntry(ctx context.Context, tx pgx.Tx, actor *User, action, targetType string, targetID int64, details any) error {
+	js, err := json.Marshal(details)
+	if err != nil {
+		return err
+	}
+
+	var actorID *int64
+	if actor != nil {
+		actorID = &actor.ID
+	}
+
+	query := `
+		INSERT INTO audit_log (actor_id, action, target_type, target_id, details)
+		VALUES ($1, $2, $3, $4, $5::jsonb)
+	`
+
+	_, err = tx.Exec(ctx, query, actorID, action, targetType, targetID, string(js))
+	return err
+}
+
+type AuditStore struct {
+	db      *pgxpool.Pool
+	timeout time.Duration
+}
+
+// GetAll returns a page of audit log entries, most recent first, and the total number of entries.
+func (s *AuditStore) GetAll(limit, offset int) ([]AuditEntry, int, error) {
+	query := `
+		SELECT l.id, COALESCE(u.username, ''), l.action, l.target_type, l.target_id, l.details, l.created_at,
+		       COUNT(*) OVER() AS total_count
+		FROM audit_log l
+		LEFT JOIN users u ON u.id = l.actor_id
+		ORDER BY l.created_at DESC, l.id DESC
+		LIMIT $1 OFFSET $2
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	rows, err := s.db.Query(ctx, query, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	entries := []AuditEntry{}
+	totalCount := 0
+	for rows.Next() {
+		var e AuditEntry
+		err := rows.Scan(&e.ID, &e.Actor, &e.Action, &e.TargetType, &e.TargetID, &e.Details, &e.CreatedAt, &totalCount)
+		if err != nil {
+			return nil, 0, err
+		}
+		entries = append(entries, e)
+	}
+
+	return entries, totalCount, rows.Err()
+}
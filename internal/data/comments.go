@@ -2,20 +2,45 @@ package data
 
 import (
 	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/96malhar/realworld-backend/internal/validator"
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// ErrInvalidCursor is returned when a comment pagination cursor cannot be decoded.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// MaxCommentDepth is the maximum nesting level of replies. Top-level comments have depth 0.
+const MaxCommentDepth = 5
+
 type Comment struct {
-	ID        int64     `json:"id"`
-	Body      string    `json:"body"`
-	ArticleID int64     `json:"-"`
-	AuthorID  int64     `json:"-"`
-	CreatedAt time.Time `json:"createdAt"`
-	UpdatedAt time.Time `json:"updatedAt"`
-	Author    Profile   `json:"author"`
+	ID          int64          `json:"id"`
+	Body        string         `json:"body"`
+	BodyHTML    string         `json:"bodyHtml,omitempty"`
+	ArticleID   int64          `json:"-"`
+	AuthorID    int64          `json:"-"`
+	ParentID    *int64         `json:"parentId"`
+	Depth       int            `json:"-"`
+	ReplyCount  int            `json:"replyCount"`
+	Deleted     bool           `json:"deleted"`
+	Hidden      bool           `json:"hidden,omitempty"`
+	Reactions   map[string]int `json:"reactions,omitempty"`
+	MyReactions []string       `json:"myReactions,omitempty"`
+	CreatedAt   time.Time      `json:"createdAt"`
+	UpdatedAt   time.Time      `json:"updatedAt"`
+	Author      Profile        `json:"author"`
+
+	// HoldReasons are the reasons the content filter gave for holding the comment back for moderation.
+	// When set, inserting the comment hides it and files a report on behalf of the filter.
+	HoldReasons []string `json:"-"`
 }
 
 func ValidateComment(v *validator.Validator, comment *Comment) {
@@ -23,6 +48,13 @@ func ValidateComment(v *validator.Validator, comment *Comment) {
 		"Body must not be empty or whitespace only")
 }
 
+// ValidateReply checks that a reply can be attached to the given parent comment.
+func ValidateReply(v *validator.Validator, comment *Comment, parent *Comment) {
+	v.Check(parent.ArticleID == comment.ArticleID, "parentId must refer to a comment on the same article")
+	v.Check(!parent.Deleted, "cannot reply to a deleted comment")
+	v.Check(parent.Depth+1 <= MaxCommentDepth, fmt.Sprintf("replies must not be nested more than %d levels deep", MaxCommentDepth))
+}
+
 type CommentStore struct {
 	db      *pgxpool.Pool
 	timeout time.Duration
@@ -30,54 +62,221 @@ type CommentStore struct {
 
 // InsertAndReturn inserts a comment and populates it with database-generated fields and author details.
 // Modifies the input comment object in place and uses currentUser from context instead of querying the database.
+// Returns ErrBlocked if the author of the article, or of the comment being replied to, has blocked the commenter.
 func (s *CommentStore) InsertAndReturn(comment *Comment, currentUser *User) (*Comment, error) {
+	// The depth of a reply is derived from its parent; top-level comments have depth 0.
+	// Nothing is inserted if the article's author or the parent comment's author has blocked the commenter.
 	query := `
-		INSERT INTO comments (body, article_id, author_id)
-		VALUES ($1, $2, $3)
-		RETURNING id, created_at, updated_at
+		INSERT INTO comments (body, article_id, author_id, parent_id, depth)
+		SELECT $1, $2, $3, $4, COALESCE((SELECT depth + 1 FROM comments WHERE id = $4), 0)
+		WHERE NOT EXISTS (
+			SELECT 1 FROM articles a
+			JOIN blocks b ON b.blocker_id = a.author_id
+			WHERE a.id = $2 AND b.blocked_id = $3
+		)
+		AND NOT EXISTS (
+			SELECT 1 FROM comments p
+			JOIN blocks b ON b.blocker_id = p.author_id
+			WHERE p.id = $4 AND b.blocked_id = $3
+		)
+		RETURNING id, depth, created_at, updated_at,
+			(SELECT slug FROM articles WHERE id = $2), (SELECT status FROM articles WHERE id = $2)
 	`
 
-	args := []any{comment.Body, comment.ArticleID, comment.AuthorID}
+	args := []any{comment.Body, comment.ArticleID, comment.AuthorID, comment.ParentID}
 
 	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
 	defer cancel()
 
-	// Scan only the fields we don't already have into the input object
-	err := s.db.QueryRow(ctx, query, args...).Scan(&comment.ID, &comment.CreatedAt, &comment.UpdatedAt)
-	if err != nil {
-		return nil, err
-	}
-
 	// Use author information from currentUser context instead of querying database
 	// Following is always false for newly created comments (user doesn't follow themselves)
 	comment.Author = currentUser.ToProfile(false)
 
+	err := pgx.BeginFunc(ctx, s.db, func(tx pgx.Tx) error {
+		// Scan only the fields we don't already have into the input object
+		payload := commentEvent{Comment: comment}
+		err := tx.QueryRow(ctx, query, args...).Scan(&comment.ID, &comment.Depth, &comment.CreatedAt, &comment.UpdatedAt,
+			&payload.ArticleSlug, &payload.ArticleStatus)
+		if err != nil {
+			return err
+		}
+
+		if len(comment.HoldReasons) > 0 {
+			if err := holdContent(ctx, tx, comment.ArticleID, &comment.ID, comment.HoldReasons); err != nil {
+				return err
+			}
+			comment.Hidden = true
+		}
+
+		return insertOutboxEvent(ctx, tx, AggregateArticle, comment.ArticleID, EventCommentCreated, payload)
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrBlocked
+		}
+		return nil, err
+	}
+
 	return comment, nil
 }
 
-// GetByArticleID retrieves all comments for an article by its article ID.
-// Returns comments with author details, ordered by creation time (newest first).
-// Uses JOIN to efficiently fetch author information in a single query.
-func (s *CommentStore) GetByArticleID(articleID int64) ([]Comment, error) {
+// GetByID retrieves a single comment by its ID, without author details.
+func (s *CommentStore) GetByID(id int64) (*Comment, error) {
 	query := `
-		SELECT c.id, c.body, c.article_id, c.author_id, c.created_at, c.updated_at,
-		       u.username, u.bio, u.image
-		FROM comments c
-		JOIN users u ON c.author_id = u.id
-		WHERE c.article_id = $1
-		ORDER BY c.created_at DESC
+		SELECT id, body, article_id, author_id, parent_id, depth, deleted_at IS NOT NULL, hidden_at IS NOT NULL,
+		       created_at, updated_at
+		FROM comments
+		WHERE id = $1
 	`
 
 	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
 	defer cancel()
 
-	rows, err := s.db.Query(ctx, query, articleID)
+	var comment Comment
+	err := s.db.QueryRow(ctx, query, id).Scan(
+		&comment.ID,
+		&comment.Body,
+		&comment.ArticleID,
+		&comment.AuthorID,
+		&comment.ParentID,
+		&comment.Depth,
+		&comment.Deleted,
+		&comment.Hidden,
+		&comment.CreatedAt,
+		&comment.UpdatedAt,
+	)
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrRecordNotFound
+		}
 		return nil, err
 	}
+
+	return &comment, nil
+}
+
+// Sort orders accepted when listing comments.
+const (
+	CommentSortNewest = "newest"
+	CommentSortOldest = "oldest"
+)
+
+// CommentFilters holds the pagination and ordering parameters for listing an article's comments.
+type CommentFilters struct {
+	Limit  int            // Maximum number of comments to return
+	Cursor *CommentCursor // Position to continue listing from; nil starts at the beginning
+	Sort   string         // Either CommentSortNewest or CommentSortOldest
+}
+
+// Validate checks that the CommentFilters fields are valid.
+// Note: Limit is validated and normalized by the readPagination helper before reaching this method.
+func (f CommentFilters) Validate(v *validator.Validator) {
+	v.Check(validator.PermittedValue(f.Sort, CommentSortNewest, CommentSortOldest),
+		"Sort must be one of newest or oldest")
+}
+
+// CommentCursor identifies the last comment of a page by its creation time and ID.
+// Comments are ordered by (created_at, id), so the pair is unique and stable across inserts.
+type CommentCursor struct {
+	CreatedAt time.Time
+	ID        int64
+}
+
+// Encode returns the opaque string form of the cursor handed out to clients.
+func (c CommentCursor) Encode() string {
+	raw := fmt.Sprintf("%d:%d", c.CreatedAt.UnixNano(), c.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeCommentCursor parses a cursor previously produced by CommentCursor.Encode.
+func DecodeCommentCursor(s string) (*CommentCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	nanos, id, found := strings.Cut(string(raw), ":")
+	if !found {
+		return nil, ErrInvalidCursor
+	}
+
+	createdAt, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	commentID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil || commentID < 1 {
+		return nil, ErrInvalidCursor
+	}
+
+	return &CommentCursor{CreatedAt: time.Unix(0, createdAt).UTC(), ID: commentID}, nil
+}
+
+// GetByArticleID retrieves a page of comments for an article by its article ID.
+// Comments are ordered by creation time, newest first unless filters.Sort asks for oldest first,
+// and paging uses the keyset (created_at, id) so pages stay consistent while new comments arrive.
+// Each comment carries its parent ID and number of direct replies, so clients can rebuild the thread tree.
+// Comments by users the current user has muted are left out of both the page and the total, as are
+// hidden comments unless the current user wrote them or is a moderator.
+// Returns the page, the total number of comments on the article, and a cursor for the next page
+// (nil when this is the last page).
+func (s *CommentStore) GetByArticleID(articleID int64, filters CommentFilters, currentUser *User) ([]Comment, int, *CommentCursor, error) {
+	userID := viewerID(currentUser)
+	seesHidden := seesHiddenContent(currentUser)
+
+	// Fetch one extra row to find out whether another page follows without a second query
+	qb := sq.Select(
+		"c.id", "c.body", "c.article_id", "c.author_id", "c.parent_id", "c.depth", "c.deleted_at IS NOT NULL",
+		"c.hidden_at IS NOT NULL", "COALESCE(rc.reply_count, 0)", "c.created_at", "c.updated_at",
+		"u.username", "u.bio", "u.image",
+	).
+		Column(`(
+			SELECT COUNT(*) FROM comments
+			WHERE article_id = ? AND author_id NOT IN (SELECT muted_id FROM mutes WHERE muter_id = ?)
+			  AND (hidden_at IS NULL OR author_id = ? OR ?)
+		) AS total_count`, articleID, userID, userID, seesHidden).
+		From("comments c").
+		Join("users u ON c.author_id = u.id").
+		LeftJoin(`(
+			SELECT parent_id, COUNT(*) AS reply_count
+			FROM comments
+			WHERE article_id = ? AND parent_id IS NOT NULL
+			GROUP BY parent_id
+		) rc ON rc.parent_id = c.id`, articleID).
+		Where("c.article_id = ?", articleID).
+		Where("NOT EXISTS (SELECT 1 FROM mutes m WHERE m.muter_id = ? AND m.muted_id = c.author_id)", userID).
+		Where("(c.hidden_at IS NULL OR c.author_id = ? OR ?)", userID, seesHidden).
+		Limit(uint64(filters.Limit + 1)).
+		PlaceholderFormat(sq.Dollar)
+
+	if filters.Sort == CommentSortOldest {
+		qb = qb.OrderBy("c.created_at ASC", "c.id ASC")
+		if filters.Cursor != nil {
+			qb = qb.Where("(c.created_at, c.id) > (?, ?)", filters.Cursor.CreatedAt, filters.Cursor.ID)
+		}
+	} else {
+		qb = qb.OrderBy("c.created_at DESC", "c.id DESC")
+		if filters.Cursor != nil {
+			qb = qb.Where("(c.created_at, c.id) < (?, ?)", filters.Cursor.CreatedAt, filters.Cursor.ID)
+		}
+	}
+
+	query, args, err := qb.ToSql()
+	if err != nil {
+		return nil, 0, nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	rows, err := s.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, 0, nil, err
+	}
 	defer rows.Close()
 
-	var comments []Comment
+	comments := []Comment{}
+	totalCount := 0
 	for rows.Next() {
 		var comment Comment
 		var author Profile
@@ -87,14 +286,20 @@ func (s *CommentStore) GetByArticleID(articleID int64) ([]Comment, error) {
 			&comment.Body,
 			&comment.ArticleID,
 			&comment.AuthorID,
+			&comment.ParentID,
+			&comment.Depth,
+			&comment.Deleted,
+			&comment.Hidden,
+			&comment.ReplyCount,
 			&comment.CreatedAt,
 			&comment.UpdatedAt,
 			&author.Username,
 			&author.Bio,
 			&author.Image,
+			&totalCount,
 		)
 		if err != nil {
-			return nil, err
+			return nil, 0, nil, err
 		}
 
 		comment.Author = author
@@ -102,15 +307,86 @@ func (s *CommentStore) GetByArticleID(articleID int64) ([]Comment, error) {
 	}
 
 	if err = rows.Err(); err != nil {
-		return nil, err
+		return nil, 0, nil, err
+	}
+
+	// A cursor past the last comment yields no rows, so the total has to be fetched separately
+	if len(comments) == 0 && filters.Cursor != nil {
+		countQuery := `
+			SELECT COUNT(*) FROM comments
+			WHERE article_id = $1 AND author_id NOT IN (SELECT muted_id FROM mutes WHERE muter_id = $2)
+			  AND (hidden_at IS NULL OR author_id = $2 OR $3)
+		`
+		err = s.db.QueryRow(ctx, countQuery, articleID, userID, seesHidden).Scan(&totalCount)
+		if err != nil {
+			return nil, 0, nil, err
+		}
 	}
 
-	// Return empty slice instead of nil if no comments found
-	if comments == nil {
-		comments = []Comment{}
+	var next *CommentCursor
+	if len(comments) > filters.Limit {
+		comments = comments[:filters.Limit]
+		last := comments[len(comments)-1]
+		next = &CommentCursor{CreatedAt: last.CreatedAt, ID: last.ID}
 	}
 
-	return comments, nil
+	return comments, totalCount, next, nil
+}
+
+// DeleteByID deletes a comment on the given article on behalf of actor. Actors may delete their own
+// comments, and other users' comments if they have the comments:delete:any permission; deleting
+// another user's comment is recorded in the audit log.
+// Comments that have replies are tombstoned instead: their body is cleared and they are
+// marked as deleted, so the thread structure below them is preserved.
+func (s *CommentStore) DeleteByID(id, articleID int64, actor *User) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	return pgx.BeginFunc(ctx, s.db, func(tx pgx.Tx) error {
+		return deleteComment(ctx, tx, id, articleID, actor)
+	})
+}
+
+// deleteComment deletes a comment as part of the transaction, as described by DeleteByID.
+func deleteComment(ctx context.Context, tx pgx.Tx, id, articleID int64, actor *User) error {
+	query := `
+		WITH target AS (
+			SELECT c.id, c.author_id, EXISTS(SELECT 1 FROM comments r WHERE r.parent_id = c.id) AS has_replies
+			FROM comments c
+			WHERE c.id = $1 AND c.article_id = $2 AND (c.author_id = $3 OR $4) AND c.deleted_at IS NULL
+		),
+		tombstoned AS (
+			UPDATE comments
+			SET body = '', deleted_at = (NOW() AT TIME ZONE 'UTC'), updated_at = (NOW() AT TIME ZONE 'UTC')
+			WHERE id IN (SELECT id FROM target WHERE has_replies)
+			RETURNING id
+		),
+		removed AS (
+			DELETE FROM comments
+			WHERE id IN (SELECT id FROM target WHERE NOT has_replies)
+			RETURNING id
+		)
+		SELECT author_id FROM target
+	`
+
+	var authorID int64
+	err := tx.QueryRow(ctx, query, id, articleID, actor.ID, actor.HasPermission(PermissionCommentsDeleteAny)).Scan(&authorID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrRecordNotFound
+		}
+		return err
+	}
+
+	if authorID != actor.ID {
+		details := map[string]int64{"articleId": articleID, "authorId": authorID}
+		err := insertAuditEntry(ctx, tx, actor, AuditCommentDeleted, AuditTargetComment, id, details)
+		if err != nil {
+			return err
+		}
+	}
+
+	return insertOutboxEvent(ctx, tx, AggregateArticle, articleID, EventCommentDeleted, map[string]int64{"commentId": id})
 }
 
 // SetFollowingStatus efficiently checks and sets the following status for all comment authors.
@@ -169,3 +445,28 @@ func (s *CommentStore) SetFollowingStatus(comments []Comment, currentUserID int6
 
 	return nil
 }
+
+// SetReactions fills in the reaction counts and the current user's own reactions for all comments
+// using a single grouped query. Anonymous users (ID 0) simply have no reactions of their own.
+func (s *CommentStore) SetReactions(comments []Comment, currentUserID int64) error {
+	ids := make([]int64, len(comments))
+	for i := range comments {
+		ids[i] = comments[i].ID
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	summaries, err := loadReactions(ctx, s.db, "comment_id", ids, currentUserID)
+	if err != nil {
+		return err
+	}
+
+	for i := range comments {
+		if summary, ok := summaries[comments[i].ID]; ok {
+			comments[i].Reactions = summary.Counts
+			comments[i].MyReactions = summary.Mine
+		}
+	}
+	return nil
+}
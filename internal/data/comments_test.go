@@ -0,0 +1,29 @@
+package data
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommentCursor_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	cursor := CommentCursor{CreatedAt: time.Date(2024, 3, 1, 12, 30, 45, 123456000, time.UTC), ID: 42}
+
+	decoded, err := DecodeCommentCursor(cursor.Encode())
+	require.NoError(t, err)
+	assert.True(t, cursor.CreatedAt.Equal(decoded.CreatedAt))
+	assert.Equal(t, cursor.ID, decoded.ID)
+}
+
+func TestDecodeCommentCursor_Invalid(t *testing.T) {
+	t.Parallel()
+
+	for _, input := range []string{"not-a-cursor", "!!!", "MTIz", "YWJjOjE", "MTIzOmFiYw", "MTIzOjA"} {
+		_, err := DecodeCommentCursor(input)
+		assert.ErrorIs(t, err, ErrInvalidCursor, input)
+	}
+}
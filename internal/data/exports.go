@@ -0,0 +1,272 @@
+package data
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Statuses of a user's data export.
+const (
+	ExportStatusPending = "pending"
+	ExportStatusReady   = "ready"
+)
+
+// exportBuildTimeout is how long an export may stay pending. An export pending for longer was
+// abandoned, for example because the server restarted while building it.
+const exportBuildTimeout = 10 * time.Minute
+
+// UserExport is the state of a user's latest data export. The archive is only loaded for download.
+type UserExport struct {
+	UserID      int64      `json:"-"`
+	Status      string     `json:"status"`
+	RequestedAt time.Time  `json:"requestedAt"`
+	CompletedAt *time.Time `json:"completedAt"`
+	ExpiresAt   *time.Time `json:"expiresAt"`
+}
+
+// Stale reports whether the export has expired or was abandoned while pending, and must be built again.
+func (e *UserExport) Stale(now time.Time) bool {
+	if e.Status == ExportStatusPending {
+		return now.Sub(e.RequestedAt) > exportBuildTimeout
+	}
+	return e.ExpiresAt != nil && !now.Before(*e.ExpiresAt)
+}
+
+// userArchive is the data export of a user: their profile and everything they created or follow.
+type userArchive struct {
+	ExportedAt time.Time          `json:"exportedAt"`
+	Profile    archivedProfile    `json:"profile"`
+	Articles   []archivedArticle  `json:"articles"`
+	Comments   []archivedComment  `json:"comments"`
+	Favorites  []archivedFavorite `json:"favorites"`
+	Following  []archivedFollow   `json:"following"`
+	Followers  []archivedFollow   `json:"followers"`
+}
+
+type archivedProfile struct {
+	Username  string    `json:"username"`
+	Email     string    `json:"email"`
+	Bio       string    `json:"bio"`
+	Image     string    `json:"image"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type archivedArticle struct {
+	Slug        string     `json:"slug"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	Body        string     `json:"body"`
+	TagList     []string   `json:"tagList"`
+	Status      string     `json:"status"`
+	PublishAt   *time.Time `json:"publishAt"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+}
+
+type archivedComment struct {
+	ID          int64     `json:"id"`
+	ArticleSlug string    `json:"articleSlug"`
+	ParentID    *int64    `json:"parentId"`
+	Body        string    `json:"body"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+type archivedFavorite struct {
+	ArticleSlug string `json:"articleSlug"`
+	Title       string `json:"title"`
+}
+
+type archivedFollow struct {
+	Username string    `json:"username"`
+	Since    time.Time `json:"since"`
+}
+
+type ExportStore struct {
+	db      *pgxpool.Pool
+	timeout time.Duration
+}
+
+// Get returns the state of the user's latest data export.
+func (s ExportStore) Get(userID int64) (*UserExport, error) {
+	query := `
+		SELECT user_id, status, requested_at, completed_at, expires_at
+		FROM user_exports
+		WHERE user_id = $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	var export UserExport
+	err := s.db.QueryRow(ctx, query, userID).Scan(
+		&export.UserID, &export.Status, &export.RequestedAt, &export.CompletedAt, &export.ExpiresAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+	return &export, nil
+}
+
+// Start records a new pending export for the user, replacing their earlier export.
+func (s ExportStore) Start(userID int64) (*UserExport, error) {
+	query := `
+		INSERT INTO user_exports (user_id) VALUES ($1)
+		ON CONFLICT (user_id) DO UPDATE
+		SET status = 'pending', archive = NULL, requested_at = NOW() AT TIME ZONE 'UTC',
+		    completed_at = NULL, expires_at = NULL
+		RETURNING user_id, status, requested_at, completed_at, expires_at`
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	var export UserExport
+	err := s.db.QueryRow(ctx, query, userID).Scan(
+		&export.UserID, &export.Status, &export.RequestedAt, &export.CompletedAt, &export.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	return &export, nil
+}
+
+// Build collects the user's data into an archive and stores it with their export, which can then be
+// downloaded until ttl has passed. The data is read from a single snapshot, so the archive is consistent.
+func (s ExportStore) Build(userID int64, ttl time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	var archive userArchive
+	txOptions := pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly}
+	err := pgx.BeginTxFunc(ctx, s.db, txOptions, func(tx pgx.Tx) error {
+		var err error
+		archive, err = collectArchive(ctx, tx, userID)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	content, err := json.Marshal(archive)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE user_exports
+		SET status = 'ready', archive = $2, completed_at = NOW() AT TIME ZONE 'UTC',
+		    expires_at = (NOW() AT TIME ZONE 'UTC') + make_interval(secs => $3)
+		WHERE user_id = $1 AND status = 'pending'`
+
+	_, err = s.db.Exec(ctx, query, userID, content, ttl.Seconds())
+	return err
+}
+
+// collectArchive reads the user's profile, articles, comments, favorites and follows.
+func collectArchive(ctx context.Context, tx pgx.Tx, userID int64) (userArchive, error) {
+	archive := userArchive{ExportedAt: time.Now().UTC()}
+
+	query := `SELECT username, email, bio, image, created_at FROM users WHERE id = $1`
+	err := tx.QueryRow(ctx, query, userID).Scan(
+		&archive.Profile.Username, &archive.Profile.Email, &archive.Profile.Bio, &archive.Profile.Image, &archive.Profile.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return archive, ErrRecordNotFound
+		}
+		return archive, err
+	}
+
+	archive.Articles, err = collectArchived[archivedArticle](ctx, tx, `
+		SELECT slug, title, description, body, COALESCE(tag_list, '{}'), status, publish_at, created_at, updated_at
+		FROM articles
+		WHERE author_id = $1
+		ORDER BY created_at, id`, userID)
+	if err != nil {
+		return archive, err
+	}
+
+	archive.Comments, err = collectArchived[archivedComment](ctx, tx, `
+		SELECT c.id, a.slug, c.parent_id, c.body, c.created_at, c.updated_at
+		FROM comments c
+		JOIN articles a ON a.id = c.article_id
+		WHERE c.author_id = $1 AND c.deleted_at IS NULL
+		ORDER BY c.created_at, c.id`, userID)
+	if err != nil {
+		return archive, err
+	}
+
+	archive.Favorites, err = collectArchived[archivedFavorite](ctx, tx, `
+		SELECT a.slug, a.title
+		FROM favorites f
+		JOIN articles a ON a.id = f.article_id
+		WHERE f.user_id = $1
+		ORDER BY a.slug`, userID)
+	if err != nil {
+		return archive, err
+	}
+
+	archive.Following, err = collectArchived[archivedFollow](ctx, tx, `
+		SELECT u.username, f.created_at
+		FROM follows f
+		JOIN users u ON u.id = f.followed_id
+		WHERE f.follower_id = $1
+		ORDER BY f.created_at, u.username`, userID)
+	if err != nil {
+		return archive, err
+	}
+
+	archive.Followers, err = collectArchived[archivedFollow](ctx, tx, `
+		SELECT u.username, f.created_at
+		FROM follows f
+		JOIN users u ON u.id = f.follower_id
+		WHERE f.followed_id = $1
+		ORDER BY f.created_at, u.username`, userID)
+	return archive, err
+}
+
+// collectArchived runs a query for the user's data and scans its rows, column by column, into T.
+func collectArchived[T any](ctx context.Context, tx pgx.Tx, query string, userID int64) ([]T, error) {
+	rows, err := tx.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowToStructByPos[T])
+}
+
+// GetArchive returns the archive of the user's ready export. Returns ErrRecordNotFound if the
+// export is still pending or has expired.
+func (s ExportStore) GetArchive(userID int64) ([]byte, error) {
+	query := `
+		SELECT archive
+		FROM user_exports
+		WHERE user_id = $1 AND status = 'ready' AND expires_at > NOW() AT TIME ZONE 'UTC'`
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	var archive []byte
+	err := s.db.QueryRow(ctx, query, userID).Scan(&archive)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+	return archive, nil
+}
+
+// Delete removes the user's export.
+func (s ExportStore) Delete(userID int64) error {
+	query := `DELETE FROM user_exports WHERE user_id = $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	_, err := s.db.Exec(ctx, query, userID)
+	return err
+}
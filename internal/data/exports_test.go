@@ -0,0 +1,33 @@
+package data
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserExport_Stale(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	later := now.Add(time.Hour)
+	earlier := now.Add(-time.Hour)
+
+	tests := []struct {
+		name   string
+		export UserExport
+		want   bool
+	}{
+		{"Pending", UserExport{Status: ExportStatusPending, RequestedAt: now.Add(-time.Minute)}, false},
+		{"Abandoned while pending", UserExport{Status: ExportStatusPending, RequestedAt: now.Add(-exportBuildTimeout - time.Minute)}, true},
+		{"Ready", UserExport{Status: ExportStatusReady, RequestedAt: earlier, ExpiresAt: &later}, false},
+		{"Expired", UserExport{Status: ExportStatusReady, RequestedAt: earlier, ExpiresAt: &now}, true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, tc.export.Stale(now))
+		})
+	}
+}
@@ -0,0 +1,221 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gosimple/unidecode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ErrIdentityLinked is returned when an external identity is already linked to an account, or the
+// account already has an identity at the same provider.
+var ErrIdentityLinked = errors.New("identity already linked")
+
+// Identity links a user to their account at an OpenID Connect provider.
+type Identity struct {
+	Provider  string
+	Subject   string
+	UserID    int64
+	Email     string
+	CreatedAt time.Time
+}
+
+// AuthRequest is a login in progress at an OpenID Connect provider. The state, nonce and PKCE code
+// verifier are checked when the provider sends the user back. UserID is set when a logged in user
+// links an identity to their account.
+type AuthRequest struct {
+	State        string
+	Provider     string
+	Nonce        string
+	CodeVerifier string
+	UserID       *int64
+	CreatedAt    time.Time
+}
+
+type IdentityStore struct {
+	db      *pgxpool.Pool
+	timeout time.Duration
+}
+
+// Get returns the identity with the given subject at the provider.
+func (s IdentityStore) Get(provider, subject string) (*Identity, error) {
+	query := `
+		SELECT provider, subject, user_id, email, created_at
+		FROM user_identities
+		WHERE provider = $1 AND subject = $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	var identity Identity
+	err := s.db.QueryRow(ctx, query, provider, subject).Scan(
+		&identity.Provider, &identity.Subject, &identity.UserID, &identity.Email, &identity.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+	return &identity, nil
+}
+
+// Link links an identity to an existing user.
+func (s IdentityStore) Link(identity *Identity) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	return pgx.BeginFunc(ctx, s.db, func(tx pgx.Tx) error {
+		return insertIdentity(ctx, tx, identity)
+	})
+}
+
+// maxGeneratedUsernameLength bounds the usernames generated for new identities.
+const maxGeneratedUsernameLength = 40
+
+// usernameRX matches runs of characters that are not kept in generated usernames.
+var usernameRX = regexp.MustCompile(`[^a-z0-9_.]+`)
+
+// GenerateUsername generates a username for a new identity from the first of the candidates, such as
+// the preferred username, name or email local part reported by the provider, that has something
+// usable in it. Like slugs, the candidate is transliterated to ASCII; the store appends a numeric
+// suffix if the username is already taken.
+func GenerateUsername(candidates ...string) string {
+	for _, candidate := range candidates {
+		username := strings.ToLower(unidecode.Unidecode(candidate))
+		username = usernameRX.ReplaceAllString(username, "-")
+		username = strings.Trim(username, "-_.")
+
+		if len(username) > maxGeneratedUsernameLength {
+			username = strings.Trim(username[:maxGeneratedUsernameLength], "-_.")
+		}
+		if username == "" {
+			continue
+		}
+
+		// Keep the prefix of anonymized accounts reserved
+		if strings.HasPrefix(username, deletedUsernamePrefix) {
+			username = "user-" + username
+		}
+		return username
+	}
+	return "user"
+}
+
+// maxUsernameAttempts bounds how often creating a user is retried after their username collided
+// with another user.
+const maxUsernameAttempts = 5
+
+// CreateUser creates a user for a new identity and links it to them. If the username is taken, a
+// numeric suffix is appended to it. Returns ErrDuplicateEmail if the email address is taken.
+func (s IdentityStore) CreateUser(user *User, identity *Identity) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	base := user.Username
+	for attempt := 1; ; attempt++ {
+		err := pgx.BeginFunc(ctx, s.db, func(tx pgx.Tx) error {
+			if err := insertUser(ctx, tx, user); err != nil {
+				return err
+			}
+			identity.UserID = user.ID
+			return insertIdentity(ctx, tx, identity)
+		})
+		if !errors.Is(err, ErrDuplicateUsername) || attempt == maxUsernameAttempts {
+			return err
+		}
+
+		suffix, err := s.nextUsernameSuffix(ctx, base)
+		if err != nil {
+			return err
+		}
+		user.Username = fmt.Sprintf("%s-%d", base, suffix)
+	}
+}
+
+// nextUsernameSuffix returns the next free numeric suffix for usernames derived from base,
+// e.g. 3 if "base" and "base-2" are taken.
+func (s IdentityStore) nextUsernameSuffix(ctx context.Context, base string) (int, error) {
+	query := `
+		SELECT COALESCE(MAX(SUBSTRING(username FROM LENGTH($1) + 2)::integer), 1) + 1
+		FROM users
+		WHERE username LIKE $1 || '-%' AND SUBSTRING(username FROM LENGTH($1) + 2) ~ '^[0-9]{1,9}$'`
+
+	var suffix int
+	err := s.db.QueryRow(ctx, query, base).Scan(&suffix)
+	return suffix, err
+}
+
+func insertIdentity(ctx context.Context, tx pgx.Tx, identity *Identity) error {
+	query := `
+		INSERT INTO user_identities (provider, subject, user_id, email)
+		VALUES ($1, $2, $3, $4)
+		RETURNING created_at`
+
+	err := tx.QueryRow(ctx, query, identity.Provider, identity.Subject, identity.UserID, identity.Email).Scan(&identity.CreatedAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return ErrIdentityLinked
+		}
+		return err
+	}
+	return nil
+}
+
+// SaveAuthRequest records a login started at a provider. Requests older than ttl are dropped at the
+// same time, since they can no longer be completed.
+func (s IdentityStore) SaveAuthRequest(request *AuthRequest, ttl time.Duration) error {
+	query := `
+		INSERT INTO oidc_auth_requests (state, provider, nonce, code_verifier, user_id)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING created_at`
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	return pgx.BeginFunc(ctx, s.db, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, `
+			DELETE FROM oidc_auth_requests
+			WHERE created_at < (NOW() AT TIME ZONE 'UTC') - make_interval(secs => $1)`, ttl.Seconds())
+		if err != nil {
+			return err
+		}
+		return tx.QueryRow(ctx, query, request.State, request.Provider, request.Nonce, request.CodeVerifier, request.UserID).
+			Scan(&request.CreatedAt)
+	})
+}
+
+// TakeAuthRequest removes and returns the login with the given state, so that it can only be
+// completed once. Returns ErrRecordNotFound if there is no such login or it is older than ttl.
+func (s IdentityStore) TakeAuthRequest(state string, ttl time.Duration) (*AuthRequest, error) {
+	query := `
+		DELETE FROM oidc_auth_requests
+		WHERE state = $1
+		RETURNING state, provider, nonce, code_verifier, user_id, created_at,
+		          created_at >= (NOW() AT TIME ZONE 'UTC') - make_interval(secs => $2)`
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	var request AuthRequest
+	var current bool
+	err := s.db.QueryRow(ctx, query, state, ttl.Seconds()).Scan(
+		&request.State, &request.Provider, &request.Nonce, &request.CodeVerifier, &request.UserID, &request.CreatedAt, &current)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+	if !current {
+		return nil, ErrRecordNotFound
+	}
+	return &request, nil
+}
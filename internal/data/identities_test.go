@@ -0,0 +1,34 @@
+package data
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateUsername(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		candidates []string
+		want       string
+	}{
+		{name: "preferred username", candidates: []string{"jane.doe", "Jane Doe", "jane"}, want: "jane.doe"},
+		{name: "name when there is no preferred username", candidates: []string{"", "Jane Doe", "jane"}, want: "jane-doe"},
+		{name: "email local part as a last resort", candidates: []string{"", "", "jdoe+news"}, want: "jdoe-news"},
+		{name: "transliterated", candidates: []string{"José Müller"}, want: "jose-muller"},
+		{name: "Cyrillic", candidates: []string{"Иван"}, want: "ivan"},
+		{name: "symbols only are skipped", candidates: []string{"!!!", "bob"}, want: "bob"},
+		{name: "nothing usable", candidates: []string{"", "???"}, want: "user"},
+		{name: "reserved prefix", candidates: []string{"deleted-user-42"}, want: "user-deleted-user-42"},
+		{name: "truncated", candidates: []string{strings.Repeat("ab ", 30)}, want: "ab-ab-ab-ab-ab-ab-ab-ab-ab-ab-ab-ab-ab-a"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, GenerateUsername(tt.candidates...))
+		})
+	}
+}
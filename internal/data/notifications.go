@@ -0,0 +1,207 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Notification types. Each describes something another user did that the recipient should hear about.
+const (
+	NotificationFollow   = "follow"
+	NotificationFavorite = "favorite"
+	NotificationComment  = "comment"
+)
+
+// Notification is an in-app notification. Activity of the same type on the same article (or, for follows,
+// on the recipient) is coalesced into a single unread notification, so ActorsCount can be more than one.
+type Notification struct {
+	ID           int64     `json:"id"`
+	Type         string    `json:"type"`
+	Message      string    `json:"message"`
+	LastActor    string    `json:"lastActor"`
+	ActorsCount  int       `json:"actorsCount"`
+	ArticleSlug  string    `json:"articleSlug,omitempty"`
+	ArticleTitle string    `json:"articleTitle,omitempty"`
+	Read         bool      `json:"read"`
+	CreatedAt    time.Time `json:"createdAt"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
+// setMessage builds the human readable summary of the notification,
+// e.g. "alice favorited your article" or "5 people favorited your article".
+func (n *Notification) setMessage() {
+	who := n.LastActor
+	if n.ActorsCount > 1 || who == "" {
+		who = fmt.Sprintf("%d people", n.ActorsCount)
+	}
+
+	switch n.Type {
+	case NotificationFollow:
+		n.Message = who + " followed you"
+	case NotificationFavorite:
+		n.Message = fmt.Sprintf("%s favorited your article %q", who, n.ArticleTitle)
+	case NotificationComment:
+		n.Message = fmt.Sprintf("%s commented on your article %q", who, n.ArticleTitle)
+	}
+}
+
+type NotificationStore struct {
+	db      *pgxpool.Pool
+	timeout time.Duration
+}
+
+// NotifyFollow notifies the followed user that actorID started following them.
+func (s *NotificationStore) NotifyFollow(actorID, followedID int64) error {
+	recipient := `SELECT $2::integer AS recipient_id, NULL::integer AS article_id`
+	return s.notify(recipient, NotificationFollow, actorID, followedID)
+}
+
+// NotifyArticleAuthor notifies the author of the article that actorID favorited or commented on it.
+// Authors are never notified of their own activity.
+func (s *NotificationStore) NotifyArticleAuthor(notificationType string, actorID, articleID int64) error {
+	recipient := `SELECT author_id AS recipient_id, id AS article_id FROM articles WHERE id = $2`
+	return s.notify(recipient, notificationType, actorID, articleID)
+}
+
+// notify records the actor's activity for the recipient selected by the recipient query, which receives
+// the target ID as $2. The activity is merged into the recipient's unread notification of the same type
+// and article if there is one. Nothing is recorded for the actor's own content, or if the recipient has
+// blocked or muted the actor. Follows and favorites are only recorded the first time, so that undoing
+// and repeating them does not notify the recipient again; every comment is new activity.
+func (s *NotificationStore) notify(recipient, notificationType string, actorID, targetID int64) error {
+	query := `
+		WITH recipient AS (` + recipient + `)
+		INSERT INTO notifications (recipient_id, type, article_id, actor_ids, last_actor_id)
+		SELECT r.recipient_id, $3, r.article_id, ARRAY[$1::integer], $1
+		FROM recipient r
+		WHERE r.recipient_id <> $1
+		  AND NOT EXISTS (SELECT 1 FROM blocks WHERE blocker_id = r.recipient_id AND blocked_id = $1)
+		  AND NOT EXISTS (SELECT 1 FROM mutes WHERE muter_id = r.recipient_id AND muted_id = $1)
+		  AND ($3 = $4 OR NOT EXISTS (
+			SELECT 1 FROM notifications n
+			WHERE n.recipient_id = r.recipient_id AND n.type = $3
+			  AND COALESCE(n.article_id, 0) = COALESCE(r.article_id, 0) AND $1 = ANY(n.actor_ids)
+		  ))
+		ON CONFLICT (recipient_id, type, COALESCE(article_id, 0)) WHERE read_at IS NULL
+		DO UPDATE SET
+			actor_ids = CASE
+				WHEN $1 = ANY(notifications.actor_ids) THEN notifications.actor_ids
+				ELSE array_append(notifications.actor_ids, $1)
+			END,
+			last_actor_id = EXCLUDED.last_actor_id,
+			updated_at = NOW() AT TIME ZONE 'UTC'
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	_, err := s.db.Exec(ctx, query, actorID, targetID, notificationType, NotificationComment)
+	return err
+}
+
+// GetAllForUser returns a page of the user's notifications, most recently updated first,
+// along with the total number of notifications matching the filter.
+func (s *NotificationStore) GetAllForUser(userID int64, unreadOnly bool, limit, offset int) ([]Notification, int, error) {
+	query := `
+		SELECT n.id, n.type, COALESCE(u.username, ''), cardinality(n.actor_ids),
+		       COALESCE(a.slug, ''), COALESCE(a.title, ''), n.read_at IS NOT NULL,
+		       n.created_at, n.updated_at, COUNT(*) OVER() AS total_count
+		FROM notifications n
+		LEFT JOIN users u ON u.id = n.last_actor_id
+		LEFT JOIN articles a ON a.id = n.article_id AND a.deleted_at IS NULL
+		WHERE n.recipient_id = $1 AND (NOT $2 OR n.read_at IS NULL)
+		ORDER BY n.updated_at DESC, n.id DESC
+		LIMIT $3 OFFSET $4
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	rows, err := s.db.Query(ctx, query, userID, unreadOnly, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	notifications := []Notification{}
+	totalCount := 0
+	for rows.Next() {
+		var n Notification
+		err := rows.Scan(
+			&n.ID,
+			&n.Type,
+			&n.LastActor,
+			&n.ActorsCount,
+			&n.ArticleSlug,
+			&n.ArticleTitle,
+			&n.Read,
+			&n.CreatedAt,
+			&n.UpdatedAt,
+			&totalCount,
+		)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		n.setMessage()
+		notifications = append(notifications, n)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return notifications, totalCount, nil
+}
+
+// UnreadCount returns the number of unread notifications of the user.
+func (s *NotificationStore) UnreadCount(userID int64) (int, error) {
+	query := `SELECT COUNT(*) FROM notifications WHERE recipient_id = $1 AND read_at IS NULL`
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	var count int
+	err := s.db.QueryRow(ctx, query, userID).Scan(&count)
+	return count, err
+}
+
+// MarkRead marks one of the user's notifications as read.
+// Returns ErrRecordNotFound if the user has no notification with that ID.
+func (s *NotificationStore) MarkRead(id, userID int64) error {
+	query := `
+		UPDATE notifications
+		SET read_at = COALESCE(read_at, NOW() AT TIME ZONE 'UTC')
+		WHERE id = $1 AND recipient_id = $2
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	result, err := s.db.Exec(ctx, query, id, userID)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrRecordNotFound
+	}
+	return nil
+}
+
+// MarkAllRead marks all of the user's notifications as read.
+func (s *NotificationStore) MarkAllRead(userID int64) error {
+	query := `
+		UPDATE notifications
+		SET read_at = NOW() AT TIME ZONE 'UTC'
+		WHERE recipient_id = $1 AND read_at IS NULL
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	_, err := s.db.Exec(ctx, query, userID)
+	return err
+}
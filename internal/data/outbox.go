@@ -0,0 +1,264 @@
+package data
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Aggregate types of outbox events. Events of the same aggregate are published in the order they were written.
+// Comment and favorite events belong to the article they are on, and follow events to the followed user.
+const (
+	AggregateArticle = "article"
+	AggregateUser    = "user"
+)
+
+// Outbox event types.
+const (
+	EventArticleCreated     = "article.created"
+	EventArticleUpdated     = "article.updated"
+	EventArticleDeleted     = "article.deleted"
+	EventArticleRestored    = "article.restored"
+	EventArticlePublished   = "article.published"
+	EventArticleFavorited   = "article.favorited"
+	EventArticleUnfavorited = "article.unfavorited"
+	EventCommentCreated     = "comment.created"
+	EventCommentDeleted     = "comment.deleted"
+	EventUserRegistered     = "user.registered"
+	EventUserUpdated        = "user.updated"
+	EventUserDeleted        = "user.deleted"
+	EventUserFollowed       = "user.followed"
+	EventUserUnfollowed     = "user.unfollowed"
+)
+
+// outboxLockKey identifies the advisory lock held while dispatching outbox events,
+// so that only one dispatcher publishes at a time and per-aggregate order is kept.
+const outboxLockKey = 7_262_019
+
+// OutboxEvent is a domain event recorded in the same transaction as the change it describes.
+type OutboxEvent struct {
+	ID            int64
+	AggregateType string
+	AggregateID   int64
+	Type          string
+	Payload       json.RawMessage
+	Attempts      int
+	CreatedAt     time.Time
+}
+
+// insertOutboxEvent records an event with the JSON encoding of payload as part of the transaction.
+func insertOutboxEvent(ctx context.Context, tx pgx.Tx, aggregateType string, aggregateID int64, eventType string, payload any) error {
+	js, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO outbox_events (aggregate_type, aggregate_id, event_type, payload)
+		VALUES ($1, $2, $3, $4::jsonb)
+	`
+
+	_, err = tx.Exec(ctx, query, aggregateType, aggregateID, eventType, string(js))
+	return err
+}
+
+type OutboxStore struct {
+	db      *pgxpool.Pool
+	timeout time.Duration
+}
+
+// WithDispatchLock runs fn while holding the outbox dispatch lock and reports whether it ran.
+// fn is not run if another dispatcher, possibly in another process, holds the lock.
+func (s *OutboxStore) WithDispatchLock(fn func() error) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	// Advisory locks belong to a session, so the lock and unlock must use the same connection
+	conn, err := s.db.Acquire(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Release()
+
+	var locked bool
+	if err := conn.QueryRow(ctx, `SELECT pg_try_advisory_lock($1)`, outboxLockKey).Scan(&locked); err != nil {
+		return false, err
+	}
+	if !locked {
+		return false, nil
+	}
+
+	fnErr := fn()
+
+	unlockCtx, unlockCancel := context.WithTimeout(context.Background(), s.timeout)
+	defer unlockCancel()
+	if _, err := conn.Exec(unlockCtx, `SELECT pg_advisory_unlock($1)`, outboxLockKey); err != nil {
+		// Close the connection so the session, and with it the lock, doesn't outlive the dispatch
+		conn.Conn().Close(unlockCtx) //nolint:errcheck
+		if fnErr == nil {
+			fnErr = err
+		}
+	}
+
+	return true, fnErr
+}
+
+// GetUnpublished returns up to limit unpublished events that are due, in the order they were written.
+// Aggregates whose earliest unpublished event is waiting to be retried are skipped entirely,
+// so that their later events are not published out of order.
+func (s *OutboxStore) GetUnpublished(limit int) ([]OutboxEvent, error) {
+	query := `
+		SELECT e.id, e.aggregate_type, e.aggregate_id, e.event_type, e.payload, e.attempts, e.created_at
+		FROM outbox_events e
+		WHERE e.published_at IS NULL
+		  AND NOT EXISTS (
+			SELECT 1 FROM outbox_events p
+			WHERE p.published_at IS NULL
+			  AND p.aggregate_type = e.aggregate_type AND p.aggregate_id = e.aggregate_id
+			  AND p.id <= e.id
+			  AND p.next_attempt_at > (NOW() AT TIME ZONE 'UTC')
+		  )
+		ORDER BY e.id
+		LIMIT $1
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	rows, err := s.db.Query(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []OutboxEvent{}
+	for rows.Next() {
+		var e OutboxEvent
+		err := rows.Scan(&e.ID, &e.AggregateType, &e.AggregateID, &e.Type, &e.Payload, &e.Attempts, &e.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+
+	return events, rows.Err()
+}
+
+// MarkPublished records that an event has been published to every sink.
+func (s *OutboxStore) MarkPublished(id int64) error {
+	query := `
+		UPDATE outbox_events
+		SET published_at = NOW() AT TIME ZONE 'UTC', last_error = NULL
+		WHERE id = $1
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	_, err := s.db.Exec(ctx, query, id)
+	return err
+}
+
+// MarkFailed records a failed attempt to publish an event, which is retried at nextAttemptAt.
+func (s *OutboxStore) MarkFailed(id int64, lastError string, nextAttemptAt time.Time) error {
+	query := `
+		UPDATE outbox_events
+		SET attempts = attempts + 1, last_error = $2, next_attempt_at = $3
+		WHERE id = $1
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	_, err := s.db.Exec(ctx, query, id, lastError, nextAttemptAt.UTC())
+	return err
+}
+
+// DeletePublished removes events that were published more than retention ago and returns how many were removed.
+func (s *OutboxStore) DeletePublished(retention time.Duration) (int64, error) {
+	query := `
+		DELETE FROM outbox_events
+		WHERE published_at < (NOW() AT TIME ZONE 'UTC') - make_interval(secs => $1)
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	result, err := s.db.Exec(ctx, query, retention.Seconds())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+// articleEvent is the payload of article events that have the full article at hand.
+type articleEvent struct {
+	Article *Article `json:"article"`
+}
+
+// newArticleEvent returns the payload of an event about the article. The article is usually loaded on
+// behalf of whoever changed it, so the payload leaves out what is specific to them, such as whether they
+// favorited or bookmarked it, and is the same for every subscriber.
+func newArticleEvent(article *Article) articleEvent {
+	neutral := *article
+	neutral.Favorited = false
+	neutral.Bookmarked = false
+	neutral.MyReactions = nil
+	neutral.Author.Following = false
+	neutral.BodyHTML = ""
+	return articleEvent{&neutral}
+}
+
+// articleSummary identifies an article in events written without loading the full article.
+type articleSummary struct {
+	ID     int64  `json:"-"`
+	Slug   string `json:"slug"`
+	Title  string `json:"title"`
+	Status string `json:"status"`
+	Hidden bool   `json:"hidden,omitempty"`
+}
+
+type articleSummaryEvent struct {
+	Article articleSummary `json:"article"`
+}
+
+// favoriteEvent is the payload of article.favorited and article.unfavorited events.
+type favoriteEvent struct {
+	ArticleSlug    string `json:"articleSlug"`
+	UserID         int64  `json:"userId"`
+	FavoritesCount int    `json:"favoritesCount"`
+}
+
+func newFavoriteEvent(article *Article, userID int64) favoriteEvent {
+	return favoriteEvent{ArticleSlug: article.Slug, UserID: userID, FavoritesCount: article.FavoritesCount}
+}
+
+// commentEvent is the payload of comment.created events.
+type commentEvent struct {
+	ArticleSlug   string   `json:"articleSlug"`
+	ArticleStatus string   `json:"articleStatus"`
+	Comment       *Comment `json:"comment"`
+	// Approved is set when a comment held for moderation is announced after a moderator approved it.
+	Approved bool `json:"approved,omitempty"`
+}
+
+// userEvent is the payload of user.registered, user.updated and user.deleted events. Email addresses and
+// password hashes are left out so that they don't end up in sinks such as logs.
+type userEvent struct {
+	Username string `json:"username"`
+	Bio      string `json:"bio"`
+	Image    string `json:"image"`
+}
+
+func newUserEvent(user *User) userEvent {
+	return userEvent{Username: user.Username, Bio: user.Bio, Image: user.Image}
+}
+
+// followEvent is the payload of user.followed and user.unfollowed events.
+type followEvent struct {
+	FollowerID int64 `json:"followerId"`
+	FollowedID int64 `json:"followedId"`
+}
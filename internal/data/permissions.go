@@ -0,0 +1,72 @@
+package data
+
+import (
+	"slices"
+
+	"github.com/96malhar/realworld-backend/internal/validator"
+)
+
+// User roles. Every user has exactly one role, and each role grants a fixed set of permissions.
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
+// Roles lists the valid roles, from least to most privileged.
+var Roles = []string{RoleUser, RoleModerator, RoleAdmin}
+
+// Permission codes, written as resource:action[:scope]. Users can always act on their own
+// content; the ":any" permissions extend an action to content written by other users.
+const (
+	PermissionArticlesUpdateAny = "articles:update:any"
+	PermissionArticlesDeleteAny = "articles:delete:any"
+	PermissionCommentsDeleteAny = "comments:delete:any"
+	PermissionContentModerate   = "content:moderate"
+	PermissionAuditLogRead      = "audit:read"
+	PermissionUsersManage       = "users:manage"
+	PermissionUsersRoles        = "users:roles"
+)
+
+// Permissions is a set of permission codes.
+type Permissions []string
+
+// Include reports whether the set contains the given permission code.
+func (p Permissions) Include(code string) bool {
+	return slices.Contains(p, code)
+}
+
+var moderatorPermissions = Permissions{
+	PermissionArticlesUpdateAny,
+	PermissionArticlesDeleteAny,
+	PermissionCommentsDeleteAny,
+	PermissionContentModerate,
+}
+
+var rolePermissions = map[string]Permissions{
+	RoleUser:      {},
+	RoleModerator: moderatorPermissions,
+	RoleAdmin:     slices.Concat(moderatorPermissions, Permissions{PermissionAuditLogRead, PermissionUsersManage, PermissionUsersRoles}),
+}
+
+// PermissionsForRole returns the permissions granted by a role. Unknown roles grant none.
+func PermissionsForRole(role string) Permissions {
+	return rolePermissions[role]
+}
+
+// HasPermission reports whether the user's role grants the given permission.
+// The anonymous user has no role and therefore no permissions.
+func (u *User) HasPermission(code string) bool {
+	return PermissionsForRole(u.Role).Include(code)
+}
+
+// seesHiddenContent reports whether the user may see content that moderators have hidden from
+// everyone but its author.
+func seesHiddenContent(u *User) bool {
+	return u != nil && u.HasPermission(PermissionContentModerate)
+}
+
+// ValidateRole checks that role is one of the known roles.
+func ValidateRole(v *validator.Validator, role string) {
+	v.Check(validator.PermittedValue(role, Roles...), "role must be one of user, moderator or admin")
+}
@@ -0,0 +1,187 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/96malhar/realworld-backend/internal/validator"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Reaction types users can leave on articles and comments.
+const (
+	ReactionLike       = "like"
+	ReactionLove       = "love"
+	ReactionInsightful = "insightful"
+	ReactionFunny      = "funny"
+	ReactionCelebrate  = "celebrate"
+)
+
+// ReactionTypes lists every supported reaction type.
+var ReactionTypes = []string{ReactionLike, ReactionLove, ReactionInsightful, ReactionFunny, ReactionCelebrate}
+
+func ValidateReactionType(v *validator.Validator, reactionType string) {
+	v.Check(validator.PermittedValue(reactionType, ReactionTypes...),
+		"Reaction must be one of like, love, insightful, funny or celebrate")
+}
+
+// ReactionTarget identifies the article or comment a reaction is attached to.
+// Use ArticleReactionTarget or CommentReactionTarget to build one.
+type ReactionTarget struct {
+	table  string
+	column string
+	id     int64
+}
+
+func ArticleReactionTarget(articleID int64) ReactionTarget {
+	return ReactionTarget{table: "articles", column: "article_id", id: articleID}
+}
+
+func CommentReactionTarget(commentID int64) ReactionTarget {
+	return ReactionTarget{table: "comments", column: "comment_id", id: commentID}
+}
+
+// ReactionSummary holds the per-type reaction counts of a target and the types the current user has left.
+type ReactionSummary struct {
+	Counts map[string]int
+	Mine   []string
+}
+
+func newReactionSummary() *ReactionSummary {
+	return &ReactionSummary{Counts: map[string]int{}, Mine: []string{}}
+}
+
+type ReactionStore struct {
+	db      *pgxpool.Pool
+	timeout time.Duration
+}
+
+// Add records a reaction by the user on the target. Adding a reaction the user has already left is a no-op.
+// Returns ErrBlocked if the author of the target has blocked the user.
+func (s *ReactionStore) Add(target ReactionTarget, userID int64, reactionType string) error {
+	query := fmt.Sprintf(`
+		WITH target AS (
+			SELECT t.id, EXISTS(SELECT 1 FROM blocks WHERE blocker_id = t.author_id AND blocked_id = $1) AS blocked
+			FROM %s t
+			WHERE t.id = $2
+		),
+		reaction_insert AS (
+			INSERT INTO reactions (user_id, %s, type)
+			SELECT $1, id, $3 FROM target WHERE NOT blocked
+			ON CONFLICT DO NOTHING
+		)
+		SELECT COALESCE((SELECT blocked FROM target), false)
+	`, target.table, target.column)
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	var blocked bool
+	err := s.db.QueryRow(ctx, query, userID, target.id, reactionType).Scan(&blocked)
+	if err != nil {
+		return err
+	}
+	if blocked {
+		return ErrBlocked
+	}
+	return nil
+}
+
+// Remove deletes the user's reaction of the given type from the target, if there is one.
+func (s *ReactionStore) Remove(target ReactionTarget, userID int64, reactionType string) error {
+	query := fmt.Sprintf(`
+		DELETE FROM reactions
+		WHERE user_id = $1 AND %s = $2 AND type = $3
+	`, target.column)
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	_, err := s.db.Exec(ctx, query, userID, target.id, reactionType)
+	return err
+}
+
+// Summarize returns the reaction counts of the target and the reactions left by the given user.
+func (s *ReactionStore) Summarize(target ReactionTarget, userID int64) (*ReactionSummary, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	summaries, err := loadReactions(ctx, s.db, target.column, []int64{target.id}, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if summary, ok := summaries[target.id]; ok {
+		return summary, nil
+	}
+	return newReactionSummary(), nil
+}
+
+// loadReactions fetches reaction summaries for many articles or comments in a single grouped query,
+// keyed by target ID. Targets without any reactions are absent from the result.
+// column must be either "article_id" or "comment_id"; it is never taken from user input.
+func loadReactions(ctx context.Context, db *pgxpool.Pool, column string, ids []int64, userID int64) (map[int64]*ReactionSummary, error) {
+	summaries := make(map[int64]*ReactionSummary)
+	if len(ids) == 0 {
+		return summaries, nil
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %[1]s, type, COUNT(*), BOOL_OR(user_id = $2)
+		FROM reactions
+		WHERE %[1]s = ANY($1)
+		GROUP BY %[1]s, type
+		ORDER BY type
+	`, column)
+
+	rows, err := db.Query(ctx, query, ids, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int64
+		var reactionType string
+		var count int
+		var mine bool
+
+		if err := rows.Scan(&id, &reactionType, &count, &mine); err != nil {
+			return nil, err
+		}
+
+		summary, ok := summaries[id]
+		if !ok {
+			summary = newReactionSummary()
+			summaries[id] = summary
+		}
+		summary.Counts[reactionType] = count
+		if mine {
+			summary.Mine = append(summary.Mine, reactionType)
+		}
+	}
+
+	return summaries, rows.Err()
+}
+
+// setArticleReactions fills in the reaction counts and the viewer's own reactions for each article.
+func setArticleReactions(ctx context.Context, db *pgxpool.Pool, articles []Article, userID int64) error {
+	ids := make([]int64, len(articles))
+	for i := range articles {
+		ids[i] = articles[i].ID
+	}
+
+	summaries, err := loadReactions(ctx, db, "article_id", ids, userID)
+	if err != nil {
+		return err
+	}
+
+	for i := range articles {
+		if summary, ok := summaries[articles[i].ID]; ok {
+			articles[i].Reactions = summary.Counts
+			articles[i].MyReactions = summary.Mine
+		}
+	}
+	return nil
+}
@@ -0,0 +1,421 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/96malhar/realworld-backend/internal/validator"
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrDuplicateReport = errors.New("duplicate report")
+	ErrReportResolved  = errors.New("report already resolved")
+	// ErrHeldContent is returned when dismissing a report filed by the content filter, which would leave
+	// the content it held back hidden with no open report to approve it from.
+	ErrHeldContent = errors.New("held content must be approved or deleted")
+)
+
+// Reasons users can give for reporting an article or comment.
+const (
+	ReportReasonSpam           = "spam"
+	ReportReasonHarassment     = "harassment"
+	ReportReasonHateSpeech     = "hate_speech"
+	ReportReasonMisinformation = "misinformation"
+	ReportReasonOther          = "other"
+)
+
+// ReportReasonContentFilter is the reason of the reports filed by the content filter when it holds content
+// back for moderation. Users cannot give it as a reason.
+const ReportReasonContentFilter = "content_filter"
+
+// ReportReasons lists every report reason users can give.
+var ReportReasons = []string{
+	ReportReasonSpam, ReportReasonHarassment, ReportReasonHateSpeech, ReportReasonMisinformation, ReportReasonOther,
+}
+
+// Report statuses. Reports are open until a moderator dismisses them, or hides, deletes or approves the reported content.
+const (
+	ReportStatusOpen      = "open"
+	ReportStatusDismissed = "dismissed"
+	ReportStatusHidden    = "hidden"
+	ReportStatusDeleted   = "deleted"
+	ReportStatusApproved  = "approved"
+)
+
+// ReportStatuses lists every report status.
+var ReportStatuses = []string{
+	ReportStatusOpen, ReportStatusDismissed, ReportStatusHidden, ReportStatusDeleted, ReportStatusApproved,
+}
+
+// Report target types.
+const (
+	ReportTargetArticle = "article"
+	ReportTargetComment = "comment"
+)
+
+// Report is a user's report of an article, or of a comment on it, awaiting or resolved by a moderator.
+// Reporter, ArticleSlug and TargetAuthor are empty once the user or content they refer to has been deleted
+// or moved to the trash; Reporter is also empty for reports filed by the content filter.
+type Report struct {
+	ID           int64      `json:"id"`
+	ReporterID   int64      `json:"-"`
+	Reporter     string     `json:"reporter"`
+	TargetType   string     `json:"targetType"`
+	ArticleID    int64      `json:"-"`
+	ArticleSlug  string     `json:"articleSlug"`
+	CommentID    *int64     `json:"commentId,omitempty"`
+	TargetAuthor string     `json:"targetAuthor"`
+	Reason       string     `json:"reason"`
+	Details      string     `json:"details"`
+	Status       string     `json:"status"`
+	ResolvedBy   string     `json:"resolvedBy,omitempty"`
+	ResolvedAt   *time.Time `json:"resolvedAt,omitempty"`
+	CreatedAt    time.Time  `json:"createdAt"`
+}
+
+func ValidateReport(v *validator.Validator, report *Report) {
+	v.Check(validator.PermittedValue(report.Reason, ReportReasons...),
+		"reason must be one of spam, harassment, hate_speech, misinformation or other")
+	v.Check(len(report.Details) <= 1000, "details must not be more than 1000 bytes long")
+}
+
+func ValidateReportStatus(v *validator.Validator, status string) {
+	v.Check(validator.PermittedValue(status, ReportStatuses...),
+		"status must be one of open, dismissed, hidden, deleted or approved")
+}
+
+type ReportStore struct {
+	db      *pgxpool.Pool
+	timeout time.Duration
+}
+
+// reportColumns and reportJoins select reports with the names of the users and the slug of the article
+// they refer to. The article and comment joins are outer joins because reports outlive the reported content,
+// and articles in the trash are left out like deleted ones.
+const reportColumns = `
+	r.id, COALESCE(r.reporter_id, 0), COALESCE(rep.username, ''), r.article_id, COALESCE(a.slug, ''),
+	r.comment_id, COALESCE(author.username, ''), r.reason, r.details, r.status,
+	COALESCE(res.username, ''), r.resolved_at, r.created_at
+`
+
+const reportJoins = `
+	LEFT JOIN users rep ON rep.id = r.reporter_id
+	LEFT JOIN articles a ON a.id = r.article_id AND a.deleted_at IS NULL
+	LEFT JOIN comments c ON c.id = r.comment_id
+	LEFT JOIN users author ON author.id = CASE WHEN r.comment_id IS NULL THEN a.author_id ELSE c.author_id END
+	LEFT JOIN users res ON res.id = r.resolved_by
+`
+
+// reportFields returns the scan destinations of reportColumns.
+func reportFields(report *Report) []any {
+	return []any{
+		&report.ID, &report.ReporterID, &report.Reporter, &report.ArticleID, &report.ArticleSlug,
+		&report.CommentID, &report.TargetAuthor, &report.Reason, &report.Details, &report.Status,
+		&report.ResolvedBy, &report.ResolvedAt, &report.CreatedAt,
+	}
+}
+
+func (r *Report) setTargetType() {
+	r.TargetType = ReportTargetArticle
+	if r.CommentID != nil {
+		r.TargetType = ReportTargetComment
+	}
+}
+
+// Insert files an open report and populates the rest of its fields. Returns ErrDuplicateReport
+// if the reporter already has an open report of the same article or comment.
+func (s *ReportStore) Insert(report *Report) error {
+	query := `
+		WITH r AS (
+			INSERT INTO reports (reporter_id, article_id, comment_id, reason, details)
+			VALUES ($1, $2, $3, $4, $5)
+			RETURNING *
+		)
+		SELECT ` + reportColumns + `FROM r` + reportJoins
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	err := s.db.QueryRow(ctx, query, report.ReporterID, report.ArticleID, report.CommentID, report.Reason, report.Details).
+		Scan(reportFields(report)...)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == "idx_reports_open_reporter_target" {
+			return ErrDuplicateReport
+		}
+		return err
+	}
+
+	report.setTargetType()
+	return nil
+}
+
+// Get retrieves a report by its ID.
+func (s *ReportStore) Get(id int64) (*Report, error) {
+	query := `SELECT ` + reportColumns + `FROM reports r` + reportJoins + `WHERE r.id = $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	var report Report
+	err := s.db.QueryRow(ctx, query, id).Scan(reportFields(&report)...)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+
+	report.setTargetType()
+	return &report, nil
+}
+
+// List returns a page of the reports with the given status, oldest first so that the moderation
+// queue is worked through in the order reports arrived, and the total number of such reports.
+func (s *ReportStore) List(status string, limit, offset int) ([]Report, int, error) {
+	query := `SELECT ` + reportColumns + `, COUNT(*) OVER() AS total_count FROM reports r` + reportJoins + `
+		WHERE r.status = $1
+		ORDER BY r.created_at, r.id
+		LIMIT $2 OFFSET $3
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	rows, err := s.db.Query(ctx, query, status, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	reports := []Report{}
+	totalCount := 0
+	for rows.Next() {
+		var report Report
+		err := rows.Scan(append(reportFields(&report), &totalCount)...)
+		if err != nil {
+			return nil, 0, err
+		}
+		report.setTargetType()
+		reports = append(reports, report)
+	}
+
+	return reports, totalCount, rows.Err()
+}
+
+// Resolve resolves an open report on behalf of the actor, setting its status to dismissed, hidden, deleted
+// or approved. Hiding, deleting or approving the reported content resolves every open report of the same
+// content, and is recorded in the audit log along with dismissals. Approving content publishes it again if
+// it was hidden by a moderator or held back by the content filter, and records the events that were held
+// back while it was hidden (see announceApprovedContent); reports resolved by hiding the content can
+// still be approved, which un-hides it and approves every report of the content resolved the same way.
+// Reports filed by the content filter cannot be dismissed: returns ErrHeldContent. Returns ErrReportResolved
+// if the report is no longer open, and ErrRecordNotFound if the reported content no longer exists.
+func (s *ReportStore) Resolve(report *Report, status string, actor *User) error {
+	if status == ReportStatusDismissed && report.Reason == ReportReasonContentFilter {
+		return ErrHeldContent
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	return pgx.BeginFunc(ctx, s.db, func(tx pgx.Tx) error {
+		var current string
+		err := tx.QueryRow(ctx, `SELECT status FROM reports WHERE id = $1 FOR UPDATE`, report.ID).Scan(&current)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrRecordNotFound
+			}
+			return err
+		}
+		// The statuses of the reports resolved along with this one
+		statuses := []string{ReportStatusOpen}
+		switch {
+		case current == ReportStatusHidden && status == ReportStatusApproved:
+			statuses = append(statuses, ReportStatusHidden)
+		case current != ReportStatusOpen:
+			return ErrReportResolved
+		}
+
+		// The reports resolved along with this one
+		resolved := sq.Eq{"article_id": report.ArticleID, "comment_id": report.CommentID}
+
+		switch status {
+		case ReportStatusDismissed:
+			resolved = sq.Eq{"id": report.ID}
+			err = insertAuditEntry(ctx, tx, actor, AuditReportDismissed, AuditTargetReport, report.ID, report)
+		case ReportStatusHidden:
+			_, err = hideReportedContent(ctx, tx, report, actor, true)
+		case ReportStatusApproved:
+			var unhidden bool
+			unhidden, err = hideReportedContent(ctx, tx, report, actor, false)
+			if err == nil && unhidden {
+				err = announceApprovedContent(ctx, tx, report)
+			}
+		case ReportStatusDeleted:
+			err = deleteReportedContent(ctx, tx, report, actor)
+			if report.CommentID == nil {
+				// Deleting an article also deletes its comments, so their reports are resolved too
+				resolved = sq.Eq{"article_id": report.ArticleID}
+			}
+		}
+		if err != nil {
+			return err
+		}
+
+		query, args, err := sq.Update("reports").
+			Set("status", status).
+			Set("resolved_by", actor.ID).
+			Set("resolved_at", sq.Expr("(NOW() AT TIME ZONE 'UTC')")).
+			Where(resolved).
+			Where(sq.Eq{"status": statuses}).
+			PlaceholderFormat(sq.Dollar).
+			ToSql()
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now().UTC()
+		report.Status = status
+		report.ResolvedBy = actor.Username
+		report.ResolvedAt = &now
+		return nil
+	})
+}
+
+// hideReportedContent hides the reported article or comment from everyone but its author and moderators,
+// or if hide is false, makes it visible to everyone again. Reports whether the content was hidden before.
+func hideReportedContent(ctx context.Context, tx pgx.Tx, report *Report, actor *User, hide bool) (bool, error) {
+	query := `
+		UPDATE articles a SET hidden_at = CASE WHEN $2 THEN COALESCE(a.hidden_at, (NOW() AT TIME ZONE 'UTC')) END
+		FROM (SELECT id, hidden_at FROM articles WHERE id = $1 FOR UPDATE) prev
+		WHERE a.id = prev.id
+		RETURNING a.id, prev.hidden_at IS NOT NULL
+	`
+	action, targetType, targetID := AuditArticleHidden, AuditTargetArticle, report.ArticleID
+	if !hide {
+		action = AuditArticleApproved
+	}
+
+	if report.CommentID != nil {
+		query = `
+			UPDATE comments c SET hidden_at = CASE WHEN $2 THEN COALESCE(c.hidden_at, (NOW() AT TIME ZONE 'UTC')) END
+			FROM (SELECT id, hidden_at FROM comments WHERE id = $1 AND deleted_at IS NULL FOR UPDATE) prev
+			WHERE c.id = prev.id
+			RETURNING c.id, prev.hidden_at IS NOT NULL
+		`
+		action, targetType, targetID = AuditCommentHidden, AuditTargetComment, *report.CommentID
+		if !hide {
+			action = AuditCommentApproved
+		}
+	}
+
+	var wasHidden bool
+	err := tx.QueryRow(ctx, query, targetID, hide).Scan(&targetID, &wasHidden)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, ErrRecordNotFound
+		}
+		return false, err
+	}
+
+	return wasHidden, insertAuditEntry(ctx, tx, actor, action, targetType, targetID, map[string]any{
+		"reportId": report.ID, "reason": report.Reason, "author": report.TargetAuthor,
+	})
+}
+
+// announceApprovedContent records the events that were held back while the approved article or comment
+// was hidden. Articles are announced with article.published, as drafts are when they are published, since
+// article.created was recorded when they were saved; articles that are not published yet are announced
+// when they are. Comments are announced with comment.created again, marked as approved so that in-process
+// subscribers can tell them from new comments, which their handlers announce themselves.
+func announceApprovedContent(ctx context.Context, tx pgx.Tx, report *Report) error {
+	if report.CommentID == nil {
+		query := `SELECT id, slug, title, status FROM articles WHERE id = $1 AND deleted_at IS NULL`
+
+		var a articleSummary
+		err := tx.QueryRow(ctx, query, report.ArticleID).Scan(&a.ID, &a.Slug, &a.Title, &a.Status)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return nil
+			}
+			return err
+		}
+		if a.Status != ArticleStatusPublished {
+			return nil
+		}
+		return insertOutboxEvent(ctx, tx, AggregateArticle, a.ID, EventArticlePublished, articleSummaryEvent{a})
+	}
+
+	query := `
+		SELECT c.id, c.body, c.parent_id, c.created_at, c.updated_at, u.username, u.bio, u.image,
+		       a.slug, a.status
+		FROM comments c
+		JOIN users u ON u.id = c.author_id
+		JOIN articles a ON a.id = c.article_id
+		WHERE c.id = $1
+	`
+
+	var comment Comment
+	payload := commentEvent{Comment: &comment, Approved: true}
+	err := tx.QueryRow(ctx, query, *report.CommentID).Scan(&comment.ID, &comment.Body, &comment.ParentID,
+		&comment.CreatedAt, &comment.UpdatedAt, &comment.Author.Username, &comment.Author.Bio, &comment.Author.Image,
+		&payload.ArticleSlug, &payload.ArticleStatus)
+	if err != nil {
+		return err
+	}
+
+	return insertOutboxEvent(ctx, tx, AggregateArticle, report.ArticleID, EventCommentCreated, payload)
+}
+
+// holdContent hides an article, or a comment on it, that the content filter held back for moderation
+// as part of the transaction, and files a report on behalf of the filter so that moderators can review it.
+func holdContent(ctx context.Context, tx pgx.Tx, articleID int64, commentID *int64, reasons []string) error {
+	table, id := "articles", articleID
+	if commentID != nil {
+		table, id = "comments", *commentID
+	}
+
+	query := fmt.Sprintf(`UPDATE %s SET hidden_at = COALESCE(hidden_at, (NOW() AT TIME ZONE 'UTC')) WHERE id = $1`, table)
+	_, err := tx.Exec(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	query = `
+		INSERT INTO reports (article_id, comment_id, reason, details)
+		VALUES ($1, $2, $3, $4)
+	`
+	_, err = tx.Exec(ctx, query, articleID, commentID, ReportReasonContentFilter, strings.Join(reasons, "; "))
+	return err
+}
+
+// deleteReportedContent deletes the reported article or comment as the actor would.
+func deleteReportedContent(ctx context.Context, tx pgx.Tx, report *Report, actor *User) error {
+	if report.CommentID != nil {
+		return deleteComment(ctx, tx, *report.CommentID, report.ArticleID, actor)
+	}
+
+	var slug string
+	err := tx.QueryRow(ctx, `SELECT slug FROM articles WHERE id = $1`, report.ArticleID).Scan(&slug)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrRecordNotFound
+		}
+		return err
+	}
+
+	return deleteArticle(ctx, tx, slug, actor)
+}
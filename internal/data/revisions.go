@@ -0,0 +1,97 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ArticleRevision is a snapshot of an article's content at a specific version.
+type ArticleRevision struct {
+	ArticleID   int64     `json:"-"`
+	Version     int       `json:"version"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	Body        string    `json:"body,omitempty"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+type RevisionStore struct {
+	db      *pgxpool.Pool
+	timeout time.Duration
+}
+
+// GetAllByArticleID retrieves all revisions of an article, newest first.
+// Bodies are excluded from the results to keep the response small.
+func (s *RevisionStore) GetAllByArticleID(articleID int64) ([]ArticleRevision, error) {
+	query := `
+		SELECT article_id, version, title, description, created_at
+		FROM article_revisions
+		WHERE article_id = $1
+		ORDER BY version DESC
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	rows, err := s.db.Query(ctx, query, articleID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := []ArticleRevision{}
+	for rows.Next() {
+		var revision ArticleRevision
+		err := rows.Scan(
+			&revision.ArticleID,
+			&revision.Version,
+			&revision.Title,
+			&revision.Description,
+			&revision.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, revision)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return revisions, nil
+}
+
+// Get retrieves a single revision of an article, including its body.
+func (s *RevisionStore) Get(articleID int64, version int) (*ArticleRevision, error) {
+	query := `
+		SELECT article_id, version, title, description, body, created_at
+		FROM article_revisions
+		WHERE article_id = $1 AND version = $2
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	var revision ArticleRevision
+	err := s.db.QueryRow(ctx, query, articleID, version).Scan(
+		&revision.ArticleID,
+		&revision.Version,
+		&revision.Title,
+		&revision.Description,
+		&revision.Body,
+		&revision.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+
+	return &revision, nil
+}
@@ -1,30 +1,53 @@
 package data
 
 import (
+	"encoding/json"
 	"errors"
 	"time"
 
+	"github.com/96malhar/realworld-backend/internal/passwords"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 var (
 	ErrRecordNotFound = errors.New("record not found")
 	ErrEditConflict   = errors.New("edit conflict")
+	ErrBlocked        = errors.New("blocked by user")
 )
 
 type ModelStore struct {
-	Users    UserStoreInterface
-	Articles ArticleStoreInterface
-	Tags     TagStoreInterface
-	Comments CommentStoreInterface
+	Users         UserStoreInterface
+	Articles      ArticleStoreInterface
+	Tags          TagStoreInterface
+	Comments      CommentStoreInterface
+	Revisions     RevisionStoreInterface
+	Reactions     ReactionStoreInterface
+	Notifications NotificationStoreInterface
+	Webhooks      WebhookStoreInterface
+	Outbox        OutboxStoreInterface
+	Audit         AuditStoreInterface
+	Reports       ReportStoreInterface
+	Exports       ExportStoreInterface
+	TwoFactor     TwoFactorStoreInterface
+	Identities    IdentityStoreInterface
 }
 
 func NewModelStore(db *pgxpool.Pool, timeout time.Duration, userCache *UserCache) ModelStore {
 	return ModelStore{
-		Users:    &UserStore{db: db, timeout: timeout, userCache: userCache},
-		Articles: &ArticleStore{db: db, timeout: timeout},
-		Tags:     &TagStore{db: db, timeout: timeout},
-		Comments: &CommentStore{db: db, timeout: timeout},
+		Users:         &UserStore{db: db, timeout: timeout, userCache: userCache},
+		Articles:      &ArticleStore{db: db, timeout: timeout},
+		Tags:          &TagStore{db: db, timeout: timeout},
+		Comments:      &CommentStore{db: db, timeout: timeout},
+		Revisions:     &RevisionStore{db: db, timeout: timeout},
+		Reactions:     &ReactionStore{db: db, timeout: timeout},
+		Notifications: &NotificationStore{db: db, timeout: timeout},
+		Webhooks:      &WebhookStore{db: db, timeout: timeout},
+		Outbox:        &OutboxStore{db: db, timeout: timeout},
+		Audit:         &AuditStore{db: db, timeout: timeout},
+		Reports:       &ReportStore{db: db, timeout: timeout},
+		Exports:       &ExportStore{db: db, timeout: timeout},
+		TwoFactor:     &TwoFactorStore{db: db, timeout: timeout},
+		Identities:    &IdentityStore{db: db, timeout: timeout},
 	}
 }
 
@@ -37,14 +60,46 @@ type UserStoreInterface interface {
 	GetByID(id int64) (*User, error)
 	// GetByUsername retrieves a specific record from the users table by username.
 	GetByUsername(username string) (*User, error)
-	// FollowUser records that a user is following another user
+	// FollowUser records that a user is following another user. Returns ErrBlocked if the followed user has blocked them.
 	FollowUser(followerID, followedID int64) error
 	// UnfollowUser records that a user has unfollowed another user
 	UnfollowUser(followerID, followedID int64) error
 	// IsFollowing checks if a user is following another user
 	IsFollowing(followerID, followedID int64) (bool, error)
+	// BlockUser blocks a user and removes any follows between the two users.
+	BlockUser(blockerID, blockedID int64) error
+	// UnblockUser removes a block.
+	UnblockUser(blockerID, blockedID int64) error
+	// MuteUser hides a user's articles and comments from the muter.
+	MuteUser(muterID, mutedID int64) error
+	// UnmuteUser removes a mute.
+	UnmuteUser(muterID, mutedID int64) error
+	// GetProfile retrieves a user's public profile with follow and article counts for the viewer.
+	GetProfile(username string, viewer *User) (*Profile, error)
+	// GetFollowerIDs returns the IDs of all users following the user.
+	GetFollowerIDs(userID int64) ([]int64, error)
+	// GetFollowers returns a page of profiles following the user, and the total number of followers.
+	GetFollowers(userID int64, viewer *User, limit, offset int) ([]Profile, int, error)
+	// GetFollowing returns a page of profiles the user follows, and the total number of followed users.
+	GetFollowing(userID int64, viewer *User, limit, offset int) ([]Profile, int, error)
 	// Update an existing user record.
 	Update(user *User) error
+	// RehashPassword replaces the user's password hash with a new hash from hasher, unless the password was changed.
+	RehashPassword(user *User, hasher passwords.Hasher, plaintextPassword string) error
+	// List returns a page of user accounts matching the filters and the total number of matches.
+	List(filters UserFilters) ([]UserAccount, int, error)
+	// SetRole changes the user's role on behalf of the actor, or the system if nil, and records it in the audit log.
+	SetRole(user *User, role string, actor *User) error
+	// Suspend suspends the user's account on behalf of the actor and records it in the audit log.
+	Suspend(user *User, reason string, actor *User) error
+	// Unsuspend lifts the suspension of the user's account on behalf of the actor and records it in the audit log.
+	Unsuspend(user *User, actor *User) error
+	// RequirePasswordReset forces the user to change their password and records it in the audit log.
+	RequirePasswordReset(user *User, actor *User) error
+	// Delete removes the user's account on behalf of the actor and records it in the audit log.
+	Delete(user *User, actor *User) error
+	// DeleteAccount deletes the user's own account, deleting or anonymizing their content under the policy.
+	DeleteAccount(user *User, policy string) error
 }
 
 type ArticleStoreInterface interface {
@@ -52,7 +107,9 @@ type ArticleStoreInterface interface {
 	// This is more efficient than Insert followed by GetBySlug as it eliminates an extra database round trip.
 	InsertAndReturn(article *Article, currentUser *User) (*Article, error)
 	// GetIDBySlug retrieves just the article ID by its slug (lightweight alternative to GetBySlug).
-	GetIDBySlug(slug string) (int64, error)
+	GetIDBySlug(slug string, currentUser *User) (int64, error)
+	// ResolveSlug returns the current slug for an article's current or previous slug.
+	ResolveSlug(slug string, currentUser *User) (string, error)
 	// GetBySlug retrieves a specific record from the articles table by slug.
 	GetBySlug(slug string, currentUser *User) (*Article, error)
 	// List retrieves articles with optional filtering and pagination.
@@ -61,12 +118,21 @@ type ArticleStoreInterface interface {
 	FavoriteBySlug(slug string, userID int64) (*Article, error)
 	// UnfavoriteBySlug unfavorites the article with the given slug for the user and returns the updated article.
 	UnfavoriteBySlug(slug string, userID int64) (*Article, error)
-	// DeleteBySlug deletes the article with the given slug.
-	DeleteBySlug(slug string, userID int64) error
-	// Update an existing article record.
-	Update(article *Article) error
-	// InsertTags inserts tags into the tags table (used for async operations).
-	InsertTags(tags ...string) error
+	// Bookmark adds the article to the user's private reading list.
+	Bookmark(articleID, userID int64) error
+	// Unbookmark removes the article from the user's private reading list.
+	Unbookmark(articleID, userID int64) error
+	// DeleteBySlug deletes the article with the given slug if the actor is its author or may delete any article.
+	// Articles deleted by their author are moved to the trash; others are deleted permanently.
+	DeleteBySlug(slug string, actor *User) error
+	// Restore moves the author's article with the given slug out of the trash.
+	Restore(slug string, author *User) error
+	// PurgeTrash permanently deletes articles in the trash for longer than retention and returns how many were deleted.
+	PurgeTrash(retention time.Duration) (int64, error)
+	// Update an existing article record on behalf of the actor.
+	Update(article *Article, actor *User) error
+	// PublishScheduled publishes all scheduled articles that are due and returns how many were published.
+	PublishScheduled() (int64, error)
 }
 
 type TagStoreInterface interface {
@@ -78,8 +144,143 @@ type CommentStoreInterface interface {
 	// InsertAndReturn inserts a comment and returns it with author details populated from currentUser.
 	// Uses the currentUser from context instead of querying the database for author information.
 	InsertAndReturn(comment *Comment, currentUser *User) (*Comment, error)
-	// GetByArticleID retrieves all comments with author details for an article by its article ID.
-	GetByArticleID(articleID int64) ([]Comment, error)
+	// GetByID retrieves a single comment by its ID.
+	GetByID(id int64) (*Comment, error)
+	// GetByArticleID retrieves a page of comments with author details for an article by its article ID,
+	// along with the article's total comment count and the cursor of the next page.
+	// Comments by users the current user has muted are excluded.
+	GetByArticleID(articleID int64, filters CommentFilters, currentUser *User) ([]Comment, int, *CommentCursor, error)
+	// DeleteByID deletes a comment the actor wrote or may delete, or tombstones it if it has replies.
+	DeleteByID(id, articleID int64, actor *User) error
 	// SetFollowingStatus efficiently checks and sets the following status for all comment authors.
 	SetFollowingStatus(comments []Comment, currentUserID int64) error
+	// SetReactions sets the reaction counts and the current user's reactions for all comments in one query.
+	SetReactions(comments []Comment, currentUserID int64) error
+}
+
+type RevisionStoreInterface interface {
+	// GetAllByArticleID retrieves all revisions of an article, newest first, without their bodies.
+	GetAllByArticleID(articleID int64) ([]ArticleRevision, error)
+	// Get retrieves a specific revision of an article by version.
+	Get(articleID int64, version int) (*ArticleRevision, error)
+}
+
+type ReactionStoreInterface interface {
+	// Add records the user's reaction on an article or comment; repeating a reaction is a no-op.
+	// Returns ErrBlocked if the author of the article or comment has blocked the user.
+	Add(target ReactionTarget, userID int64, reactionType string) error
+	// Remove deletes the user's reaction of the given type from an article or comment.
+	Remove(target ReactionTarget, userID int64, reactionType string) error
+	// Summarize returns the per-type reaction counts of a target and the user's own reactions.
+	Summarize(target ReactionTarget, userID int64) (*ReactionSummary, error)
+}
+
+type NotificationStoreInterface interface {
+	// NotifyFollow notifies a user that they have a new follower.
+	NotifyFollow(actorID, followedID int64) error
+	// NotifyArticleAuthor notifies an article's author that it was favorited or commented on.
+	NotifyArticleAuthor(notificationType string, actorID, articleID int64) error
+	// GetAllForUser returns a page of the user's notifications and the total number matching the filter.
+	GetAllForUser(userID int64, unreadOnly bool, limit, offset int) ([]Notification, int, error)
+	// UnreadCount returns the number of unread notifications of the user.
+	UnreadCount(userID int64) (int, error)
+	// MarkRead marks one of the user's notifications as read.
+	MarkRead(id, userID int64) error
+	// MarkAllRead marks all of the user's notifications as read.
+	MarkAllRead(userID int64) error
+}
+
+type WebhookStoreInterface interface {
+	// Insert adds a webhook for a user.
+	Insert(webhook *Webhook) error
+	// Get returns one of the user's webhooks.
+	Get(id, userID int64) (*Webhook, error)
+	// GetAllForUser returns all of the user's webhooks.
+	GetAllForUser(userID int64) ([]Webhook, error)
+	// Delete removes one of the user's webhooks and its deliveries.
+	Delete(id, userID int64) error
+	// Enqueue queues an outbox event about an article for delivery to the webhooks subscribed to its
+	// type whose owners wrote the article or follow its author. Queueing the same event again is a no-op.
+	Enqueue(eventID int64, eventType string, articleID int64, payload json.RawMessage) error
+	// ClaimDue leases up to limit deliveries that are due to be attempted.
+	ClaimDue(limit int, lease time.Duration) ([]WebhookDelivery, error)
+	// RecordAttempt logs a delivery attempt and updates the delivery's status and next attempt time.
+	RecordAttempt(deliveryID int64, attempt WebhookAttempt, status string, nextAttemptAt time.Time) error
+	// GetDeliveries returns a page of a webhook's deliveries with their attempt logs, and the total number of deliveries.
+	GetDeliveries(webhookID int64, limit, offset int) ([]WebhookDelivery, int, error)
+}
+
+type OutboxStoreInterface interface {
+	// WithDispatchLock runs fn while holding the lock that allows a single dispatcher at a time,
+	// and reports whether the lock was acquired.
+	WithDispatchLock(fn func() error) (bool, error)
+	// GetUnpublished returns up to limit due events, skipping aggregates that are waiting for a retry.
+	GetUnpublished(limit int) ([]OutboxEvent, error)
+	// MarkPublished records that an event was published.
+	MarkPublished(id int64) error
+	// MarkFailed records a failed attempt to publish an event and when to retry it.
+	MarkFailed(id int64, lastError string, nextAttemptAt time.Time) error
+	// DeletePublished removes events published longer than retention ago.
+	DeletePublished(retention time.Duration) (int64, error)
+}
+
+type AuditStoreInterface interface {
+	// GetAll returns a page of audit log entries, most recent first, and the total number of entries.
+	GetAll(limit, offset int) ([]AuditEntry, int, error)
+}
+
+type ReportStoreInterface interface {
+	// Insert files an open report. Returns ErrDuplicateReport if the reporter already has an open report of the content.
+	Insert(report *Report) error
+	// Get retrieves a report by its ID.
+	Get(id int64) (*Report, error)
+	// List returns a page of the reports with the given status, oldest first, and the total number of such reports.
+	List(status string, limit, offset int) ([]Report, int, error)
+	// Resolve dismisses a report, or hides, deletes or approves the reported content and resolves all of its open reports.
+	Resolve(report *Report, status string, actor *User) error
+}
+
+type ExportStoreInterface interface {
+	// Get returns the state of the user's latest data export.
+	Get(userID int64) (*UserExport, error)
+	// Start records a new pending export for the user, replacing their earlier export.
+	Start(userID int64) (*UserExport, error)
+	// Build collects the user's data into an archive that can be downloaded until ttl has passed.
+	Build(userID int64, ttl time.Duration) error
+	// GetArchive returns the archive of the user's ready export. Returns ErrRecordNotFound if there is none.
+	GetArchive(userID int64) ([]byte, error)
+	// Delete removes the user's export.
+	Delete(userID int64) error
+}
+
+type TwoFactorStoreInterface interface {
+	// Get returns the user's two-factor authentication. Returns ErrRecordNotFound if they have not enrolled.
+	Get(userID int64) (*TwoFactor, error)
+	// Enroll starts a new enrollment, replacing an unconfirmed one. Returns ErrTwoFactorEnabled if two-factor authentication is already enabled.
+	Enroll(userID int64, secret string, recoveryCodes []string) error
+	// Confirm enables the user's pending enrollment. Returns ErrRecordNotFound if there is none.
+	Confirm(userID int64, step int64) error
+	// UseStep records a login with the code of the given step and reports whether the step had not been used yet.
+	UseStep(userID int64, step int64) (bool, error)
+	// UseRecoveryCode marks a recovery code as used and reports whether it was one of the user's unused codes.
+	UseRecoveryCode(userID int64, code string) (bool, error)
+	// RecordFailedAttempt records a wrong code entered at login and reports whether the user is now locked out.
+	RecordFailedAttempt(userID int64) (bool, error)
+	// ResetFailedAttempts forgets the wrong codes entered before a successful login.
+	ResetFailedAttempts(userID int64) error
+	// Disable turns off the user's two-factor authentication.
+	Disable(userID int64) error
+}
+
+type IdentityStoreInterface interface {
+	// Get returns the identity with the given subject at the provider. Returns ErrRecordNotFound if it is not linked.
+	Get(provider, subject string) (*Identity, error)
+	// Link links an identity to an existing user. Returns ErrIdentityLinked if either already has a link at the provider.
+	Link(identity *Identity) error
+	// CreateUser creates a user for a new identity, making their username unique. Returns ErrDuplicateEmail if the email address is taken.
+	CreateUser(user *User, identity *Identity) error
+	// SaveAuthRequest records a login started at a provider and drops those older than ttl.
+	SaveAuthRequest(request *AuthRequest, ttl time.Duration) error
+	// TakeAuthRequest removes and returns a login by its state. Returns ErrRecordNotFound if there is none younger than ttl.
+	TakeAuthRequest(state string, ttl time.Duration) (*AuthRequest, error)
 }
@@ -0,0 +1,243 @@
+package data
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var ErrTwoFactorEnabled = errors.New("two-factor authentication already enabled")
+
+// RecoveryCodeCount is the number of recovery codes generated when a user enrolls in two-factor authentication.
+const RecoveryCodeCount = 10
+
+// MaxTwoFactorAttempts is the number of wrong codes a user can enter at login before they are locked out
+// for TwoFactorLockout. A six-digit code cannot be guessed within a few attempts.
+const (
+	MaxTwoFactorAttempts = 5
+	TwoFactorLockout     = 15 * time.Minute
+)
+
+// TwoFactor is a user's TOTP two-factor authentication. It is enabled once the user has confirmed
+// their enrollment with a code.
+type TwoFactor struct {
+	UserID      int64
+	Secret      string
+	LastStep    *int64
+	CreatedAt   time.Time
+	ConfirmedAt *time.Time
+	// Locked is set while the user is locked out after entering too many wrong codes.
+	Locked bool
+}
+
+// Enabled reports whether the user has confirmed their enrollment.
+func (t *TwoFactor) Enabled() bool {
+	return t.ConfirmedAt != nil
+}
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// NewRecoveryCodes generates RecoveryCodeCount random recovery codes, formatted as "xxxxx-xxxxx".
+func NewRecoveryCodes() ([]string, error) {
+	codes := make([]string, RecoveryCodeCount)
+	for i := range codes {
+		b := make([]byte, 5)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		code := strings.ToLower(recoveryCodeEncoding.EncodeToString(b))
+		codes[i] = code[:5] + "-" + code[5:]
+	}
+	return codes, nil
+}
+
+// hashRecoveryCode hashes a recovery code as entered by the user, ignoring case, spaces and dashes.
+// Recovery codes are random, so a fast hash is enough.
+func hashRecoveryCode(code string) []byte {
+	code = strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	sum := sha256.Sum256([]byte(code))
+	return sum[:]
+}
+
+type TwoFactorStore struct {
+	db      *pgxpool.Pool
+	timeout time.Duration
+}
+
+// Get returns the user's two-factor authentication, which may still be pending confirmation.
+func (s TwoFactorStore) Get(userID int64) (*TwoFactor, error) {
+	query := `
+		SELECT user_id, secret, last_step, created_at, confirmed_at,
+		       COALESCE(locked_until > NOW() AT TIME ZONE 'UTC', false)
+		FROM user_two_factor
+		WHERE user_id = $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	var twoFactor TwoFactor
+	err := s.db.QueryRow(ctx, query, userID).Scan(
+		&twoFactor.UserID, &twoFactor.Secret, &twoFactor.LastStep, &twoFactor.CreatedAt, &twoFactor.ConfirmedAt,
+		&twoFactor.Locked)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+	return &twoFactor, nil
+}
+
+// Enroll starts the user's enrollment with a new secret and recovery codes, replacing an earlier
+// enrollment that was not confirmed. Returns ErrTwoFactorEnabled if two-factor authentication is
+// already enabled.
+func (s TwoFactorStore) Enroll(userID int64, secret string, recoveryCodes []string) error {
+	query := `
+		INSERT INTO user_two_factor (user_id, secret) VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE
+		SET secret = EXCLUDED.secret, last_step = NULL, created_at = EXCLUDED.created_at
+		WHERE user_two_factor.confirmed_at IS NULL`
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	return pgx.BeginFunc(ctx, s.db, func(tx pgx.Tx) error {
+		result, err := tx.Exec(ctx, query, userID, secret)
+		if err != nil {
+			return err
+		}
+		if result.RowsAffected() == 0 {
+			return ErrTwoFactorEnabled
+		}
+
+		if _, err := tx.Exec(ctx, `DELETE FROM user_recovery_codes WHERE user_id = $1`, userID); err != nil {
+			return err
+		}
+		rows := make([][]any, len(recoveryCodes))
+		for i, code := range recoveryCodes {
+			rows[i] = []any{userID, hashRecoveryCode(code)}
+		}
+		_, err = tx.CopyFrom(ctx, pgx.Identifier{"user_recovery_codes"}, []string{"user_id", "code_hash"}, pgx.CopyFromRows(rows))
+		return err
+	})
+}
+
+// Confirm enables the user's pending two-factor authentication with the step of the code they entered.
+// Returns ErrRecordNotFound if there is no pending enrollment.
+func (s TwoFactorStore) Confirm(userID int64, step int64) error {
+	query := `
+		UPDATE user_two_factor
+		SET confirmed_at = NOW() AT TIME ZONE 'UTC', last_step = $2
+		WHERE user_id = $1 AND confirmed_at IS NULL`
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	result, err := s.db.Exec(ctx, query, userID, step)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrRecordNotFound
+	}
+	return nil
+}
+
+// UseStep records that the user logged in with the code of the given step, and reports whether the
+// step was later than that of the last code used. A code can therefore only be used once.
+func (s TwoFactorStore) UseStep(userID int64, step int64) (bool, error) {
+	query := `
+		UPDATE user_two_factor
+		SET last_step = $2
+		WHERE user_id = $1 AND confirmed_at IS NOT NULL AND (last_step IS NULL OR last_step < $2)`
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	result, err := s.db.Exec(ctx, query, userID, step)
+	if err != nil {
+		return false, err
+	}
+	return result.RowsAffected() == 1, nil
+}
+
+// UseRecoveryCode marks one of the user's unused recovery codes as used, and reports whether the
+// code was one of them.
+func (s TwoFactorStore) UseRecoveryCode(userID int64, code string) (bool, error) {
+	query := `
+		UPDATE user_recovery_codes
+		SET used_at = NOW() AT TIME ZONE 'UTC'
+		WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL`
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	result, err := s.db.Exec(ctx, query, userID, hashRecoveryCode(code))
+	if err != nil {
+		return false, err
+	}
+	return result.RowsAffected() == 1, nil
+}
+
+// RecordFailedAttempt records that the user entered a wrong code at login, and reports whether they are
+// now locked out. The user is locked out for TwoFactorLockout after MaxTwoFactorAttempts wrong codes in a
+// row, after which they get MaxTwoFactorAttempts more.
+func (s TwoFactorStore) RecordFailedAttempt(userID int64) (bool, error) {
+	query := `
+		UPDATE user_two_factor
+		SET failed_attempts = CASE WHEN failed_attempts + 1 >= $2 THEN 0 ELSE failed_attempts + 1 END,
+		    locked_until = CASE
+		        WHEN failed_attempts + 1 >= $2 THEN (NOW() AT TIME ZONE 'UTC') + make_interval(secs => $3)
+		        ELSE locked_until
+		    END
+		WHERE user_id = $1
+		RETURNING COALESCE(locked_until > NOW() AT TIME ZONE 'UTC', false)`
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	var locked bool
+	err := s.db.QueryRow(ctx, query, userID, MaxTwoFactorAttempts, TwoFactorLockout.Seconds()).Scan(&locked)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, ErrRecordNotFound
+		}
+		return false, err
+	}
+	return locked, nil
+}
+
+// ResetFailedAttempts forgets the wrong codes the user entered before logging in successfully.
+func (s TwoFactorStore) ResetFailedAttempts(userID int64) error {
+	query := `
+		UPDATE user_two_factor
+		SET failed_attempts = 0, locked_until = NULL
+		WHERE user_id = $1 AND (failed_attempts > 0 OR locked_until IS NOT NULL)`
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	_, err := s.db.Exec(ctx, query, userID)
+	return err
+}
+
+// Disable turns off the user's two-factor authentication and deletes their recovery codes.
+func (s TwoFactorStore) Disable(userID int64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	return pgx.BeginFunc(ctx, s.db, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, `DELETE FROM user_recovery_codes WHERE user_id = $1`, userID); err != nil {
+			return err
+		}
+		_, err := tx.Exec(ctx, `DELETE FROM user_two_factor WHERE user_id = $1`, userID)
+		return err
+	})
+}
@@ -3,12 +3,13 @@ package data
 import (
 	"context"
 	"errors"
+	"strings"
 	"time"
 
+	"github.com/96malhar/realworld-backend/internal/passwords"
 	"github.com/96malhar/realworld-backend/internal/validator"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
-	"golang.org/x/crypto/bcrypt"
 )
 
 var (
@@ -26,15 +27,31 @@ type User struct {
 	Image    string   `json:"image"`
 	Bio      string   `json:"bio"`
 	Token    string   `json:"token"`
+	Role     string   `json:"-"`
 	Version  int      `json:"-"`
+
+	CreatedAt             time.Time  `json:"-"`
+	SuspendedAt           *time.Time `json:"-"`
+	SuspensionReason      string     `json:"-"`
+	PasswordResetRequired bool       `json:"-"`
 }
 
 // Profile represents a user's public profile with follow status.
+// The counts are only filled in when a single profile is requested, and are omitted
+// when the profile is embedded as an article or comment author.
 type Profile struct {
-	Username  string `json:"username"`
-	Bio       string `json:"bio"`
-	Image     string `json:"image"`
-	Following bool   `json:"following"`
+	Username       string `json:"username"`
+	Bio            string `json:"bio"`
+	Image          string `json:"image"`
+	Following      bool   `json:"following"`
+	FollowersCount *int   `json:"followersCount,omitempty"`
+	FollowingCount *int   `json:"followingCount,omitempty"`
+	ArticlesCount  *int   `json:"articlesCount,omitempty"`
+}
+
+// IsSuspended returns true if an admin has suspended the user's account.
+func (u *User) IsSuspended() bool {
+	return u.SuspendedAt != nil
 }
 
 // IsAnonymous returns true if the user is the special AnonymousUser user.
@@ -42,6 +59,21 @@ func (u *User) IsAnonymous() bool {
 	return u == AnonymousUser
 }
 
+// HasPassword reports whether the user has a password. Accounts created through an identity provider
+// have none until the user sets one.
+func (u *User) HasPassword() bool {
+	return u.Password.isUsable()
+}
+
+// viewerID returns the ID used to personalise queries for the given user.
+// Anonymous users map to -1, which never matches a real user ID.
+func viewerID(u *User) int64 {
+	if u == nil || u.IsAnonymous() {
+		return -1
+	}
+	return u.ID
+}
+
 // ToProfile converts a User to a Profile with the specified following status.
 func (u *User) ToProfile(following bool) Profile {
 	return Profile{
@@ -57,8 +89,9 @@ type password struct {
 	hash      []byte
 }
 
-func (p *password) Set(plaintextPassword string) error {
-	hash, err := bcrypt.GenerateFromPassword([]byte(plaintextPassword), 12)
+// Set hashes the plaintext password with the hasher.
+func (p *password) Set(hasher passwords.Hasher, plaintextPassword string) error {
+	hash, err := hasher.Hash(plaintextPassword)
 	if err != nil {
 		return err
 	}
@@ -69,19 +102,30 @@ func (p *password) Set(plaintextPassword string) error {
 	return nil
 }
 
+// SetUnusable leaves the user without a password, for accounts created through an identity provider.
+// No password matches until one is set.
+func (p *password) SetUnusable() {
+	p.plaintext = nil
+	p.hash = []byte{}
+}
+
+// isUsable reports whether the user has a password they can log in with.
+func (p *password) isUsable() bool {
+	return len(p.hash) > 0
+}
+
 // Matches compares the plaintext password against the hash and returns true if they match.
-func (p *password) Matches(plaintextPassword string) (bool, error) {
-	err := bcrypt.CompareHashAndPassword(p.hash, []byte(plaintextPassword))
-	if err != nil {
-		switch {
-		case errors.Is(err, bcrypt.ErrMismatchedHashAndPassword):
-			return false, nil
-		default:
-			return false, err
-		}
+func (p *password) Matches(hasher passwords.Hasher, plaintextPassword string) (bool, error) {
+	if !p.isUsable() {
+		return false, nil
 	}
+	return hasher.Matches(p.hash, plaintextPassword)
+}
 
-	return true, nil
+// NeedsRehash reports whether the hash was created with an outdated algorithm or parameters
+// and should be replaced by setting the password again.
+func (p *password) NeedsRehash(hasher passwords.Hasher) bool {
+	return p.isUsable() && hasher.NeedsRehash(p.hash)
 }
 
 func ValidateEmail(v *validator.Validator, email string) {
@@ -89,10 +133,24 @@ func ValidateEmail(v *validator.Validator, email string) {
 	v.Check(validator.Matches(email, validator.EmailRX), "email must be a valid email address")
 }
 
+// maxPasswordLength bounds the work of hashing a password; Argon2id itself has no practical limit.
+const maxPasswordLength = 1024
+
 func ValidatePasswordPlaintext(v *validator.Validator, password string) {
 	v.Check(password != "", "password must be provided")
 	v.Check(len(password) >= 8, "password must be at least 8 bytes long")
-	v.Check(len(password) <= 72, "password must not be more than 72 bytes long")
+	v.Check(len(password) <= maxPasswordLength, "password must not be more than 1024 bytes long")
+}
+
+// ValidatePasswordPolicy checks a new password set on the user against the password policy.
+// It does nothing if the user's password was not changed.
+func ValidatePasswordPolicy(v *validator.Validator, policy passwords.Policy, user User) {
+	if user.Password.plaintext == nil {
+		return
+	}
+	for _, problem := range policy.Check(*user.Password.plaintext, user.Username, user.Email) {
+		v.AddError(problem)
+	}
 }
 
 // ValidateUser checks the values provided by the user are valid. It performs validation on the
@@ -100,6 +158,7 @@ func ValidatePasswordPlaintext(v *validator.Validator, password string) {
 func ValidateUser(v *validator.Validator, user User) {
 	v.Check(user.Username != "", "username must be provided")
 	v.Check(len(user.Username) <= 500, "name must not be more than 500 bytes long")
+	v.Check(!strings.HasPrefix(strings.ToLower(user.Username), deletedUsernamePrefix), "username is reserved")
 
 	ValidateEmail(v, user.Email)
 
@@ -121,17 +180,25 @@ type UserStore struct {
 
 // Insert adds a new record in the users table.
 func (s UserStore) Insert(user *User) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	return pgx.BeginFunc(ctx, s.db, func(tx pgx.Tx) error {
+		return insertUser(ctx, tx, user)
+	})
+}
+
+// insertUser inserts the user within tx, recording their registration in the outbox. Returns
+// ErrDuplicateEmail or ErrDuplicateUsername if either is taken.
+func insertUser(ctx context.Context, tx pgx.Tx, user *User) error {
 	query := `
 		INSERT INTO users (username, email, password_hash, image, bio) 
 		VALUES ($1, $2, $3, $4, $5)
-		RETURNING id`
+		RETURNING id, role, created_at`
 
 	args := []any{user.Username, user.Email, user.Password.hash, user.Image, user.Bio}
 
-	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
-	defer cancel()
-
-	err := s.db.QueryRow(ctx, query, args...).Scan(&user.ID)
+	err := tx.QueryRow(ctx, query, args...).Scan(&user.ID, &user.Role, &user.CreatedAt)
 	if err != nil {
 		switch {
 		case err.Error() == `ERROR: duplicate key value violates unique constraint "users_email_key" (SQLSTATE 23505)`:
@@ -142,22 +209,36 @@ func (s UserStore) Insert(user *User) error {
 			return err
 		}
 	}
-	return nil
+	return insertOutboxEvent(ctx, tx, AggregateUser, user.ID, EventUserRegistered, newUserEvent(user))
 }
 
 // GetByEmail retrieves a user by their email address.
 func (s UserStore) GetByEmail(email string) (*User, error) {
 	query := `
-		SELECT id, username, email, password_hash, image, bio, version
+		SELECT id, username, email, password_hash, image, bio, role, version,
+		       created_at, suspended_at, suspension_reason, password_reset_required
 		FROM users
-		WHERE email = $1`
+		WHERE email = $1 AND deleted_at IS NULL`
 
 	var user User
 
 	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
 	defer cancel()
 
-	err := s.db.QueryRow(ctx, query, email).Scan(&user.ID, &user.Username, &user.Email, &user.Password.hash, &user.Image, &user.Bio, &user.Version)
+	err := s.db.QueryRow(ctx, query, email).Scan(
+		&user.ID,
+		&user.Username,
+		&user.Email,
+		&user.Password.hash,
+		&user.Image,
+		&user.Bio,
+		&user.Role,
+		&user.Version,
+		&user.CreatedAt,
+		&user.SuspendedAt,
+		&user.SuspensionReason,
+		&user.PasswordResetRequired,
+	)
 	if err != nil {
 		switch {
 		case errors.Is(err, pgx.ErrNoRows):
@@ -181,9 +262,10 @@ func (s UserStore) GetByID(id int64) (*User, error) {
 	}
 
 	query := `
-		SELECT id, username, email, password_hash, image, bio, version
+		SELECT id, username, email, password_hash, image, bio, role, version,
+		       created_at, suspended_at, suspension_reason, password_reset_required
 		FROM users
-		WHERE id = $1`
+		WHERE id = $1 AND deleted_at IS NULL`
 
 	var user User
 
@@ -197,7 +279,12 @@ func (s UserStore) GetByID(id int64) (*User, error) {
 		&user.Password.hash,
 		&user.Image,
 		&user.Bio,
+		&user.Role,
 		&user.Version,
+		&user.CreatedAt,
+		&user.SuspendedAt,
+		&user.SuspensionReason,
+		&user.PasswordResetRequired,
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -216,7 +303,11 @@ func (s UserStore) GetByID(id int64) (*User, error) {
 
 // GetByUsername retrieves a user by their username from the database.
 func (s UserStore) GetByUsername(username string) (*User, error) {
-	query := `SELECT id, username, email, image, bio, version FROM users WHERE username = $1`
+	query := `
+		SELECT id, username, email, image, bio, role, version,
+		       created_at, suspended_at, suspension_reason, password_reset_required
+		FROM users
+		WHERE username = $1 AND deleted_at IS NULL`
 	var user User
 
 	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
@@ -228,7 +319,12 @@ func (s UserStore) GetByUsername(username string) (*User, error) {
 		&user.Email,
 		&user.Image,
 		&user.Bio,
+		&user.Role,
 		&user.Version,
+		&user.CreatedAt,
+		&user.SuspendedAt,
+		&user.SuspensionReason,
+		&user.PasswordResetRequired,
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -240,15 +336,40 @@ func (s UserStore) GetByUsername(username string) (*User, error) {
 }
 
 // FollowUser creates a follow relationship between two users.
+// Returns ErrBlocked if the followed user has blocked the follower.
 func (s UserStore) FollowUser(followerID, followedID int64) error {
 	if followerID == followedID {
 		return errors.New("cannot follow yourself")
 	}
-	query := `INSERT INTO follows (follower_id, followed_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`
+	// The follow is only inserted if the followed user has not blocked the follower
+	query := `
+		WITH block AS (
+			SELECT EXISTS(SELECT 1 FROM blocks WHERE blocker_id = $2 AND blocked_id = $1) AS blocked
+		),
+		follow_insert AS (
+			INSERT INTO follows (follower_id, followed_id)
+			SELECT $1, $2 FROM block WHERE NOT blocked
+			ON CONFLICT DO NOTHING
+			RETURNING follower_id
+		)
+		SELECT blocked, EXISTS(SELECT 1 FROM follow_insert) FROM block`
 	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
 	defer cancel()
-	_, err := s.db.Exec(ctx, query, followerID, followedID)
-	return err
+	var blocked bool
+	err := pgx.BeginFunc(ctx, s.db, func(tx pgx.Tx) error {
+		var followed bool
+		if err := tx.QueryRow(ctx, query, followerID, followedID).Scan(&blocked, &followed); err != nil || !followed {
+			return err
+		}
+		return insertOutboxEvent(ctx, tx, AggregateUser, followedID, EventUserFollowed, followEvent{followerID, followedID})
+	})
+	if err != nil {
+		return err
+	}
+	if blocked {
+		return ErrBlocked
+	}
+	return nil
 }
 
 // UnfollowUser removes a follow relationship between two users.
@@ -256,8 +377,13 @@ func (s UserStore) UnfollowUser(followerID, followedID int64) error {
 	query := `DELETE FROM follows WHERE follower_id = $1 AND followed_id = $2`
 	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
 	defer cancel()
-	_, err := s.db.Exec(ctx, query, followerID, followedID)
-	return err
+	return pgx.BeginFunc(ctx, s.db, func(tx pgx.Tx) error {
+		result, err := tx.Exec(ctx, query, followerID, followedID)
+		if err != nil || result.RowsAffected() == 0 {
+			return err
+		}
+		return insertOutboxEvent(ctx, tx, AggregateUser, followedID, EventUserUnfollowed, followEvent{followerID, followedID})
+	})
 }
 
 // IsFollowing checks if followerID is following followedID.
@@ -270,19 +396,206 @@ func (s UserStore) IsFollowing(followerID, followedID int64) (bool, error) {
 	return exists, err
 }
 
+// BlockUser records that blocker has blocked the other user and removes any follows between them.
+// Blocking a user twice is a no-op.
+func (s UserStore) BlockUser(blockerID, blockedID int64) error {
+	query := `
+		WITH block_insert AS (
+			INSERT INTO blocks (blocker_id, blocked_id) VALUES ($1, $2)
+			ON CONFLICT DO NOTHING
+		)
+		DELETE FROM follows
+		WHERE (follower_id = $1 AND followed_id = $2) OR (follower_id = $2 AND followed_id = $1)`
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+	_, err := s.db.Exec(ctx, query, blockerID, blockedID)
+	return err
+}
+
+// UnblockUser removes a block. Follows removed by the block are not restored.
+func (s UserStore) UnblockUser(blockerID, blockedID int64) error {
+	query := `DELETE FROM blocks WHERE blocker_id = $1 AND blocked_id = $2`
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+	_, err := s.db.Exec(ctx, query, blockerID, blockedID)
+	return err
+}
+
+// MuteUser hides the muted user's articles and comments from the muter. Muting a user twice is a no-op.
+func (s UserStore) MuteUser(muterID, mutedID int64) error {
+	query := `INSERT INTO mutes (muter_id, muted_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+	_, err := s.db.Exec(ctx, query, muterID, mutedID)
+	return err
+}
+
+// UnmuteUser removes a mute.
+func (s UserStore) UnmuteUser(muterID, mutedID int64) error {
+	query := `DELETE FROM mutes WHERE muter_id = $1 AND muted_id = $2`
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+	_, err := s.db.Exec(ctx, query, muterID, mutedID)
+	return err
+}
+
+// GetProfile retrieves the public profile of the user with the given username, together with
+// their follower, following and published article counts and whether the viewer follows them.
+// Everything is fetched in a single query.
+func (s UserStore) GetProfile(username string, viewer *User) (*Profile, error) {
+	query := `
+		SELECT u.username, u.bio, u.image,
+		       EXISTS(SELECT 1 FROM follows WHERE follower_id = $2 AND followed_id = u.id),
+		       (SELECT COUNT(*) FROM follows WHERE followed_id = u.id),
+		       (SELECT COUNT(*) FROM follows WHERE follower_id = u.id),
+		       (SELECT COUNT(*) FROM articles WHERE author_id = u.id AND status = 'published' AND deleted_at IS NULL)
+		FROM users u
+		WHERE u.username = $1 AND u.deleted_at IS NULL`
+
+	var profile Profile
+	var followers, following, articles int
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	err := s.db.QueryRow(ctx, query, username, viewerID(viewer)).Scan(
+		&profile.Username,
+		&profile.Bio,
+		&profile.Image,
+		&profile.Following,
+		&followers,
+		&following,
+		&articles,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	profile.FollowersCount = &followers
+	profile.FollowingCount = &following
+	profile.ArticlesCount = &articles
+	return &profile, nil
+}
+
+// GetFollowerIDs returns the IDs of all users following the given user.
+func (s UserStore) GetFollowerIDs(userID int64) ([]int64, error) {
+	query := `SELECT follower_id FROM follows WHERE followed_id = $1`
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	rows, err := s.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowTo[int64])
+}
+
+// GetFollowers returns a page of the profiles following the given user, most recent follows first,
+// along with the total number of followers.
+func (s UserStore) GetFollowers(userID int64, viewer *User, limit, offset int) ([]Profile, int, error) {
+	return s.listFollowProfiles("f.follower_id", "f.followed_id", userID, viewer, limit, offset)
+}
+
+// GetFollowing returns a page of the profiles the given user follows, most recent follows first,
+// along with the total number of followed users.
+func (s UserStore) GetFollowing(userID int64, viewer *User, limit, offset int) ([]Profile, int, error) {
+	return s.listFollowProfiles("f.followed_id", "f.follower_id", userID, viewer, limit, offset)
+}
+
+// listFollowProfiles lists the users on one side of the follows relationship of userID.
+// profileColumn is the follows column holding the listed users and userColumn the one matching userID.
+// The viewer's following flag comes from a LEFT JOIN so the whole page is fetched in a single query.
+func (s UserStore) listFollowProfiles(profileColumn, userColumn string, userID int64, viewer *User, limit, offset int) ([]Profile, int, error) {
+	query := `
+		SELECT u.username, u.bio, u.image,
+		       vf.follower_id IS NOT NULL AS following,
+		       COUNT(*) OVER() AS total_count
+		FROM follows f
+		JOIN users u ON u.id = ` + profileColumn + `
+		LEFT JOIN follows vf ON vf.followed_id = u.id AND vf.follower_id = $2
+		WHERE ` + userColumn + ` = $1
+		ORDER BY f.created_at DESC, u.username
+		LIMIT $3 OFFSET $4`
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	rows, err := s.db.Query(ctx, query, userID, viewerID(viewer), limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	profiles := []Profile{}
+	totalCount := 0
+	for rows.Next() {
+		var profile Profile
+		err := rows.Scan(&profile.Username, &profile.Bio, &profile.Image, &profile.Following, &totalCount)
+		if err != nil {
+			return nil, 0, err
+		}
+		profiles = append(profiles, profile)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return profiles, totalCount, nil
+}
+
+// RehashPassword replaces the user's password hash with a new hash of the plaintext password, unless
+// the password was changed in the meantime. It is not an edit of the user, so their version is kept.
+func (s UserStore) RehashPassword(user *User, hasher passwords.Hasher, plaintextPassword string) error {
+	query := `UPDATE users SET password_hash = $1 WHERE id = $2 AND password_hash = $3`
+
+	previous := user.Password.hash
+	if err := user.Password.Set(hasher, plaintextPassword); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	_, err := s.db.Exec(ctx, query, user.Password.hash, user.ID, previous)
+	if err != nil {
+		return err
+	}
+
+	if s.userCache != nil {
+		s.userCache.Delete(user.ID)
+	}
+
+	return nil
+}
+
 // Update updates an existing user record in the database.
+// Setting a new password completes a reset forced by an admin; otherwise the user's PasswordResetRequired
+// is left as it is in the database, since a reset may have been forced after the user was loaded.
 // Invalidates the cache for the updated user.
 func (s UserStore) Update(user *User) error {
 	query := `
 		UPDATE users
-		SET username = $1, email = $2, password_hash = $3, image = $4, bio = $5, version = version + 1
-		WHERE id = $6
-		RETURNING version`
-	args := []any{user.Username, user.Email, user.Password.hash, user.Image, user.Bio, user.ID}
+		SET username = $1, email = $2, password_hash = $3, image = $4, bio = $5,
+		    password_reset_required = password_reset_required AND NOT $6, version = version + 1
+		WHERE id = $7
+		RETURNING version, password_reset_required`
+	passwordChanged := user.Password.plaintext != nil
+	args := []any{user.Username, user.Email, user.Password.hash, user.Image, user.Bio, passwordChanged, user.ID}
 	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
 	defer cancel()
 
-	err := s.db.QueryRow(ctx, query, args...).Scan(&user.Version)
+	err := pgx.BeginFunc(ctx, s.db, func(tx pgx.Tx) error {
+		if err := tx.QueryRow(ctx, query, args...).Scan(&user.Version, &user.PasswordResetRequired); err != nil {
+			return err
+		}
+		return insertOutboxEvent(ctx, tx, AggregateUser, user.ID, EventUserUpdated, newUserEvent(user))
+	})
 	if err != nil {
 		return err
 	}
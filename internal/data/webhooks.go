@@ -0,0 +1,375 @@
+package data
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"time"
+
+	"github.com/96malhar/realworld-backend/internal/validator"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Webhook event types, a subset of the outbox event types. Events are only sent for published articles
+// and comments on them. Articles created as published only send article.created; article.published is
+// sent when a draft or scheduled article is published later.
+const (
+	WebhookArticleCreated   = EventArticleCreated
+	WebhookArticlePublished = EventArticlePublished
+	WebhookArticleUpdated   = EventArticleUpdated
+	WebhookArticleDeleted   = EventArticleDeleted
+	WebhookCommentCreated   = EventCommentCreated
+)
+
+// WebhookEventTypes lists every event type a webhook can subscribe to.
+var WebhookEventTypes = []string{
+	WebhookArticleCreated, WebhookArticlePublished, WebhookArticleUpdated, WebhookArticleDeleted, WebhookCommentCreated,
+}
+
+// Webhook delivery statuses. Pending deliveries are retried until they succeed or run out of attempts.
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed"
+)
+
+// Webhook is a URL registered by a user to receive events of the given types.
+// Secret is only returned when the webhook is created.
+type Webhook struct {
+	ID        int64     `json:"id"`
+	UserID    int64     `json:"-"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func ValidateWebhook(v *validator.Validator, webhook *Webhook) {
+	u, err := url.Parse(webhook.URL)
+	v.Check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "",
+		"URL must be an absolute http or https URL")
+
+	v.Check(len(webhook.Events) > 0, "Events must contain at least one event type")
+	v.Check(validator.Unique(webhook.Events), "Events must not contain duplicate values")
+	for _, event := range webhook.Events {
+		if !validator.PermittedValue(event, WebhookEventTypes...) {
+			v.AddError("Events must only contain article.created, article.published, article.updated, article.deleted or comment.created")
+			break
+		}
+	}
+}
+
+// WebhookDelivery is a queued event for a webhook, with a log of every attempt to deliver it.
+type WebhookDelivery struct {
+	ID            int64            `json:"id"`
+	EventType     string           `json:"event"`
+	Payload       json.RawMessage  `json:"payload"`
+	Status        string           `json:"status"`
+	Attempts      int              `json:"attempts"`
+	NextAttemptAt *time.Time       `json:"nextAttemptAt,omitempty"`
+	AttemptLog    []WebhookAttempt `json:"attemptLog"`
+	CreatedAt     time.Time        `json:"createdAt"`
+	UpdatedAt     time.Time        `json:"updatedAt"`
+
+	// URL and Secret of the webhook, populated for deliveries claimed for sending.
+	URL    string `json:"-"`
+	Secret string `json:"-"`
+}
+
+// WebhookAttempt records the outcome of a single delivery attempt. StatusCode is nil if no
+// response was received, in which case Error describes what went wrong.
+type WebhookAttempt struct {
+	Attempt    int       `json:"attempt"`
+	StatusCode *int      `json:"statusCode"`
+	Error      string    `json:"error,omitempty"`
+	DurationMs int64     `json:"durationMs"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+type WebhookStore struct {
+	db      *pgxpool.Pool
+	timeout time.Duration
+}
+
+// Insert adds a webhook for the user and sets its ID and creation time.
+func (s *WebhookStore) Insert(webhook *Webhook) error {
+	query := `
+		INSERT INTO webhooks (user_id, url, events, secret)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	return s.db.QueryRow(ctx, query, webhook.UserID, webhook.URL, webhook.Events, webhook.Secret).
+		Scan(&webhook.ID, &webhook.CreatedAt)
+}
+
+// Get returns one of the user's webhooks, without its secret.
+func (s *WebhookStore) Get(id, userID int64) (*Webhook, error) {
+	query := `SELECT id, user_id, url, events, created_at FROM webhooks WHERE id = $1 AND user_id = $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	var webhook Webhook
+	err := s.db.QueryRow(ctx, query, id, userID).
+		Scan(&webhook.ID, &webhook.UserID, &webhook.URL, &webhook.Events, &webhook.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+
+	return &webhook, nil
+}
+
+// GetAllForUser returns the user's webhooks, oldest first, without their secrets.
+func (s *WebhookStore) GetAllForUser(userID int64) ([]Webhook, error) {
+	query := `SELECT id, user_id, url, events, created_at FROM webhooks WHERE user_id = $1 ORDER BY id`
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	rows, err := s.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	webhooks := []Webhook{}
+	for rows.Next() {
+		var webhook Webhook
+		if err := rows.Scan(&webhook.ID, &webhook.UserID, &webhook.URL, &webhook.Events, &webhook.CreatedAt); err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, webhook)
+	}
+
+	return webhooks, rows.Err()
+}
+
+// Delete removes one of the user's webhooks along with its queued deliveries.
+// Returns ErrRecordNotFound if the user has no webhook with that ID.
+func (s *WebhookStore) Delete(id, userID int64) error {
+	query := `DELETE FROM webhooks WHERE id = $1 AND user_id = $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	result, err := s.db.Exec(ctx, query, id, userID)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrRecordNotFound
+	}
+	return nil
+}
+
+// Enqueue queues an outbox event about an article, or a comment on it, for delivery to the webhooks
+// subscribed to its type whose owners wrote the article or follow its author. Nothing is queued if the
+// article has been hidden or permanently deleted since. Each event is queued at most once per webhook,
+// so publishing an event again is a no-op.
+func (s *WebhookStore) Enqueue(eventID int64, eventType string, articleID int64, payload json.RawMessage) error {
+	query := `
+		INSERT INTO webhook_deliveries (webhook_id, outbox_event_id, event_type, payload)
+		SELECT w.id, $1, $2::text, $3::jsonb
+		FROM webhooks w
+		JOIN articles a ON a.id = $4
+		WHERE $2::text = ANY(w.events) AND a.hidden_at IS NULL
+		  AND (a.author_id = w.user_id
+		       OR EXISTS (SELECT 1 FROM follows f WHERE f.follower_id = w.user_id AND f.followed_id = a.author_id))
+		ON CONFLICT (webhook_id, outbox_event_id) DO NOTHING
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	_, err := s.db.Exec(ctx, query, eventID, eventType, string(payload), articleID)
+	return err
+}
+
+// ClaimDue returns up to limit pending deliveries whose next attempt is due, oldest first, along with the
+// URL and secret of their webhooks. Claimed deliveries are leased: their next attempt is pushed back by
+// lease so that other dispatchers skip them, and they become due again if no attempt is recorded in time.
+func (s *WebhookStore) ClaimDue(limit int, lease time.Duration) ([]WebhookDelivery, error) {
+	query := `
+		WITH due AS (
+			SELECT id
+			FROM webhook_deliveries
+			WHERE status = 'pending' AND next_attempt_at <= (NOW() AT TIME ZONE 'UTC')
+			ORDER BY next_attempt_at, id
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		), claimed AS (
+			UPDATE webhook_deliveries d
+			SET next_attempt_at = (NOW() AT TIME ZONE 'UTC') + make_interval(secs => $2)
+			FROM due
+			WHERE d.id = due.id
+			RETURNING d.id, d.webhook_id, d.event_type, d.payload, d.status, d.attempts,
+			          d.next_attempt_at, d.created_at, d.updated_at
+		)
+		SELECT c.id, c.event_type, c.payload, c.status, c.attempts, c.next_attempt_at,
+		       c.created_at, c.updated_at, w.url, w.secret
+		FROM claimed c
+		INNER JOIN webhooks w ON w.id = c.webhook_id
+		ORDER BY c.created_at, c.id
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	rows, err := s.db.Query(ctx, query, limit, lease.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []WebhookDelivery{}
+	for rows.Next() {
+		var d WebhookDelivery
+		err := rows.Scan(
+			&d.ID,
+			&d.EventType,
+			&d.Payload,
+			&d.Status,
+			&d.Attempts,
+			&d.NextAttemptAt,
+			&d.CreatedAt,
+			&d.UpdatedAt,
+			&d.URL,
+			&d.Secret,
+		)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+
+	return deliveries, rows.Err()
+}
+
+// RecordAttempt logs an attempt to deliver a delivery and moves it to the given status.
+// Pending deliveries are retried at nextAttemptAt.
+func (s *WebhookStore) RecordAttempt(deliveryID int64, attempt WebhookAttempt, status string, nextAttemptAt time.Time) error {
+	query := `
+		WITH attempt AS (
+			INSERT INTO webhook_delivery_attempts (delivery_id, attempt, status_code, error, duration_ms)
+			VALUES ($1, $2, $3, NULLIF($4, ''), $5)
+		)
+		UPDATE webhook_deliveries
+		SET attempts = $2, status = $6, next_attempt_at = $7, updated_at = NOW() AT TIME ZONE 'UTC'
+		WHERE id = $1
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	_, err := s.db.Exec(ctx, query, deliveryID, attempt.Attempt, attempt.StatusCode, attempt.Error,
+		attempt.DurationMs, status, nextAttemptAt.UTC())
+	return err
+}
+
+// GetDeliveries returns a page of a webhook's deliveries, most recent first, with their attempt logs,
+// along with the total number of deliveries.
+func (s *WebhookStore) GetDeliveries(webhookID int64, limit, offset int) ([]WebhookDelivery, int, error) {
+	query := `
+		SELECT id, event_type, payload, status, attempts, next_attempt_at, created_at, updated_at,
+		       COUNT(*) OVER() AS total_count
+		FROM webhook_deliveries
+		WHERE webhook_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2 OFFSET $3
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	rows, err := s.db.Query(ctx, query, webhookID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	deliveries := []WebhookDelivery{}
+	totalCount := 0
+	for rows.Next() {
+		var d WebhookDelivery
+		var nextAttemptAt time.Time
+		err := rows.Scan(
+			&d.ID,
+			&d.EventType,
+			&d.Payload,
+			&d.Status,
+			&d.Attempts,
+			&nextAttemptAt,
+			&d.CreatedAt,
+			&d.UpdatedAt,
+			&totalCount,
+		)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		// The next attempt time is only meaningful while the delivery is still being retried
+		if d.Status == DeliveryPending {
+			d.NextAttemptAt = &nextAttemptAt
+		}
+		d.AttemptLog = []WebhookAttempt{}
+		deliveries = append(deliveries, d)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	if err = s.loadAttempts(ctx, deliveries); err != nil {
+		return nil, 0, err
+	}
+
+	return deliveries, totalCount, nil
+}
+
+// loadAttempts fills in the attempt logs of the deliveries in a single query.
+func (s *WebhookStore) loadAttempts(ctx context.Context, deliveries []WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, len(deliveries))
+	byID := make(map[int64]*WebhookDelivery, len(deliveries))
+	for i := range deliveries {
+		ids[i] = deliveries[i].ID
+		byID[deliveries[i].ID] = &deliveries[i]
+	}
+
+	query := `
+		SELECT delivery_id, attempt, status_code, COALESCE(error, ''), duration_ms, created_at
+		FROM webhook_delivery_attempts
+		WHERE delivery_id = ANY($1)
+		ORDER BY delivery_id, attempt
+	`
+
+	rows, err := s.db.Query(ctx, query, ids)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var deliveryID int64
+		var a WebhookAttempt
+		if err := rows.Scan(&deliveryID, &a.Attempt, &a.StatusCode, &a.Error, &a.DurationMs, &a.CreatedAt); err != nil {
+			return err
+		}
+		d := byID[deliveryID]
+		d.AttemptLog = append(d.AttemptLog, a)
+	}
+
+	return rows.Err()
+}
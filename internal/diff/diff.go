@@ -0,0 +1,196 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// opKind describes how a single line changed between two texts.
+type opKind int
+
+const (
+	opEqual opKind = iota
+	opDelete
+	opInsert
+)
+
+type op struct {
+	kind opKind
+	line string
+}
+
+// Unified returns a unified diff between texts a and b, labelled with fromName and toName.
+// Each hunk includes up to contextLines lines of unchanged text around the changes.
+// An empty string is returned if the texts are identical.
+func Unified(fromName, toName, a, b string, contextLines int) string {
+	if a == b {
+		return ""
+	}
+
+	ops := lineOps(splitLines(a), splitLines(b))
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", fromName, toName)
+
+	for _, h := range hunks(ops, contextLines) {
+		sb.WriteString(h)
+	}
+
+	return sb.String()
+}
+
+// splitLines splits text into lines, ignoring a single trailing newline.
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// lineOps computes the edit script that turns a into b using the longest common subsequence of lines.
+// Common prefixes and suffixes are stripped first, which keeps the quadratic table small for typical edits.
+func lineOps(a, b []string) []op {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	midA := a[prefix : len(a)-suffix]
+	midB := b[prefix : len(b)-suffix]
+
+	// lcs[i][j] holds the LCS length of midA[i:] and midB[j:]
+	lcs := make([][]int, len(midA)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(midB)+1)
+	}
+	for i := len(midA) - 1; i >= 0; i-- {
+		for j := len(midB) - 1; j >= 0; j-- {
+			if midA[i] == midB[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	ops := make([]op, 0, len(a)+len(b))
+	for _, line := range a[:prefix] {
+		ops = append(ops, op{opEqual, line})
+	}
+
+	i, j := 0, 0
+	for i < len(midA) && j < len(midB) {
+		switch {
+		case midA[i] == midB[j]:
+			ops = append(ops, op{opEqual, midA[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, op{opDelete, midA[i]})
+			i++
+		default:
+			ops = append(ops, op{opInsert, midB[j]})
+			j++
+		}
+	}
+	for ; i < len(midA); i++ {
+		ops = append(ops, op{opDelete, midA[i]})
+	}
+	for ; j < len(midB); j++ {
+		ops = append(ops, op{opInsert, midB[j]})
+	}
+
+	for _, line := range a[len(a)-suffix:] {
+		ops = append(ops, op{opEqual, line})
+	}
+
+	return ops
+}
+
+// hunks groups the edit script into unified diff hunks with the given amount of context.
+func hunks(ops []op, contextLines int) []string {
+	var result []string
+
+	// Line positions (0-based) in a and b at the start of each op
+	posA := make([]int, len(ops)+1)
+	posB := make([]int, len(ops)+1)
+	for k, o := range ops {
+		posA[k+1], posB[k+1] = posA[k], posB[k]
+		if o.kind != opInsert {
+			posA[k+1]++
+		}
+		if o.kind != opDelete {
+			posB[k+1]++
+		}
+	}
+
+	k := 0
+	for k < len(ops) {
+		// Find the next change
+		for k < len(ops) && ops[k].kind == opEqual {
+			k++
+		}
+		if k == len(ops) {
+			break
+		}
+
+		start := max(k-contextLines, 0)
+
+		// Extend the hunk while changes are separated by at most 2*contextLines equal lines
+		end := k
+		for end < len(ops) {
+			if ops[end].kind != opEqual {
+				end++
+				continue
+			}
+			run := end
+			for run < len(ops) && ops[run].kind == opEqual {
+				run++
+			}
+			if run == len(ops) || run-end > 2*contextLines {
+				end = min(end+contextLines, len(ops))
+				break
+			}
+			end = run
+		}
+
+		var sb strings.Builder
+		lenA := posA[end] - posA[start]
+		lenB := posB[end] - posB[start]
+		fmt.Fprintf(&sb, "@@ -%s +%s @@\n", hunkRange(posA[start], lenA), hunkRange(posB[start], lenB))
+		for _, o := range ops[start:end] {
+			switch o.kind {
+			case opEqual:
+				sb.WriteString(" ")
+			case opDelete:
+				sb.WriteString("-")
+			case opInsert:
+				sb.WriteString("+")
+			}
+			sb.WriteString(o.line)
+			sb.WriteString("\n")
+		}
+		result = append(result, sb.String())
+
+		k = end
+	}
+
+	return result
+}
+
+// hunkRange formats the start,length pair of a hunk header. Following the unified
+// format, an empty range refers to the line before the hunk.
+func hunkRange(start, length int) string {
+	if length == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if length == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, length)
+}
@@ -0,0 +1,64 @@
+package diff
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnified(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		a    string
+		b    string
+		want string
+	}{
+		{
+			name: "identical texts",
+			a:    "one\ntwo\n",
+			b:    "one\ntwo\n",
+			want: "",
+		},
+		{
+			name: "single line changed",
+			a:    "one\ntwo\nthree",
+			b:    "one\n2\nthree",
+			want: "--- v1\n+++ v2\n@@ -1,3 +1,3 @@\n one\n-two\n+2\n three\n",
+		},
+		{
+			name: "line added to empty text",
+			a:    "",
+			b:    "hello",
+			want: "--- v1\n+++ v2\n@@ -0,0 +1 @@\n+hello\n",
+		},
+		{
+			name: "line removed",
+			a:    "one\ntwo",
+			b:    "one",
+			want: "--- v1\n+++ v2\n@@ -1,2 +1 @@\n one\n-two\n",
+		},
+		{
+			name: "distant changes produce separate hunks",
+			a:    "a\nb\nc\nd\ne\nf\ng\nh\ni\nj",
+			b:    "A\nb\nc\nd\ne\nf\ng\nh\ni\nJ",
+			want: "--- v1\n+++ v2\n" +
+				"@@ -1,2 +1,2 @@\n-a\n+A\n b\n" +
+				"@@ -9,2 +9,2 @@\n i\n-j\n+J\n",
+		},
+		{
+			name: "nearby changes are merged into one hunk",
+			a:    "a\nb\nc\nd",
+			b:    "A\nb\nc\nD",
+			want: "--- v1\n+++ v2\n@@ -1,4 +1,4 @@\n-a\n+A\n b\n c\n-d\n+D\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			contextLines := 1
+			assert.Equal(t, tt.want, Unified("v1", "v2", tt.a, tt.b, contextLines))
+		})
+	}
+}
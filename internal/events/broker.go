@@ -0,0 +1,147 @@
+// Package events provides an in-process publish/subscribe broker for pushing
+// real-time updates to connected users.
+package events
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// subscriberBuffer is the number of events that can be queued for a subscriber before it
+// is considered too slow and disconnected. Disconnected clients resume with their last event ID.
+const subscriberBuffer = 64
+
+// Event is a message addressed to one or more users.
+type Event struct {
+	ID   int64
+	Type string
+	Data json.RawMessage
+
+	recipients map[int64]struct{}
+}
+
+func (e *Event) addressedTo(userID int64) bool {
+	_, ok := e.recipients[userID]
+	return ok
+}
+
+// Subscription receives the events addressed to a single user.
+type Subscription struct {
+	// Events delivers events in ID order. It is closed when the subscription is closed,
+	// either by calling Close or because the subscriber fell too far behind.
+	Events <-chan Event
+
+	userID int64
+	ch     chan Event
+	broker *Broker
+	once   sync.Once
+}
+
+// Close stops delivery to the subscription. It is safe to call more than once.
+func (s *Subscription) Close() {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+	s.closeLocked()
+}
+
+func (s *Subscription) closeLocked() {
+	s.once.Do(func() {
+		delete(s.broker.subscribers, s)
+		close(s.ch)
+	})
+}
+
+// Broker fans published events out to subscribers and keeps a bounded history of recent
+// events so that clients reconnecting with a Last-Event-ID can catch up on what they missed.
+type Broker struct {
+	mu          sync.Mutex
+	lastID      int64
+	history     []Event
+	historySize int
+	subscribers map[*Subscription]struct{}
+}
+
+// NewBroker creates a broker that retains up to historySize events for replay.
+// Event IDs start from the current time in microseconds, so they keep increasing
+// across restarts and IDs from a previous process never look newer than current ones.
+func NewBroker(historySize int) *Broker {
+	return &Broker{
+		lastID:      time.Now().UnixMicro(),
+		historySize: historySize,
+		subscribers: make(map[*Subscription]struct{}),
+	}
+}
+
+// Publish sends an event with the JSON encoding of data to the given users.
+// Publishing to no recipients is a no-op.
+func (b *Broker) Publish(eventType string, data any, recipients ...int64) error {
+	if len(recipients) == 0 {
+		return nil
+	}
+
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	event := Event{Type: eventType, Data: payload, recipients: make(map[int64]struct{}, len(recipients))}
+	for _, id := range recipients {
+		event.recipients[id] = struct{}{}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.lastID++
+	event.ID = b.lastID
+
+	if b.historySize > 0 {
+		if len(b.history) == b.historySize {
+			b.history = append(b.history[:0], b.history[1:]...)
+		}
+		b.history = append(b.history, event)
+	}
+
+	for sub := range b.subscribers {
+		if !event.addressedTo(sub.userID) {
+			continue
+		}
+		select {
+		case sub.ch <- event:
+		default:
+			// The subscriber is not keeping up; drop it rather than block every publisher.
+			sub.closeLocked()
+		}
+	}
+
+	return nil
+}
+
+// Subscribe registers a subscription for the user. Retained events addressed to the user
+// with an ID greater than lastEventID are queued first; pass 0 to only receive new events.
+func (b *Broker) Subscribe(userID int64, lastEventID int64) *Subscription {
+	ch := make(chan Event, subscriberBuffer)
+	sub := &Subscription{Events: ch, userID: userID, ch: ch, broker: b}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if lastEventID > 0 {
+		for _, event := range b.history {
+			if event.ID <= lastEventID || !event.addressedTo(userID) {
+				continue
+			}
+			select {
+			case ch <- event:
+			default:
+				// More missed events than fit in the buffer; deliver the most recent ones we can.
+				<-ch
+				ch <- event
+			}
+		}
+	}
+
+	b.subscribers[sub] = struct{}{}
+	return sub
+}
@@ -0,0 +1,99 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// drain returns the events currently queued on the subscription without blocking.
+func drain(sub *Subscription) []Event {
+	var events []Event
+	for {
+		select {
+		case event, ok := <-sub.Events:
+			if !ok {
+				return events
+			}
+			events = append(events, event)
+		default:
+			return events
+		}
+	}
+}
+
+func TestBroker_PublishDeliversToRecipientsOnly(t *testing.T) {
+	t.Parallel()
+
+	b := NewBroker(10)
+	alice := b.Subscribe(1, 0)
+	bob := b.Subscribe(2, 0)
+	defer alice.Close()
+	defer bob.Close()
+
+	require.NoError(t, b.Publish("article", map[string]string{"slug": "hello"}, 1))
+
+	events := drain(alice)
+	require.Len(t, events, 1)
+	assert.Equal(t, "article", events[0].Type)
+	assert.JSONEq(t, `{"slug": "hello"}`, string(events[0].Data))
+	assert.Empty(t, drain(bob))
+}
+
+func TestBroker_SubscribeReplaysMissedEvents(t *testing.T) {
+	t.Parallel()
+
+	b := NewBroker(10)
+	require.NoError(t, b.Publish("a", 1, 1))
+	require.NoError(t, b.Publish("b", 2, 1))
+	require.NoError(t, b.Publish("c", 3, 2))
+	require.NoError(t, b.Publish("d", 4, 1))
+
+	first := b.Subscribe(1, 1)
+	events := drain(first)
+	first.Close()
+	require.Len(t, events, 3)
+
+	// Resuming after the first event replays the later events addressed to the user
+	resumed := b.Subscribe(1, events[0].ID)
+	defer resumed.Close()
+	replayed := drain(resumed)
+	require.Len(t, replayed, 2)
+	assert.Equal(t, "b", replayed[0].Type)
+	assert.Equal(t, "d", replayed[1].Type)
+	assert.Greater(t, replayed[1].ID, replayed[0].ID)
+}
+
+func TestBroker_HistoryIsBounded(t *testing.T) {
+	t.Parallel()
+
+	b := NewBroker(2)
+	for i := 0; i < 5; i++ {
+		require.NoError(t, b.Publish("event", i, 1))
+	}
+
+	sub := b.Subscribe(1, 1)
+	defer sub.Close()
+	events := drain(sub)
+	require.Len(t, events, 2)
+	assert.JSONEq(t, "3", string(events[0].Data))
+	assert.JSONEq(t, "4", string(events[1].Data))
+}
+
+func TestBroker_SlowSubscriberIsDisconnected(t *testing.T) {
+	t.Parallel()
+
+	b := NewBroker(0)
+	sub := b.Subscribe(1, 0)
+	for i := 0; i <= subscriberBuffer; i++ {
+		require.NoError(t, b.Publish("event", i, 1))
+	}
+
+	assert.Len(t, drain(sub), subscriberBuffer)
+	_, ok := <-sub.Events
+	assert.False(t, ok, "subscription should be closed")
+
+	// Closing an already closed subscription is a no-op
+	sub.Close()
+}
@@ -0,0 +1,47 @@
+package markdown
+
+import (
+	"bytes"
+	"regexp"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/renderer/html"
+)
+
+// Renderer converts user-provided Markdown into HTML that is safe to embed in a web page.
+type Renderer struct {
+	md     goldmark.Markdown
+	policy *bluemonday.Policy
+}
+
+// NewRenderer creates a Renderer that supports GitHub Flavored Markdown.
+//
+// Raw HTML in the Markdown source is passed through by the Markdown renderer and then
+// filtered by an allowlist sanitizer, so the sanitizer is the single place that decides
+// which elements, attributes and URL schemes are allowed.
+func NewRenderer() *Renderer {
+	md := goldmark.New(
+		goldmark.WithExtensions(extension.GFM),
+		goldmark.WithRendererOptions(html.WithUnsafe()),
+	)
+
+	// UGCPolicy allows common formatting elements, only permits http, https and mailto URLs,
+	// strips event handler attributes and adds rel="nofollow" to links.
+	policy := bluemonday.UGCPolicy()
+	// Keep the language hint on fenced code blocks so that clients can highlight them
+	policy.AllowAttrs("class").Matching(regexp.MustCompile(`^language-[\w+-]+$`)).OnElements("code")
+
+	return &Renderer{md: md, policy: policy}
+}
+
+// Render converts the Markdown source to sanitized HTML.
+func (r *Renderer) Render(source string) (string, error) {
+	var buf bytes.Buffer
+	if err := r.md.Convert([]byte(source), &buf); err != nil {
+		return "", err
+	}
+
+	return r.policy.Sanitize(buf.String()), nil
+}
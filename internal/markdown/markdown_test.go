@@ -0,0 +1,127 @@
+package markdown
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderer_Render(t *testing.T) {
+	t.Parallel()
+
+	renderer := NewRenderer()
+
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{
+			name:   "basic formatting",
+			source: "# Title\n\nSome *emphasis* and **strong** text.",
+			want:   "<h1>Title</h1>\n<p>Some <em>emphasis</em> and <strong>strong</strong> text.</p>\n",
+		},
+		{
+			name:   "links get rel nofollow",
+			source: "[conduit](https://example.com)",
+			want:   "<p><a href=\"https://example.com\" rel=\"nofollow\">conduit</a></p>\n",
+		},
+		{
+			name:   "fenced code keeps its language class",
+			source: "```go\nfmt.Println(\"hi\")\n```",
+			want:   "<pre><code class=\"language-go\">fmt.Println(&#34;hi&#34;)\n</code></pre>\n",
+		},
+		{
+			name:   "GFM strikethrough",
+			source: "~~gone~~",
+			want:   "<p><del>gone</del></p>\n",
+		},
+		{
+			name:   "safe inline HTML is kept",
+			source: "<em>inline</em>",
+			want:   "<p><em>inline</em></p>\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := renderer.Render(tt.source)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestRenderer_Render_XSS(t *testing.T) {
+	t.Parallel()
+
+	renderer := NewRenderer()
+
+	tests := []struct {
+		name      string
+		source    string
+		forbidden []string
+	}{
+		{
+			name:      "script tag",
+			source:    "Hello <script>alert('xss')</script> world",
+			forbidden: []string{"<script", "alert("},
+		},
+		{
+			name:      "script block",
+			source:    "<script>\ndocument.location = 'https://evil.example'\n</script>",
+			forbidden: []string{"<script", "document.location"},
+		},
+		{
+			name:      "javascript URL in markdown link",
+			source:    "[click me](javascript:alert(1))",
+			forbidden: []string{"javascript:"},
+		},
+		{
+			name:      "javascript URL in HTML link",
+			source:    `<a href="javascript:alert(1)">click me</a>`,
+			forbidden: []string{"javascript:"},
+		},
+		{
+			name:      "obfuscated javascript URL",
+			source:    `<a href="jav&#x09;ascript:alert(1)">click me</a>`,
+			forbidden: []string{"ascript:", "alert(1)"},
+		},
+		{
+			name:      "event handler attribute",
+			source:    `<img src="https://example.com/x.png" onerror="alert(1)">`,
+			forbidden: []string{"onerror", "alert(1)"},
+		},
+		{
+			name:      "event handler on allowed element",
+			source:    `<p onclick="alert(1)">text</p>`,
+			forbidden: []string{"onclick"},
+		},
+		{
+			name:      "iframe",
+			source:    `<iframe src="https://evil.example"></iframe>`,
+			forbidden: []string{"<iframe"},
+		},
+		{
+			name:      "data URL image",
+			source:    `![x](data:text/html;base64,PHNjcmlwdD5hbGVydCgxKTwvc2NyaXB0Pg==)`,
+			forbidden: []string{"data:"},
+		},
+		{
+			name:      "style attribute",
+			source:    `<span style="background:url(javascript:alert(1))">x</span>`,
+			forbidden: []string{"style=", "javascript:"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := renderer.Render(tt.source)
+			require.NoError(t, err)
+			for _, forbidden := range tt.forbidden {
+				assert.NotContains(t, got, forbidden)
+			}
+		})
+	}
+}
@@ -0,0 +1,291 @@
+// Package oidc implements the client side of OpenID Connect login with the authorization code flow
+// and PKCE (RFC 7636): provider discovery, authorization URLs, the code exchange and verification of
+// the returned ID token against the provider's published keys.
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ErrRejected is returned when the provider rejects an authorization code, or when the ID token it
+// returns is not valid. Other errors are failures to reach the provider.
+var ErrRejected = errors.New("oidc: login rejected")
+
+// maxResponseSize bounds the responses read from providers.
+const maxResponseSize = 1 << 20
+
+// clockSkew is the leeway given when checking the times in ID tokens.
+const clockSkew = time.Minute
+
+// Config configures a provider that users can log in with.
+type Config struct {
+	// Name identifies the provider in URLs and linked identities, e.g. "google".
+	Name string `json:"name"`
+	// Issuer is the issuer URL of the provider; its discovery document is fetched from
+	// <Issuer>/.well-known/openid-configuration.
+	Issuer       string `json:"issuer"`
+	ClientID     string `json:"clientId"`
+	ClientSecret string `json:"clientSecret"`
+	// RedirectURL is where the provider sends users back to with the authorization code.
+	RedirectURL string `json:"redirectUrl"`
+	// Scopes requested in addition to "openid". Defaults to "email" and "profile".
+	Scopes []string `json:"scopes"`
+}
+
+// Claims are the claims of a verified ID token that identify the user.
+type Claims struct {
+	// Subject identifies the user at the provider; it never changes, unlike their email address.
+	Subject           string
+	Email             string
+	EmailVerified     bool
+	Name              string
+	PreferredUsername string
+	Picture           string
+}
+
+// metadata holds the parts of a provider's discovery document that the flow needs.
+type metadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Provider is an OpenID Connect provider that users can log in with.
+type Provider struct {
+	config   Config
+	metadata metadata
+	client   *http.Client
+	keys     *keySet
+	// Now returns the current time used to check the times in ID tokens. Defaults to time.Now.
+	Now func() time.Time
+}
+
+// Discover fetches the discovery document of the provider and returns a Provider using client for
+// all requests to it.
+func Discover(ctx context.Context, client *http.Client, config Config) (*Provider, error) {
+	if config.Name == "" || config.Issuer == "" || config.ClientID == "" || config.RedirectURL == "" {
+		return nil, errors.New("oidc: provider name, issuer, client ID and redirect URL must be set")
+	}
+
+	discoveryURL := strings.TrimSuffix(config.Issuer, "/") + "/.well-known/openid-configuration"
+	var meta metadata
+	if err := getJSON(ctx, client, discoveryURL, &meta); err != nil {
+		return nil, fmt.Errorf("oidc: discovering %s: %w", config.Name, err)
+	}
+
+	// The issuer in the document must be the one configured, or the ID tokens cannot be trusted
+	if meta.Issuer != config.Issuer {
+		return nil, fmt.Errorf("oidc: discovering %s: issuer %q does not match %q", config.Name, meta.Issuer, config.Issuer)
+	}
+	if meta.AuthorizationEndpoint == "" || meta.TokenEndpoint == "" || meta.JWKSURI == "" {
+		return nil, fmt.Errorf("oidc: discovering %s: incomplete discovery document", config.Name)
+	}
+
+	if len(config.Scopes) == 0 {
+		config.Scopes = []string{"email", "profile"}
+	}
+
+	return &Provider{
+		config:   config,
+		metadata: meta,
+		client:   client,
+		keys:     newKeySet(client, meta.JWKSURI),
+	}, nil
+}
+
+// Name returns the configured name of the provider.
+func (p *Provider) Name() string {
+	return p.config.Name
+}
+
+// RandomToken returns a random URL-safe string suitable as a state, nonce or PKCE code verifier.
+func RandomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// CodeChallenge returns the S256 PKCE code challenge of a code verifier.
+func CodeChallenge(codeVerifier string) string {
+	sum := sha256.Sum256([]byte(codeVerifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// AuthCodeURL returns the URL of the provider's login page. The provider sends the user back to the
+// redirect URL with the state and an authorization code, which Exchange swaps for their claims.
+func (p *Provider) AuthCodeURL(state, nonce, codeVerifier string) string {
+	values := url.Values{}
+	values.Set("response_type", "code")
+	values.Set("client_id", p.config.ClientID)
+	values.Set("redirect_uri", p.config.RedirectURL)
+	values.Set("scope", strings.Join(append([]string{"openid"}, p.config.Scopes...), " "))
+	values.Set("state", state)
+	values.Set("nonce", nonce)
+	values.Set("code_challenge", CodeChallenge(codeVerifier))
+	values.Set("code_challenge_method", "S256")
+
+	separator := "?"
+	if strings.Contains(p.metadata.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return p.metadata.AuthorizationEndpoint + separator + values.Encode()
+}
+
+// Exchange swaps an authorization code for an ID token and returns its claims once it is verified
+// to be signed by the provider, issued for this client and bound to the nonce of the login.
+func (p *Provider) Exchange(ctx context.Context, code, codeVerifier, nonce string) (*Claims, error) {
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.config.RedirectURL)
+	form.Set("code_verifier", codeVerifier)
+	form.Set("client_id", p.config.ClientID)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.metadata.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.config.ClientSecret != "" {
+		// client_secret_basic, the default client authentication method of OpenID Connect
+		req.SetBasicAuth(url.QueryEscape(p.config.ClientID), url.QueryEscape(p.config.ClientSecret))
+	}
+
+	res, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close() //nolint: errcheck
+
+	var body struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(io.LimitReader(res.Body, maxResponseSize)).Decode(&body); err != nil {
+		return nil, fmt.Errorf("oidc: token response: %w", err)
+	}
+
+	switch {
+	case res.StatusCode >= 400 && res.StatusCode < 500:
+		return nil, fmt.Errorf("%w: %s %s", ErrRejected, body.Error, body.ErrorDescription)
+	case res.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("oidc: token endpoint returned %s", res.Status)
+	case body.IDToken == "":
+		return nil, fmt.Errorf("%w: no ID token in the token response", ErrRejected)
+	}
+
+	return p.verify(ctx, body.IDToken, nonce)
+}
+
+// idTokenClaims are the claims of an ID token.
+type idTokenClaims struct {
+	jwt.RegisteredClaims
+	Nonce             string       `json:"nonce"`
+	AuthorizedParty   string       `json:"azp"`
+	Email             string       `json:"email"`
+	EmailVerified     flexibleBool `json:"email_verified"`
+	Name              string       `json:"name"`
+	PreferredUsername string       `json:"preferred_username"`
+	Picture           string       `json:"picture"`
+}
+
+// flexibleBool decodes a boolean that some providers send as a string.
+type flexibleBool bool
+
+func (b *flexibleBool) UnmarshalJSON(data []byte) error {
+	switch string(data) {
+	case "true", `"true"`:
+		*b = true
+	case "false", `"false"`, "null":
+		*b = false
+	default:
+		return fmt.Errorf("invalid boolean %s", data)
+	}
+	return nil
+}
+
+// verify checks an ID token as required by OpenID Connect Core section 3.1.3.7.
+func (p *Provider) verify(ctx context.Context, idToken, nonce string) (*Claims, error) {
+	now := time.Now
+	if p.Now != nil {
+		now = p.Now
+	}
+
+	keyFunc := func(token *jwt.Token) (any, error) {
+		kid, _ := token.Header["kid"].(string)
+		return p.keys.get(ctx, kid)
+	}
+
+	var claims idTokenClaims
+	_, err := jwt.ParseWithClaims(idToken, &claims, keyFunc,
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}),
+		jwt.WithIssuer(p.config.Issuer),
+		jwt.WithAudience(p.config.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(clockSkew),
+		jwt.WithTimeFunc(now),
+	)
+	if err != nil {
+		if errors.Is(err, errKeysUnavailable) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %w", ErrRejected, err)
+	}
+
+	if len(claims.Audience) > 1 && claims.AuthorizedParty != p.config.ClientID {
+		return nil, fmt.Errorf("%w: ID token was issued to another party", ErrRejected)
+	}
+	if claims.Nonce != nonce {
+		return nil, fmt.Errorf("%w: ID token nonce does not match", ErrRejected)
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: ID token has no subject", ErrRejected)
+	}
+
+	return &Claims{
+		Subject:           claims.Subject,
+		Email:             claims.Email,
+		EmailVerified:     bool(claims.EmailVerified),
+		Name:              claims.Name,
+		PreferredUsername: claims.PreferredUsername,
+		Picture:           claims.Picture,
+	}, nil
+}
+
+func getJSON(ctx context.Context, client *http.Client, url string, dst any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close() //nolint: errcheck
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s returned %s", url, res.Status)
+	}
+	return json.NewDecoder(io.LimitReader(res.Body, maxResponseSize)).Decode(dst)
+}
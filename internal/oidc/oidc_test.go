@@ -0,0 +1,158 @@
+package oidc_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/96malhar/realworld-backend/internal/oidc"
+	"github.com/96malhar/realworld-backend/internal/oidc/oidctest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newProvider(t *testing.T) (*oidctest.Server, *oidc.Provider) {
+	t.Helper()
+	server := oidctest.NewServer(t)
+	provider, err := oidc.Discover(context.Background(), http.DefaultClient, server.Config("mock"))
+	require.NoError(t, err)
+	return server, provider
+}
+
+// login starts a login and returns the code the provider redirected back with.
+func login(t *testing.T, server *oidctest.Server, provider *oidc.Provider, state, nonce, verifier string) string {
+	t.Helper()
+	code, gotState, err := server.Authorize(provider.AuthCodeURL(state, nonce, verifier))
+	require.NoError(t, err)
+	require.Equal(t, state, gotState)
+	return code
+}
+
+func TestDiscover(t *testing.T) {
+	server := oidctest.NewServer(t)
+
+	t.Run("Issuer must match", func(t *testing.T) {
+		config := server.Config("mock")
+		config.Issuer = server.URL + "/"
+		_, err := oidc.Discover(context.Background(), http.DefaultClient, config)
+		assert.ErrorContains(t, err, "does not match")
+	})
+
+	t.Run("Missing document", func(t *testing.T) {
+		empty := httptest.NewServer(http.NotFoundHandler())
+		defer empty.Close()
+
+		config := server.Config("mock")
+		config.Issuer = empty.URL
+		_, err := oidc.Discover(context.Background(), http.DefaultClient, config)
+		assert.Error(t, err)
+	})
+
+	t.Run("Incomplete configuration", func(t *testing.T) {
+		config := server.Config("mock")
+		config.ClientID = ""
+		_, err := oidc.Discover(context.Background(), http.DefaultClient, config)
+		assert.Error(t, err)
+	})
+}
+
+func TestAuthCodeURL(t *testing.T) {
+	server, provider := newProvider(t)
+
+	authURL, err := url.Parse(provider.AuthCodeURL("state-1", "nonce-1", "verifier-1"))
+	require.NoError(t, err)
+
+	assert.Equal(t, server.URL+"/authorize", authURL.Scheme+"://"+authURL.Host+authURL.Path)
+	query := authURL.Query()
+	assert.Equal(t, "code", query.Get("response_type"))
+	assert.Equal(t, oidctest.ClientID, query.Get("client_id"))
+	assert.Equal(t, oidctest.RedirectURL, query.Get("redirect_uri"))
+	assert.Equal(t, "openid email profile", query.Get("scope"))
+	assert.Equal(t, "state-1", query.Get("state"))
+	assert.Equal(t, "nonce-1", query.Get("nonce"))
+	assert.Equal(t, oidc.CodeChallenge("verifier-1"), query.Get("code_challenge"))
+	assert.Equal(t, "S256", query.Get("code_challenge_method"))
+}
+
+func TestCodeChallenge(t *testing.T) {
+	// Example from RFC 7636 appendix B
+	assert.Equal(t, "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM",
+		oidc.CodeChallenge("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"))
+}
+
+func TestExchange(t *testing.T) {
+	server, provider := newProvider(t)
+	server.SetUser(oidctest.User{
+		Subject:           "248289761001",
+		Email:             "jane@example.com",
+		EmailVerified:     true,
+		Name:              "Jane Doe",
+		PreferredUsername: "jane",
+	})
+
+	verifier, err := oidc.RandomToken()
+	require.NoError(t, err)
+
+	t.Run("Valid code", func(t *testing.T) {
+		code := login(t, server, provider, "state", "nonce", verifier)
+
+		claims, err := provider.Exchange(context.Background(), code, verifier, "nonce")
+		require.NoError(t, err)
+		assert.Equal(t, &oidc.Claims{
+			Subject:           "248289761001",
+			Email:             "jane@example.com",
+			EmailVerified:     true,
+			Name:              "Jane Doe",
+			PreferredUsername: "jane",
+		}, claims)
+
+		// Codes can only be used once
+		_, err = provider.Exchange(context.Background(), code, verifier, "nonce")
+		assert.ErrorIs(t, err, oidc.ErrRejected)
+	})
+
+	t.Run("Wrong code verifier", func(t *testing.T) {
+		code := login(t, server, provider, "state", "nonce", verifier)
+		_, err := provider.Exchange(context.Background(), code, verifier+"x", "nonce")
+		assert.ErrorIs(t, err, oidc.ErrRejected)
+	})
+
+	t.Run("Wrong nonce", func(t *testing.T) {
+		code := login(t, server, provider, "state", "nonce", verifier)
+		server.SetNonce("replayed")
+		_, err := provider.Exchange(context.Background(), code, verifier, "nonce")
+		assert.ErrorIs(t, err, oidc.ErrRejected)
+	})
+
+	t.Run("Expired ID token", func(t *testing.T) {
+		provider.Now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+		defer func() { provider.Now = nil }()
+
+		code := login(t, server, provider, "state", "nonce", verifier)
+		_, err := provider.Exchange(context.Background(), code, verifier, "nonce")
+		assert.ErrorIs(t, err, oidc.ErrRejected)
+	})
+
+	t.Run("Wrong client secret", func(t *testing.T) {
+		config := server.Config("mock")
+		config.ClientSecret = "wrong"
+		other, err := oidc.Discover(context.Background(), http.DefaultClient, config)
+		require.NoError(t, err)
+
+		code := login(t, server, other, "state", "nonce", verifier)
+		_, err = other.Exchange(context.Background(), code, verifier, "nonce")
+		assert.ErrorIs(t, err, oidc.ErrRejected)
+	})
+
+	t.Run("Provider is down", func(t *testing.T) {
+		code := login(t, server, provider, "state", "nonce", verifier)
+		server.Close()
+
+		_, err := provider.Exchange(context.Background(), code, verifier, "nonce")
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, oidc.ErrRejected)
+	})
+}
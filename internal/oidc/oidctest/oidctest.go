@@ -0,0 +1,268 @@
+// Package oidctest provides a mock OpenID Connect provider for tests. It implements discovery, the
+// authorization endpoint, the token endpoint with PKCE verification and a key set, and logs users in
+// without asking for credentials.
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/96malhar/realworld-backend/internal/oidc"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	// ClientID and ClientSecret are the credentials of the only client the server knows.
+	ClientID     = "conduit-test"
+	ClientSecret = "conduit-test-secret"
+	// RedirectURL is the only redirect URL the server accepts.
+	RedirectURL = "http://localhost:3000/oidc/callback"
+
+	keyID = "test-key"
+)
+
+// User is a user at the mock provider.
+type User struct {
+	Subject           string
+	Email             string
+	EmailVerified     bool
+	Name              string
+	PreferredUsername string
+}
+
+// Server is a mock OpenID Connect provider.
+type Server struct {
+	*httptest.Server
+
+	key *rsa.PrivateKey
+
+	mu sync.Mutex
+	// user is the user logged in at the provider, who approves every authorization request.
+	user User
+	// codes holds the pending authorization requests by code.
+	codes map[string]authorization
+	// nonceOverride, if set, replaces the nonce of the next ID token.
+	nonceOverride string
+}
+
+type authorization struct {
+	nonce         string
+	codeChallenge string
+	redirectURI   string
+	user          User
+}
+
+// NewServer starts a mock provider. It is closed when the test ends.
+func NewServer(t testing.TB) *Server {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generating key: %v", err)
+	}
+
+	s := &Server{key: key, codes: make(map[string]authorization)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", s.discovery)
+	mux.HandleFunc("GET /authorize", s.authorize)
+	mux.HandleFunc("POST /token", s.token)
+	mux.HandleFunc("GET /jwks", s.jwks)
+	s.Server = httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+// Config returns the configuration of a provider with the given name backed by the server.
+func (s *Server) Config(name string) oidc.Config {
+	return oidc.Config{
+		Name:         name,
+		Issuer:       s.URL,
+		ClientID:     ClientID,
+		ClientSecret: ClientSecret,
+		RedirectURL:  RedirectURL,
+	}
+}
+
+// SetUser logs a user in at the provider.
+func (s *Server) SetUser(user User) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.user = user
+}
+
+// SetNonce makes the next ID token carry the given nonce instead of the one of the login.
+func (s *Server) SetNonce(nonce string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nonceOverride = nonce
+}
+
+// Authorize follows an authorization URL like a browser would, and returns the code and state the
+// provider redirects back with.
+func (s *Server) Authorize(authURL string) (code, state string, err error) {
+	client := &http.Client{
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+	res, err := client.Get(authURL)
+	if err != nil {
+		return "", "", err
+	}
+	defer res.Body.Close() //nolint: errcheck
+
+	if res.StatusCode != http.StatusFound {
+		return "", "", fmt.Errorf("authorization returned %s", res.Status)
+	}
+	location, err := url.Parse(res.Header.Get("Location"))
+	if err != nil {
+		return "", "", err
+	}
+	return location.Query().Get("code"), location.Query().Get("state"), nil
+}
+
+func (s *Server) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"issuer":                                s.URL,
+		"authorization_endpoint":                s.URL + "/authorize",
+		"token_endpoint":                        s.URL + "/token",
+		"jwks_uri":                              s.URL + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+	})
+}
+
+func (s *Server) authorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	switch {
+	case query.Get("client_id") != ClientID:
+		http.Error(w, "unknown client", http.StatusBadRequest)
+		return
+	case query.Get("redirect_uri") != RedirectURL:
+		http.Error(w, "unknown redirect URI", http.StatusBadRequest)
+		return
+	case query.Get("response_type") != "code":
+		http.Error(w, "unsupported response type", http.StatusBadRequest)
+		return
+	case query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "":
+		http.Error(w, "PKCE is required", http.StatusBadRequest)
+		return
+	}
+
+	code := rand.Text()
+	s.mu.Lock()
+	s.codes[code] = authorization{
+		nonce:         query.Get("nonce"),
+		codeChallenge: query.Get("code_challenge"),
+		redirectURI:   query.Get("redirect_uri"),
+		user:          s.user,
+	}
+	s.mu.Unlock()
+
+	redirect := url.Values{"code": {code}, "state": {query.Get("state")}}
+	http.Redirect(w, r, RedirectURL+"?"+redirect.Encode(), http.StatusFound)
+}
+
+func (s *Server) token(w http.ResponseWriter, r *http.Request) {
+	clientID, clientSecret, _ := r.BasicAuth()
+	clientID, _ = url.QueryUnescape(clientID)
+	clientSecret, _ = url.QueryUnescape(clientSecret)
+	if clientID != ClientID || clientSecret != ClientSecret {
+		tokenError(w, http.StatusUnauthorized, "invalid_client")
+		return
+	}
+	if r.PostFormValue("grant_type") != "authorization_code" {
+		tokenError(w, http.StatusBadRequest, "unsupported_grant_type")
+		return
+	}
+
+	// Codes can only be used once
+	s.mu.Lock()
+	auth, ok := s.codes[r.PostFormValue("code")]
+	delete(s.codes, r.PostFormValue("code"))
+	nonce := auth.nonce
+	if s.nonceOverride != "" {
+		nonce, s.nonceOverride = s.nonceOverride, ""
+	}
+	s.mu.Unlock()
+
+	sum := sha256.Sum256([]byte(r.PostFormValue("code_verifier")))
+	switch {
+	case !ok, r.PostFormValue("redirect_uri") != auth.redirectURI,
+		base64.RawURLEncoding.EncodeToString(sum[:]) != auth.codeChallenge:
+		tokenError(w, http.StatusBadRequest, "invalid_grant")
+		return
+	}
+
+	idToken, err := s.idToken(auth.user, nonce)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"access_token": rand.Text(),
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     idToken,
+	})
+}
+
+func (s *Server) idToken(user User, nonce string) (string, error) {
+	if user.Subject == "" {
+		return "", errors.New("no user is logged in")
+	}
+
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":                s.URL,
+		"sub":                user.Subject,
+		"aud":                ClientID,
+		"iat":                now.Unix(),
+		"exp":                now.Add(time.Hour).Unix(),
+		"nonce":              nonce,
+		"email":              user.Email,
+		"email_verified":     user.EmailVerified,
+		"name":               user.Name,
+		"preferred_username": user.PreferredUsername,
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = keyID
+	return token.SignedString(s.key)
+}
+
+func (s *Server) jwks(w http.ResponseWriter, r *http.Request) {
+	pub := s.key.PublicKey
+	writeJSON(w, http.StatusOK, map[string]any{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": keyID,
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}},
+	})
+}
+
+func tokenError(w http.ResponseWriter, status int, code string) {
+	writeJSON(w, status, map[string]string{"error": code})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v) //nolint: errcheck
+}
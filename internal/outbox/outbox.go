@@ -0,0 +1,125 @@
+// Package outbox publishes domain events recorded in the transactional outbox to pluggable sinks.
+//
+// Events are delivered at least once: an event is marked as published only after every sink accepted
+// it, and a failure anywhere means the event is offered to every sink again later. Sinks must therefore
+// tolerate duplicates. Events of the same aggregate are published in the order they were written; when
+// one fails, the aggregate's later events wait until it has been published.
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/96malhar/realworld-backend/internal/data"
+)
+
+const (
+	// BatchSize is the maximum number of events published per dispatch.
+	BatchSize = 100
+	// publishTimeout bounds how long the sinks may take to publish one event.
+	publishTimeout = 30 * time.Second
+	// maxBackoff caps the delay between attempts to publish an event.
+	maxBackoff = time.Hour
+)
+
+// Sink receives published events.
+type Sink interface {
+	// Name identifies the sink in logs.
+	Name() string
+	// Publish handles an event. Returning an error causes the event to be retried.
+	Publish(ctx context.Context, event data.OutboxEvent) error
+}
+
+// Dispatcher moves events from the outbox to its sinks.
+type Dispatcher struct {
+	store        data.OutboxStoreInterface
+	logger       *slog.Logger
+	retryBackoff time.Duration
+	sinks        []Sink
+}
+
+// NewDispatcher creates a dispatcher that publishes to the given sinks in order.
+// Failed events are retried after retryBackoff, doubling with every failed attempt.
+func NewDispatcher(store data.OutboxStoreInterface, logger *slog.Logger, retryBackoff time.Duration, sinks ...Sink) *Dispatcher {
+	return &Dispatcher{store: store, logger: logger, retryBackoff: retryBackoff, sinks: sinks}
+}
+
+type aggregateKey struct {
+	aggregateType string
+	aggregateID   int64
+}
+
+// Dispatch publishes a batch of due events and returns how many were published. Nothing is published
+// if another dispatcher is running, since events could otherwise be published out of order.
+func (d *Dispatcher) Dispatch() (int, error) {
+	published := 0
+
+	_, err := d.store.WithDispatchLock(func() error {
+		events, err := d.store.GetUnpublished(BatchSize)
+		if err != nil {
+			return err
+		}
+
+		// Aggregates with a failed event in this batch; their later events must wait for it
+		failed := make(map[aggregateKey]bool)
+
+		for _, event := range events {
+			key := aggregateKey{event.AggregateType, event.AggregateID}
+			if failed[key] {
+				continue
+			}
+
+			if err := d.publish(event); err != nil {
+				failed[key] = true
+				d.logger.Warn("failed to publish outbox event",
+					"id", event.ID, "type", event.Type, "attempt", event.Attempts+1, "error", err)
+
+				nextAttemptAt := time.Now().Add(d.backoff(event.Attempts + 1))
+				if err := d.store.MarkFailed(event.ID, err.Error(), nextAttemptAt); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if err := d.store.MarkPublished(event.ID); err != nil {
+				return err
+			}
+			published++
+		}
+
+		return nil
+	})
+
+	return published, err
+}
+
+// publish offers the event to every sink, stopping at the first failure.
+func (d *Dispatcher) publish(event data.OutboxEvent) (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+	defer cancel()
+
+	// A panicking sink must not take the dispatcher down with it
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	for _, sink := range d.sinks {
+		if err := sink.Publish(ctx, event); err != nil {
+			return fmt.Errorf("%s: %w", sink.Name(), err)
+		}
+	}
+	return nil
+}
+
+// backoff returns the delay before retrying an event after the given failed attempt (starting at 1).
+func (d *Dispatcher) backoff(attempt int) time.Duration {
+	delay := d.retryBackoff
+	for i := 1; i < attempt && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, maxBackoff)
+}
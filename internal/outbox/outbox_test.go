@@ -0,0 +1,245 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/96malhar/realworld-backend/internal/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeStore is an in-memory outbox. Like the database, GetUnpublished skips aggregates
+// whose earliest unpublished event is not yet due.
+type fakeStore struct {
+	data.OutboxStoreInterface
+	locked    bool
+	events    []data.OutboxEvent
+	notBefore map[int64]time.Time
+	published []int64
+	failures  map[int64]string
+}
+
+func newFakeStore(events ...data.OutboxEvent) *fakeStore {
+	return &fakeStore{events: events, notBefore: map[int64]time.Time{}, failures: map[int64]string{}}
+}
+
+func (s *fakeStore) WithDispatchLock(fn func() error) (bool, error) {
+	if s.locked {
+		return false, nil
+	}
+	return true, fn()
+}
+
+func (s *fakeStore) GetUnpublished(limit int) ([]data.OutboxEvent, error) {
+	waiting := map[aggregateKey]bool{}
+	var due []data.OutboxEvent
+	for _, e := range s.events {
+		key := aggregateKey{e.AggregateType, e.AggregateID}
+		if s.isPublished(e.ID) {
+			continue
+		}
+		if waiting[key] || s.notBefore[e.ID].After(time.Now()) {
+			waiting[key] = true
+			continue
+		}
+		if len(due) < limit {
+			due = append(due, e)
+		}
+	}
+	return due, nil
+}
+
+func (s *fakeStore) isPublished(id int64) bool {
+	for _, p := range s.published {
+		if p == id {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *fakeStore) MarkPublished(id int64) error {
+	s.published = append(s.published, id)
+	return nil
+}
+
+func (s *fakeStore) MarkFailed(id int64, lastError string, nextAttemptAt time.Time) error {
+	s.failures[id] = lastError
+	s.notBefore[id] = nextAttemptAt
+	for i := range s.events {
+		if s.events[i].ID == id {
+			s.events[i].Attempts++
+		}
+	}
+	return nil
+}
+
+// recordingSink records the events it receives and fails for events listed in failOn.
+type recordingSink struct {
+	received []int64
+	failOn   map[int64]bool
+}
+
+func (s *recordingSink) Name() string { return "recording" }
+
+func (s *recordingSink) Publish(_ context.Context, event data.OutboxEvent) error {
+	s.received = append(s.received, event.ID)
+	if s.failOn[event.ID] {
+		return errors.New("unavailable")
+	}
+	return nil
+}
+
+func event(id int64, aggregateID int64, eventType string) data.OutboxEvent {
+	return data.OutboxEvent{ID: id, AggregateType: data.AggregateArticle, AggregateID: aggregateID, Type: eventType, Payload: json.RawMessage(`{}`)}
+}
+
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func TestDispatcher_PublishesInOrder(t *testing.T) {
+	t.Parallel()
+
+	store := newFakeStore(event(1, 10, "a"), event(2, 20, "b"), event(3, 10, "c"))
+	sink := &recordingSink{}
+	d := NewDispatcher(store, discardLogger, time.Minute, LogSink{Logger: discardLogger}, sink)
+
+	count, err := d.Dispatch()
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+	assert.Equal(t, []int64{1, 2, 3}, sink.received)
+	assert.Equal(t, []int64{1, 2, 3}, store.published)
+
+	count, err = d.Dispatch()
+	require.NoError(t, err)
+	assert.Zero(t, count)
+}
+
+func TestDispatcher_FailureHoldsBackLaterEventsOfTheAggregate(t *testing.T) {
+	t.Parallel()
+
+	store := newFakeStore(event(1, 10, "a"), event(2, 20, "b"), event(3, 10, "c"), event(4, 20, "d"))
+	sink := &recordingSink{failOn: map[int64]bool{1: true}}
+	d := NewDispatcher(store, discardLogger, time.Minute, sink)
+
+	count, err := d.Dispatch()
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+	assert.Equal(t, []int64{1, 2, 4}, sink.received, "event 3 must wait for event 1")
+	assert.Equal(t, []int64{2, 4}, store.published)
+	assert.Equal(t, "recording: unavailable", store.failures[1])
+	assert.WithinDuration(t, time.Now().Add(time.Minute), store.notBefore[1], time.Second)
+
+	// The aggregate stays blocked until the failed event is due again
+	count, err = d.Dispatch()
+	require.NoError(t, err)
+	assert.Zero(t, count)
+
+	// Once it is due and succeeds, the held back event follows it
+	sink.failOn = nil
+	store.notBefore[1] = time.Now()
+	count, err = d.Dispatch()
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+	assert.Equal(t, []int64{2, 4, 1, 3}, store.published)
+}
+
+func TestDispatcher_SkipsWhenAnotherDispatcherHoldsTheLock(t *testing.T) {
+	t.Parallel()
+
+	store := newFakeStore(event(1, 10, "a"))
+	store.locked = true
+	sink := &recordingSink{}
+
+	count, err := NewDispatcher(store, discardLogger, time.Minute, sink).Dispatch()
+	require.NoError(t, err)
+	assert.Zero(t, count)
+	assert.Empty(t, sink.received)
+}
+
+type panickingSink struct{}
+
+func (panickingSink) Name() string { return "panicking" }
+
+func (panickingSink) Publish(context.Context, data.OutboxEvent) error { panic("boom") }
+
+func TestDispatcher_RecoversFromPanickingSinks(t *testing.T) {
+	t.Parallel()
+
+	store := newFakeStore(event(1, 10, "a"))
+
+	count, err := NewDispatcher(store, discardLogger, time.Minute, panickingSink{}).Dispatch()
+	require.NoError(t, err)
+	assert.Zero(t, count)
+	assert.Equal(t, "panic: boom", store.failures[1])
+}
+
+func TestDispatcher_Backoff(t *testing.T) {
+	t.Parallel()
+
+	d := NewDispatcher(nil, discardLogger, 5*time.Second)
+	assert.Equal(t, 5*time.Second, d.backoff(1))
+	assert.Equal(t, 10*time.Second, d.backoff(2))
+	assert.Equal(t, 40*time.Second, d.backoff(4))
+	assert.Equal(t, time.Hour, d.backoff(20))
+}
+
+func TestSubscribers(t *testing.T) {
+	t.Parallel()
+
+	subscribers := NewSubscribers()
+	var all, created []string
+	subscribers.Subscribe(func(_ context.Context, e data.OutboxEvent) error {
+		all = append(all, e.Type)
+		return nil
+	})
+	subscribers.Subscribe(func(_ context.Context, e data.OutboxEvent) error {
+		created = append(created, e.Type)
+		return errors.New("handler failed")
+	}, data.EventArticleCreated, data.EventCommentCreated)
+
+	require.NoError(t, subscribers.Publish(context.Background(), event(1, 10, data.EventArticleUpdated)))
+	err := subscribers.Publish(context.Background(), event(2, 10, data.EventArticleCreated))
+	assert.EqualError(t, err, "handler failed")
+
+	assert.Equal(t, []string{data.EventArticleUpdated, data.EventArticleCreated}, all)
+	assert.Equal(t, []string{data.EventArticleCreated}, created)
+}
+
+// fakeWebhooks records the events queued for webhooks.
+type fakeWebhooks struct {
+	data.WebhookStoreInterface
+	enqueued []string
+}
+
+func (f *fakeWebhooks) Enqueue(_ int64, eventType string, _ int64, _ json.RawMessage) error {
+	f.enqueued = append(f.enqueued, eventType)
+	return nil
+}
+
+func TestWebhookSink(t *testing.T) {
+	t.Parallel()
+
+	webhooks := &fakeWebhooks{}
+	sink := WebhookSink{Webhooks: webhooks}
+
+	events := []data.OutboxEvent{
+		{Type: data.EventArticleCreated, Payload: json.RawMessage(`{"article": {"slug": "a", "status": "published"}}`)},
+		{Type: data.EventArticleUpdated, Payload: json.RawMessage(`{"article": {"slug": "b", "status": "draft"}}`)},
+		{Type: data.EventCommentCreated, Payload: json.RawMessage(`{"articleSlug": "a", "articleStatus": "published", "comment": {"id": 1}}`)},
+		{Type: data.EventCommentCreated, Payload: json.RawMessage(`{"articleSlug": "b", "articleStatus": "draft", "comment": {"id": 2}}`)},
+		{Type: data.EventArticleCreated, Payload: json.RawMessage(`{"article": {"slug": "c", "status": "published", "hidden": true}}`)},
+		{Type: data.EventCommentCreated, Payload: json.RawMessage(`{"articleSlug": "a", "articleStatus": "published", "comment": {"id": 3, "hidden": true}}`)},
+		{Type: data.EventArticleFavorited, Payload: json.RawMessage(`{"articleSlug": "a"}`)},
+	}
+	for _, e := range events {
+		require.NoError(t, sink.Publish(context.Background(), e))
+	}
+
+	assert.Equal(t, []string{data.EventArticleCreated, data.EventCommentCreated}, webhooks.enqueued)
+}
@@ -0,0 +1,118 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"slices"
+	"sync"
+
+	"github.com/96malhar/realworld-backend/internal/data"
+)
+
+// LogSink writes every event to a logger at debug level.
+type LogSink struct {
+	Logger *slog.Logger
+}
+
+func (s LogSink) Name() string { return "log" }
+
+func (s LogSink) Publish(ctx context.Context, event data.OutboxEvent) error {
+	s.Logger.DebugContext(ctx, "outbox event",
+		"id", event.ID,
+		"type", event.Type,
+		"aggregateType", event.AggregateType,
+		"aggregateId", event.AggregateID,
+		"payload", string(event.Payload),
+	)
+	return nil
+}
+
+// WebhookSink queues deliveries for the webhooks subscribed to an event. Only the event types
+// webhooks can subscribe to are forwarded, and article and comment events only while the article is published
+// and the content is not hidden. Every webhook event belongs to an article aggregate, which the store uses to
+// limit deliveries to webhooks whose owners wrote the article or follow its author.
+type WebhookSink struct {
+	Webhooks data.WebhookStoreInterface
+}
+
+func (s WebhookSink) Name() string { return "webhooks" }
+
+func (s WebhookSink) Publish(_ context.Context, event data.OutboxEvent) error {
+	if !slices.Contains(data.WebhookEventTypes, event.Type) {
+		return nil
+	}
+
+	var payload struct {
+		Article *struct {
+			Status string `json:"status"`
+			Hidden bool   `json:"hidden"`
+		} `json:"article"`
+		ArticleStatus string `json:"articleStatus"`
+		Comment       *struct {
+			Hidden bool `json:"hidden"`
+		} `json:"comment"`
+	}
+	if err := json.Unmarshal(event.Payload, &payload); err != nil {
+		return err
+	}
+	status, hidden := payload.ArticleStatus, false
+	if payload.Article != nil {
+		status, hidden = payload.Article.Status, payload.Article.Hidden
+	}
+	if payload.Comment != nil {
+		hidden = payload.Comment.Hidden
+	}
+	if status != data.ArticleStatusPublished || hidden {
+		return nil
+	}
+
+	return s.Webhooks.Enqueue(event.ID, event.Type, event.AggregateID, event.Payload)
+}
+
+// Handler handles an event published to in-process subscribers.
+type Handler func(ctx context.Context, event data.OutboxEvent) error
+
+// Subscribers is a sink that passes events to handlers registered in the same process.
+type Subscribers struct {
+	mu       sync.RWMutex
+	handlers map[string][]Handler
+	all      []Handler
+}
+
+func NewSubscribers() *Subscribers {
+	return &Subscribers{handlers: make(map[string][]Handler)}
+}
+
+// Subscribe registers a handler for the given event types, or for every event if none are given.
+func (s *Subscribers) Subscribe(handler Handler, eventTypes ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(eventTypes) == 0 {
+		s.all = append(s.all, handler)
+		return
+	}
+	for _, eventType := range eventTypes {
+		s.handlers[eventType] = append(s.handlers[eventType], handler)
+	}
+}
+
+func (s *Subscribers) Name() string { return "subscribers" }
+
+// Publish calls every handler subscribed to the event. All handlers are called even if some fail,
+// so a retried event may be handled more than once by handlers that succeeded the first time.
+func (s *Subscribers) Publish(ctx context.Context, event data.OutboxEvent) error {
+	s.mu.RLock()
+	handlers := slices.Concat(s.all, s.handlers[event.Type])
+	s.mu.RUnlock()
+
+	var errs []error
+	for _, handler := range handlers {
+		if err := handler(ctx, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
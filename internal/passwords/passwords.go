@@ -0,0 +1,230 @@
+// Package passwords hashes user passwords and verifies them against stored hashes.
+//
+// New passwords are hashed with Argon2id. Hashes created with an older algorithm, or with weaker
+// parameters than currently configured, are still verified but reported by NeedsRehash, so that
+// they can be replaced the next time the user logs in with their password.
+//
+// A Policy decides whether a new password is strong enough, using a zxcvbn-style strength Score and
+// an optional list of passwords known from data breaches.
+package passwords
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var ErrUnknownHash = errors.New("password hash was not created by a known algorithm")
+
+// Hasher hashes passwords and verifies passwords against their hash.
+type Hasher interface {
+	// Hash returns the hash of a new password.
+	Hash(plaintext string) ([]byte, error)
+	// Matches reports whether the password matches the hash.
+	Matches(hash []byte, plaintext string) (bool, error)
+	// NeedsRehash reports whether the hash should be replaced by a hash from Hash.
+	NeedsRehash(hash []byte) bool
+}
+
+// Scheme is a Hasher for one algorithm that can recognize the hashes it created.
+type Scheme interface {
+	Hasher
+	// Identifies reports whether the hash was created with the scheme's algorithm.
+	Identifies(hash []byte) bool
+}
+
+// Upgrading hashes new passwords with its current scheme and verifies hashes of any of its schemes.
+// Hashes of the legacy schemes always need to be rehashed.
+type Upgrading struct {
+	current Scheme
+	legacy  []Scheme
+}
+
+// NewUpgrading returns a Hasher that hashes new passwords with current and still verifies hashes
+// created with the legacy schemes.
+func NewUpgrading(current Scheme, legacy ...Scheme) *Upgrading {
+	return &Upgrading{current: current, legacy: legacy}
+}
+
+// NewDefault returns the Hasher used for user passwords: Argon2id with the given parameters, with
+// verification of the bcrypt hashes created before Argon2id was introduced.
+func NewDefault(params Argon2id) *Upgrading {
+	return NewUpgrading(params, Bcrypt{Cost: bcryptCost})
+}
+
+func (u *Upgrading) Hash(plaintext string) ([]byte, error) {
+	return u.current.Hash(plaintext)
+}
+
+// Matches verifies the password with the scheme that created the hash. Returns ErrUnknownHash
+// if none of the schemes did.
+func (u *Upgrading) Matches(hash []byte, plaintext string) (bool, error) {
+	if u.current.Identifies(hash) {
+		return u.current.Matches(hash, plaintext)
+	}
+	for _, scheme := range u.legacy {
+		if scheme.Identifies(hash) {
+			return scheme.Matches(hash, plaintext)
+		}
+	}
+	return false, ErrUnknownHash
+}
+
+func (u *Upgrading) NeedsRehash(hash []byte) bool {
+	return !u.current.Identifies(hash) || u.current.NeedsRehash(hash)
+}
+
+// Argon2id hashes passwords with Argon2id, encoding the hash in the PHC string format
+// ($argon2id$v=19$m=<memory>,t=<iterations>,p=<parallelism>$<salt>$<key>).
+type Argon2id struct {
+	Memory      uint32 // Memory in KiB
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// DefaultArgon2id are the parameters recommended by RFC 9106 for memory-constrained environments.
+var DefaultArgon2id = Argon2id{
+	Memory:      64 * 1024,
+	Iterations:  3,
+	Parallelism: 4,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+var argon2idPrefix = []byte("$argon2id$")
+
+// Validate checks that the parameters can be used to hash passwords.
+func (a Argon2id) Validate() error {
+	switch {
+	case a.Iterations < 1:
+		return errors.New("argon2id iterations must be at least 1")
+	case a.Parallelism < 1:
+		return errors.New("argon2id parallelism must be at least 1")
+	case a.Memory < 8*uint32(a.Parallelism):
+		return errors.New("argon2id memory must be at least 8 KiB per thread")
+	case a.SaltLength < 8:
+		return errors.New("argon2id salt must be at least 8 bytes long")
+	case a.KeyLength < 16:
+		return errors.New("argon2id key must be at least 16 bytes long")
+	}
+	return nil
+}
+
+func (a Argon2id) Hash(plaintext string) ([]byte, error) {
+	salt := make([]byte, a.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	key := argon2.IDKey([]byte(plaintext), salt, a.Iterations, a.Memory, a.Parallelism, a.KeyLength)
+
+	encoded := fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, a.Memory, a.Iterations, a.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key))
+	return []byte(encoded), nil
+}
+
+func (a Argon2id) Matches(hash []byte, plaintext string) (bool, error) {
+	params, salt, key, err := decodeArgon2id(hash)
+	if err != nil {
+		return false, err
+	}
+
+	other := argon2.IDKey([]byte(plaintext), salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength)
+	return subtle.ConstantTimeCompare(key, other) == 1, nil
+}
+
+// NeedsRehash reports whether the hash was created with different parameters.
+func (a Argon2id) NeedsRehash(hash []byte) bool {
+	params, _, _, err := decodeArgon2id(hash)
+	if err != nil {
+		return true
+	}
+	return params.Memory != a.Memory || params.Iterations != a.Iterations ||
+		params.Parallelism != a.Parallelism || params.SaltLength != a.SaltLength || params.KeyLength != a.KeyLength
+}
+
+func (a Argon2id) Identifies(hash []byte) bool {
+	return bytes.HasPrefix(hash, argon2idPrefix)
+}
+
+// decodeArgon2id parses a hash created by Argon2id.Hash into its parameters, salt and key.
+func decodeArgon2id(hash []byte) (Argon2id, []byte, []byte, error) {
+	var params Argon2id
+	var version int
+
+	parts := bytes.Split(hash, []byte("$"))
+	if len(parts) != 6 || !bytes.HasPrefix(hash, argon2idPrefix) {
+		return params, nil, nil, ErrUnknownHash
+	}
+	if _, err := fmt.Sscanf(string(parts[2]), "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, ErrUnknownHash
+	}
+	_, err := fmt.Sscanf(string(parts[3]), "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism)
+	if err != nil {
+		return params, nil, nil, ErrUnknownHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(string(parts[4]))
+	if err != nil {
+		return params, nil, nil, ErrUnknownHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(string(parts[5]))
+	if err != nil {
+		return params, nil, nil, ErrUnknownHash
+	}
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+
+	return params, salt, key, nil
+}
+
+// bcryptCost is the cost passwords were hashed with before Argon2id was introduced.
+const bcryptCost = 12
+
+// bcryptMaxLength is the length of the longest password bcrypt can hash.
+const bcryptMaxLength = 72
+
+// Bcrypt hashes passwords with bcrypt. Passwords longer than 72 bytes cannot be hashed.
+type Bcrypt struct {
+	Cost int
+}
+
+func (b Bcrypt) Hash(plaintext string) ([]byte, error) {
+	return bcrypt.GenerateFromPassword([]byte(plaintext), b.Cost)
+}
+
+func (b Bcrypt) Matches(hash []byte, plaintext string) (bool, error) {
+	// Longer passwords could never have been hashed, so they cannot match
+	if len(plaintext) > bcryptMaxLength {
+		return false, nil
+	}
+
+	err := bcrypt.CompareHashAndPassword(hash, []byte(plaintext))
+	if err != nil {
+		switch {
+		case errors.Is(err, bcrypt.ErrMismatchedHashAndPassword):
+			return false, nil
+		default:
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// NeedsRehash reports whether the hash was created with a lower cost.
+func (b Bcrypt) NeedsRehash(hash []byte) bool {
+	cost, err := bcrypt.Cost(hash)
+	return err != nil || cost < b.Cost
+}
+
+func (b Bcrypt) Identifies(hash []byte) bool {
+	return bytes.HasPrefix(hash, []byte("$2a$")) || bytes.HasPrefix(hash, []byte("$2b$")) || bytes.HasPrefix(hash, []byte("$2y$"))
+}
@@ -0,0 +1,120 @@
+package passwords
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testArgon2id are cheap parameters that keep the tests fast.
+var testArgon2id = Argon2id{Memory: 8 * 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+func TestArgon2id(t *testing.T) {
+	t.Parallel()
+
+	hash, err := testArgon2id.Hash("pa55word")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(hash), "$argon2id$v=19$m=8192,t=1,p=1$"))
+	assert.True(t, testArgon2id.Identifies(hash))
+
+	matches, err := testArgon2id.Matches(hash, "pa55word")
+	require.NoError(t, err)
+	assert.True(t, matches)
+
+	matches, err = testArgon2id.Matches(hash, "wrong password")
+	require.NoError(t, err)
+	assert.False(t, matches)
+
+	other, err := testArgon2id.Hash("pa55word")
+	require.NoError(t, err)
+	assert.NotEqual(t, hash, other, "every hash has its own salt")
+
+	// Passwords beyond bcrypt's limit are supported
+	long := strings.Repeat("a", 100)
+	hash, err = testArgon2id.Hash(long)
+	require.NoError(t, err)
+	matches, err = testArgon2id.Matches(hash, long[:72])
+	require.NoError(t, err)
+	assert.False(t, matches)
+
+	_, err = testArgon2id.Matches([]byte("$argon2id$v=19$garbage"), "pa55word")
+	assert.ErrorIs(t, err, ErrUnknownHash)
+}
+
+func TestArgon2id_NeedsRehash(t *testing.T) {
+	t.Parallel()
+
+	hash, err := testArgon2id.Hash("pa55word")
+	require.NoError(t, err)
+	assert.False(t, testArgon2id.NeedsRehash(hash))
+
+	stronger := testArgon2id
+	stronger.Iterations = 2
+	assert.True(t, stronger.NeedsRehash(hash))
+
+	// Hashes created with the old parameters still match
+	matches, err := stronger.Matches(hash, "pa55word")
+	require.NoError(t, err)
+	assert.True(t, matches)
+}
+
+func TestArgon2id_Validate(t *testing.T) {
+	t.Parallel()
+
+	assert.NoError(t, DefaultArgon2id.Validate())
+	assert.NoError(t, testArgon2id.Validate())
+
+	params := testArgon2id
+	params.Iterations = 0
+	assert.Error(t, params.Validate())
+
+	params = testArgon2id
+	params.Parallelism = 4
+	params.Memory = 16
+	assert.Error(t, params.Validate())
+}
+
+func TestUpgrading(t *testing.T) {
+	t.Parallel()
+
+	legacy := Bcrypt{Cost: 4}
+	hasher := NewUpgrading(testArgon2id, legacy)
+
+	bcryptHash, err := legacy.Hash("pa55word")
+	require.NoError(t, err)
+
+	matches, err := hasher.Matches(bcryptHash, "pa55word")
+	require.NoError(t, err)
+	assert.True(t, matches)
+	assert.True(t, hasher.NeedsRehash(bcryptHash), "hashes of legacy schemes are replaced")
+
+	matches, err = hasher.Matches(bcryptHash, strings.Repeat("a", 100))
+	require.NoError(t, err)
+	assert.False(t, matches, "passwords too long for bcrypt do not match")
+
+	hash, err := hasher.Hash("pa55word")
+	require.NoError(t, err)
+	assert.True(t, testArgon2id.Identifies(hash))
+	assert.False(t, hasher.NeedsRehash(hash))
+
+	matches, err = hasher.Matches(hash, "pa55word")
+	require.NoError(t, err)
+	assert.True(t, matches)
+
+	_, err = hasher.Matches([]byte("plaintext"), "plaintext")
+	assert.ErrorIs(t, err, ErrUnknownHash)
+	assert.True(t, hasher.NeedsRehash([]byte("plaintext")))
+}
+
+func TestBcrypt_NeedsRehash(t *testing.T) {
+	t.Parallel()
+
+	hash, err := Bcrypt{Cost: 4}.Hash("pa55word")
+	require.NoError(t, err)
+
+	assert.True(t, Bcrypt{Cost: 4}.Identifies(hash))
+	assert.False(t, Bcrypt{Cost: 4}.NeedsRehash(hash))
+	assert.True(t, Bcrypt{Cost: 5}.NeedsRehash(hash))
+}
@@ -0,0 +1,117 @@
+package passwords
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// minUserInputLength is the length below which a username or email local part is too short to be
+// meaningfully looked for in a password.
+const minUserInputLength = 3
+
+// Policy decides whether a new password is strong enough. The zero value accepts every password.
+type Policy struct {
+	// MinScore is the lowest acceptable Score; 0 accepts any score.
+	MinScore int
+	// RejectUserInputs rejects passwords containing the user's username, email address or the local part of it.
+	RejectUserInputs bool
+	// Breached, if set, rejects passwords known from data breaches.
+	Breached *BreachedList
+}
+
+// Check returns the reasons a new password is not acceptable for the user with the given
+// username and email address, or nil if it is.
+func (p Policy) Check(plaintext, username, email string) []string {
+	var problems []string
+
+	if p.RejectUserInputs {
+		lower := strings.ToLower(plaintext)
+		local, _, _ := strings.Cut(email, "@")
+		if containsInput(lower, username) {
+			problems = append(problems, "password must not contain the username")
+		}
+		if containsInput(lower, email) || containsInput(lower, local) {
+			problems = append(problems, "password must not contain the email address")
+		}
+	}
+
+	if p.MinScore > 0 && Score(plaintext, username, email) < p.MinScore {
+		problems = append(problems, "password is too easy to guess")
+	}
+
+	if p.Breached != nil && p.Breached.Contains(plaintext) {
+		problems = append(problems, "password has appeared in a data breach")
+	}
+
+	return problems
+}
+
+func containsInput(lowerPassword, input string) bool {
+	return len(input) >= minUserInputLength && strings.Contains(lowerPassword, strings.ToLower(input))
+}
+
+// rangePrefixLength is the number of hex characters of a SHA-1 hash that select its range.
+const rangePrefixLength = 5
+
+// BreachedList holds the SHA-1 hashes of passwords known from data breaches. Like the k-anonymity
+// range API of Have I Been Pwned, hashes are grouped into ranges by their first five hex characters,
+// so a password is only ever looked up by the prefix of its hash.
+type BreachedList struct {
+	ranges map[string][]string
+}
+
+// LoadBreachedList reads a list of uppercase or lowercase hex SHA-1 hashes, one per line, in the
+// format of the Have I Been Pwned downloads: each hash may be followed by ":" and a count, which is
+// ignored. Blank lines and lines starting with # are skipped.
+func LoadBreachedList(r io.Reader) (*BreachedList, error) {
+	list := &BreachedList{ranges: make(map[string][]string)}
+
+	scanner := bufio.NewScanner(r)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		hash, _, _ := strings.Cut(line, ":")
+		hash = strings.ToUpper(hash)
+		if len(hash) != 2*sha1.Size || !isHex(hash) {
+			return nil, fmt.Errorf("line %d: %q is not a SHA-1 hash", lineNumber, hash)
+		}
+
+		prefix := hash[:rangePrefixLength]
+		list.ranges[prefix] = append(list.ranges[prefix], hash[rangePrefixLength:])
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, suffixes := range list.ranges {
+		slices.Sort(suffixes)
+	}
+	return list, nil
+}
+
+// Range returns the sorted hash suffixes of the breached passwords whose hash starts with prefix.
+func (l *BreachedList) Range(prefix string) []string {
+	return l.ranges[strings.ToUpper(prefix)]
+}
+
+// Contains reports whether the password is in the list.
+func (l *BreachedList) Contains(plaintext string) bool {
+	sum := sha1.Sum([]byte(plaintext))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	_, found := slices.BinarySearch(l.Range(hash[:rangePrefixLength]), hash[rangePrefixLength:])
+	return found
+}
+
+func isHex(s string) bool {
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
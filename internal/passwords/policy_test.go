@@ -0,0 +1,80 @@
+package passwords
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sha1("password") and sha1("123456")
+const breachedHashes = `
+# Breached passwords
+5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8:9545824
+7c4a8d09ca3762af61e59520943dc26494f8941b
+`
+
+func TestPolicy_Check(t *testing.T) {
+	t.Parallel()
+
+	breached, err := LoadBreachedList(strings.NewReader(breachedHashes))
+	require.NoError(t, err)
+
+	policy := Policy{MinScore: 3, RejectUserInputs: true, Breached: breached}
+
+	testcases := []struct {
+		name         string
+		password     string
+		wantProblems []string
+	}{
+		{
+			name:     "Strong password",
+			password: "velvet-orbit-lantern-57",
+		},
+		{
+			name:         "Contains the username",
+			password:     "velvet-AWhite-lantern-57",
+			wantProblems: []string{"password must not contain the username"},
+		},
+		{
+			name:         "Contains the email address",
+			password:     "velvet-alice.w-lantern-57",
+			wantProblems: []string{"password must not contain the email address"},
+		},
+		{
+			name:         "Weak password",
+			password:     "letmein2019",
+			wantProblems: []string{"password is too easy to guess"},
+		},
+		{
+			name:         "Breached password",
+			password:     "password",
+			wantProblems: []string{"password is too easy to guess", "password has appeared in a data breach"},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.wantProblems, policy.Check(tc.password, "awhite", "alice.w@example.com"))
+		})
+	}
+
+	assert.Empty(t, Policy{}.Check("password", "password", "password@example.com"), "the zero policy accepts every password")
+}
+
+func TestLoadBreachedList(t *testing.T) {
+	t.Parallel()
+
+	list, err := LoadBreachedList(strings.NewReader(breachedHashes))
+	require.NoError(t, err)
+
+	assert.True(t, list.Contains("password"))
+	assert.True(t, list.Contains("123456"))
+	assert.False(t, list.Contains("Password"))
+	assert.Equal(t, []string{"1E4C9B93F3F0682250B6CF8331B7EE68FD8"}, list.Range("5baa6"))
+	assert.Empty(t, list.Range("00000"))
+
+	_, err = LoadBreachedList(strings.NewReader("not-a-hash\n"))
+	assert.ErrorContains(t, err, "line 1")
+}
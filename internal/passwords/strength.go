@@ -0,0 +1,378 @@
+package passwords
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// Score estimates how hard a password is to guess, on zxcvbn's scale from 0 (trivially guessable)
+// to 4 (very unlikely to be guessed). Like zxcvbn, the estimate is the number of guesses an attacker
+// trying common passwords, words from userInputs, repeats, sequences, keyboard patterns and years
+// first would need; whatever remains is assumed to be brute-forced. Only the first 100 characters
+// are considered, which is more than enough to reach the highest score.
+func Score(plaintext string, userInputs ...string) int {
+	runes := []rune(plaintext)
+	if len(runes) > maxScoredLength {
+		runes = runes[:maxScoredLength]
+	}
+
+	guesses := estimateGuesses(runes, userInputs)
+	switch {
+	case guesses < 1e3:
+		return 0
+	case guesses < 1e6:
+		return 1
+	case guesses < 1e8:
+		return 2
+	case guesses < 1e10:
+		return 3
+	default:
+		return 4
+	}
+}
+
+const (
+	// bruteforceCardinality is the number of guesses per character not covered by a pattern.
+	bruteforceCardinality = 10
+	// minPatternGuesses keeps patterns from being cheaper than brute-forcing their first characters.
+	minPatternGuesses = 50
+	// maxScoredLength bounds the work of matching patterns, which grows quickly with the length.
+	maxScoredLength = 100
+)
+
+// match is a pattern found at runes [start, end) of the password.
+type match struct {
+	start, end int
+	guesses    float64
+}
+
+// estimateGuesses returns the fewest guesses over all ways of splitting the password into
+// patterns and brute-forced characters.
+func estimateGuesses(password []rune, userInputs []string) float64 {
+	lower := []rune(strings.ToLower(string(password)))
+	if len(lower) != len(password) {
+		lower = password
+	}
+
+	byEnd := make(map[int][]match)
+	for _, m := range findMatches(password, lower, userInputs) {
+		byEnd[m.end] = append(byEnd[m.end], m)
+	}
+
+	// best[i] is the fewest guesses needed for the first i runes
+	best := make([]float64, len(password)+1)
+	best[0] = 1
+	for i := 1; i <= len(password); i++ {
+		best[i] = best[i-1] * bruteforceCardinality
+		for _, m := range byEnd[i] {
+			best[i] = math.Min(best[i], best[m.start]*m.guesses)
+		}
+	}
+	return best[len(password)]
+}
+
+func findMatches(password, lower []rune, userInputs []string) []match {
+	dictionary := userDictionary(userInputs)
+
+	var matches []match
+	matches = append(matches, dictionaryMatches(password, lower, dictionary)...)
+	matches = append(matches, repeatMatches(lower, userInputs)...)
+	matches = append(matches, sequenceMatches(lower)...)
+	matches = append(matches, keyboardMatches(lower)...)
+	matches = append(matches, yearMatches(lower)...)
+	return matches
+}
+
+// userDictionary ranks the lowercased user inputs, such as a username or email address, ahead of
+// the common passwords. Email addresses also contribute their local part.
+func userDictionary(userInputs []string) map[string]int {
+	dictionary := make(map[string]int)
+	for _, input := range userInputs {
+		input = strings.ToLower(input)
+		words := []string{input}
+		if local, _, found := strings.Cut(input, "@"); found {
+			words = append(words, local)
+		}
+		for _, word := range words {
+			if _, ok := dictionary[word]; !ok && word != "" {
+				dictionary[word] = len(dictionary) + 1
+			}
+		}
+	}
+	return dictionary
+}
+
+// l33tSubstitutions maps characters commonly substituted for letters back to the letter.
+var l33tSubstitutions = map[rune]rune{
+	'4': 'a', '@': 'a', '8': 'b', '3': 'e', '6': 'g', '1': 'i', '!': 'i', '0': 'o', '$': 's', '5': 's', '7': 't', '+': 't', '2': 'z',
+}
+
+// dictionaryMatches finds common passwords and user inputs, also when capitalized or spelled in l33t.
+func dictionaryMatches(password, lower []rune, userDictionary map[string]int) []match {
+	unl33ted := make([]rune, len(lower))
+	for i, r := range lower {
+		if sub, ok := l33tSubstitutions[r]; ok {
+			unl33ted[i] = sub
+		} else {
+			unl33ted[i] = r
+		}
+	}
+
+	var matches []match
+	for i := range lower {
+		for j := i + 3; j <= len(lower); j++ {
+			word := string(lower[i:j])
+			rank, ok := dictionaryRank(word, userDictionary)
+			variations := 1.0
+			if !ok {
+				rank, ok = dictionaryRank(string(unl33ted[i:j]), userDictionary)
+				variations = 2
+			}
+			if !ok {
+				continue
+			}
+			guesses := float64(rank) * variations * uppercaseVariations(password[i:j])
+			matches = append(matches, match{i, j, math.Max(guesses, minPatternGuesses)})
+		}
+	}
+	return matches
+}
+
+func dictionaryRank(word string, userDictionary map[string]int) (int, bool) {
+	if rank, ok := userDictionary[word]; ok {
+		return rank, true
+	}
+	rank, ok := commonPasswordRanks[word]
+	return rank + len(userDictionary), ok
+}
+
+// uppercaseVariations is the number of capitalizations to try for a word: none for lowercase
+// words, a couple for capitalized or uppercase words, and many for mixed case.
+func uppercaseVariations(word []rune) float64 {
+	upper := 0
+	for _, r := range word {
+		if unicode.IsUpper(r) {
+			upper++
+		}
+	}
+	switch {
+	case upper == 0:
+		return 1
+	case upper == len(word), upper == 1 && unicode.IsUpper(word[0]):
+		return 2
+	default:
+		return math.Pow(2, float64(min(upper, len(word)-upper)))
+	}
+}
+
+// repeatMatches finds blocks repeated back to back, such as "aaaa" or "abcabc". A repeat costs
+// the guesses for its block times the number of repetitions. Like zxcvbn, repeats are found from
+// left to right, each taking the longest run of the shortest block.
+func repeatMatches(lower []rune, userInputs []string) []match {
+	var matches []match
+	for i := 0; i < len(lower); {
+		var best match
+		size := 0
+		for s := 1; i+2*s <= len(lower); s++ {
+			end := i + s
+			for end+s <= len(lower) && string(lower[end:end+s]) == string(lower[i:i+s]) {
+				end += s
+			}
+			count := (end - i) / s
+			if count >= 2 && (s > 1 || count >= 3) && end > best.end {
+				best = match{start: i, end: end}
+				size = s
+			}
+		}
+		if size == 0 {
+			i++
+			continue
+		}
+
+		count := float64((best.end - best.start) / size)
+		best.guesses = math.Max(estimateGuesses(lower[i:i+size], userInputs)*count, minPatternGuesses)
+		matches = append(matches, best)
+		i = best.end
+	}
+	return matches
+}
+
+// sequenceMatches finds runs of consecutive letters or digits, such as "abcd" or "9876".
+func sequenceMatches(lower []rune) []match {
+	var matches []match
+	for i := 0; i < len(lower)-2; {
+		delta := lower[i+1] - lower[i]
+		if (delta != 1 && delta != -1) || !sameClass(lower[i], lower[i+1]) {
+			i++
+			continue
+		}
+
+		j := i + 2
+		for j < len(lower) && lower[j]-lower[j-1] == delta && sameClass(lower[j-1], lower[j]) {
+			j++
+		}
+		if j-i >= 3 {
+			base := 26.0
+			switch {
+			case strings.ContainsRune("az019", lower[i]):
+				base = 4
+			case unicode.IsDigit(lower[i]):
+				base = 10
+			}
+			if delta < 0 {
+				base *= 2
+			}
+			matches = append(matches, match{i, j, math.Max(base*float64(j-i), minPatternGuesses)})
+		}
+		i = j - 1
+	}
+	return matches
+}
+
+func sameClass(a, b rune) bool {
+	return (unicode.IsDigit(a) && unicode.IsDigit(b)) || (a >= 'a' && a <= 'z' && b >= 'a' && b <= 'z')
+}
+
+// keyboardRows are the rows of a QWERTY keyboard.
+var keyboardRows = []string{"1234567890-=", "qwertyuiop[]", "asdfghjkl;'", "zxcvbnm,./"}
+
+// keyboardMatches finds runs of at least four adjacent keys on a keyboard row, in either direction.
+func keyboardMatches(lower []rune) []match {
+	var matches []match
+	for _, row := range keyboardRows {
+		for _, keys := range []string{row, reverse(row)} {
+			for i := range lower {
+				j := i
+				for j < len(lower) && strings.Contains(keys, string(lower[i:j+1])) {
+					j++
+				}
+				if j-i >= 4 {
+					matches = append(matches, match{i, j, math.Max(float64(2*len(keys)*(j-i)), minPatternGuesses)})
+				}
+			}
+		}
+	}
+	return matches
+}
+
+func reverse(s string) string {
+	runes := []rune(s)
+	for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+		runes[i], runes[j] = runes[j], runes[i]
+	}
+	return string(runes)
+}
+
+// yearMatches finds recent years, which are guessed from a small range.
+func yearMatches(lower []rune) []match {
+	var matches []match
+	for i := 0; i+4 <= len(lower); i++ {
+		year := string(lower[i : i+4])
+		if (strings.HasPrefix(year, "19") || strings.HasPrefix(year, "20")) && isDigits(year) {
+			matches = append(matches, match{i, i + 4, 2 * minPatternGuesses})
+		}
+	}
+	return matches
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// commonPasswordRanks ranks the most common passwords from public breach corpora, most common first.
+var commonPasswordRanks = func() map[string]int {
+	ranks := make(map[string]int, len(commonPasswords))
+	for i, password := range commonPasswords {
+		if _, ok := ranks[password]; !ok {
+			ranks[password] = i + 1
+		}
+	}
+	return ranks
+}()
+
+var commonPasswords = []string{
+	"123456", "password", "12345678", "qwerty", "123456789", "12345", "1234", "111111", "1234567", "dragon",
+	"123123", "baseball", "abc123", "football", "monkey", "letmein", "696969", "shadow", "master", "666666",
+	"qwertyuiop", "123321", "mustang", "1234567890", "michael", "654321", "superman", "1qaz2wsx", "7777777", "121212",
+	"000000", "qazwsx", "123qwe", "killer", "trustno1", "jordan", "jennifer", "zxcvbnm", "asdfgh", "hunter",
+	"buster", "soccer", "harley", "batman", "andrew", "tigger", "sunshine", "iloveyou", "2000", "charlie",
+	"robert", "thomas", "hockey", "ranger", "daniel", "starwars", "klaster", "112233", "george", "computer",
+	"michelle", "jessica", "pepper", "1111", "zxcvbn", "555555", "11111111", "131313", "freedom", "777777",
+	"pass", "maggie", "159753", "aaaaaa", "ginger", "princess", "joshua", "cheese", "amanda", "summer",
+	"love", "ashley", "6969", "nicole", "chelsea", "biteme", "matthew", "access", "yankees", "987654321",
+	"dallas", "austin", "thunder", "taylor", "matrix", "william", "corvette", "hello", "martin", "heather",
+	"secret", "merlin", "diamond", "1234qwer", "gfhjkm", "hammer", "silver", "222222", "88888888", "anthony",
+	"justin", "test", "bailey", "q1w2e3r4t5", "patrick", "internet", "scooter", "orange", "11111", "golfer",
+	"cookie", "richard", "samantha", "bigdog", "guitar", "jackson", "whatever", "mickey", "chicken", "sparky",
+	"snoopy", "maverick", "phoenix", "camaro", "peanut", "morgan", "welcome", "falcon", "cowboy", "ferrari",
+	"samsung", "andrea", "smokey", "steelers", "joseph", "mercedes", "dakota", "arsenal", "eagles", "melissa",
+	"boomer", "booboo", "spider", "nascar", "monster", "tigers", "yellow", "xxxxxx", "123123123", "gateway",
+	"marina", "diablo", "bulldog", "qwer1234", "compaq", "purple", "banana", "junior", "hannah", "123654",
+	"porsche", "lakers", "iceman", "money", "cowboys", "987654", "london", "tennis", "999999", "ncc1701",
+	"coffee", "scooby", "0000", "miller", "boston", "q1w2e3r4", "brandon", "yamaha", "chester", "mother",
+	"forever", "johnny", "edward", "333333", "oliver", "redsox", "player", "nikita", "knight", "fender",
+	"barney", "midnight", "please", "brandy", "chicago", "badboy", "slayer", "rangers", "charles", "angel",
+	"flower", "bigdaddy", "rabbit", "wizard", "jasper", "enter", "rachel", "chris", "steven", "winner",
+	"adidas", "victoria", "natasha", "1q2w3e4r", "jasmine", "winter", "prince", "marine", "ghbdtn", "fishing",
+	"cocacola", "casper", "james", "232323", "raiders", "888888", "marlboro", "gandalf", "asdfasdf", "crystal",
+	"87654321", "12344321", "golden", "8675309", "panther", "lauren", "angela", "spanky", "thx1138", "angels",
+	"madison", "winston", "shannon", "mike", "toyota", "jordan23", "canada", "sophie", "apples", "tiger",
+	"razz", "123abc", "pokemon", "qazxsw", "55555", "qwaszx", "muffin", "johnson", "murphy", "cooper",
+	"jonathan", "liverpoo", "david", "danielle", "159357", "jackie", "1990", "123456a", "789456", "turtle",
+	"abcd1234", "scorpion", "qazwsxedc", "101010", "butter", "carlos", "password1", "dennis", "slipknot", "qwerty123",
+	"booger", "asdf", "1991", "black", "startrek", "12341234", "cameron", "newyork", "rainbow", "nathan",
+	"john", "1992", "rocket", "viking", "redskins", "asdfghjkl", "1212", "sierra", "peaches", "gemini",
+	"doctor", "wilson", "sandra", "helpme", "qwertyui", "victor", "florida", "dolphin", "pookie", "captain",
+	"tucker", "blue", "liverpool", "theman", "bandit", "dolphins", "maddog", "packers", "jaguar", "lovers",
+	"nicholas", "united", "tiffany", "maxwell", "zzzzzz", "nirvana", "jeremy", "stupid", "monica", "elephant",
+	"giants", "hotdog", "rosebud", "success", "debbie", "mountain", "444444", "xxxxxxxx", "warrior", "1q2w3e4r5t",
+	"q1w2e3", "123456q", "albert", "metallic", "lucky", "azerty", "7777", "alex", "bond007", "alexis",
+	"1111111", "samson", "5150", "willie", "scorpio", "bonnie", "gators", "benjamin", "voodoo", "driver",
+	"dexter", "2112", "jason", "calvin", "freddy", "212121", "creative", "12345a", "sydney", "rush2112",
+	"1989", "asdfghjk", "red123", "bubba", "4815162342", "passw0rd", "trouble", "gunner", "happy", "gordon",
+	"legend", "jessie", "stella", "qwert", "eminem", "arthur", "apple", "nissan", "bear", "america",
+	"1qazxsw2", "nothing", "parker", "4444", "rebecca", "qweqwe", "garfield", "01012011", "beavis", "69696969",
+	"jack", "asdasd", "december", "2222", "102030", "252525", "11223344", "magic", "apollo", "skippy",
+	"315475", "girls", "kitten", "golf", "copper", "braves", "shelby", "godzilla", "beaver", "fred",
+	"tomcat", "august", "buddy", "airborne", "1993", "1988", "lifehack", "qqqqqq", "brooklyn", "animal",
+	"platinum", "phantom", "online", "xavier", "darkness", "blink182", "power", "fish", "green", "789456123",
+	"voyager", "police", "travis", "12qwaszx", "heaven", "snowball", "lover", "abcdef", "00000", "pakistan",
+	"007007", "walter", "playboy", "blazer", "cricket", "sniper", "hooters", "donkey", "willow", "loveme",
+	"saturn", "therock", "redwings", "bigboy", "pumpkin", "trinity", "williams", "nintendo", "digital", "destiny",
+	"topgun", "runner", "marvin", "guinness", "chance", "bubbles", "testing", "fire", "november", "minecraft",
+	"asdf1234", "lasvegas", "sergey", "broncos", "cartman", "private", "celtic", "birdie", "little", "cassie",
+	"babygirl", "donald", "beatles", "1313", "family", "12121212", "school", "louise", "gabriel", "eclipse",
+	"fluffy", "147258369", "lol123", "explorer", "beer", "nelson", "flyers", "spencer", "scott", "lovely",
+	"gibson", "doggie", "cherry", "andrey", "snickers", "buffalo", "pantera", "metallica", "member", "carter",
+	"qwertyu", "peter", "alexande", "steve", "bronco", "paradise", "goober", "5555", "samuel", "montana",
+	"mexico", "dreams", "michigan", "carolina", "yankee", "friends", "magnum", "surfer", "maximus", "genius",
+	"cool", "vampire", "lacrosse", "asd123", "aaaa", "christin", "kimberly", "speedy", "sharon", "carmen",
+	"111222", "kristina", "sammy", "racing", "ou812", "sabrina", "horses", "0987654321", "qwerty1", "pimpin",
+	"baby", "stalker", "enigma", "147147", "star", "poohbear", "147258", "simple", "12345q", "marcus",
+	"brian", "1987", "qweasdzxc", "drowssap", "hahaha", "caroline", "barbara", "dave", "viper", "drummer",
+	"action", "einstein", "genesis", "hello1", "scotty", "friend", "forest", "010203", "hotrod", "google",
+	"vanessa", "spitfire", "badger", "maryjane", "friday", "alaska", "1232323q", "tester", "jester", "jake",
+	"champion", "billy", "147852", "rock", "hawaii", "badass", "chevy", "420420", "walker", "stephen",
+	"eagle1", "bill", "1986", "october", "gregory", "svetlana", "pamela", "1984", "music", "shorty",
+	"westside", "stanley", "diesel", "courtney", "242424", "kevin", "hitman", "mark", "12345qwert", "reddog",
+	"frank", "qwe123", "popcorn", "patricia", "aaaaaaaa", "1969", "teresa", "mozart", "buddha", "anderson",
+	"paul", "melanie", "abcdefg", "security", "lucky1", "lizard", "denise", "3333", "a12345", "123789",
+	"ruslan", "stargate", "simpsons", "scarface", "eagle", "123456789a", "thumper", "olivia", "naruto", "1234554321",
+	"general", "cherokee", "a123456", "vincent", "spooky", "qweasd", "free", "frankie", "douglas", "death",
+	"1980", "loveyou", "kitty", "kelly", "veronica", "suzuki", "semperfi", "penguin", "mercury", "liberty",
+	"spirit", "scotland", "natalie", "marley", "vikings", "system", "sucker", "king", "allison", "marshall",
+	"1979", "098765", "qwerty12", "hummer", "adrian", "1985", "vfhbyf", "sandman", "rocky", "leslie",
+	"antonio", "98765432", "4321", "softball", "passion", "mnbvcxz", "passport", "rascal", "howard", "franklin",
+	"bigred", "alexander", "homer", "redrum", "jupiter", "claudia", "55555555", "141414", "zaq12wsx", "patches",
+	"raider", "infinity", "andre", "54321", "galore", "college", "russia", "kawasaki", "bishop", "77777777",
+	"vladimir", "money1", "freeuser", "wildcats", "francis", "disney", "budlight", "brittany", "1994", "00000000",
+	"sweet", "oksana", "honda", "domino", "bulldogs", "brutus", "swordfis", "norman", "monday", "jimmy",
+	"ironman", "ford", "fantasy", "9999", "7654321", "rockstar", "changeme", "admin", "administrator", "root",
+	"toor", "login", "guest", "default", "qwerty123456", "iloveu",
+}
@@ -0,0 +1,53 @@
+package passwords
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScore(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		password   string
+		userInputs []string
+		wantScore  int
+	}{
+		{password: "password", wantScore: 0},
+		{password: "P@ssw0rd", wantScore: 0},
+		{password: "12345678", wantScore: 0},
+		{password: "87654321", wantScore: 0},
+		{password: "qwertyuiop", wantScore: 0},
+		{password: "aaaaaaaaaaaa", wantScore: 0},
+		{password: "abcabcabcabc", wantScore: 0},
+		{password: "password123", wantScore: 1},
+		{password: "letmein2019", wantScore: 1},
+		{password: "alicealice", userInputs: []string{"alice"}, wantScore: 0},
+		{password: "kx7tqzpa", wantScore: 3},
+		{password: "Tr0ub4dor&3", wantScore: 4},
+		{password: "correct horse battery staple", wantScore: 4},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.password, func(t *testing.T) {
+			assert.Equal(t, tc.wantScore, Score(tc.password, tc.userInputs...))
+		})
+	}
+}
+
+func TestScore_UserInputs(t *testing.T) {
+	t.Parallel()
+
+	assert.Less(t, Score("martinezrulez", "martinez@example.com"), Score("martinezrulez"),
+		"the email's local part is a dictionary word")
+}
+
+func TestScore_LongPasswords(t *testing.T) {
+	t.Parallel()
+
+	// Only the first characters are scored
+	assert.Equal(t, Score(strings.Repeat("a", 100)), Score(strings.Repeat("a", 1024)))
+	assert.Equal(t, Score(strings.Repeat("kx7tqzpa", 12)+"kx7t"), Score(strings.Repeat("kx7tqzpa", 128)))
+}
@@ -0,0 +1,83 @@
+package totp
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rfcSecret is the SHA-1 key of the RFC 6238 test vectors, "12345678901234567890", base32 encoded.
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestCode(t *testing.T) {
+	t.Parallel()
+
+	// The last six digits of the eight digit codes in RFC 6238 appendix B
+	testcases := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, tc := range testcases {
+		code, err := Code(rfcSecret, Step(time.Unix(tc.unix, 0)))
+		require.NoError(t, err)
+		assert.Equal(t, tc.want, code, "time %d", tc.unix)
+	}
+
+	_, err := Code("not base32!", 1)
+	assert.Error(t, err)
+}
+
+func TestMatch(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(1111111109, 0)
+	step := Step(now)
+
+	code, err := Code(rfcSecret, step)
+	require.NoError(t, err)
+
+	matched, ok := Match(rfcSecret, code, now)
+	assert.True(t, ok)
+	assert.Equal(t, step, matched)
+
+	// Codes of the neighbouring periods are accepted to allow for clock drift
+	matched, ok = Match(rfcSecret, code, now.Add(Period))
+	assert.True(t, ok)
+	assert.Equal(t, step, matched)
+
+	_, ok = Match(rfcSecret, code, now.Add(2*Period))
+	assert.False(t, ok, "codes expire")
+
+	_, ok = Match(rfcSecret, "000000", now)
+	assert.False(t, ok)
+	_, ok = Match(rfcSecret, "12345", now)
+	assert.False(t, ok)
+}
+
+func TestNewSecretAndURI(t *testing.T) {
+	t.Parallel()
+
+	secret, err := NewSecret()
+	require.NoError(t, err)
+	assert.Len(t, secret, 32)
+
+	uri, err := url.Parse(URI("Conduit", "alice@example.com", secret))
+	require.NoError(t, err)
+	assert.Equal(t, "otpauth", uri.Scheme)
+	assert.Equal(t, "totp", uri.Host)
+	assert.Equal(t, "/Conduit:alice@example.com", uri.Path)
+	assert.Equal(t, secret, uri.Query().Get("secret"))
+	assert.Equal(t, "Conduit", uri.Query().Get("issuer"))
+	assert.Equal(t, "6", uri.Query().Get("digits"))
+}
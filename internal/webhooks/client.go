@@ -0,0 +1,94 @@
+package webhooks
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrForbiddenAddress is returned when a delivery would connect to an address webhooks may not reach.
+var ErrForbiddenAddress = errors.New("webhook URLs must not point to internal addresses")
+
+// forbiddenPrefixes are special-purpose ranges that the netip.Addr predicates used by IsPublicAddress
+// do not cover. The IPv6 transition ranges embed IPv4 addresses, which may be internal ones.
+var forbiddenPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),      // "this" network
+	netip.MustParsePrefix("100.64.0.0/10"),  // carrier-grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),   // IETF protocol assignments
+	netip.MustParsePrefix("198.18.0.0/15"),  // benchmarking
+	netip.MustParsePrefix("240.0.0.0/4"),    // reserved
+	netip.MustParsePrefix("::/96"),          // IPv4-compatible
+	netip.MustParsePrefix("64:ff9b::/96"),   // NAT64
+	netip.MustParsePrefix("64:ff9b:1::/48"), // local-use NAT64
+	netip.MustParsePrefix("100::/64"),       // discard-only
+	netip.MustParsePrefix("2001::/32"),      // Teredo
+	netip.MustParsePrefix("2002::/16"),      // 6to4
+	netip.MustParsePrefix("fec0::/10"),      // deprecated site-local
+}
+
+// IsPublicAddress reports whether deliveries may be sent to the IP address: loopback, private,
+// link-local, multicast and other special-purpose addresses are refused.
+func IsPublicAddress(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return false
+	}
+	for _, prefix := range forbiddenPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// IsPublicHost reports whether a webhook URL's host may be public. Host names other than localhost
+// can only be checked once they are resolved, which the client returned by NewClient does.
+func IsPublicHost(host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return false
+	}
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return IsPublicAddress(addr)
+	}
+	return true
+}
+
+// NewClient returns the HTTP client deliveries are sent with; timeout bounds each attempt. Unless
+// allowPrivate is set, the client refuses to connect to addresses IsPublicAddress rejects. The check
+// runs on the address actually dialed, after the host name is resolved, so host names that resolve
+// or are re-bound to internal addresses are refused too. Proxies from the environment are not used,
+// since they would be dialed instead of the webhook's host.
+func NewClient(timeout time.Duration, allowPrivate bool) *http.Client {
+	if allowPrivate {
+		return &http.Client{Timeout: timeout}
+	}
+
+	dialer := &net.Dialer{
+		Timeout:   timeout,
+		KeepAlive: 30 * time.Second,
+		Control:   checkDialAddress,
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
+
+// checkDialAddress is a net.Dialer Control function that refuses to connect to non-public addresses.
+func checkDialAddress(_, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+	if !IsPublicAddress(addrPort.Addr()) {
+		return ErrForbiddenAddress
+	}
+	return nil
+}
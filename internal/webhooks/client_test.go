@@ -0,0 +1,62 @@
+package webhooks
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsPublicAddress(t *testing.T) {
+	t.Parallel()
+
+	for _, addr := range []string{"93.184.215.14", "8.8.8.8", "2606:2800:21f:cb07:6820:80da:af6b:8b2c"} {
+		assert.True(t, IsPublicAddress(netip.MustParseAddr(addr)), addr)
+	}
+
+	for _, addr := range []string{
+		"127.0.0.1", "::1", // loopback
+		"10.1.2.3", "172.16.0.1", "192.168.1.1", "fd00::1", // private
+		"169.254.169.254", "fe80::1", // link-local
+		"0.0.0.0", "::", "224.0.0.1", "255.255.255.255", // unspecified, multicast, broadcast
+		"100.64.0.1", "::ffff:127.0.0.1", "64:ff9b::a00:1", // shared, IPv4-mapped and NAT64
+	} {
+		assert.False(t, IsPublicAddress(netip.MustParseAddr(addr)), addr)
+	}
+}
+
+func TestIsPublicHost(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, IsPublicHost("example.com"))
+	assert.True(t, IsPublicHost("93.184.215.14"))
+	assert.False(t, IsPublicHost("localhost"))
+	assert.False(t, IsPublicHost("api.localhost."))
+	assert.False(t, IsPublicHost("127.0.0.1"))
+	assert.False(t, IsPublicHost("::1"))
+}
+
+func TestNewClient(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(server.Close)
+
+	t.Run("Internal addresses are refused", func(t *testing.T) {
+		_, err := NewClient(time.Second, false).Get(server.URL)
+		assert.ErrorIs(t, err, ErrForbiddenAddress)
+	})
+
+	t.Run("Internal addresses can be allowed", func(t *testing.T) {
+		res, err := NewClient(time.Second, true).Get(server.URL)
+		require.NoError(t, err)
+		defer res.Body.Close() //nolint:errcheck
+		assert.Equal(t, http.StatusNoContent, res.StatusCode)
+	})
+}
@@ -0,0 +1,64 @@
+// Package webhooks implements the signing and retry schedule used for outbound webhook deliveries.
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Headers sent with every delivery.
+const (
+	HeaderEvent     = "X-Conduit-Event"
+	HeaderDelivery  = "X-Conduit-Delivery"
+	HeaderTimestamp = "X-Conduit-Timestamp"
+	HeaderSignature = "X-Conduit-Signature"
+)
+
+// maxBackoff caps the delay between attempts.
+const maxBackoff = 24 * time.Hour
+
+// NewSecret generates a random secret for signing a webhook's deliveries.
+func NewSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+// Sign returns the signature header value for a delivery body sent at the given Unix timestamp:
+// "sha256=" followed by the hex encoded HMAC-SHA256 of "<timestamp>.<body>" keyed with the secret.
+// Including the timestamp lets receivers reject replayed deliveries.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature is a valid signature of the body and timestamp for the secret.
+func Verify(secret string, timestamp int64, body []byte, signature string) bool {
+	if !strings.HasPrefix(signature, "sha256=") {
+		return false
+	}
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
+
+// Backoff returns how long to wait before retrying after the given failed attempt (starting at 1).
+// The delay doubles with every attempt: base, 2*base, 4*base and so on, up to a day.
+func Backoff(base time.Duration, attempt int) time.Duration {
+	delay := base
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= maxBackoff {
+			return maxBackoff
+		}
+	}
+	return min(delay, maxBackoff)
+}
@@ -0,0 +1,60 @@
+package webhooks
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	t.Parallel()
+
+	body := []byte(`{"event":"article.created"}`)
+	signature := Sign("secret", 1700000000, body)
+
+	assert.True(t, strings.HasPrefix(signature, "sha256="))
+	assert.Len(t, signature, len("sha256=")+64)
+	assert.True(t, Verify("secret", 1700000000, body, signature))
+
+	assert.False(t, Verify("other-secret", 1700000000, body, signature), "wrong secret")
+	assert.False(t, Verify("secret", 1700000001, body, signature), "wrong timestamp")
+	assert.False(t, Verify("secret", 1700000000, []byte(`{}`), signature), "tampered body")
+	assert.False(t, Verify("secret", 1700000000, body, strings.TrimPrefix(signature, "sha256=")), "missing prefix")
+}
+
+func TestNewSecret(t *testing.T) {
+	t.Parallel()
+
+	first, err := NewSecret()
+	require.NoError(t, err)
+	second, err := NewSecret()
+	require.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(first, "whsec_"))
+	assert.Len(t, first, len("whsec_")+64)
+	assert.NotEqual(t, first, second)
+}
+
+func TestBackoff(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{attempt: 1, want: 30 * time.Second},
+		{attempt: 2, want: time.Minute},
+		{attempt: 3, want: 2 * time.Minute},
+		{attempt: 6, want: 16 * time.Minute},
+		{attempt: 12, want: 17*time.Hour + 4*time.Minute},
+		{attempt: 13, want: 24 * time.Hour},
+		{attempt: 1000, want: 24 * time.Hour},
+	}
+
+	for _, tc := range tests {
+		assert.Equal(t, tc.want, Backoff(30*time.Second, tc.attempt), "attempt %d", tc.attempt)
+	}
+}
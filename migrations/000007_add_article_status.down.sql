@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_articles_scheduled_publish_at;
+ALTER TABLE articles
+    DROP CONSTRAINT IF EXISTS articles_status_check,
+    DROP COLUMN IF EXISTS publish_at,
+    DROP COLUMN IF EXISTS status;
@@ -0,0 +1,7 @@
+ALTER TABLE articles
+    ADD COLUMN status     VARCHAR(20) NOT NULL DEFAULT 'published',
+    ADD COLUMN publish_at TIMESTAMP,
+    ADD CONSTRAINT articles_status_check CHECK (status IN ('draft', 'published', 'scheduled'));
+
+-- Index used by the background publisher to find scheduled articles that are due
+CREATE INDEX idx_articles_scheduled_publish_at ON articles (publish_at) WHERE status = 'scheduled';
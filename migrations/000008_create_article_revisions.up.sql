@@ -0,0 +1,16 @@
+CREATE TABLE article_revisions
+(
+    article_id  INTEGER      NOT NULL,
+    version     INTEGER      NOT NULL,
+    title       VARCHAR(255) NOT NULL,
+    description TEXT,
+    body        TEXT         NOT NULL,
+    created_at  TIMESTAMP    NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC'),
+    PRIMARY KEY (article_id, version),
+    FOREIGN KEY (article_id) REFERENCES articles (id) ON DELETE CASCADE
+);
+
+-- Record the current state of existing articles as their first known revision
+INSERT INTO article_revisions (article_id, version, title, description, body, created_at)
+SELECT id, version, title, description, body, updated_at
+FROM articles;
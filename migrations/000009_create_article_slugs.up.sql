@@ -0,0 +1,10 @@
+-- Previous slugs of articles, used to resolve links created before a title change
+CREATE TABLE article_slugs
+(
+    slug       VARCHAR(255) PRIMARY KEY,
+    article_id INTEGER   NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC'),
+    FOREIGN KEY (article_id) REFERENCES articles (id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_article_slugs_article_id ON article_slugs (article_id);
@@ -0,0 +1,6 @@
+ALTER TABLE comments
+    ADD COLUMN parent_id  INTEGER REFERENCES comments (id) ON DELETE CASCADE,
+    ADD COLUMN depth      INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN deleted_at TIMESTAMP;
+
+CREATE INDEX idx_comments_parent_id ON comments (parent_id);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_reactions_comment_user_type;
+DROP INDEX IF EXISTS idx_reactions_article_user_type;
+DROP TABLE IF EXISTS reactions;
@@ -0,0 +1,18 @@
+CREATE TABLE reactions
+(
+    id         BIGSERIAL PRIMARY KEY,
+    user_id    INTEGER   NOT NULL,
+    article_id INTEGER,
+    comment_id INTEGER,
+    type       TEXT      NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC'),
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+    FOREIGN KEY (article_id) REFERENCES articles (id) ON DELETE CASCADE,
+    FOREIGN KEY (comment_id) REFERENCES comments (id) ON DELETE CASCADE,
+    -- A reaction belongs to exactly one article or one comment
+    CONSTRAINT reactions_single_target CHECK ((article_id IS NULL) <> (comment_id IS NULL))
+);
+
+-- A user can leave each reaction type once per target; these also serve the per-target lookups
+CREATE UNIQUE INDEX idx_reactions_article_user_type ON reactions (article_id, user_id, type) WHERE article_id IS NOT NULL;
+CREATE UNIQUE INDEX idx_reactions_comment_user_type ON reactions (comment_id, user_id, type) WHERE comment_id IS NOT NULL;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_bookmarks_article_id;
+DROP TABLE IF EXISTS bookmarks;
@@ -0,0 +1,12 @@
+CREATE TABLE bookmarks
+(
+    user_id    INTEGER   NOT NULL,
+    article_id INTEGER   NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC'),
+    PRIMARY KEY (user_id, article_id),
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+    FOREIGN KEY (article_id) REFERENCES articles (id) ON DELETE CASCADE
+);
+
+-- The primary key covers lookups by user; this one serves cascading deletes of articles
+CREATE INDEX idx_bookmarks_article_id ON bookmarks (article_id);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_follows_followed_id;
+
+ALTER TABLE follows
+    DROP COLUMN IF EXISTS created_at;
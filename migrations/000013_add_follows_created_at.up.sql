@@ -0,0 +1,5 @@
+ALTER TABLE follows
+    ADD COLUMN created_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC');
+
+-- The primary key covers "who does X follow"; this index serves "who follows X"
+CREATE INDEX idx_follows_followed_id ON follows (followed_id);
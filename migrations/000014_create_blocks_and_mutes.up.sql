@@ -0,0 +1,19 @@
+-- A blocked user cannot follow the blocker or comment on, favorite or react to their content
+CREATE TABLE blocks
+(
+    blocker_id INTEGER   NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    blocked_id INTEGER   NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC'),
+    PRIMARY KEY (blocker_id, blocked_id),
+    CHECK (blocker_id <> blocked_id)
+);
+
+-- A muted user's articles and comments are hidden from the muter
+CREATE TABLE mutes
+(
+    muter_id   INTEGER   NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    muted_id   INTEGER   NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC'),
+    PRIMARY KEY (muter_id, muted_id),
+    CHECK (muter_id <> muted_id)
+);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_notifications_recipient_updated_at;
+DROP INDEX IF EXISTS idx_notifications_unread_group;
+DROP TABLE IF EXISTS notifications;
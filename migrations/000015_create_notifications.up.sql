@@ -0,0 +1,18 @@
+CREATE TABLE notifications
+(
+    id            BIGSERIAL PRIMARY KEY,
+    recipient_id  INTEGER   NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    type          TEXT      NOT NULL,
+    article_id    INTEGER REFERENCES articles (id) ON DELETE CASCADE,
+    -- Distinct users whose actions were coalesced into this notification, and the most recent of them
+    actor_ids     INTEGER[] NOT NULL,
+    last_actor_id INTEGER REFERENCES users (id) ON DELETE SET NULL,
+    read_at       TIMESTAMP,
+    created_at    TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC'),
+    updated_at    TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC')
+);
+
+-- At most one unread notification per recipient, type and article; new activity is merged into it
+CREATE UNIQUE INDEX idx_notifications_unread_group ON notifications (recipient_id, type, COALESCE(article_id, 0))
+    WHERE read_at IS NULL;
+CREATE INDEX idx_notifications_recipient_updated_at ON notifications (recipient_id, updated_at DESC);
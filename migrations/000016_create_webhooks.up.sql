@@ -0,0 +1,43 @@
+CREATE TABLE webhooks
+(
+    id         BIGSERIAL PRIMARY KEY,
+    user_id    INTEGER   NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    url        TEXT      NOT NULL,
+    events     TEXT[]    NOT NULL,
+    -- Shared secret used to sign deliveries with HMAC-SHA256
+    secret     TEXT      NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC')
+);
+
+CREATE INDEX idx_webhooks_user_id ON webhooks (user_id);
+
+-- Durable delivery queue; each row is one event to deliver to one webhook
+CREATE TABLE webhook_deliveries
+(
+    id              BIGSERIAL PRIMARY KEY,
+    webhook_id      BIGINT    NOT NULL REFERENCES webhooks (id) ON DELETE CASCADE,
+    event_type      TEXT      NOT NULL,
+    payload         JSONB     NOT NULL,
+    status          TEXT      NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'succeeded', 'failed')),
+    attempts        INTEGER   NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC'),
+    created_at      TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC'),
+    updated_at      TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC')
+);
+
+CREATE INDEX idx_webhook_deliveries_due ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX idx_webhook_deliveries_webhook_id ON webhook_deliveries (webhook_id, created_at DESC);
+
+CREATE TABLE webhook_delivery_attempts
+(
+    id          BIGSERIAL PRIMARY KEY,
+    delivery_id BIGINT    NOT NULL REFERENCES webhook_deliveries (id) ON DELETE CASCADE,
+    attempt     INTEGER   NOT NULL,
+    -- NULL when no response was received, in which case error describes the failure
+    status_code INTEGER,
+    error       TEXT,
+    duration_ms INTEGER   NOT NULL,
+    created_at  TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC')
+);
+
+CREATE INDEX idx_webhook_delivery_attempts_delivery_id ON webhook_delivery_attempts (delivery_id, attempt);
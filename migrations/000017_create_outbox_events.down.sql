@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_webhook_deliveries_outbox_event;
+ALTER TABLE webhook_deliveries DROP COLUMN IF EXISTS outbox_event_id;
+DROP INDEX IF EXISTS idx_outbox_events_published_at;
+DROP INDEX IF EXISTS idx_outbox_events_unpublished;
+DROP TABLE IF EXISTS outbox_events;
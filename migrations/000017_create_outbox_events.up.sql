@@ -0,0 +1,25 @@
+-- Domain events written in the same transaction as the change they describe, and published
+-- to sinks by the outbox dispatcher. Events of the same aggregate are published in ID order.
+CREATE TABLE outbox_events
+(
+    id              BIGSERIAL PRIMARY KEY,
+    aggregate_type  TEXT      NOT NULL,
+    aggregate_id    BIGINT    NOT NULL,
+    event_type      TEXT      NOT NULL,
+    payload         JSONB     NOT NULL,
+    attempts        INTEGER   NOT NULL DEFAULT 0,
+    last_error      TEXT,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC'),
+    published_at    TIMESTAMP,
+    created_at      TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC')
+);
+
+CREATE INDEX idx_outbox_events_unpublished ON outbox_events (aggregate_type, aggregate_id, id)
+    WHERE published_at IS NULL;
+CREATE INDEX idx_outbox_events_published_at ON outbox_events (published_at)
+    WHERE published_at IS NOT NULL;
+
+-- Webhook deliveries are queued from outbox events; remembering the event makes queueing idempotent
+-- when the dispatcher publishes an event more than once
+ALTER TABLE webhook_deliveries ADD COLUMN outbox_event_id BIGINT;
+CREATE UNIQUE INDEX idx_webhook_deliveries_outbox_event ON webhook_deliveries (webhook_id, outbox_event_id);
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_audit_log_actor_id;
+DROP INDEX IF EXISTS idx_audit_log_created_at;
+DROP TABLE IF EXISTS audit_log;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS role;
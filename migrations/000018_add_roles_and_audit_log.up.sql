@@ -0,0 +1,18 @@
+ALTER TABLE users
+    ADD COLUMN role TEXT NOT NULL DEFAULT 'user' CHECK (role IN ('user', 'moderator', 'admin'));
+
+-- Privileged actions, such as a moderator deleting another user's article
+CREATE TABLE audit_log
+(
+    id          BIGSERIAL PRIMARY KEY,
+    -- Kept when the actor's account is deleted so the entry still describes what happened
+    actor_id    BIGINT    REFERENCES users (id) ON DELETE SET NULL,
+    action      TEXT      NOT NULL,
+    target_type TEXT      NOT NULL,
+    target_id   BIGINT    NOT NULL,
+    details     JSONB     NOT NULL DEFAULT '{}',
+    created_at  TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC')
+);
+
+CREATE INDEX idx_audit_log_created_at ON audit_log (created_at DESC, id DESC);
+CREATE INDEX idx_audit_log_actor_id ON audit_log (actor_id);
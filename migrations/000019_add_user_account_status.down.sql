@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_users_created_at;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS password_reset_required,
+    DROP COLUMN IF EXISTS suspension_reason,
+    DROP COLUMN IF EXISTS suspended_at,
+    DROP COLUMN IF EXISTS created_at;
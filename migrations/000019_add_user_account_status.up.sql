@@ -0,0 +1,8 @@
+-- Existing users get the time of the migration as their creation time
+ALTER TABLE users
+    ADD COLUMN created_at              TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC'),
+    ADD COLUMN suspended_at            TIMESTAMP,
+    ADD COLUMN suspension_reason       TEXT      NOT NULL DEFAULT '',
+    ADD COLUMN password_reset_required BOOLEAN   NOT NULL DEFAULT FALSE;
+
+CREATE INDEX idx_users_created_at ON users (created_at DESC, id DESC);
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_reports_status_created_at;
+DROP INDEX IF EXISTS idx_reports_target;
+DROP INDEX IF EXISTS idx_reports_open_reporter_target;
+DROP TABLE IF EXISTS reports;
+
+ALTER TABLE comments
+    DROP COLUMN IF EXISTS hidden_at;
+ALTER TABLE articles
+    DROP COLUMN IF EXISTS hidden_at;
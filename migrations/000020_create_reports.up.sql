@@ -0,0 +1,27 @@
+-- Content hidden by a moderator is only visible to its author and moderators
+ALTER TABLE articles
+    ADD COLUMN hidden_at TIMESTAMP;
+ALTER TABLE comments
+    ADD COLUMN hidden_at TIMESTAMP;
+
+-- Reports outlive the reported content, so the targets are not foreign keys
+CREATE TABLE reports
+(
+    id          BIGSERIAL PRIMARY KEY,
+    reporter_id BIGINT    REFERENCES users (id) ON DELETE SET NULL,
+    article_id  BIGINT    NOT NULL,
+    -- Set for reports of a comment on the article
+    comment_id  BIGINT,
+    reason      TEXT      NOT NULL CHECK (reason IN ('spam', 'harassment', 'hate_speech', 'misinformation', 'other')),
+    details     TEXT      NOT NULL DEFAULT '',
+    status      TEXT      NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'dismissed', 'hidden', 'deleted')),
+    resolved_by BIGINT    REFERENCES users (id) ON DELETE SET NULL,
+    resolved_at TIMESTAMP,
+    created_at  TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC')
+);
+
+-- A user can only have one open report per article or comment
+CREATE UNIQUE INDEX idx_reports_open_reporter_target ON reports (reporter_id, article_id, COALESCE(comment_id, 0))
+    WHERE status = 'open';
+CREATE INDEX idx_reports_target ON reports (article_id, comment_id) WHERE status = 'open';
+CREATE INDEX idx_reports_status_created_at ON reports (status, created_at, id);
@@ -0,0 +1,14 @@
+DELETE FROM reports
+WHERE reason = 'content_filter';
+
+UPDATE reports
+SET status = 'dismissed'
+WHERE status = 'approved';
+
+ALTER TABLE reports
+    DROP CONSTRAINT reports_status_check,
+    ADD CONSTRAINT reports_status_check
+        CHECK (status IN ('open', 'dismissed', 'hidden', 'deleted')),
+    DROP CONSTRAINT reports_reason_check,
+    ADD CONSTRAINT reports_reason_check
+        CHECK (reason IN ('spam', 'harassment', 'hate_speech', 'misinformation', 'other'));
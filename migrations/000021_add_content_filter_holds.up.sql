@@ -0,0 +1,9 @@
+-- Content held back by the content filter is hidden and reported on behalf of the filter,
+-- and moderators can approve hidden content to publish it again
+ALTER TABLE reports
+    DROP CONSTRAINT reports_reason_check,
+    ADD CONSTRAINT reports_reason_check
+        CHECK (reason IN ('spam', 'harassment', 'hate_speech', 'misinformation', 'other', 'content_filter')),
+    DROP CONSTRAINT reports_status_check,
+    ADD CONSTRAINT reports_status_check
+        CHECK (status IN ('open', 'dismissed', 'hidden', 'deleted', 'approved'));
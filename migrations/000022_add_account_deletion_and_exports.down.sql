@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS user_exports;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS deleted_at;
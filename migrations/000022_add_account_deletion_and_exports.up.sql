@@ -0,0 +1,16 @@
+-- Accounts deleted under the anonymize policy keep their row, scrubbed of personal data,
+-- so that their articles and comments stay attributed to it
+ALTER TABLE users
+    ADD COLUMN deleted_at TIMESTAMP;
+
+-- The latest data export of each user. The archive is built in the background while the export is
+-- pending, and can be downloaded until the export expires
+CREATE TABLE user_exports
+(
+    user_id      BIGINT PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+    status       TEXT      NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'ready')),
+    archive      BYTEA,
+    requested_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC'),
+    completed_at TIMESTAMP,
+    expires_at   TIMESTAMP
+);
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_articles_deleted_at;
+
+-- Articles still in the trash would reappear without their deletion time
+DELETE FROM articles WHERE deleted_at IS NOT NULL;
+
+ALTER TABLE articles
+    DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,6 @@
+-- Articles deleted by their author are kept in their trash until they are restored or purged
+ALTER TABLE articles
+    ADD COLUMN deleted_at TIMESTAMP;
+
+-- Used by the background purge to find articles that have been in the trash for too long
+CREATE INDEX idx_articles_deleted_at ON articles (deleted_at) WHERE deleted_at IS NOT NULL;
@@ -0,0 +1,20 @@
+-- TOTP two-factor authentication of users. Enrollment is pending until the user confirms it with a
+-- code from their authenticator app. last_step is the time step of the last code used, so that a
+-- code cannot be used twice
+CREATE TABLE user_two_factor
+(
+    user_id      BIGINT PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+    secret       TEXT      NOT NULL,
+    last_step    BIGINT,
+    created_at   TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC'),
+    confirmed_at TIMESTAMP
+);
+
+-- Single-use recovery codes for users who lost their authenticator, stored as SHA-256 hashes
+CREATE TABLE user_recovery_codes
+(
+    user_id   BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    code_hash BYTEA  NOT NULL,
+    used_at   TIMESTAMP,
+    PRIMARY KEY (user_id, code_hash)
+);
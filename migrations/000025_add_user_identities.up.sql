@@ -0,0 +1,24 @@
+-- External identities users log in with through OpenID Connect providers. subject is the
+-- provider's stable ID of the user; email is what the provider reported at the first login
+CREATE TABLE user_identities
+(
+    provider   TEXT      NOT NULL,
+    subject    TEXT      NOT NULL,
+    user_id    BIGINT    NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    email      TEXT      NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC'),
+    PRIMARY KEY (provider, subject),
+    UNIQUE (user_id, provider)
+);
+
+-- Logins in progress at an OpenID Connect provider, by the state sent to it. user_id is set when
+-- a logged in user links an identity to their account
+CREATE TABLE oidc_auth_requests
+(
+    state         TEXT PRIMARY KEY,
+    provider      TEXT      NOT NULL,
+    nonce         TEXT      NOT NULL,
+    code_verifier TEXT      NOT NULL,
+    user_id       BIGINT REFERENCES users (id) ON DELETE CASCADE,
+    created_at    TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC')
+);
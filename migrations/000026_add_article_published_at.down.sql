@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_articles_published_at;
+
+ALTER TABLE articles
+    DROP COLUMN IF EXISTS published_at;
@@ -0,0 +1,8 @@
+-- When an article was published, which can be long after its draft was created
+ALTER TABLE articles
+    ADD COLUMN published_at TIMESTAMP;
+
+UPDATE articles SET published_at = created_at WHERE status = 'published';
+
+-- Lists and feeds are ordered by publication time
+CREATE INDEX idx_articles_published_at ON articles (published_at DESC);
@@ -0,0 +1,5 @@
+-- Failed two-factor codes entered at login since the last successful one. Once there are too many,
+-- the user cannot log in with a code until locked_until has passed
+ALTER TABLE user_two_factor
+    ADD COLUMN failed_attempts INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN locked_until    TIMESTAMP;